@@ -19,6 +19,8 @@ type dbCfg struct {
 	MaxIdleConnection int
 	MaxOpenConnection int
 	MaxQueryTime      time.Duration
+	Observe           ObserveFunc
+	Hook              HookFunc
 }
 
 // GetConn from db conn pool
@@ -85,6 +87,13 @@ func NewDb(opts ...Option) (err error) {
 	if err != nil {
 		return err
 	}
+	if dbcfg.Observe != nil || dbcfg.Hook != nil {
+		// reopen db by wrapped driver,so every exec and query can be observed
+		_db = sql.OpenDB(&observeConnector{
+			dsn:    dbcfg.Dsn,
+			driver: &observeDriver{driver: _db.Driver(), observe: dbcfg.Observe, hook: dbcfg.Hook},
+		})
+	}
 	_db.SetMaxOpenConns(dbcfg.MaxOpenConnection)
 	_db.SetMaxIdleConns(dbcfg.MaxIdleConnection)
 	err = _db.Ping()
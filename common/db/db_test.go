@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestNewDb(t *testing.T) {
@@ -25,3 +28,108 @@ func TestNewDb(t *testing.T) {
 	conn.Close()
 	_ = os.Remove("sqlite3.db")
 }
+
+func TestObserve(t *testing.T) {
+	var observed int
+	err := NewDb(Drivename("sqlite3"),
+		Dsn("sqlite3.db"),
+		Observe(func(operation string, d time.Duration) {
+			observed++
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewDb Err: %v", err)
+	}
+	defer os.Remove("sqlite3.db")
+	conn, err := GetConn(context.Background())
+	if err != nil {
+		t.Fatalf("Get Conn Err: %v", err)
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(context.Background(), "CREATE TABLE IF NOT EXISTS t (id INT)")
+	if err != nil {
+		t.Fatalf("ExecContext Err: %v", err)
+	}
+	var count int
+	err = conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM t WHERE id > ?", 0).Scan(&count)
+	if err != nil {
+		t.Fatalf("QueryRowContext Err: %v", err)
+	}
+	if observed != 2 {
+		t.Errorf("want observe 2 times, but get %d", observed)
+	}
+}
+
+// skipdriver return driver.ErrSkip for exec with args like mysql without interpolateParams
+type skipdriver struct{ bad bool }
+
+func (d *skipdriver) Open(name string) (driver.Conn, error) { return &skipconn{driver: d}, nil }
+
+type skipconn struct{ driver *skipdriver }
+
+func (c *skipconn) Prepare(query string) (driver.Stmt, error) { return skipstmt{}, nil }
+func (c *skipconn) Close() error                              { return nil }
+func (c *skipconn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+func (c *skipconn) IsValid() bool                             { return !c.driver.bad }
+func (c *skipconn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	return driver.ResultNoRows, nil
+}
+func (c *skipconn) ResetSession(ctx context.Context) error {
+	if c.driver.bad {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+type skipstmt struct{}
+
+func (skipstmt) Close() error                                    { return nil }
+func (skipstmt) NumInput() int                                   { return -1 }
+func (skipstmt) Exec(args []driver.Value) (driver.Result, error) { return driver.ResultNoRows, nil }
+func (skipstmt) Query(args []driver.Value) (driver.Rows, error)  { return nil, driver.ErrSkip }
+
+func TestObserveConn(t *testing.T) {
+	var (
+		observed int
+		ended    int
+		d        = &skipdriver{}
+	)
+	db := sql.OpenDB(&observeConnector{driver: &observeDriver{
+		driver:  d,
+		observe: func(operation string, d time.Duration) { observed++ },
+		hook: func(ctx context.Context, operation, query string) func(err error) {
+			return func(err error) { ended++ }
+		},
+	}})
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// 直接执行和driver跳过后预处理执行都只观察一次
+	_, err := db.Exec("UPDATE t SET id=1")
+	if err != nil {
+		t.Fatalf("Exec Err: %v", err)
+	}
+	_, err = db.Exec("UPDATE t SET id=?", 1)
+	if err != nil {
+		t.Fatalf("Exec Err: %v", err)
+	}
+	if observed != 2 || ended != 2 {
+		t.Errorf("want observe 2 times, but get %d observed %d ended", observed, ended)
+	}
+
+	// driver判断连接失效时database/sql丢弃连接
+	d.bad = true
+	oc, err := (&observeDriver{driver: d}).Open("")
+	if err != nil {
+		t.Fatalf("Open Err: %v", err)
+	}
+	if oc.(driver.Validator).IsValid() {
+		t.Error("want conn is invalid")
+	}
+	if err = oc.(driver.SessionResetter).ResetSession(context.Background()); err != driver.ErrBadConn {
+		t.Errorf("want reset session get ErrBadConn, but get %v", err)
+	}
+}
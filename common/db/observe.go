@@ -0,0 +1,260 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// ObserveFunc will be called after every exec or query with its duration
+// operation is exec or query
+type ObserveFunc func(operation string, d time.Duration)
+
+// Observe set observe func, it can be used to collect db latency
+func Observe(fn ObserveFunc) Option {
+	return func(dbcfg *dbCfg) {
+		dbcfg.Observe = fn
+	}
+}
+
+// HookFunc will be called before every exec or query with the ctx and query,
+// the returned func will be called with the error after it finished
+type HookFunc func(ctx context.Context, operation, query string) func(err error)
+
+// Hook set hook func, it can be used to trace db call
+func Hook(fn HookFunc) Option {
+	return func(dbcfg *dbCfg) {
+		dbcfg.Hook = fn
+	}
+}
+
+// observeConnector open conn from wrapped driver
+type observeConnector struct {
+	dsn    string
+	driver *observeDriver
+}
+
+func (oc *observeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return oc.driver.Open(oc.dsn)
+}
+
+func (oc *observeConnector) Driver() driver.Driver {
+	return oc.driver
+}
+
+// observeDriver wrap the real driver, all conn and stmt it open will be observed
+type observeDriver struct {
+	driver  driver.Driver
+	observe ObserveFunc
+	hook    HookFunc
+}
+
+func (od *observeDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := od.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &observeConn{Conn: conn, observe: od.observe, hook: od.hook}, nil
+}
+
+// observeConn forward the driver's optional interfaces, exec and query by ExecerContext, QueryerContext
+// or prepared observeStmt will be observed
+type observeConn struct {
+	driver.Conn
+	observe ObserveFunc
+	hook    HookFunc
+	// driver return driver.ErrSkip for ExecerContext or QueryerContext,
+	// database/sql will prepare the query on this conn and retry, so the retry end this observe
+	skipquery string
+	skipdone  func(err error)
+}
+
+func (oc *observeConn) Prepare(query string) (driver.Stmt, error) {
+	return oc.PrepareContext(context.Background(), query)
+}
+
+func (oc *observeConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if cp, ok := oc.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = cp.PrepareContext(ctx, query)
+	} else {
+		stmt, err = oc.Conn.Prepare(query)
+	}
+	if err != nil {
+		if done := oc.takeskip(query); done != nil {
+			done(err)
+		}
+		return nil, err
+	}
+	return &observeStmt{Stmt: stmt, conn: oc, query: query}, nil
+}
+
+func (oc *observeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := oc.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	done := oc.start(ctx, "exec", query)
+	result, err := ec.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		oc.setskip(query, done)
+		return nil, err
+	}
+	done(err)
+	return result, err
+}
+
+func (oc *observeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := oc.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	done := oc.start(ctx, "query", query)
+	rows, err := qc.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		oc.setskip(query, done)
+		return nil, err
+	}
+	done(err)
+	return rows, err
+}
+
+func (oc *observeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cb, ok := oc.Conn.(driver.ConnBeginTx); ok {
+		return cb.BeginTx(ctx, opts)
+	}
+	return oc.Conn.Begin() // nolint
+}
+
+func (oc *observeConn) Ping(ctx context.Context) error {
+	if p, ok := oc.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (oc *observeConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if c, ok := oc.Conn.(driver.NamedValueChecker); ok {
+		return c.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession return driver.ErrBadConn if driver find the conn is bad, database/sql will discard it
+func (oc *observeConn) ResetSession(ctx context.Context) error {
+	if done := oc.takeskip(oc.skipquery); done != nil {
+		done(nil)
+	}
+	if sr, ok := oc.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid return false if driver find the conn is bad, database/sql will discard it
+func (oc *observeConn) IsValid() bool {
+	if v, ok := oc.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// start call hook before exec or query,
+// the returned func observe the duration and end the hook
+func (oc *observeConn) start(ctx context.Context, operation, query string) func(err error) {
+	var (
+		begin = time.Now()
+		end   func(err error)
+	)
+	if oc.hook != nil {
+		end = oc.hook(ctx, operation, query)
+	}
+	return func(err error) {
+		if oc.observe != nil {
+			oc.observe(operation, time.Since(begin))
+		}
+		if end != nil {
+			end(err)
+		}
+	}
+}
+
+// setskip save the observe of query which driver skipped, it will be end by the retry
+func (oc *observeConn) setskip(query string, done func(err error)) {
+	if old := oc.takeskip(oc.skipquery); old != nil {
+		old(nil)
+	}
+	oc.skipquery, oc.skipdone = query, done
+}
+
+// takeskip return the observe of query which driver skipped
+func (oc *observeConn) takeskip(query string) func(err error) {
+	if oc.skipdone == nil || oc.skipquery != query {
+		return nil
+	}
+	done := oc.skipdone
+	oc.skipquery, oc.skipdone = "", nil
+	return done
+}
+
+// observeStmt observe exec and query duration
+type observeStmt struct {
+	driver.Stmt
+	conn  *observeConn
+	query string
+}
+
+func (s *observeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (_ driver.Result, err error) {
+	done := s.start(ctx, "exec")
+	defer func() { done(err) }()
+	if se, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return se.ExecContext(ctx, args)
+	}
+	values, err := namedValueToValue(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values) // nolint
+}
+
+func (s *observeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (_ driver.Rows, err error) {
+	done := s.start(ctx, "query")
+	defer func() { done(err) }()
+	if sq, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return sq.QueryContext(ctx, args)
+	}
+	values, err := namedValueToValue(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values) // nolint
+}
+
+func (s *observeStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if c, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return c.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// start return the observe of query skipped by driver if this is the retry, otherwise start a new observe
+func (s *observeStmt) start(ctx context.Context, operation string) func(err error) {
+	if done := s.conn.takeskip(s.query); done != nil {
+		return done
+	}
+	return s.conn.start(ctx, operation, s.query)
+}
+
+func namedValueToValue(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, driver.ErrSkip
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
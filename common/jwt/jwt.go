@@ -1,45 +1,155 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// 签名密钥从配置文件中读取，token头部带有kid，轮换密钥时先添加新密钥并切换activekid，
+// 旧密钥在refresh token过期后再删除，已签发的token仍可以使用旧密钥校验
+
 const (
-	secret = "WteHkuweywy_egf7263i,ewd"
+	// TypeAccess access token, used to request api
+	TypeAccess = "access"
+	// TypeRefresh refresh token, only used to get new access token
+	TypeRefresh = "refresh"
+
+	issuer = "crocodile"
+	kidkey = "kid"
 )
 
+// Key jwt signing key
+type Key struct {
+	ID     string
+	Secret string
+}
+
+type keyring struct {
+	sync.RWMutex
+	keys       map[string][]byte
+	activekid  string
+	accessttl  time.Duration
+	refreshttl time.Duration
+}
+
+var signkeys = &keyring{
+	accessttl:  15 * time.Minute,
+	refreshttl: 7 * 24 * time.Hour,
+}
+
+func init() {
+	// 未配置密钥时使用随机密钥，重启或多个调度中心之间token会失效
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+	signkeys.keys = map[string][]byte{"": b}
+}
+
+// Init set signing keys, token is signed by active key and can be verified by all keys
+// if keys is empty, random key will be used
+func Init(keys []Key, activekid string, accessttl, refreshttl time.Duration) error {
+	signkeys.Lock()
+	defer signkeys.Unlock()
+	if accessttl > 0 {
+		signkeys.accessttl = accessttl
+	}
+	if refreshttl > 0 {
+		signkeys.refreshttl = refreshttl
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	m := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if key.Secret == "" {
+			return fmt.Errorf("secret of key %s is empty", key.ID)
+		}
+		if _, ok := m[key.ID]; ok {
+			return fmt.Errorf("key %s is duplicate", key.ID)
+		}
+		m[key.ID] = []byte(key.Secret)
+	}
+	if activekid == "" && len(keys) == 1 {
+		activekid = keys[0].ID
+	}
+	if _, ok := m[activekid]; !ok {
+		return fmt.Errorf("active key %s is not exist", activekid)
+	}
+	signkeys.keys = m
+	signkeys.activekid = activekid
+	return nil
+}
+
+// AccessTTL return access token expire duration
+func AccessTTL() time.Duration {
+	signkeys.RLock()
+	defer signkeys.RUnlock()
+	return signkeys.accessttl
+}
+
+// RefreshTTL return refresh token expire duration
+func RefreshTTL() time.Duration {
+	signkeys.RLock()
+	defer signkeys.RUnlock()
+	return signkeys.refreshttl
+}
+
 // Claims Jwt token
 type Claims struct {
 	jwt.StandardClaims
 	UID      string
 	UserName string
+	Type     string
+	// issued time in millisecond, token issued in the same second as revoke user is valid
+	IssuedAtMs int64
 }
 
-// GenerateToken generate get token for uid
-func GenerateToken(uid string, username string) (token string, err error) {
-	var (
-		now         time.Time
-		expireTime  time.Time
-		claims      Claims
-		tokenClaims *jwt.Token
-	)
-	now = time.Now()
-	expireTime = now.Add(7 * 24 * time.Hour)
-
-	claims = Claims{
-		UID:      uid,
-		UserName: username,
+func generate(uid, username, tokentype string) (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand.Read failed: %w", err)
+	}
+	signkeys.RLock()
+	defer signkeys.RUnlock()
+	ttl := signkeys.accessttl
+	if tokentype == TypeRefresh {
+		ttl = signkeys.refreshttl
+	}
+	now := time.Now()
+	claims := Claims{
+		UID:        uid,
+		UserName:   username,
+		Type:       tokentype,
+		IssuedAtMs: unixms(now),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expireTime.Unix(),
-			Issuer:    "crocodile",
+			Id:        hex.EncodeToString(b),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+			Issuer:    issuer,
 		},
 	}
-	tokenClaims = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err = tokenClaims.SignedString([]byte(secret))
+	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenClaims.Header[kidkey] = signkeys.activekid
+	return tokenClaims.SignedString(signkeys.keys[signkeys.activekid])
+}
 
-	return
+// GenerateToken generate access token for uid
+func GenerateToken(uid string, username string) (token string, err error) {
+	return generate(uid, username, TypeAccess)
+}
+
+// GenerateRefreshToken generate refresh token for uid
+func GenerateRefreshToken(uid string, username string) (token string, err error) {
+	return generate(uid, username, TypeRefresh)
 }
 
 // ParseToken parse token is valid
@@ -48,9 +158,19 @@ func ParseToken(token string) (claims *Claims, err error) {
 		tokenClaims *jwt.Token
 		ok          bool
 	)
-	// 解析出token的声明字段
+	// 解析出token的声明字段,根据kid选择校验的密钥
 	tokenClaims, err = jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header[kidkey].(string)
+		signkeys.RLock()
+		defer signkeys.RUnlock()
+		key, ok := signkeys.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("signing key %s is not exist", kid)
+		}
+		return key, nil
 	})
 
 	if tokenClaims != nil {
@@ -58,5 +178,8 @@ func ParseToken(token string) (claims *Claims, err error) {
 			return
 		}
 	}
-	return
+	if err == nil {
+		err = errors.New("token is invalid")
+	}
+	return nil, err
 }
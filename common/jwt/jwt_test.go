@@ -1,6 +1,9 @@
 package jwt
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 var (
 	token string
@@ -29,3 +32,82 @@ func TestParseToken(t *testing.T) {
 	}
 	t.Logf("User Id: %s", calims.Id)
 }
+
+func TestKeyRotation(t *testing.T) {
+	keys, activekid := signkeys.keys, signkeys.activekid
+	accessttl, refreshttl := signkeys.accessttl, signkeys.refreshttl
+	defer func() {
+		signkeys.keys, signkeys.activekid = keys, activekid
+		signkeys.accessttl, signkeys.refreshttl = accessttl, refreshttl
+	}()
+
+	if Init([]Key{{ID: "k1", Secret: "secret1"}}, "k2", 0, 0) == nil {
+		t.Fatalf("want init with not exist active key failed, but success")
+	}
+	err := Init([]Key{{ID: "k1", Secret: "secret1"}}, "", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	token1, err := GenerateToken("121212121", "user")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	refresh, err := GenerateRefreshToken("121212121", "user")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken failed: %v", err)
+	}
+	claims, err := ParseToken(refresh)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	if claims.Type != TypeRefresh || claims.ExpiresAt-claims.IssuedAt != 3600 {
+		t.Errorf("want refresh token expire in 1h, but get %s %ds", claims.Type, claims.ExpiresAt-claims.IssuedAt)
+	}
+
+	// 轮换密钥后旧token仍然有效
+	err = Init([]Key{{ID: "k1", Secret: "secret1"}, {ID: "k2", Secret: "secret2"}}, "k2", 0, 0)
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	token2, err := GenerateToken("121212121", "user")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	for _, token := range []string{token1, token2} {
+		claims, err := ParseToken(token)
+		if err != nil {
+			t.Fatalf("ParseToken failed: %v", err)
+		}
+		if claims.Type != TypeAccess || claims.ExpiresAt-claims.IssuedAt != 60 {
+			t.Errorf("want access token expire in 1m, but get %s %ds", claims.Type, claims.ExpiresAt-claims.IssuedAt)
+		}
+	}
+
+	// 删除旧密钥后旧token失效
+	err = Init([]Key{{ID: "k2", Secret: "secret2"}}, "", 0, 0)
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err = ParseToken(token1); err == nil {
+		t.Errorf("want parse token signed by removed key failed, but success")
+	}
+	if _, err = ParseToken(token2); err != nil {
+		t.Errorf("ParseToken failed: %v", err)
+	}
+}
+
+func TestIssuedBefore(t *testing.T) {
+	now := time.Now()
+	claims := &Claims{IssuedAtMs: unixms(now)}
+	claims.IssuedAt = now.Unix()
+	if !issuedbefore(claims, unixms(now)+1) {
+		t.Error("token issued before revoke time should be revoked")
+	}
+	if issuedbefore(claims, unixms(now)) {
+		t.Error("token issued at revoke time should be valid")
+	}
+	// 旧版本的秒级时间
+	if !issuedbefore(&Claims{StandardClaims: claims.StandardClaims}, now.Unix()+1) {
+		t.Error("old token issued before old revoke time should be revoked")
+	}
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// 吊销的token保存在redis中，所有调度中心共享
+// 单个token按jti保存到token过期，注销用户所有会话时记录注销时间，之前签发的token都无效
+
+const (
+	revokedtoken  = "jwt:revoked:"     // jwt:revoked:jti
+	revokedbefore = "jwt:revokeduser:" // jwt:revokeduser:uid unix time in millisecond
+)
+
+var rc *redis.Client
+
+// InitRevoke set redis client which save revoked tokens
+func InitRevoke(client *redis.Client) {
+	rc = client
+}
+
+// Revoke revoke token until it expire,
+// return false if token is already revoked, so refresh token can only be used once
+func Revoke(claims *Claims) (bool, error) {
+	if rc == nil {
+		return true, nil
+	}
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return true, nil
+	}
+	return rc.SetNX(revokedtoken+claims.Id, 1, ttl).Result()
+}
+
+// RevokeUser revoke all tokens of user which issued before now
+func RevokeUser(uid string) error {
+	if rc == nil {
+		return nil
+	}
+	// refresh token live longest, all tokens issued before is expired after refreshttl
+	return rc.Set(revokedbefore+uid, unixms(time.Now()), RefreshTTL()).Err()
+}
+
+// IsRevoked check token or all tokens of user is revoked
+func IsRevoked(claims *Claims) (bool, error) {
+	if rc == nil {
+		return false, nil
+	}
+	pipe := rc.Pipeline()
+	exist := pipe.Exists(revokedtoken + claims.Id)
+	before := pipe.Get(revokedbefore + claims.UID)
+	_, err := pipe.Exec()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+	if exist.Val() > 0 {
+		return true, nil
+	}
+	if before.Err() == redis.Nil {
+		return false, nil
+	}
+	revoketime, err := strconv.ParseInt(before.Val(), 10, 64)
+	if err != nil {
+		return false, err
+	}
+	return issuedbefore(claims, revoketime), nil
+}
+
+// issuedbefore check token is issued before revoke time in millisecond
+func issuedbefore(claims *Claims, revoketime int64) bool {
+	issued := claims.IssuedAtMs
+	if issued == 0 {
+		// 旧版本签发的token没有毫秒时间
+		issued = claims.IssuedAt * 1000
+	}
+	if revoketime < 1e12 {
+		// 旧版本保存的注销时间为秒
+		revoketime *= 1000
+	}
+	return issued < revoketime
+}
+
+func unixms(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
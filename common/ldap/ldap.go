@@ -0,0 +1,228 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAP登陆
+// 使用服务账号查找用户DN、所属组和是否被禁用，然后使用用户DN和密码绑定校验密码，
+// 支持ldaps://和StartTLS
+
+var (
+	// ErrInvalidCredentials user name or password is wrong
+	ErrInvalidCredentials = errors.New("ldap invalid credentials")
+	// ErrUserNotExist user is not found by user filter
+	ErrUserNotExist = errors.New("ldap user is not exist")
+	// ErrUserDisabled user match disabled filter
+	ErrUserDisabled = errors.New("ldap user is disabled")
+)
+
+// Config ldap server and search config
+type Config struct {
+	URL                string // ldap://host:389 or ldaps://host:636
+	StartTLS           bool
+	CAFile             string
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // %s is user name, default (uid=%s)
+	UserNameAttr       string // default uid
+	GroupBaseDN        string // default BaseDN
+	GroupFilter        string // %s is user dn, default (member=%s)
+	GroupNameAttr      string // default cn
+	DisabledFilter     string // user entry match it is disabled, like (nsAccountLock=TRUE)
+	Timeout            time.Duration
+}
+
+// User ldap user
+type User struct {
+	DN       string
+	Name     string
+	Groups   []string
+	Disabled bool
+}
+
+// Client ldap client, a new connection is created for each call
+type Client struct {
+	conf    Config
+	tlsconf *tls.Config
+}
+
+// New return ldap client
+func New(conf Config) (*Client, error) {
+	if conf.UserFilter == "" {
+		conf.UserFilter = "(uid=%s)"
+	}
+	if conf.UserNameAttr == "" {
+		conf.UserNameAttr = "uid"
+	}
+	if conf.GroupBaseDN == "" {
+		conf.GroupBaseDN = conf.BaseDN
+	}
+	if conf.GroupFilter == "" {
+		conf.GroupFilter = "(member=%s)"
+	}
+	if conf.GroupNameAttr == "" {
+		conf.GroupNameAttr = "cn"
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = 10 * time.Second
+	}
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("url.Parse failed: %w", err)
+	}
+	host, _, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host = u.Host
+	}
+	tlsconf := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+	if conf.CAFile != "" {
+		ca, err := ioutil.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ioutil.ReadFile failed: %w", err)
+		}
+		tlsconf.RootCAs = x509.NewCertPool()
+		if !tlsconf.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no cert is found in %s", conf.CAFile)
+		}
+	}
+	return &Client{conf: conf, tlsconf: tlsconf}, nil
+}
+
+// connect dial ldap server and bind service account
+func (c *Client) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(c.conf.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: c.conf.Timeout}),
+		ldap.DialWithTLSConfig(c.tlsconf))
+	if err != nil {
+		return nil, fmt.Errorf("ldap.DialURL failed: %w", err)
+	}
+	conn.SetTimeout(c.conf.Timeout)
+	if c.conf.StartTLS {
+		err = conn.StartTLS(c.tlsconf)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("conn.StartTLS failed: %w", err)
+		}
+	}
+	if c.conf.BindDN != "" {
+		err = conn.Bind(c.conf.BindDN, c.conf.BindPassword)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("bind %s failed: %w", c.conf.BindDN, err)
+		}
+	}
+	return conn, nil
+}
+
+// Authenticate check user name and password, return user with groups
+func (c *Client) Authenticate(name, password string) (*User, error) {
+	// empty password is an unauthenticated bind, it always success
+	if name == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	res, err := conn.Search(ldap.NewSearchRequest(c.conf.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(c.conf.UserFilter, ldap.EscapeFilter(name)),
+		[]string{c.conf.UserNameAttr}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("search user %s failed: %w", name, err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, ErrUserNotExist
+	}
+	user, err := c.getuser(conn, res.Entries[0])
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	err = conn.Bind(user.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bind %s failed: %w", user.DN, err)
+	}
+	return user, nil
+}
+
+// Users return users of dns, user which is not exist is not in result
+func (c *Client) Users(dns []string) (map[string]*User, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	users := make(map[string]*User, len(dns))
+	for _, dn := range dns {
+		res, err := conn.Search(ldap.NewSearchRequest(dn,
+			ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false,
+			"(objectClass=*)", []string{c.conf.UserNameAttr}, nil))
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("search user %s failed: %w", dn, err)
+		}
+		if len(res.Entries) != 1 {
+			continue
+		}
+		user, err := c.getuser(conn, res.Entries[0])
+		if err != nil {
+			return nil, err
+		}
+		users[dn] = user
+	}
+	return users, nil
+}
+
+// getuser get groups and disabled status of user entry
+func (c *Client) getuser(conn *ldap.Conn, entry *ldap.Entry) (*User, error) {
+	user := &User{
+		DN:     entry.DN,
+		Name:   entry.GetAttributeValue(c.conf.UserNameAttr),
+		Groups: []string{},
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(c.conf.GroupBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(c.conf.GroupFilter, ldap.EscapeFilter(entry.DN)),
+		[]string{c.conf.GroupNameAttr}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("search groups of %s failed: %w", entry.DN, err)
+	}
+	for _, group := range res.Entries {
+		user.Groups = append(user.Groups, group.GetAttributeValue(c.conf.GroupNameAttr))
+	}
+	if c.conf.DisabledFilter == "" {
+		return user, nil
+	}
+	res, err = conn.Search(ldap.NewSearchRequest(entry.DN,
+		ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false,
+		c.conf.DisabledFilter, []string{"1.1"}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("search disabled status of %s failed: %w", entry.DN, err)
+	}
+	user.Disabled = len(res.Entries) > 0
+	return user, nil
+}
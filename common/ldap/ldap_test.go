@@ -0,0 +1,296 @@
+package ldap
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// mockserver in process ldap server, support simple bind, search with
+// and/or/not/equality/present filter and StartTLS
+type mockserver struct {
+	ln        net.Listener
+	tlsconf   *tls.Config
+	passwords map[string]string
+	entries   map[string]map[string][]string
+}
+
+func newmockserver(t *testing.T, ldaps bool) (*mockserver, string) {
+	cert, capath := gencert(t)
+	s := &mockserver{
+		tlsconf: &tls.Config{Certificates: []tls.Certificate{cert}},
+		passwords: map[string]string{
+			"cn=admin,dc=example,dc=com":            "adminpass",
+			"uid=alice,ou=people,dc=example,dc=com": "alicepass",
+			"uid=bob,ou=people,dc=example,dc=com":   "bobpass",
+		},
+		entries: map[string]map[string][]string{
+			"uid=alice,ou=people,dc=example,dc=com": {"objectClass": {"person"}, "uid": {"alice"}},
+			"uid=bob,ou=people,dc=example,dc=com":   {"objectClass": {"person"}, "uid": {"bob"}, "nsAccountLock": {"TRUE"}},
+			"cn=ops,ou=groups,dc=example,dc=com": {"objectClass": {"groupOfNames"}, "cn": {"ops"},
+				"member": {"uid=alice,ou=people,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"}},
+			"cn=dev,ou=groups,dc=example,dc=com": {"objectClass": {"groupOfNames"}, "cn": {"dev"},
+				"member": {"uid=alice,ou=people,dc=example,dc=com"}},
+		},
+	}
+	var err error
+	if ldaps {
+		s.ln, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsconf)
+	} else {
+		s.ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := s.ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, capath
+}
+
+func (s *mockserver) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	binddn := ""
+	for {
+		p, err := ber.ReadPacket(conn)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		id := p.Children[0].Value.(int64)
+		op := p.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			if pass, ok := s.passwords[dn]; !ok || pass != password {
+				s.result(conn, id, ldap.ApplicationBindResponse, ldap.LDAPResultInvalidCredentials)
+				continue
+			}
+			binddn = dn
+			s.result(conn, id, ldap.ApplicationBindResponse, ldap.LDAPResultSuccess)
+		case ldap.ApplicationSearchRequest:
+			if binddn == "" {
+				s.result(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights)
+				continue
+			}
+			base := op.Children[0].Value.(string)
+			scope := op.Children[1].Value.(int64)
+			if scope == ldap.ScopeBaseObject && s.entries[base] == nil {
+				s.result(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject)
+				continue
+			}
+			for dn, attrs := range s.entries {
+				if scope == ldap.ScopeBaseObject && dn != base || !strings.HasSuffix(dn, base) {
+					continue
+				}
+				if !match(op.Children[6], attrs) {
+					continue
+				}
+				entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+				entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, ""))
+				attrlist := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				for name, values := range attrs {
+					attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+					set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					for _, v := range values {
+						set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+					}
+					attr.AppendChild(set)
+					attrlist.AppendChild(attr)
+				}
+				entry.AppendChild(attrlist)
+				s.write(conn, id, entry)
+			}
+			s.result(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+		case ldap.ApplicationExtendedRequest:
+			s.result(conn, id, ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess)
+			tlsconn := tls.Server(conn, s.tlsconf)
+			if tlsconn.Handshake() != nil {
+				return
+			}
+			conn = tlsconn
+		default:
+			return
+		}
+	}
+}
+
+func (s *mockserver) result(conn net.Conn, id int64, tag ber.Tag, code uint16) {
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	s.write(conn, id, res)
+}
+
+func (s *mockserver) write(conn net.Conn, id int64, op *ber.Packet) {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	p.AppendChild(op)
+	conn.Write(p.Bytes())
+}
+
+func match(filter *ber.Packet, attrs map[string][]string) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, f := range filter.Children {
+			if !match(f, attrs) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, f := range filter.Children {
+			if match(f, attrs) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !match(filter.Children[0], attrs)
+	case ldap.FilterEqualityMatch:
+		for _, v := range attrs[filter.Children[0].Data.String()] {
+			if strings.EqualFold(v, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		return filter.Data.String() == "objectClass" || len(attrs[filter.Data.String()]) > 0
+	default:
+		return false
+	}
+}
+
+// gencert generate self signed cert of 127.0.0.1 and return ca file path
+func gencert(t *testing.T) (tls.Certificate, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certpem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	cert, err := tls.X509KeyPair(certpem,
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "ldap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	capath := filepath.Join(dir, "ca.pem")
+	err = ioutil.WriteFile(capath, certpem, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, capath
+}
+
+func TestClient(t *testing.T) {
+	for _, ldaps := range []bool{false, true} {
+		s, capath := newmockserver(t, ldaps)
+		defer s.ln.Close()
+		defer os.RemoveAll(filepath.Dir(capath))
+		conf := Config{
+			URL:            "ldap://" + s.ln.Addr().String(),
+			StartTLS:       !ldaps,
+			CAFile:         capath,
+			BindDN:         "cn=admin,dc=example,dc=com",
+			BindPassword:   "adminpass",
+			BaseDN:         "dc=example,dc=com",
+			UserFilter:     "(&(objectClass=person)(uid=%s))",
+			GroupFilter:    "(&(objectClass=groupOfNames)(member=%s))",
+			DisabledFilter: "(nsAccountLock=TRUE)",
+		}
+		if ldaps {
+			conf.URL = "ldaps://" + s.ln.Addr().String()
+		}
+		c, err := New(conf)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		user, err := c.Authenticate("alice", "alicepass")
+		if err != nil {
+			t.Fatalf("Authenticate failed: %v", err)
+		}
+		if user.Name != "alice" || len(user.Groups) != 2 {
+			t.Errorf("get unexpected user %+v", user)
+		}
+		for _, tc := range []struct {
+			name, password string
+			err            error
+		}{
+			{"alice", "wrongpass", ErrInvalidCredentials},
+			{"alice", "", ErrInvalidCredentials},
+			{"carol", "carolpass", ErrUserNotExist},
+			{"*", "alicepass", ErrUserNotExist},
+			{"bob", "bobpass", ErrUserDisabled},
+		} {
+			_, err = c.Authenticate(tc.name, tc.password)
+			if err != tc.err {
+				t.Errorf("Authenticate %s want err %v, but get %v", tc.name, tc.err, err)
+			}
+		}
+
+		users, err := c.Users([]string{
+			"uid=alice,ou=people,dc=example,dc=com",
+			"uid=bob,ou=people,dc=example,dc=com",
+			"uid=carol,ou=people,dc=example,dc=com",
+		})
+		if err != nil {
+			t.Fatalf("Users failed: %v", err)
+		}
+		if len(users) != 2 || users["uid=alice,ou=people,dc=example,dc=com"].Disabled ||
+			!users["uid=bob,ou=people,dc=example,dc=com"].Disabled {
+			t.Errorf("get unexpected users %v", users)
+		}
+
+		// wrong ca
+		_, othercapath := gencert(t)
+		defer os.RemoveAll(filepath.Dir(othercapath))
+		conf.CAFile = othercapath
+		c, err = New(conf)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if _, err = c.Authenticate("alice", "alicepass"); err == nil {
+			t.Errorf("want authenticate with untrusted server cert failed, but success")
+		}
+	}
+}
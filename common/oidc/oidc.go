@@ -0,0 +1,284 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OpenID Connect 授权码模式登陆
+// 通过issuer的/.well-known/openid-configuration获取授权、token和jwks地址，
+// 使用code换取id token后校验签名(RS256)、issuer、audience、过期时间和nonce
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// unknown kid will reload jwks, but at most once per interval
+	jwksReloadInterval = time.Minute
+)
+
+// Config oidc provider and client config
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // default openid profile email
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider openid connect provider, metadata is discovered when first used
+type Provider struct {
+	sync.Mutex
+	conf       Config
+	client     *http.Client
+	meta       *metadata
+	keys       map[string]*rsa.PublicKey
+	keysloaded time.Time
+}
+
+// New return provider of config
+func New(conf Config) *Provider {
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &Provider{
+		conf:   conf,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *Provider) getjson(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("http.NewRequest failed: %w", err)
+	}
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("get %s failed: %w", u, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s failed: status %s", u, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// discover get provider metadata
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+	meta := &metadata{}
+	err := p.getjson(ctx, strings.TrimSuffix(p.conf.Issuer, "/")+discoveryPath, meta)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Issuer != p.conf.Issuer {
+		return nil, fmt.Errorf("issuer %s in discovery is not match %s", meta.Issuer, p.conf.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("authorization_endpoint, token_endpoint or jwks_uri is empty in discovery")
+	}
+	p.meta = meta
+	return meta, nil
+}
+
+// AuthCodeURL return url which redirect user to login
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.conf.ClientID},
+		"redirect_uri":  {p.conf.RedirectURL},
+		"scope":         {strings.Join(p.conf.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		return meta.AuthorizationEndpoint + "&" + v.Encode(), nil
+	}
+	return meta.AuthorizationEndpoint + "?" + v.Encode(), nil
+}
+
+// Exchange exchange code for id token and verify it, return claims of id token
+func (p *Provider) Exchange(ctx context.Context, code, nonce string) (Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.conf.RedirectURL},
+	}
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.conf.ClientID), url.QueryEscape(p.conf.ClientSecret))
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("request token failed: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadAll failed: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request token failed: status %s %s", res.Status, body)
+	}
+	token := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal failed: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("id_token is empty in token response")
+	}
+	return p.Verify(ctx, token.IDToken, nonce)
+}
+
+// Verify verify id token signature and claims
+func (p *Provider) Verify(ctx context.Context, idtoken, nonce string) (Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idtoken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.getkey(ctx, meta.JWKSURI, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify id token failed: %w", err)
+	}
+	if !claims.VerifyIssuer(meta.Issuer, true) {
+		return nil, fmt.Errorf("issuer of id token is not %s", meta.Issuer)
+	}
+	// aud may be a string or an array
+	if !Claims(claims).contains("aud", p.conf.ClientID) {
+		return nil, fmt.Errorf("audience of id token is not %s", p.conf.ClientID)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("id token has no exp")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, errors.New("nonce of id token is not match")
+	}
+	return Claims(claims), nil
+}
+
+// getkey return public key of kid, reload jwks if kid is not found
+func (p *Provider) getkey(ctx context.Context, jwksuri, kid string) (*rsa.PublicKey, error) {
+	p.Lock()
+	defer p.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysloaded) < jwksReloadInterval {
+		return nil, fmt.Errorf("key %s is not exist in jwks", kid)
+	}
+	jwks := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	err := p.getjson(ctx, jwksuri, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n of key %s failed: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e of key %s failed: %w", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	p.keys = keys
+	p.keysloaded = time.Now()
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("key %s is not exist in jwks", kid)
+	}
+	return key, nil
+}
+
+// Claims id token claims
+type Claims map[string]interface{}
+
+// String return string claim
+func (c Claims) String(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+// Strings return claim which may be a string or an array of string, like groups
+func (c Claims) Strings(key string) []string {
+	switch v := c[key].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+func (c Claims) contains(key, value string) bool {
+	for _, v := range c.Strings(key) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// mockprovider local oidc provider, token endpoint return id token with claims
+type mockprovider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newmockprovider(t *testing.T) *mockprovider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp := &mockprovider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(metadata{
+			Issuer:                mp.URL,
+			AuthorizationEndpoint: mp.URL + "/authorize",
+			TokenEndpoint:         mp.URL + "/token",
+			JWKSURI:               mp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "crocodile" || secret != "secret" || r.FormValue("code") != "code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, mp.claims)
+		token.Header["kid"] = "k1"
+		idtoken, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idtoken})
+	})
+	mp.Server = httptest.NewServer(mux)
+	return mp
+}
+
+func TestProvider(t *testing.T) {
+	mp := newmockprovider(t)
+	defer mp.Close()
+	ctx := context.Background()
+	p := New(Config{
+		Issuer:       mp.URL,
+		ClientID:     "crocodile",
+		ClientSecret: "secret",
+		RedirectURL:  "http://127.0.0.1:8080/api/v1/user/oidc/callback",
+	})
+	authurl, err := p.AuthCodeURL(ctx, "state", "nonce")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	u, _ := url.Parse(authurl)
+	if u.Path != "/authorize" || u.Query().Get("state") != "state" || u.Query().Get("scope") != "openid profile email" {
+		t.Errorf("get unexpected auth url %s", authurl)
+	}
+
+	validclaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                mp.URL,
+			"aud":                []string{"crocodile"},
+			"sub":                "user1",
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"nonce":              "nonce",
+			"preferred_username": "user1",
+			"groups":             []string{"dev", "ops"},
+		}
+	}
+	mp.claims = validclaims()
+	claims, err := p.Exchange(ctx, "code", "nonce")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if claims.String("sub") != "user1" || len(claims.Strings("groups")) != 2 {
+		t.Errorf("get unexpected claims %v", claims)
+	}
+
+	if _, err = p.Exchange(ctx, "badcode", "nonce"); err == nil {
+		t.Errorf("want exchange bad code failed, but success")
+	}
+	if _, err = p.Exchange(ctx, "code", "othernonce"); err == nil {
+		t.Errorf("want exchange with other nonce failed, but success")
+	}
+	mp.claims = validclaims()
+	mp.claims["aud"] = "other"
+	if _, err = p.Exchange(ctx, "code", "nonce"); err == nil {
+		t.Errorf("want exchange token of other audience failed, but success")
+	}
+	mp.claims = validclaims()
+	mp.claims["iss"] = "http://other"
+	if _, err = p.Exchange(ctx, "code", "nonce"); err == nil {
+		t.Errorf("want exchange token of other issuer failed, but success")
+	}
+	mp.claims = validclaims()
+	mp.claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	if _, err = p.Exchange(ctx, "code", "nonce"); err == nil {
+		t.Errorf("want exchange expired token failed, but success")
+	}
+
+	// token signed by other key
+	otherkey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, validclaims())
+	token.Header["kid"] = "k1"
+	idtoken, _ := token.SignedString(otherkey)
+	if _, err = p.Verify(ctx, idtoken, "nonce"); err == nil {
+		t.Errorf("want verify token signed by other key failed, but success")
+	}
+	// hs256 token signed by client secret is not accept
+	token = jwt.NewWithClaims(jwt.SigningMethodHS256, validclaims())
+	idtoken, _ = token.SignedString([]byte("secret"))
+	if _, err = p.Verify(ctx, idtoken, "nonce"); err == nil {
+		t.Errorf("want verify hs256 token failed, but success")
+	}
+}
@@ -0,0 +1,27 @@
+package sysinfo
+
+import (
+	"runtime"
+)
+
+// Info host resource usage
+type Info struct {
+	CPUNum       int
+	Load1        float64
+	Load5        float64
+	Load15       float64
+	MemTotal     uint64 // bytes
+	MemAvailable uint64 // bytes
+	DiskTotal    uint64 // bytes
+	DiskFree     uint64 // bytes
+}
+
+// Get return host resource usage, disk usage is of the filesystem where path is located
+// if some resource can not get, it will be 0
+func Get(path string) Info {
+	info := Info{CPUNum: runtime.NumCPU()}
+	info.Load1, info.Load5, info.Load15 = loadavg()
+	info.MemTotal, info.MemAvailable = meminfo()
+	info.DiskTotal, info.DiskFree = diskusage(path)
+	return info
+}
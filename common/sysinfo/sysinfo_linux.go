@@ -0,0 +1,91 @@
+package sysinfo
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// loadavg read load average from /proc/loadavg
+func loadavg() (load1, load5, load15 float64) {
+	content, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return
+	}
+	return parseloadavg(content)
+}
+
+// parseloadavg parse content like "0.20 0.18 0.12 1/80 11206"
+func parseloadavg(content []byte) (load1, load5, load15 float64) {
+	fields := strings.Fields(string(content))
+	if len(fields) < 3 {
+		return
+	}
+	load1, _ = strconv.ParseFloat(fields[0], 64)
+	load5, _ = strconv.ParseFloat(fields[1], 64)
+	load15, _ = strconv.ParseFloat(fields[2], 64)
+	return
+}
+
+// meminfo read memory total and available from /proc/meminfo
+func meminfo() (total, available uint64) {
+	content, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return
+	}
+	return parsememinfo(content)
+}
+
+// parsememinfo parse content like "MemTotal:  1882064 kB"
+func parsememinfo(content []byte) (total, available uint64) {
+	var free, buffers, cached uint64
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		// kB
+		value *= 1024
+		switch fields[0] {
+		case "MemTotal:":
+			total = value
+		case "MemAvailable:":
+			available = value
+		case "MemFree:":
+			free = value
+		case "Buffers:":
+			buffers = value
+		case "Cached:":
+			cached = value
+		}
+	}
+	// kernel older than 3.14 has no MemAvailable
+	if available == 0 {
+		available = free + buffers + cached
+	}
+	return
+}
+
+// diskusage return total and free bytes of the filesystem where path is located
+func diskusage(path string) (total, free uint64) {
+	if path == "" {
+		path, _ = os.Getwd()
+	}
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return
+	}
+	total = stat.Blocks * uint64(stat.Bsize)
+	free = stat.Bavail * uint64(stat.Bsize)
+	return
+}
@@ -0,0 +1,39 @@
+package sysinfo
+
+import "testing"
+
+func Test_parsememinfo(t *testing.T) {
+	content := []byte(`MemTotal:        1882064 kB
+MemFree:          126704 kB
+MemAvailable:     913312 kB
+Buffers:          101960 kB
+Cached:           692552 kB`)
+	total, available := parsememinfo(content)
+	if total != 1882064*1024 || available != 913312*1024 {
+		t.Errorf("parsememinfo get %d %d", total, available)
+	}
+
+	// no MemAvailable
+	content = []byte(`MemTotal:        1882064 kB
+MemFree:          100 kB
+Buffers:          10 kB
+Cached:           1 kB`)
+	_, available = parsememinfo(content)
+	if available != 111*1024 {
+		t.Errorf("want get available %d, but get %d", 111*1024, available)
+	}
+}
+
+func Test_parseloadavg(t *testing.T) {
+	load1, load5, load15 := parseloadavg([]byte("0.20 0.18 0.12 1/80 11206\n"))
+	if load1 != 0.20 || load5 != 0.18 || load15 != 0.12 {
+		t.Errorf("parseloadavg get %v %v %v", load1, load5, load15)
+	}
+}
+
+func TestGet(t *testing.T) {
+	info := Get("")
+	if info.CPUNum <= 0 || info.MemTotal == 0 || info.DiskTotal == 0 {
+		t.Errorf("get info failed: %+v", info)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package sysinfo
+
+// loadavg is not support
+func loadavg() (load1, load5, load15 float64) {
+	return
+}
+
+// meminfo is not support
+func meminfo() (total, available uint64) {
+	return
+}
+
+// diskusage is not support
+func diskusage(path string) (total, free uint64) {
+	return
+}
@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP 两步验证 RFC 6238
+// 使用HMAC-SHA1，30秒一个时间步，6位数字，和常见的验证器App(Google Authenticator等)兼容
+
+const (
+	period     = 30
+	digits     = 6
+	secretSize = 20
+	// recovery code like 3f2a9-c81d0
+	recoverySize = 5
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generate random base32 secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand.Read failed: %w", err)
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// Step return time step of t
+func Step(t time.Time) int64 {
+	return t.Unix() / period
+}
+
+// Code return code of secret at time step
+func Code(secret string, step int64) (string, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("decode secret failed: %w", err)
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", digits, value%1000000), nil
+}
+
+// Validate check code of secret in skew steps around t, return the matched step
+func Validate(secret, code string, t time.Time, skew int) (int64, bool) {
+	if len(code) != digits {
+		return 0, false
+	}
+	step := Step(t)
+	for i := -skew; i <= skew; i++ {
+		want, err := Code(secret, step+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(want), []byte(code)) {
+			return step + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// URL return otpauth url which can be added to authenticator app
+func URL(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("period", fmt.Sprint(period))
+	v.Set("digits", fmt.Sprint(digits))
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s",
+		url.PathEscape(issuer), url.PathEscape(account), v.Encode())
+}
+
+// GenerateRecoveryCodes generate n one-time recovery codes
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, recoverySize)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, fmt.Errorf("rand.Read failed: %w", err)
+		}
+		code := hex.EncodeToString(b)
+		codes = append(codes, code[:recoverySize]+"-"+code[recoverySize:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode return sha256 of recovery code, case and '-' are ignored
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
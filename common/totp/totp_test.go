@@ -0,0 +1,72 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B SHA1测试向量，取后6位
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, test := range tests {
+		code, err := Code(secret, Step(time.Unix(test.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code failed: %v", err)
+		}
+		if code != test.code {
+			t.Errorf("Code at %d = %s, want %s", test.unix, code, test.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	now := time.Now()
+	code, err := Code(secret, Step(now)-1)
+	if err != nil {
+		t.Fatalf("Code failed: %v", err)
+	}
+	step, ok := Validate(secret, code, now, 1)
+	if !ok || step != Step(now)-1 {
+		t.Errorf("Validate previous step code failed, step %d ok %v", step, ok)
+	}
+	if _, ok = Validate(secret, code, now.Add(2*period*time.Second), 1); ok {
+		t.Error("Validate code out of skew should fail")
+	}
+	if _, ok = Validate(secret, "12345", now, 1); ok {
+		t.Error("Validate short code should fail")
+	}
+}
+
+func TestRecoveryCode(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes failed: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes return %d codes", len(codes))
+	}
+	code := codes[0]
+	if HashRecoveryCode(code) != HashRecoveryCode(" "+strings.ToUpper(strings.Replace(code, "-", "", 1))) {
+		t.Error("HashRecoveryCode should ignore case and '-'")
+	}
+	if HashRecoveryCode(codes[0]) == HashRecoveryCode(codes[1]) {
+		t.Error("recovery codes should be different")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/labulaka521/crocodile/common/utils"
 	"github.com/labulaka521/crocodile/core/config"
 	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/stats"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"go.uber.org/zap"
 )
@@ -55,6 +56,30 @@ const (
 {{- end }}`
 )
 
+const (
+	approvaltitle     = "审核请求 {{ .Action }} {{ .TaskName }} {{ .Status }}"
+	approvalalarmtmpl = `请求         : {{ .Action }}
+任务名称    : {{ .TaskName }}
+申请人      : {{ .CreateBy }}
+审核人      : {{ .Approvers }}
+状态         : {{ .Status }}
+{{- if .ReviewBy }}
+处理人      : {{ .ReviewBy }}
+{{- end }}
+{{- if .ReviewRemark }}
+审核备注    : {{ .ReviewRemark }}
+{{- end }}`
+)
+
+const (
+	hosttitle     = "主机通知 {{ .Addr }} {{ .Event }}"
+	hostalarmtmpl = `主机地址    : {{ .Addr }}
+主机名      : {{ .HostName }}
+主机ID      : {{ .HostID }}
+事件         : {{ .Event }}
+事件时间   : {{ .EventTime }}`
+)
+
 // InitAlarm init alarm notify
 func InitAlarm() {
 	log.Info("start init alarm")
@@ -171,29 +196,8 @@ func sendalarm(notifyuids []string, taskname, taskid, starttime, endtime, status
 		return err
 	}
 	alarmUsernNames := make([]string, 0, len(alarmusers))
-	alarmEmail := make([]string, 0, len(alarmusers))
-	alarmWeChat := make([]string, 0, len(alarmusers))
-	alarmDingDing := make([]string, 0, len(alarmusers))
-	alarmSlack := make([]string, 0, len(alarmusers)) // tg
-	alarmTelegram := make([]string, 0, len(alarmusers))
-
 	for _, user := range alarmusers {
-		if user.Email != "" {
-			alarmEmail = append(alarmEmail, user.Email)
-		}
-		if user.WeChat != "" {
-			alarmWeChat = append(alarmWeChat, user.WeChat)
-		}
-		if user.DingPhone != "" {
-			alarmDingDing = append(alarmDingDing, user.DingPhone)
-		}
-		if user.Slack != "" {
-			alarmSlack = append(alarmSlack, user.Slack)
-		}
-		if user.Telegram != "" {
-			alarmTelegram = append(alarmTelegram, user.Telegram)
-		}
-		alarmUsernNames = append(alarmUsernNames,user.Name)
+		alarmUsernNames = append(alarmUsernNames, user.Name)
 	}
 
 	notifymsg := notifymsg{
@@ -211,17 +215,7 @@ func sendalarm(notifyuids []string, taskname, taskid, starttime, endtime, status
 	}
 
 	// send webhook
-
-	if config.CoreConf.Notify.WebHook.Enable {
-		log.Debug("start send alarm to webhooks")
-		_, err := notify.JSONPost(http.MethodPost, config.CoreConf.Notify.WebHook.WebHookURL, notifymsg, http.DefaultClient)
-		if err != nil {
-			log.Error("send webhook failed",
-				zap.String("webhookurl", config.CoreConf.Notify.WebHook.WebHookURL),
-				zap.Error(err),
-			)
-		}
-	}
+	sendwebhook(notifymsg)
 
 	alarmtitle, err := template.New("title").Parse(title)
 	if err != nil {
@@ -249,15 +243,44 @@ func sendalarm(notifyuids []string, taskname, taskid, starttime, endtime, status
 		return err
 	}
 
-	for _, uid := range notifyuids {
+	return sendnotify(alarmusers, define.TaskNotify, taskname, titlebuf.String(), contentbuf.String())
+}
+
+// sendnotify save notify and send notify to users by all enabled senders
+func sendnotify(users []define.User, notifytype define.NotifyType, notifytitle, title, content string) error {
+	alarmEmail := make([]string, 0, len(users))
+	alarmWeChat := make([]string, 0, len(users))
+	alarmDingDing := make([]string, 0, len(users))
+	alarmSlack := make([]string, 0, len(users)) // tg
+	alarmTelegram := make([]string, 0, len(users))
+
+	for _, user := range users {
+		if user.Email != "" {
+			alarmEmail = append(alarmEmail, user.Email)
+		}
+		if user.WeChat != "" {
+			alarmWeChat = append(alarmWeChat, user.WeChat)
+		}
+		if user.DingPhone != "" {
+			alarmDingDing = append(alarmDingDing, user.DingPhone)
+		}
+		if user.Slack != "" {
+			alarmSlack = append(alarmSlack, user.Slack)
+		}
+		if user.Telegram != "" {
+			alarmTelegram = append(alarmTelegram, user.Telegram)
+		}
+	}
+
+	for _, user := range users {
 		notify := define.Notify{
-			NotifyType: define.TaskNotify,
-			NotifyUID:  uid,
-			Title:      taskname,
-			Content:    contentbuf.String(),
+			NotifyType: notifytype,
+			NotifyUID:  user.ID,
+			Title:      notifytitle,
+			Content:    content,
 			NotifyTime: time.Now().Unix(),
 		}
-		err = model.SaveNewNotify(context.Background(), notify)
+		err := model.SaveNewNotify(context.Background(), notify)
 		if err != nil {
 			log.Error("model.SaveNewNotify", zap.Error(err))
 		}
@@ -266,42 +289,178 @@ func sendalarm(notifyuids []string, taskname, taskid, starttime, endtime, status
 	// send notify alarm
 	if send.dingding != nil && len(alarmDingDing) != 0 {
 		log.Debug("start send alarm to dingding", zap.Strings("alarmuser", alarmDingDing))
-		err := send.dingding.Send(alarmDingDing, titlebuf.String(), contentbuf.String())
+		err := send.dingding.Send(alarmDingDing, title, content)
 		if err != nil {
 			log.Error("send dingding notify failed", zap.Error(err))
+			stats.AlarmSendFailed("dingding")
 		}
 	}
 
 	if send.email != nil && len(alarmEmail) != 0 {
 		log.Debug("start send alarm to email", zap.Strings("alarmuser", alarmEmail))
-		err := send.email.Send(alarmEmail, titlebuf.String(), contentbuf.String())
+		err := send.email.Send(alarmEmail, title, content)
 		if err != nil {
 			log.Error("send email notify failed", zap.Error(err))
+			stats.AlarmSendFailed("email")
 		}
 	}
 
 	if send.wechat != nil && len(alarmWeChat) != 0 {
 		log.Debug("start send alarm to wechat", zap.Strings("alarmuser", alarmWeChat))
-		err := send.wechat.Send(alarmWeChat, titlebuf.String(), contentbuf.String())
+		err := send.wechat.Send(alarmWeChat, title, content)
 		if err != nil {
 			log.Error("send wechat notify failed", zap.Error(err))
+			stats.AlarmSendFailed("wechat")
 		}
 	}
 
 	if send.slack != nil && len(alarmSlack) != 0 {
 		log.Debug("start send alarm to slack", zap.Strings("alarmuser", alarmSlack))
-		err := send.slack.Send(alarmSlack, titlebuf.String(), contentbuf.String())
+		err := send.slack.Send(alarmSlack, title, content)
 		if err != nil {
 			log.Error("send slack notify failed", zap.Error(err))
+			stats.AlarmSendFailed("slack")
 		}
 	}
 
 	if send.telegram != nil && len(alarmTelegram) != 0 {
 		log.Debug("start send alarm to telegram", zap.Strings("alarmuser", alarmTelegram))
-		err := send.telegram.Send(alarmTelegram, titlebuf.String(), contentbuf.String())
+		err := send.telegram.Send(alarmTelegram, title, content)
 		if err != nil {
 			log.Error("send telegram notify failed", zap.Error(err))
+			stats.AlarmSendFailed("telegram")
 		}
 	}
 	return nil
 }
+
+// sendwebhook post msg to webhook
+func sendwebhook(msg interface{}) {
+	if !config.CoreConf.Notify.WebHook.Enable {
+		return
+	}
+	log.Debug("start send alarm to webhooks")
+	_, err := notify.JSONPost(http.MethodPost, config.CoreConf.Notify.WebHook.WebHookURL, msg, http.DefaultClient)
+	if err != nil {
+		log.Error("send webhook failed",
+			zap.String("webhookurl", config.CoreConf.Notify.WebHook.WebHookURL),
+			zap.Error(err),
+		)
+		stats.AlarmSendFailed("webhook")
+	}
+}
+
+type hostnotifymsg struct {
+	HostID     string   `json:"host_id"`
+	Addr       string   `json:"addr"`
+	HostName   string   `json:"hostname"`
+	Event      string   `json:"event"`
+	EventTime  string   `json:"event_time"`
+	AlarmUsers []string `json:"alarm_users"`
+}
+
+// HostNotify send host online or offline notify to host alarm users in config
+func HostNotify(event define.HostEvent) {
+	names := config.CoreConf.Notify.HostAlarmUsers
+	if len(names) == 0 {
+		return
+	}
+	log.Info("start send host alarm", zap.Strings("users", names), zap.String("addr", event.Addr))
+	alarmusers := make([]define.User, 0, len(names))
+	for _, name := range names {
+		user, err := model.GetUserByName(context.Background(), name)
+		if err != nil {
+			log.Error("get host alarm user failed", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		alarmusers = append(alarmusers, *user)
+	}
+	alarmUsernNames := make([]string, 0, len(alarmusers))
+	for _, user := range alarmusers {
+		alarmUsernNames = append(alarmUsernNames, user.Name)
+	}
+	notifymsg := hostnotifymsg{
+		HostID:     event.HostID,
+		Addr:       event.Addr,
+		HostName:   event.HostName,
+		Event:      event.Event.String(),
+		EventTime:  utils.UnixToStr(event.EventTime),
+		AlarmUsers: alarmUsernNames,
+	}
+	sendwebhook(notifymsg)
+
+	var titlebuf, contentbuf bytes.Buffer
+	err := template.Must(template.New("hosttitle").Parse(hosttitle)).Execute(&titlebuf, notifymsg)
+	if err != nil {
+		log.Error("host title template execute failed", zap.Error(err))
+		return
+	}
+	err = template.Must(template.New("hostcontent").Parse(hostalarmtmpl)).Execute(&contentbuf, notifymsg)
+	if err != nil {
+		log.Error("host content template execute failed", zap.Error(err))
+		return
+	}
+	err = sendnotify(alarmusers, define.HostNotify, event.Addr, titlebuf.String(), contentbuf.String())
+	if err != nil {
+		log.Error("sendnotify failed", zap.Error(err))
+	}
+}
+
+type approvalnotifymsg struct {
+	ApprovalID   string   `json:"approval_id"`
+	Action       string   `json:"action"`
+	TaskID       string   `json:"task_id"`
+	TaskName     string   `json:"task_name"`
+	CreateBy     string   `json:"create_by"`
+	Approvers    []string `json:"approvers"`
+	Status       string   `json:"status"`
+	ReviewBy     string   `json:"review_by,omitempty"`
+	ReviewRemark string   `json:"review_remark,omitempty"`
+	AlarmUsers   []string `json:"alarm_users"`
+}
+
+// ApprovalNotify send approval request or review result to users
+func ApprovalNotify(approval *define.Approval, uids []string) {
+	if len(uids) == 0 {
+		return
+	}
+	log.Info("start send approval notify", zap.Strings("uids", uids), zap.String("approval", approval.ID))
+	alarmusers, _, err := model.GetUsers(context.Background(), uids, 0, 0)
+	if err != nil {
+		log.Error("get approval notify users failed", zap.Error(err))
+		return
+	}
+	alarmUsernNames := make([]string, 0, len(alarmusers))
+	for _, user := range alarmusers {
+		alarmUsernNames = append(alarmUsernNames, user.Name)
+	}
+	notifymsg := approvalnotifymsg{
+		ApprovalID:   approval.ID,
+		Action:       approval.Action.String(),
+		TaskID:       approval.TaskID,
+		TaskName:     approval.TaskName,
+		CreateBy:     approval.CreateBy,
+		Approvers:    approval.Approvers,
+		Status:       approval.Status.String(),
+		ReviewBy:     approval.ReviewBy,
+		ReviewRemark: approval.ReviewRemark,
+		AlarmUsers:   alarmUsernNames,
+	}
+	sendwebhook(notifymsg)
+
+	var titlebuf, contentbuf bytes.Buffer
+	err = template.Must(template.New("approvaltitle").Parse(approvaltitle)).Execute(&titlebuf, notifymsg)
+	if err != nil {
+		log.Error("approval title template execute failed", zap.Error(err))
+		return
+	}
+	err = template.Must(template.New("approvalcontent").Parse(approvalalarmtmpl)).Execute(&contentbuf, notifymsg)
+	if err != nil {
+		log.Error("approval content template execute failed", zap.Error(err))
+		return
+	}
+	err = sendnotify(alarmusers, define.ReviewReq, approval.TaskName, titlebuf.String(), contentbuf.String())
+	if err != nil {
+		log.Error("sendnotify failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,273 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 内置CA
+// 调度中心和worker使用同一个CA签发的证书进行双向认证，证书的OU标记证书角色(scheduler/worker)，
+// worker证书的CN为worker的主机名，调度中心只接受worker证书的请求，worker只接受调度中心证书的请求
+// 吊销证书后将crl文件分发到各个节点，节点会定时重新加载证书和crl文件，替换证书文件即可轮换证书
+
+const (
+	// RoleScheduler cert role of scheduler, save in cert subject OU
+	RoleScheduler = "scheduler"
+	// RoleWorker cert role of worker, save in cert subject OU
+	RoleWorker = "worker"
+
+	// CAFile ca cert file name in ca dir
+	CAFile = "ca.pem"
+	// CAKeyFile ca key file name in ca dir
+	CAKeyFile = "ca-key.pem"
+	// CRLFile certificate revocation list file name in ca dir
+	CRLFile = "crl.pem"
+
+	// crl next update time, crl is valid until next revoke
+	crlExpire = time.Hour * 24 * 3650
+)
+
+// InitCA generate ca cert, key and empty crl in cadir
+func InitCA(cadir string, days int) error {
+	err := os.MkdirAll(cadir, 0755)
+	if err != nil {
+		return fmt.Errorf("os.MkdirAll failed: %w", err)
+	}
+	if _, err = os.Stat(filepath.Join(cadir, CAKeyFile)); err == nil {
+		return fmt.Errorf("ca key %s already exist", filepath.Join(cadir, CAKeyFile))
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("ecdsa.GenerateKey failed: %w", err)
+	}
+	serial, err := newserial()
+	if err != nil {
+		return err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: ServerName + " ca"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour * 24 * time.Duration(days)),
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("x509.CreateCertificate failed: %w", err)
+	}
+	err = writekeypair(filepath.Join(cadir, CAFile), filepath.Join(cadir, CAKeyFile), derBytes, key)
+	if err != nil {
+		return err
+	}
+	ca, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return fmt.Errorf("x509.ParseCertificate failed: %w", err)
+	}
+	return writecrl(cadir, ca, key, nil)
+}
+
+// IssueCert issue scheduler or worker cert signed by ca in cadir,
+// cert and key will be saved as name.pem and name-key.pem in outdir,
+// hosts are ip or dns name which will be add to cert san
+func IssueCert(cadir, outdir, name, role string, hosts []string, days int) error {
+	if role != RoleScheduler && role != RoleWorker {
+		return fmt.Errorf("unsupport cert role %s, only support %s or %s", role, RoleScheduler, RoleWorker)
+	}
+	if name == "" {
+		return errors.New("cert name can not be empty")
+	}
+	ca, cakey, err := readca(cadir)
+	if err != nil {
+		return err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("ecdsa.GenerateKey failed: %w", err)
+	}
+	serial, err := newserial()
+	if err != nil {
+		return err
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         name,
+			OrganizationalUnit: []string{role},
+		},
+		KeyUsage: x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		// 调度中心和worker都会作为grpc的服务端和客户端
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		// 客户端使用ServerName校验服务端证书
+		DNSNames:  []string{ServerName},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(time.Hour * 24 * time.Duration(days)),
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, ca, &key.PublicKey, cakey)
+	if err != nil {
+		return fmt.Errorf("x509.CreateCertificate failed: %w", err)
+	}
+	err = os.MkdirAll(outdir, 0755)
+	if err != nil {
+		return fmt.Errorf("os.MkdirAll failed: %w", err)
+	}
+	return writekeypair(filepath.Join(outdir, name+".pem"), filepath.Join(outdir, name+"-key.pem"), derBytes, key)
+}
+
+// RevokeCert add cert serial to crl in cadir
+func RevokeCert(cadir string, serial *big.Int) error {
+	ca, cakey, err := readca(cadir)
+	if err != nil {
+		return err
+	}
+	revoked, err := readcrl(filepath.Join(cadir, CRLFile), ca)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, cert := range revoked {
+		if cert.SerialNumber.Cmp(serial) == 0 {
+			return fmt.Errorf("cert %s already revoked", serial.Text(16))
+		}
+	}
+	revoked = append(revoked, pkix.RevokedCertificate{
+		SerialNumber:   serial,
+		RevocationTime: time.Now(),
+	})
+	return writecrl(cadir, ca, cakey, revoked)
+}
+
+// CertSerial return serial of cert file
+func CertSerial(certfile string) (*big.Int, error) {
+	cert, err := readcert(certfile)
+	if err != nil {
+		return nil, err
+	}
+	return cert.SerialNumber, nil
+}
+
+func newserial() (*big.Int, error) {
+	max := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return nil, fmt.Errorf("rand.Int failed: %w", err)
+	}
+	return serial, nil
+}
+
+func readcert(certfile string) (*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(certfile)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile failed: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("can not find cert in %s", certfile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParseCertificate failed: %w", err)
+	}
+	return cert, nil
+}
+
+func readca(cadir string) (*x509.Certificate, crypto.Signer, error) {
+	ca, err := readcert(filepath.Join(cadir, CAFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(cadir, CAKeyFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ioutil.ReadFile failed: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, nil, fmt.Errorf("can not find ca key in %s", filepath.Join(cadir, CAKeyFile))
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x509.ParseECPrivateKey failed: %w", err)
+	}
+	return ca, key, nil
+}
+
+// readcrl return revoked certs in crl file, crl must be signed by ca
+func readcrl(crlfile string, ca *x509.Certificate) ([]pkix.RevokedCertificate, error) {
+	data, err := ioutil.ReadFile(crlfile)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile failed: %w", err)
+	}
+	crl, err := x509.ParseCRL(data)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParseCRL failed: %w", err)
+	}
+	err = ca.CheckCRLSignature(crl)
+	if err != nil {
+		return nil, fmt.Errorf("ca.CheckCRLSignature failed: %w", err)
+	}
+	return crl.TBSCertList.RevokedCertificates, nil
+}
+
+func writecrl(cadir string, ca *x509.Certificate, cakey crypto.Signer, revoked []pkix.RevokedCertificate) error {
+	now := time.Now()
+	crlBytes, err := ca.CreateCRL(rand.Reader, cakey, revoked, now, now.Add(crlExpire))
+	if err != nil {
+		return fmt.Errorf("ca.CreateCRL failed: %w", err)
+	}
+	return writepem(filepath.Join(cadir, CRLFile), "X509 CRL", crlBytes, 0644)
+}
+
+func writekeypair(certfile, keyfile string, derBytes []byte, key *ecdsa.PrivateKey) error {
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("x509.MarshalECPrivateKey failed: %w", err)
+	}
+	err = writepem(certfile, "CERTIFICATE", derBytes, 0644)
+	if err != nil {
+		return err
+	}
+	return writepem(keyfile, "EC PRIVATE KEY", keyBytes, 0600)
+}
+
+// writepem write to tmp file and rename it, so the file reloaded by node is always complete
+func writepem(path, blocktype string, data []byte, perm os.FileMode) error {
+	tmppath := path + ".tmp"
+	out, err := os.OpenFile(tmppath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile failed: %w", err)
+	}
+	err = pem.Encode(out, &pem.Block{Type: blocktype, Bytes: data})
+	if err != nil {
+		out.Close()
+		return fmt.Errorf("pem.Encode failed: %w", err)
+	}
+	err = out.Close()
+	if err != nil {
+		return fmt.Errorf("out.Close failed: %w", err)
+	}
+	err = os.Rename(tmppath, path)
+	if err != nil {
+		return fmt.Errorf("os.Rename failed: %w", err)
+	}
+	return nil
+}
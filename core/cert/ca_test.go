@@ -0,0 +1,142 @@
+package cert
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+)
+
+func handshake(servertls, clienttls *tls.Config) (tls.ConnectionState, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer lis.Close()
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), clienttls)
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		// client cert is verified by server after client handshake finished in tls1.3
+		_, err = conn.Read(make([]byte, 1))
+		errc <- err
+	}()
+	conn, err := lis.Accept()
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	server := tls.Server(conn, servertls)
+	err = server.Handshake()
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	_, err = server.Write([]byte{1})
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	err = <-errc
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return server.ConnectionState(), nil
+}
+
+func TestCA(t *testing.T) {
+	log.InitLog(log.Level("error"))
+	dir, err := ioutil.TempDir("", "crocodile-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cadir := filepath.Join(dir, "ca")
+	err = InitCA(cadir, 1)
+	if err != nil {
+		t.Fatalf("InitCA failed: %v", err)
+	}
+	if InitCA(cadir, 1) == nil {
+		t.Fatalf("want init exist ca failed, but success")
+	}
+	err = IssueCert(cadir, dir, "scheduler", RoleScheduler, []string{"127.0.0.1"}, 1)
+	if err != nil {
+		t.Fatalf("IssueCert failed: %v", err)
+	}
+	err = IssueCert(cadir, dir, "worker1", RoleWorker, nil, 1)
+	if err != nil {
+		t.Fatalf("IssueCert failed: %v", err)
+	}
+	if IssueCert(cadir, dir, "worker2", "admin", nil, 1) == nil {
+		t.Fatalf("want issue unsupport role cert failed, but success")
+	}
+
+	cafile := filepath.Join(cadir, CAFile)
+	crlfile := filepath.Join(cadir, CRLFile)
+	servertls, err := NewServerTLSConfig(filepath.Join(dir, "scheduler.pem"), filepath.Join(dir, "scheduler-key.pem"), cafile, crlfile)
+	if err != nil {
+		t.Fatalf("NewServerTLSConfig failed: %v", err)
+	}
+	ks, _, err := newkeystore(filepath.Join(dir, "worker1.pem"), filepath.Join(dir, "worker1-key.pem"), cafile, crlfile)
+	if err != nil {
+		t.Fatalf("newkeystore failed: %v", err)
+	}
+	clienttls, err := NewClientTLSConfig(ks.certfile, ks.keyfile, cafile, crlfile)
+	if err != nil {
+		t.Fatalf("NewClientTLSConfig failed: %v", err)
+	}
+	state, err := handshake(servertls, clienttls)
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	peer := state.VerifiedChains[0][0]
+	if peer.Subject.CommonName != "worker1" || peer.Subject.OrganizationalUnit[0] != RoleWorker {
+		t.Errorf("want get worker1 worker cert, but get %s %v", peer.Subject.CommonName, peer.Subject.OrganizationalUnit)
+	}
+
+	// cert is not signed by ca
+	err = GenerateCert(dir)
+	if err != nil {
+		t.Fatalf("GenerateCert failed: %v", err)
+	}
+	selfsigned, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clienttls.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &selfsigned, nil
+	}
+	if _, err = handshake(servertls, clienttls); err == nil {
+		t.Errorf("want handshake with self signed cert failed, but success")
+	}
+
+	serial, err := CertSerial(ks.certfile)
+	if err != nil {
+		t.Fatalf("CertSerial failed: %v", err)
+	}
+	err = RevokeCert(cadir, serial)
+	if err != nil {
+		t.Fatalf("RevokeCert failed: %v", err)
+	}
+	if RevokeCert(cadir, serial) == nil {
+		t.Errorf("want revoke revoked cert failed, but success")
+	}
+	// skip reload interval
+	ks.checked = time.Time{}
+	err = ks.reload()
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	cert, _ := ks.getcert()
+	err = ks.verifypeer(cert.Certificate, state.VerifiedChains)
+	if err == nil {
+		t.Errorf("want verify revoked cert failed, but success")
+	}
+}
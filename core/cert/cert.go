@@ -42,7 +42,7 @@ func GenerateCert(pemkeydir string) error {
 		NotBefore:    time.Now(),
 		NotAfter:     time.Now().Add(time.Hour * 24 * 3650),
 	}
-	pk, _ := rsa.GenerateKey(rand.Reader, 1024)
+	pk, _ := rsa.GenerateKey(rand.Reader, 2048)
 
 	derBytes, _ := x509.CreateCertificate(rand.Reader, &template, &template, &pk.PublicKey, pk) //DER 格式
 	certOut, err := os.Create(fmt.Sprintf("%s/cert.pem", pemkeydir))
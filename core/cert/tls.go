@@ -0,0 +1,202 @@
+package cert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// check cert and crl file change interval, so cert can be rotated without restart
+	reloadInterval = time.Second * 10
+)
+
+// keystore reload cert and crl when file changed
+type keystore struct {
+	sync.Mutex
+	certfile, keyfile, crlfile string
+	ca                         *x509.Certificate
+
+	checked    time.Time
+	certmod    time.Time
+	crlmod     time.Time
+	cert       *tls.Certificate
+	revokedset map[string]bool
+}
+
+func newkeystore(certfile, keyfile, cafile, crlfile string) (*keystore, *x509.CertPool, error) {
+	ca, err := readcert(cafile)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ks := &keystore{
+		certfile:   certfile,
+		keyfile:    keyfile,
+		crlfile:    crlfile,
+		ca:         ca,
+		revokedset: make(map[string]bool),
+	}
+	err = ks.reload()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ks, pool, nil
+}
+
+// reload load cert and crl if file changed after last check
+func (ks *keystore) reload() error {
+	ks.Lock()
+	defer ks.Unlock()
+	if ks.cert != nil && time.Since(ks.checked) < reloadInterval {
+		return nil
+	}
+	ks.checked = time.Now()
+
+	info, err := os.Stat(ks.certfile)
+	if err != nil {
+		return fmt.Errorf("os.Stat failed: %w", err)
+	}
+	if ks.cert == nil || !info.ModTime().Equal(ks.certmod) {
+		cert, err := tls.LoadX509KeyPair(ks.certfile, ks.keyfile)
+		if err != nil {
+			return fmt.Errorf("tls.LoadX509KeyPair failed: %w", err)
+		}
+		if ks.cert != nil {
+			log.Info("cert is changed, reload it", zap.String("cert", ks.certfile))
+		}
+		ks.cert = &cert
+		ks.certmod = info.ModTime()
+	}
+
+	if ks.crlfile == "" {
+		return nil
+	}
+	info, err = os.Stat(ks.crlfile)
+	if err != nil {
+		return fmt.Errorf("os.Stat failed: %w", err)
+	}
+	if info.ModTime().Equal(ks.crlmod) {
+		return nil
+	}
+	revoked, err := readcrl(ks.crlfile, ks.ca)
+	if err != nil {
+		return err
+	}
+	revokedset := make(map[string]bool, len(revoked))
+	for _, cert := range revoked {
+		revokedset[cert.SerialNumber.String()] = true
+	}
+	ks.revokedset = revokedset
+	ks.crlmod = info.ModTime()
+	log.Info("load crl success", zap.String("crl", ks.crlfile), zap.Int("revoked", len(revoked)))
+	return nil
+}
+
+// getcert return current cert, if reload failed, old cert will be used
+func (ks *keystore) getcert() (*tls.Certificate, error) {
+	err := ks.reload()
+	if err != nil {
+		log.Error("reload cert failed", zap.Error(err))
+	}
+	ks.Lock()
+	defer ks.Unlock()
+	if ks.cert == nil {
+		return nil, err
+	}
+	return ks.cert, nil
+}
+
+// verifypeer reject revoked peer cert
+func (ks *keystore) verifypeer(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	err := ks.reload()
+	if err != nil {
+		log.Error("reload cert failed", zap.Error(err))
+	}
+	ks.Lock()
+	defer ks.Unlock()
+	for _, chain := range verifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+		if ks.revokedset[chain[0].SerialNumber.String()] {
+			return fmt.Errorf("cert %s[%s] is revoked", chain[0].Subject.CommonName, chain[0].SerialNumber.Text(16))
+		}
+	}
+	return nil
+}
+
+// NewServerTLSConfig return tls config which require and verify client cert issued by ca
+func NewServerTLSConfig(certfile, keyfile, cafile, crlfile string) (*tls.Config, error) {
+	ks, pool, err := newkeystore(certfile, keyfile, cafile, crlfile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return ks.getcert()
+		},
+		ClientAuth:            tls.RequireAndVerifyClientCert,
+		ClientCAs:             pool,
+		VerifyPeerCertificate: ks.verifypeer,
+		MinVersion:            tls.VersionTLS12,
+	}, nil
+}
+
+// NewClientTLSConfig return tls config which send client cert and verify server cert issued by ca
+func NewClientTLSConfig(certfile, keyfile, cafile, crlfile string) (*tls.Config, error) {
+	ks, pool, err := newkeystore(certfile, keyfile, cafile, crlfile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return ks.getcert()
+		},
+		RootCAs:               pool,
+		ServerName:            ServerName,
+		VerifyPeerCertificate: ks.verifypeer,
+		MinVersion:            tls.VersionTLS12,
+	}, nil
+}
+
+// PeerCert return verified cert of grpc peer
+func PeerCert(ctx context.Context) (*x509.Certificate, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, errors.New("get peer failed")
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, errors.New("peer does not use tls")
+	}
+	if len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, errors.New("peer cert is not verified")
+	}
+	return info.State.VerifiedChains[0][0], nil
+}
+
+// CheckPeerRole check grpc peer cert role and return peer cert
+func CheckPeerRole(ctx context.Context, role string) (*x509.Certificate, error) {
+	cert, err := PeerCert(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == role {
+			return cert, nil
+		}
+	}
+	return nil, fmt.Errorf("cert %s is not %s cert", cert.Subject.CommonName, role)
+}
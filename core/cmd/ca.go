@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/labulaka521/crocodile/core/cert"
+	"github.com/spf13/cobra"
+)
+
+// CA manage built-in ca, issue and revoke scheduler or worker cert
+func CA() *cobra.Command {
+	cmdCA := &cobra.Command{
+		Use:   "ca",
+		Short: "manage built-in ca for mutual tls",
+	}
+	cmdCA.AddCommand(caInit(), caIssue(), caRevoke())
+	return cmdCA
+}
+
+func caInit() *cobra.Command {
+	var (
+		cadir string
+		days  int
+	)
+	cmdInit := &cobra.Command{
+		Use:   "init",
+		Short: "generate ca cert, key and empty crl",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := cert.InitCA(cadir, days)
+			if err != nil {
+				fmt.Println("InitCA failed: ", err)
+				return
+			}
+			fmt.Printf("ca is saved in %s\n", cadir)
+		},
+	}
+	cmdInit.Flags().StringVarP(&cadir, "dir", "d", "ca", "ca dir")
+	cmdInit.Flags().IntVar(&days, "days", 3650, "ca valid days")
+	return cmdInit
+}
+
+func caIssue() *cobra.Command {
+	var (
+		cadir  string
+		outdir string
+		name   string
+		role   string
+		hosts  []string
+		days   int
+	)
+	cmdIssue := &cobra.Command{
+		Use:   "issue",
+		Short: "issue scheduler or worker cert",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := cert.IssueCert(cadir, outdir, name, role, hosts, days)
+			if err != nil {
+				fmt.Println("IssueCert failed: ", err)
+				return
+			}
+			fmt.Printf("cert is saved as %s/%s.pem, key is saved as %s/%s-key.pem\n", outdir, name, outdir, name)
+		},
+	}
+	cmdIssue.Flags().StringVarP(&cadir, "dir", "d", "ca", "ca dir")
+	cmdIssue.Flags().StringVarP(&outdir, "out", "o", ".", "output cert dir")
+	cmdIssue.Flags().StringVarP(&name, "name", "n", "", "cert common name, must be the hostname of worker")
+	cmdIssue.Flags().StringVarP(&role, "role", "r", cert.RoleWorker, "cert role, scheduler or worker")
+	cmdIssue.Flags().StringSliceVar(&hosts, "host", nil, "ip or dns name add to cert")
+	cmdIssue.Flags().IntVar(&days, "days", 365, "cert valid days")
+	return cmdIssue
+}
+
+func caRevoke() *cobra.Command {
+	var (
+		cadir    string
+		certfile string
+		serial   string
+	)
+	cmdRevoke := &cobra.Command{
+		Use:   "revoke",
+		Short: "revoke cert and update crl",
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				sn  *big.Int
+				err error
+			)
+			switch {
+			case certfile != "":
+				sn, err = cert.CertSerial(certfile)
+				if err != nil {
+					fmt.Println("CertSerial failed: ", err)
+					return
+				}
+			case serial != "":
+				var ok bool
+				sn, ok = new(big.Int).SetString(serial, 16)
+				if !ok {
+					fmt.Printf("invalid serial %s\n", serial)
+					return
+				}
+			default:
+				fmt.Println("cert or serial must be set")
+				return
+			}
+			err = cert.RevokeCert(cadir, sn)
+			if err != nil {
+				fmt.Println("RevokeCert failed: ", err)
+				return
+			}
+			fmt.Printf("cert %s is revoked, distribute %s/%s to all nodes\n", sn.Text(16), cadir, cert.CRLFile)
+		},
+	}
+	cmdRevoke.Flags().StringVarP(&cadir, "dir", "d", "ca", "ca dir")
+	cmdRevoke.Flags().StringVarP(&certfile, "cert", "c", "", "cert file to revoke")
+	cmdRevoke.Flags().StringVarP(&serial, "serial", "s", "", "hex serial of cert to revoke")
+	return cmdRevoke
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/labulaka521/crocodile/core/config"
 	"github.com/labulaka521/crocodile/core/router"
 	"github.com/labulaka521/crocodile/core/schedule"
+	"github.com/labulaka521/crocodile/core/tracing"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	mylog "github.com/labulaka521/crocodile/core/utils/log"
 	"github.com/labulaka521/crocodile/core/version"
@@ -31,6 +32,10 @@ func Client() *cobra.Command {
 			}
 			config.Init(cfg)
 			mylog.Init()
+			err := tracing.Init(define.Client)
+			if err != nil {
+				log.Fatal("init tracing failed", zap.Error(err))
+			}
 			schedule.InitWorker()
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
@@ -41,6 +46,9 @@ func Client() *cobra.Command {
 			_, port, _ := net.SplitHostPort(lis.Addr().String())
 			intport, _ := strconv.Atoi(port)
 			go schedule.RegistryClient(version.Version, intport)
+			if config.CoreConf.Client.Tunnel {
+				go schedule.RunTunnel(intport)
+			}
 			err = router.Run(define.Client, lis)
 			if err != nil {
 				log.Error("router.Run error", zap.Error(err))
@@ -1,14 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"os"
 
+	"github.com/labulaka521/crocodile/common/jwt"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/core/alarm"
 	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/ldapauth"
 	"github.com/labulaka521/crocodile/core/model"
 	"github.com/labulaka521/crocodile/core/router"
 	"github.com/labulaka521/crocodile/core/schedule"
+	"github.com/labulaka521/crocodile/core/stats"
+	"github.com/labulaka521/crocodile/core/tracing"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	mylog "github.com/labulaka521/crocodile/core/utils/log"
 	"github.com/labulaka521/crocodile/core/version"
@@ -31,12 +36,29 @@ func Server() *cobra.Command {
 			}
 			config.Init(cfg)
 			mylog.Init()
+			err := tracing.Init(define.Server)
+			if err != nil {
+				log.Fatal("init tracing failed", zap.Error(err))
+			}
+			err = initjwt()
+			if err != nil {
+				log.Fatal("init jwt failed", zap.Error(err))
+			}
 			alarm.InitAlarm()
-			err := model.InitDb()
+			err = model.InitDb()
 			if err != nil {
 				log.Fatal("InitDb failed", zap.Error(err))
 			}
 			model.InitRabc()
+			err = model.Migrate(context.Background())
+			if err != nil {
+				log.Fatal("migrate db failed", zap.Error(err))
+			}
+			err = ldapauth.Init()
+			if err != nil {
+				log.Fatal("init ldap failed", zap.Error(err))
+			}
+			stats.SetWorkerCounter(model.CountHostGroupWorkers)
 			go version.CheckLatest() // check new version
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
@@ -60,3 +82,16 @@ func Server() *cobra.Command {
 	cmdServer.Flags().StringVarP(&cfg, "conf", "c", "", "server config [toml]")
 	return cmdServer
 }
+
+// initjwt load jwt signing keys from config
+func initjwt() error {
+	conf := config.CoreConf.Server.JWT
+	keys := make([]jwt.Key, 0, len(conf.Keys))
+	for _, key := range conf.Keys {
+		keys = append(keys, jwt.Key{ID: key.Kid, Secret: key.Secret})
+	}
+	if len(keys) == 0 {
+		log.Warn("jwt signing key is not set, use random key, all tokens will be invalid after restart")
+	}
+	return jwt.Init(keys, conf.ActiveKid, conf.AccessTTL.Duration, conf.RefreshTTL.Duration)
+}
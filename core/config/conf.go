@@ -29,6 +29,8 @@ type coreConf struct {
 	Server      Server
 	Client      Client
 	Notify      Notify
+	Metrics     Metrics
+	Trace       Trace
 }
 
 // Log Config
@@ -42,11 +44,29 @@ type Log struct {
 	Format     string
 }
 
+// Metrics prometheus metrics config
+type Metrics struct {
+	Enable bool
+	Port   int    // 0 serve on the main port
+	Path   string // default /metrics
+}
+
+// Trace opentelemetry trace config
+type Trace struct {
+	Enable      bool
+	Endpoint    string  // otlp grpc collector addr, default localhost:4317
+	Insecure    bool    // connect collector without tls
+	SampleRatio float64 // 0 ~ 1, 0 will sample all
+}
+
 // Cert tls cert
 type Cert struct {
 	Enable   bool
 	CertFile string
 	KeyFile  string
+	// if set, enable mutual tls, peer cert must be issued by this ca
+	CAFile  string
+	CRLFile string
 }
 
 // Server crocodile server config
@@ -55,6 +75,97 @@ type Server struct {
 	MaxHTTPTime duration
 	DB          db
 	Redis       redis
+	JWT         jwtconf
+	OIDC        oidcconf
+	LDAP        ldapconf
+	Login       loginconf
+	TOTP        totpconf
+}
+
+type jwtconf struct {
+	Keys       []JWTKey // token signed by active key, and can be verified by all keys
+	ActiveKid  string   // can be empty if only one key
+	AccessTTL  duration // default 15m
+	RefreshTTL duration // default 168h
+}
+
+type oidcconf struct {
+	Enable       bool
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // http://crocodile.host/api/v1/user/oidc/callback
+	Scopes       []string // default openid profile email
+	// claim used as user name, default preferred_username
+	UserNameClaim string
+	// claim used to map role, value can be a string or an array, like groups
+	RoleClaim    string
+	AdminValues  []string
+	NormalValues []string
+	GuestValues  []string
+	// role of user whose claim is not match, guest normal or none, none means deny login
+	DefaultRole string
+	// only allow oidc login
+	DisablePasswordLogin bool
+	// amr values mean provider did multi-factor authentication, default mfa,
+	// user who enabled totp can only login by oidc with these amr values
+	MFAValues []string
+}
+
+type ldapconf struct {
+	Enable             bool
+	URL                string // ldap://host:389 or ldaps://host:636
+	StartTLS           bool
+	CAFile             string
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // %s is user name
+	UserNameAttr       string
+	GroupBaseDN        string
+	GroupFilter        string // %s is user dn
+	GroupNameAttr      string
+	DisabledFilter     string // user match it is disabled
+	// map group to role, admin > normal > guest
+	AdminGroups  []string
+	NormalGroups []string
+	GuestGroups  []string
+	// role of user who is not in any role group, guest normal or none, none means deny login
+	DefaultRole string
+	// users in same owner group share owner of hostgroups and tasks
+	OwnerGroups []string
+	// sync disabled users, role and owner groups, default 10m
+	SyncInterval duration
+}
+
+type loginconf struct {
+	// max login attempts of a user name or ip per minute, 0 is unlimited
+	UserRateLimit int
+	IPRateLimit   int
+	// lock user after failed logins in failurewindow, 0 never lock
+	MaxFailures   int
+	FailureWindow duration // default 15m
+	LockDuration  duration // default 15m
+	// local password policy
+	PasswordMinLength     int // at least 8
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordExpire        duration // 0 never expire
+}
+
+type totpconf struct {
+	Issuer string // name show in authenticator app, default crocodile
+	// admin must enable totp before login
+	RequireAdmin bool
+}
+
+// JWTKey jwt signing key with key id
+type JWTKey struct {
+	Kid    string
+	Secret string
 }
 
 type db struct {
@@ -72,12 +183,19 @@ type redis struct {
 
 // Client crocodile client config
 type Client struct {
-	Port        int
-	ServerAddrs []string
-	ServerPort  int
-	HostGroup   string
-	Weight      int
-	Remark      string
+	Port           int
+	ServerAddrs    []string
+	ServerPort     int
+	HostGroup      string
+	Weight         int
+	Remark         string
+	TaskSlots      int      // task slots report to server, 0 use maxconcurrent or cpu num
+	Labels         []string // worker labels like os=linux, task can select worker by labels
+	MaxConcurrent  int      // max tasks run at the same time, 0 is unlimited
+	DrainTimeout   duration // max time wait running tasks finish when drain worker
+	Tunnel         bool     // worker open tunnel to scheduler, scheduler run task by tunnel instead of dial worker
+	EnrollToken    string   // worker exchange enroll token for its own credential when registry
+	CredentialFile string   // file save credential issued by scheduler
 }
 
 type duration struct {
@@ -93,12 +211,13 @@ func (d *duration) UnmarshalText(text []byte) error {
 
 // Notify send msg to user
 type Notify struct {
-	Email    email
-	DingDing dingding
-	Slack    slack
-	Telegram telegram
-	WeChat   wechat
-	WebHook  webhook
+	Email          email
+	DingDing       dingding
+	Slack          slack
+	Telegram       telegram
+	WeChat         wechat
+	WebHook        webhook
+	HostAlarmUsers []string // user names which will recv host online and offline notify
 }
 
 type email struct {
@@ -0,0 +1,258 @@
+package ldapauth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/jwt"
+	"github.com/labulaka521/crocodile/common/ldap"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+)
+
+// LDAP登陆和同步
+// 开启后LDAP用户使用LDAP密码登陆，第一次登陆时自动创建，每次登陆和定时同步时根据组更新角色和所有权组，
+// 在LDAP中被删除或者禁用的用户会被禁止登陆并注销所有会话，重新启用后需要管理员允许登陆
+// 本地用户(如安装时创建的管理员)仍然使用本地密码登陆
+
+const defaultSyncInterval = 10 * time.Minute
+
+var client *ldap.Client
+
+// Init create ldap client and start sync if ldap is enabled
+func Init() error {
+	conf := config.CoreConf.Server.LDAP
+	if !conf.Enable {
+		return nil
+	}
+	c, err := ldap.New(ldap.Config{
+		URL:                conf.URL,
+		StartTLS:           conf.StartTLS,
+		CAFile:             conf.CAFile,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+		BindDN:             conf.BindDN,
+		BindPassword:       conf.BindPassword,
+		BaseDN:             conf.BaseDN,
+		UserFilter:         conf.UserFilter,
+		UserNameAttr:       conf.UserNameAttr,
+		GroupBaseDN:        conf.GroupBaseDN,
+		GroupFilter:        conf.GroupFilter,
+		GroupNameAttr:      conf.GroupNameAttr,
+		DisabledFilter:     conf.DisabledFilter,
+	})
+	if err != nil {
+		return fmt.Errorf("ldap.New failed: %w", err)
+	}
+	client = c
+	go runsync()
+	return nil
+}
+
+// Enabled return whether ldap login is enabled
+func Enabled() bool {
+	return client != nil
+}
+
+// Login login user by ldap, local user is login by local password
+func Login(ctx context.Context, name, password string) (*define.LoginToken, error) {
+	exist, err := model.Check(ctx, model.TBUser, model.Name, name)
+	if err != nil {
+		return nil, fmt.Errorf("model.Check failed: %w", err)
+	}
+	var user *define.User
+	if exist {
+		user, err = model.GetUserByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("model.GetUserByName failed: %w", err)
+		}
+		if user.LDAPDN == "" {
+			return model.LoginUser(ctx, name, password)
+		}
+	}
+	ldapuser, err := client.Authenticate(name, password)
+	switch err {
+	case nil:
+	case ldap.ErrInvalidCredentials, ldap.ErrUserNotExist:
+		return nil, fmt.Errorf("client.Authenticate failed: %w", define.ErrUserPass{Err: err})
+	case ldap.ErrUserDisabled:
+		if user != nil {
+			forbid(ctx, user)
+		}
+		return nil, fmt.Errorf("ldap user is disabled: %w", define.ErrForbid{Name: name})
+	default:
+		return nil, fmt.Errorf("client.Authenticate failed: %w", err)
+	}
+	role, ok := grouprole(ldapuser.Groups)
+	if !ok {
+		return nil, fmt.Errorf("ldap user is not in any role group: %w", define.ErrForbid{Name: name})
+	}
+	if user == nil {
+		if len(name) > 30 {
+			return nil, fmt.Errorf("ldap user name is too long: %w", define.ErrForbid{Name: name})
+		}
+		uid, err := model.AddLDAPUser(ctx, name, ldapuser.DN, role)
+		if err != nil {
+			return nil, fmt.Errorf("model.AddLDAPUser failed: %w", err)
+		}
+		log.Info("provision ldap user", zap.String("user", name), zap.String("role", role.String()))
+		err = model.SetUserGroups(ctx, uid, ownergroups(ldapuser.Groups))
+		if err != nil {
+			return nil, fmt.Errorf("model.SetUserGroups failed: %w", err)
+		}
+		return model.NewLoginToken(uid, name)
+	}
+	if user.Forbid {
+		return nil, fmt.Errorf("user is forbid: %w", define.ErrForbid{Name: name})
+	}
+	err = syncuser(ctx, user, ldapuser, role)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewLoginToken(user.ID, user.Name)
+}
+
+// syncuser sync dn, role and owner groups of user
+func syncuser(ctx context.Context, user *define.User, ldapuser *ldap.User, role define.Role) error {
+	if user.LDAPDN != ldapuser.DN {
+		err := model.ChangeUserLDAPDN(ctx, user.ID, ldapuser.DN)
+		if err != nil {
+			return fmt.Errorf("model.ChangeUserLDAPDN failed: %w", err)
+		}
+	}
+	if user.Role != role {
+		err := model.AdminChangeUser(ctx, user.ID, role, user.Forbid, "", user.Remark)
+		if err != nil {
+			return fmt.Errorf("model.AdminChangeUser failed: %w", err)
+		}
+		log.Info("sync ldap user role", zap.String("user", user.Name),
+			zap.String("from", user.Role.String()), zap.String("to", role.String()))
+	}
+	err := model.SetUserGroups(ctx, user.ID, ownergroups(ldapuser.Groups))
+	if err != nil {
+		return fmt.Errorf("model.SetUserGroups failed: %w", err)
+	}
+	return nil
+}
+
+// forbid forbid user login and revoke all sessions
+func forbid(ctx context.Context, user *define.User) {
+	if user.Forbid {
+		return
+	}
+	err := model.AdminChangeUser(ctx, user.ID, user.Role, true, "", user.Remark)
+	if err != nil {
+		log.Error("model.AdminChangeUser failed", zap.String("user", user.Name), zap.Error(err))
+		return
+	}
+	err = jwt.RevokeUser(user.ID)
+	if err != nil {
+		log.Error("jwt.RevokeUser failed", zap.String("user", user.Name), zap.Error(err))
+	}
+	log.Warn("ldap user is deleted or disabled, forbid it", zap.String("user", user.Name), zap.String("dn", user.LDAPDN))
+}
+
+// runsync sync ldap users every sync interval
+func runsync() {
+	interval := config.CoreConf.Server.LDAP.SyncInterval.Duration
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := syncusers(ctx)
+		if err != nil {
+			log.Error("sync ldap users failed", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// syncusers forbid users which are deleted or disabled in ldap, sync role and owner groups of others
+func syncusers(ctx context.Context) error {
+	users, err := model.GetLDAPUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("model.GetLDAPUsers failed: %w", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	dns := make([]string, 0, len(users))
+	for _, user := range users {
+		dns = append(dns, user.LDAPDN)
+	}
+	ldapusers, err := client.Users(dns)
+	if err != nil {
+		return fmt.Errorf("client.Users failed: %w", err)
+	}
+	for i := range users {
+		user := &users[i]
+		ldapuser, ok := ldapusers[user.LDAPDN]
+		if !ok || ldapuser.Disabled {
+			forbid(ctx, user)
+			continue
+		}
+		role, ok := grouprole(ldapuser.Groups)
+		if !ok {
+			forbid(ctx, user)
+			continue
+		}
+		if user.Forbid {
+			continue
+		}
+		err = syncuser(ctx, user, ldapuser, role)
+		if err != nil {
+			log.Error("syncuser failed", zap.String("user", user.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// grouprole map groups to role, admin > normal > guest,
+// if not match use default role, return false if user can not login
+func grouprole(groups []string) (define.Role, bool) {
+	conf := config.CoreConf.Server.LDAP
+	for _, m := range []struct {
+		role   define.Role
+		groups []string
+	}{
+		{define.AdminUser, conf.AdminGroups},
+		{define.NormalUser, conf.NormalGroups},
+		{define.GuestUser, conf.GuestGroups},
+	} {
+		if len(intersect(m.groups, groups)) > 0 {
+			return m.role, true
+		}
+	}
+	switch conf.DefaultRole {
+	case "normal":
+		return define.NormalUser, true
+	case "guest":
+		return define.GuestUser, true
+	default:
+		return 0, false
+	}
+}
+
+// ownergroups return owner groups in groups
+func ownergroups(groups []string) []string {
+	return intersect(config.CoreConf.Server.LDAP.OwnerGroups, groups)
+}
+
+func intersect(a, b []string) []string {
+	res := []string{}
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				res = append(res, x)
+				break
+			}
+		}
+	}
+	return res
+}
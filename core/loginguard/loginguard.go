@@ -0,0 +1,135 @@
+package loginguard
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/labulaka521/crocodile/core/config"
+)
+
+// 登陆保护
+// 按用户名和IP限制每分钟的登陆次数，连续登陆失败多次后锁定用户一段时间，管理员可以解锁
+// 计数保存在redis中，所有调度中心共享
+
+const (
+	ratelimituser = "login:ratelimit:user:" // login:ratelimit:user:name
+	ratelimitip   = "login:ratelimit:ip:"   // login:ratelimit:ip:ip
+	failures      = "login:failures:"       // login:failures:name
+	locked        = "login:locked:"         // login:locked:name
+
+	defaultFailureWindow = 15 * time.Minute
+	defaultLockDuration  = 15 * time.Minute
+)
+
+var rc *redis.Client
+
+// Init set redis client which save login counters
+func Init(client *redis.Client) {
+	rc = client
+}
+
+// incr increase counter in window, return count
+func incr(key string, window time.Duration) (int64, error) {
+	count, err := rc.Incr(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		err = rc.Expire(key, window).Err()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// Allow count a login attempt of name from ip, return false if exceed rate limit
+func Allow(name, ip string) (bool, error) {
+	if rc == nil {
+		return true, nil
+	}
+	conf := config.CoreConf.Server.Login
+	if conf.UserRateLimit > 0 {
+		count, err := incr(ratelimituser+name, time.Minute)
+		if err != nil {
+			return false, err
+		}
+		if count > int64(conf.UserRateLimit) {
+			return false, nil
+		}
+	}
+	if conf.IPRateLimit > 0 {
+		count, err := incr(ratelimitip+ip, time.Minute)
+		if err != nil {
+			return false, err
+		}
+		if count > int64(conf.IPRateLimit) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Locked return remaining lock time of user, 0 if user is not locked
+func Locked(name string) (time.Duration, error) {
+	if rc == nil {
+		return 0, nil
+	}
+	ttl, err := rc.TTL(locked + name).Result()
+	if err != nil {
+		return 0, err
+	}
+	// key不存在时ttl为负数
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Fail record a failed login of user, return true if user is locked by this failure
+func Fail(name string) (bool, error) {
+	conf := config.CoreConf.Server.Login
+	if rc == nil || conf.MaxFailures <= 0 {
+		return false, nil
+	}
+	window := conf.FailureWindow.Duration
+	if window <= 0 {
+		window = defaultFailureWindow
+	}
+	count, err := incr(failures+name, window)
+	if err != nil {
+		return false, err
+	}
+	if count < int64(conf.MaxFailures) {
+		return false, nil
+	}
+	lockduration := conf.LockDuration.Duration
+	if lockduration <= 0 {
+		lockduration = defaultLockDuration
+	}
+	pipe := rc.TxPipeline()
+	pipe.Set(locked+name, strconv.FormatInt(time.Now().Unix(), 10), lockduration)
+	pipe.Del(failures + name)
+	_, err = pipe.Exec()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Succeed clear failed logins of user
+func Succeed(name string) error {
+	if rc == nil {
+		return nil
+	}
+	return rc.Del(failures + name).Err()
+}
+
+// Unlock unlock user and clear failed logins
+func Unlock(name string) error {
+	if rc == nil {
+		return nil
+	}
+	return rc.Del(locked+name, failures+name).Err()
+}
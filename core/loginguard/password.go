@@ -0,0 +1,61 @@
+package loginguard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+
+	"github.com/labulaka521/crocodile/core/config"
+)
+
+// 本地密码复杂度和过期策略，LDAP和单点登陆用户的密码不受影响
+
+const minPasswordLength = 8
+
+// CheckPassword check password match the password policy
+func CheckPassword(password string) error {
+	conf := config.CoreConf.Server.Login
+	minlength := conf.PasswordMinLength
+	if minlength < minPasswordLength {
+		minlength = minPasswordLength
+	}
+	if len([]rune(password)) < minlength {
+		return fmt.Errorf("password must be at least %d characters", minlength)
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if conf.PasswordRequireUpper && !upper {
+		return errors.New("password must contain upper case letter")
+	}
+	if conf.PasswordRequireLower && !lower {
+		return errors.New("password must contain lower case letter")
+	}
+	if conf.PasswordRequireDigit && !digit {
+		return errors.New("password must contain digit")
+	}
+	if conf.PasswordRequireSymbol && !symbol {
+		return errors.New("password must contain symbol")
+	}
+	return nil
+}
+
+// PasswordExpired check password changed at updatetime is expired
+func PasswordExpired(updatetime int64) bool {
+	expire := config.CoreConf.Server.Login.PasswordExpire.Duration
+	if expire <= 0 {
+		return false
+	}
+	return time.Unix(updatetime, 0).Add(expire).Before(time.Now())
+}
@@ -0,0 +1,59 @@
+package loginguard
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/labulaka521/crocodile/core/config"
+)
+
+func initconf(t *testing.T, conf string) {
+	f, err := ioutil.TempFile("", "core*.toml")
+	if err != nil {
+		t.Fatalf("ioutil.TempFile failed: %v", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(conf)
+	if err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	f.Close()
+	config.Init(f.Name())
+}
+
+func TestCheckPassword(t *testing.T) {
+	initconf(t, `
+[server.login]
+passwordminlength = 10
+passwordrequireupper = true
+passwordrequiredigit = true
+passwordrequiresymbol = true
+passwordexpire = "24h"
+`)
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"Ab1!", false},
+		{"abcdefgh1!", false},
+		{"Abcdefghi!", false},
+		{"Abcdefgh12", false},
+		{"Abcdefgh1!", true},
+		{"密码Abcdefg1!", true},
+	}
+	for _, test := range tests {
+		err := CheckPassword(test.password)
+		if (err == nil) != test.valid {
+			t.Errorf("CheckPassword(%q) = %v, want valid %v", test.password, err, test.valid)
+		}
+	}
+
+	if PasswordExpired(time.Now().Unix()) {
+		t.Error("password changed now should not expire")
+	}
+	if !PasswordExpired(time.Now().Add(-25 * time.Hour).Unix()) {
+		t.Error("password changed 25h ago should expire")
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+)
+
+// API令牌
+// 用户为自动化脚本创建的令牌，在Authorization中使用，权限为令牌范围和用户角色的交集
+// read: 只能GET请求 run: 还可以运行和终止任务 write: 用户角色允许的所有请求
+// 设置了任务ID时只能请求这些任务的接口
+
+var (
+	// run scope can request these api besides GET
+	runscopeapi = map[string]string{
+		"/api/v1/task/run":  http.MethodPut,
+		"/api/v1/task/kill": http.MethodPut,
+	}
+	taskapiprefix = "/api/v1/task"
+	// 用户接口中除了查询都是账号和安全相关的接口
+	userapiprefix = "/api/v1/user"
+	// account and security api which api token can not request by GET
+	accountgetapi = map[string]bool{
+		"/api/v1/user/apitoken": true,
+		"/api/v1/user/totp":     true,
+	}
+)
+
+// checkAPITokenScope check request is allowed by api token scope and tasks
+func checkAPITokenScope(c *gin.Context, apitoken *define.APIToken) bool {
+	path := c.Request.URL.Path
+	method := c.Request.Method
+	// api token can not request account and security api
+	if strings.HasPrefix(path, userapiprefix) && (method != http.MethodGet || accountgetapi[path]) {
+		return false
+	}
+	switch apitoken.Scope {
+	case define.ScopeRead:
+		if method != http.MethodGet {
+			return false
+		}
+	case define.ScopeRun:
+		if method != http.MethodGet && runscopeapi[path] != method {
+			return false
+		}
+	case define.ScopeWrite:
+	default:
+		return false
+	}
+	if len(apitoken.TaskIDs) == 0 {
+		return true
+	}
+	if !strings.HasPrefix(path, taskapiprefix) {
+		return false
+	}
+	taskid := requestid(c)
+	if taskid == "" {
+		return false
+	}
+	for _, id := range apitoken.TaskIDs {
+		if id == taskid {
+			return true
+		}
+	}
+	log.Error("task is not allowed by api token", zap.String("apitoken", apitoken.Name), zap.String("taskid", taskid))
+	return false
+}
+
+// requestid get task id which handler binds
+// GET请求的处理函数绑定query中的id，其他请求绑定json body中的id
+// body中没有id或者和query中的id不一致时返回空
+func requestid(c *gin.Context) string {
+	queryid := c.Query("id")
+	if c.Request.Method == http.MethodGet {
+		return queryid
+	}
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Error("ioutil.ReadAll failed", zap.Error(err))
+		return ""
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	getid := define.GetID{}
+	err = json.Unmarshal(body, &getid)
+	if err != nil || getid.ID == "" {
+		return ""
+	}
+	if queryid != "" && queryid != getid.ID {
+		log.Error("query id is not equal to body id", zap.String("queryid", queryid), zap.String("bodyid", getid.ID))
+		return ""
+	}
+	return getid.ID
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/utils/define"
+)
+
+const (
+	taskid      = "100000000000000001"
+	othertaskid = "100000000000000002"
+)
+
+func idbody(id string) string {
+	return `{"id":"` + id + `"}`
+}
+
+func newcontext(method, url, body string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, url, nil)
+	} else {
+		req = httptest.NewRequest(method, url, strings.NewReader(body))
+	}
+	c.Request = req
+	return c
+}
+
+func Test_checkAPITokenScope(t *testing.T) {
+	log.InitLog(log.Level("error"))
+	tests := []struct {
+		name    string
+		scope   define.APITokenScope
+		taskids []string
+		method  string
+		url     string
+		body    string
+		allow   bool
+	}{
+		{"read get", define.ScopeRead, nil, http.MethodGet, "/api/v1/task", "", true},
+		{"read run", define.ScopeRead, nil, http.MethodPut, "/api/v1/task/run", idbody(taskid), false},
+		{"read change", define.ScopeRead, nil, http.MethodPut, "/api/v1/task", idbody(taskid), false},
+		{"run get", define.ScopeRun, nil, http.MethodGet, "/api/v1/task", "", true},
+		{"run run", define.ScopeRun, nil, http.MethodPut, "/api/v1/task/run", idbody(taskid), true},
+		{"run kill", define.ScopeRun, nil, http.MethodPut, "/api/v1/task/kill", idbody(taskid), true},
+		{"run delete", define.ScopeRun, nil, http.MethodDelete, "/api/v1/task", idbody(taskid), false},
+		{"write change", define.ScopeWrite, nil, http.MethodPut, "/api/v1/task", idbody(taskid), true},
+		{"write delete", define.ScopeWrite, nil, http.MethodDelete, "/api/v1/task", idbody(taskid), true},
+		{"unknown scope", "admin", nil, http.MethodGet, "/api/v1/task", "", false},
+
+		// 账号和安全相关的接口
+		{"user info", define.ScopeWrite, nil, http.MethodGet, "/api/v1/user/info", "", true},
+		{"change user info", define.ScopeWrite, nil, http.MethodPut, "/api/v1/user/info", `{}`, false},
+		{"disable totp", define.ScopeWrite, nil, http.MethodDelete, "/api/v1/user/totp", "", false},
+		{"logout sessions", define.ScopeWrite, nil, http.MethodDelete, "/api/v1/user/sessions", "", false},
+		{"create api token", define.ScopeWrite, nil, http.MethodPost, "/api/v1/user/apitoken", `{}`, false},
+		{"refresh", define.ScopeWrite, nil, http.MethodPost, "/api/v1/user/refresh", `{}`, false},
+		{"get api tokens", define.ScopeWrite, nil, http.MethodGet, "/api/v1/user/apitoken", "", false},
+		{"get totp", define.ScopeRead, nil, http.MethodGet, "/api/v1/user/totp", "", false},
+
+		// 只能操作指定的任务
+		{"task get", define.ScopeRun, []string{taskid}, http.MethodGet, "/api/v1/task/info?id=" + taskid, "", true},
+		{"other task get", define.ScopeRun, []string{taskid}, http.MethodGet, "/api/v1/task/info?id=" + othertaskid, "", false},
+		{"task run", define.ScopeRun, []string{taskid}, http.MethodPut, "/api/v1/task/run", idbody(taskid), true},
+		{"other task run", define.ScopeRun, []string{taskid}, http.MethodPut, "/api/v1/task/run", idbody(othertaskid), false},
+		{"query id only", define.ScopeRun, []string{taskid}, http.MethodPut, "/api/v1/task/run?id=" + taskid, "", false},
+		{"body without id", define.ScopeRun, []string{taskid}, http.MethodPut, "/api/v1/task/run?id=" + taskid, `{}`, false},
+		{"query id mismatch", define.ScopeRun, []string{taskid}, http.MethodPut, "/api/v1/task/run?id=" + taskid, idbody(othertaskid), false},
+		{"query id match", define.ScopeRun, []string{taskid}, http.MethodPut, "/api/v1/task/run?id=" + taskid, idbody(taskid), true},
+		{"task list", define.ScopeRead, []string{taskid}, http.MethodGet, "/api/v1/task", "", false},
+		{"host list", define.ScopeRead, []string{taskid}, http.MethodGet, "/api/v1/host", "", false},
+	}
+	for _, test := range tests {
+		c := newcontext(test.method, test.url, test.body)
+		apitoken := &define.APIToken{Name: "test", Scope: test.scope, TaskIDs: test.taskids}
+		if allow := checkAPITokenScope(c, apitoken); allow != test.allow {
+			t.Errorf("%s: want allow %v, but get %v", test.name, test.allow, allow)
+		}
+	}
+}
+
+func Test_requestid(t *testing.T) {
+	log.InitLog(log.Level("error"))
+	body := idbody(taskid)
+	c := newcontext(http.MethodPut, "/api/v1/task/run", body)
+	if id := requestid(c); id != taskid {
+		t.Fatalf("want get id %s, but get %s", taskid, id)
+	}
+	// 处理函数还可以读取到请求体
+	content, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll failed: %v", err)
+	}
+	if string(content) != body {
+		t.Errorf("want handler read body %s, but get %s", body, content)
+	}
+	getid := define.GetID{}
+	c = newcontext(http.MethodPut, "/api/v1/task/run", body)
+	requestid(c)
+	if err = c.ShouldBindJSON(&getid); err != nil || getid.ID != taskid {
+		t.Errorf("want handler bind id %s, but get %s %v", taskid, getid.ID, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
@@ -19,6 +20,8 @@ import (
 
 const (
 	tokenpre = "Bearer "
+	// server-sent events api path suffix
+	ssepathsuffix = "/sse"
 )
 
 // CheckToken check token is valid
@@ -29,7 +32,21 @@ func CheckToken(token string) (string, string, bool) {
 		return "", "", false
 	}
 	if !claims.VerifyExpiresAt(time.Now().Unix(), false) {
-		log.Error("Token is Expire", zap.String("token", token))
+		log.Error("Token is Expire", zap.String("uid", claims.UID))
+		return "", "", false
+	}
+	// refresh token can only be used to get new token
+	if claims.Type != jwt.TypeAccess {
+		log.Error("Token is not access token", zap.String("uid", claims.UID), zap.String("type", claims.Type))
+		return "", "", false
+	}
+	revoked, err := jwt.IsRevoked(claims)
+	if err != nil {
+		log.Error("jwt.IsRevoked failed", zap.Error(err))
+		return "", "", false
+	}
+	if revoked {
+		log.Error("Token is revoked", zap.String("uid", claims.UID), zap.String("jti", claims.Id))
 		return "", "", false
 	}
 
@@ -39,24 +56,50 @@ func CheckToken(token string) (string, string, bool) {
 // 权限检查
 func checkAuth(c *gin.Context) (pass bool, err error) {
 	token := strings.TrimPrefix(c.GetHeader("Authorization"), tokenpre)
+	// 浏览器的EventSource不能设置请求头，和websocket一样从query中获取token
+	if token == "" && strings.HasSuffix(c.Request.URL.Path, ssepathsuffix) {
+		token = c.Query("token")
+	}
+	return checkTokenAuth(c, token)
+}
 
+// checkTokenAuth check token and casbin permission of request
+func checkTokenAuth(c *gin.Context, token string) (pass bool, err error) {
 	if token == "" {
 		err = errors.New("invalid token")
 		return
 	}
 
-	uid, username, pass := CheckToken(token)
-	if !pass {
-		return false, errors.New("CheckToken failed")
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	var uid, username string
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		apitoken, err := model.VerifyAPIToken(ctx, token)
+		if err != nil {
+			return false, fmt.Errorf("model.VerifyAPIToken failed: %w", err)
+		}
+		if !checkAPITokenScope(c, apitoken) {
+			log.Error("request is not allowed by api token",
+				zap.String("apitoken", apitoken.Name),
+				zap.String("scope", string(apitoken.Scope)),
+				zap.String("url", c.Request.URL.Path))
+			return false, nil
+		}
+		uid, username = apitoken.UID, apitoken.UserName
+		// 操作审计中记录使用的令牌
+		c.Set("apitoken", apitoken.Name)
+	} else {
+		uid, username, pass = CheckToken(token)
+		if !pass {
+			return false, errors.New("CheckToken failed")
+		}
 	}
 
 	c.Set("uid", uid)
 	c.Set("username", username)
 
-	ctx, cancel := context.WithTimeout(context.Background(),
-		config.CoreConf.Server.DB.MaxQueryTime.Duration)
-	defer cancel()
-
 	ok, err := model.Check(ctx, model.TBUser, model.UID, uid)
 	if err != nil {
 		return false, err
@@ -69,8 +112,7 @@ func checkAuth(c *gin.Context) (pass bool, err error) {
 	role, err := model.QueryUserRule(ctx, uid)
 	if err != nil {
 		log.Error("QueryUserRule failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
-		return
+		return false, fmt.Errorf("model.QueryUserRule failed: %w", err)
 	}
 	c.Set("role", role)
 
@@ -80,7 +122,39 @@ func checkAuth(c *gin.Context) (pass bool, err error) {
 	return enforcer.Enforce(uid, requrl, method)
 }
 
-var excludepath = []string{"login", "logout", "install", "websocket"}
+var (
+	// websocket read token from first frame timeout
+	defaultReadTokenTimeout = 10 * time.Second
+)
+
+// CheckWebsocketAuth check websocket request token and casbin permission
+// PermissionControl skip websocket path,so websocket handler must call it after upgrade
+// token is read from Authorization header or token query, if both are empty, read it from the first frame
+func CheckWebsocketAuth(c *gin.Context, conn *websocket.Conn) error {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), tokenpre)
+	if token == "" {
+		token = c.Query("token")
+	}
+	if token == "" {
+		conn.SetReadDeadline(time.Now().Add(defaultReadTokenTimeout))
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("get token failed: %w", err)
+		}
+		conn.SetReadDeadline(time.Time{})
+		token = strings.TrimPrefix(string(msg), tokenpre)
+	}
+	pass, err := checkTokenAuth(c, token)
+	if err != nil {
+		return fmt.Errorf("checkTokenAuth failed: %w", err)
+	}
+	if !pass {
+		return errors.New("check token auth fail")
+	}
+	return nil
+}
+
+var excludepath = []string{"login", "logout", "refresh", "install", "websocket", "oidc"}
 
 // PermissionControl 权限控制middle
 func PermissionControl() func(c *gin.Context) {
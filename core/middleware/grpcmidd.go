@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/cert"
 	"github.com/labulaka521/crocodile/core/config"
 	pb "github.com/labulaka521/crocodile/core/proto"
 	"go.uber.org/zap"
@@ -57,23 +60,91 @@ func RecoveryInterceptor(ctx context.Context,
 
 }
 
-// CheckSecretInterceptor check token valid
-func CheckSecretInterceptor(ctx context.Context,
-	req interface{},
-	info *grpc.UnaryServerInfo,
-	handler grpc.UnaryHandler) (interface{}, error) {
+// AuthFunc check rpc request which is not authed by secret token,
+// return ctx with auth info like the worker of credential
+type AuthFunc func(ctx context.Context, method string) (context.Context, error)
+
+// CheckSecretInterceptor check token valid, if secret token is invalid, check by auth
+func CheckSecretInterceptor(auth AuthFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		ctx, err := checksecret(ctx, info.FullMethod, auth)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CheckSecretStreamInterceptor check token valid of stream rpc, if secret token is invalid, check by auth
+func CheckSecretStreamInterceptor(auth AuthFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+
+		ctx, err := checksecret(ss.Context(), info.FullMethod, auth)
+		if err != nil {
+			return err
+		}
+		wrapped := grpc_middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
 
+func checksecret(ctx context.Context, method string, auth AuthFunc) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "can not get token")
+		return ctx, status.Errorf(codes.Unauthenticated, "can not get token")
 	}
 	var secrettoken string
 	v, ok := md["secret_token"]
 	if ok {
 		secrettoken = v[0]
 	}
-	if secrettoken != config.CoreConf.SecretToken {
-		return nil, status.Errorf(codes.Unauthenticated, "secrettoken auth failed")
+	// secrettoken为空时禁用共享密钥
+	if config.CoreConf.SecretToken != "" &&
+		subtle.ConstantTimeCompare([]byte(secrettoken), []byte(config.CoreConf.SecretToken)) == 1 {
+		return ctx, nil
+	}
+	if auth != nil {
+		return auth(ctx, method)
+	}
+	return ctx, status.Errorf(codes.Unauthenticated, "secrettoken auth failed")
+}
+
+// CheckCertInterceptor check peer cert is issued for role when mutual tls is enabled
+func CheckCertInterceptor(role string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		_, err := cert.CheckPeerRole(ctx, role)
+		if err != nil {
+			log.Error("cert.CheckPeerRole failed", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, status.Errorf(codes.Unauthenticated, "cert auth failed: %v", err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CheckCertStreamInterceptor check peer cert of stream rpc is issued for role when mutual tls is enabled
+func CheckCertStreamInterceptor(role string) grpc.StreamServerInterceptor {
+	return func(srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+
+		_, err := cert.CheckPeerRole(ss.Context(), role)
+		if err != nil {
+			log.Error("cert.CheckPeerRole failed", zap.String("method", info.FullMethod), zap.Error(err))
+			return status.Errorf(codes.Unauthenticated, "cert auth failed: %v", err)
+		}
+		return handler(srv, ss)
 	}
-	return handler(ctx, req)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/labulaka521/crocodile/core/config"
 	"github.com/labulaka521/crocodile/core/model"
 	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
 	"go.uber.org/zap"
 )
 
@@ -24,6 +25,7 @@ const (
 	hostgroup = "hostgroup"
 	host      = "host"
 	user      = "user"
+	project   = "project"
 )
 
 var moduleMap = map[string]string{
@@ -31,6 +33,7 @@ var moduleMap = map[string]string{
 	hostgroup: "主机组",
 	host:      "主机",
 	user:      "用户",
+	project:   "项目",
 }
 
 // Oprtation save all user operate log
@@ -72,6 +75,11 @@ func Oprtation() func(c *gin.Context) {
 			c.Next()
 			return
 		}
+		// 项目成员的修改不记录
+		if module == project && c.Request.URL.Path != "/api/v1/project" {
+			c.Next()
+			return
+		}
 
 		body, err := ioutil.ReadAll(c.Request.Body)
 		if err != nil {
@@ -113,8 +121,13 @@ func Oprtation() func(c *gin.Context) {
 				return
 			}
 			c.Next() // 为了获取状态码
+			desc := fmt.Sprintf("通过任务 %s 克隆新的任务 %s", clonetask.Name, idname.Name)
 			// 任务ID不存在时，返回值为nil
-			if c.GetInt("statuscode") != 0 {
+			switch c.GetInt("statuscode") {
+			case 0:
+			case resp.ApprovalPending:
+				desc = "提交审核请求: " + desc
+			default:
 				log.Error("req status code is not 0, do not save", zap.Int("statuscode", c.GetInt("statuscode")))
 				return
 			}
@@ -126,7 +139,7 @@ func Oprtation() func(c *gin.Context) {
 				module,
 				idname.Name,
 				operatetimne,
-				fmt.Sprintf("通过任务 %s 克隆新的任务 %s", clonetask.Name, idname.Name), columns)
+				desc, columns)
 			if err != nil {
 				log.Error("model.SaveOperateLog failed", zap.Error(err))
 			}
@@ -180,6 +193,10 @@ func Oprtation() func(c *gin.Context) {
 				return
 			}
 			c.Next()
+			desc := fmt.Sprintf("触发运行任务%s", task.Name)
+			if c.GetInt("statuscode") == resp.ApprovalPending {
+				desc = "提交审核请求: " + desc
+			}
 			model.SaveOperateLog(ctx, c,
 				uid,
 				username,
@@ -188,7 +205,7 @@ func Oprtation() func(c *gin.Context) {
 				module,
 				task.Name,
 				operatetimne,
-				fmt.Sprintf("触发运行任务%s", task.Name), columns)
+				desc, columns)
 			if err != nil {
 				log.Error("model.SaveOperateLog failed", zap.Error(err))
 			}
@@ -226,6 +243,159 @@ func Oprtation() func(c *gin.Context) {
 			}
 			return
 		}
+		// 解锁用户
+		// /api/v1/user/admin/unlock PUT
+		if c.Request.Method == http.MethodPut && c.Request.URL.Path == "/api/v1/user/admin/unlock" {
+			getid := define.GetID{}
+			err = json.Unmarshal(body, &getid)
+			if err != nil {
+				log.Error("json.Unmarshal failed", zap.Error(err))
+				c.Next()
+				return
+			}
+			userData, err := model.GetUserByID(ctx, getid.ID)
+			if err != nil {
+				log.Error("model.GetUserByID failed", zap.Error(err))
+				c.Next()
+				return
+			}
+			c.Next() // 为了获取状态码
+			err = model.SaveOperateLog(ctx, c,
+				uid,
+				username,
+				role,
+				c.Request.Method,
+				module,
+				userData.Name,
+				operatetimne,
+				fmt.Sprintf("解锁用户%s", userData.Name), columns)
+			if err != nil {
+				log.Error("model.SaveOperateLog failed", zap.Error(err))
+			}
+			return
+		}
+		// 开启、关闭和重置两步验证
+		// /api/v1/user/totp PUT DELETE
+		// /api/v1/user/admin/totp DELETE
+		if (c.Request.URL.Path == "/api/v1/user/totp" && c.Request.Method != http.MethodPost) ||
+			c.Request.URL.Path == "/api/v1/user/admin/totp" {
+			var desc string
+			modulename = username
+			switch {
+			case c.Request.URL.Path == "/api/v1/user/admin/totp":
+				getid := define.GetID{}
+				err = json.Unmarshal(body, &getid)
+				if err != nil {
+					log.Error("json.Unmarshal failed", zap.Error(err))
+					c.Next()
+					return
+				}
+				userData, err := model.GetUserByID(ctx, getid.ID)
+				if err != nil {
+					log.Error("model.GetUserByID failed", zap.Error(err))
+					c.Next()
+					return
+				}
+				modulename = userData.Name
+				desc = fmt.Sprintf("重置用户%s的两步验证", userData.Name)
+			case c.Request.Method == http.MethodPut:
+				desc = "开启两步验证"
+			default:
+				desc = "关闭两步验证"
+			}
+			c.Next() // 为了获取状态码
+			err = model.SaveOperateLog(ctx, c,
+				uid,
+				username,
+				role,
+				c.Request.Method,
+				module,
+				modulename,
+				operatetimne,
+				desc, columns)
+			if err != nil {
+				log.Error("model.SaveOperateLog failed", zap.Error(err))
+			}
+			return
+		}
+
+		// 审核和撤销审核请求，通过时记录执行的修改
+		// /api/v1/task/approval PUT DELETE
+		if c.Request.URL.Path == "/api/v1/task/approval" {
+			review := define.ReviewApproval{}
+			err = json.Unmarshal(body, &review)
+			if err != nil {
+				log.Error("json.Unmarshal failed", zap.Error(err))
+				c.Next()
+				return
+			}
+			approval, err := model.GetApprovalByID(ctx, review.ID)
+			if err != nil {
+				log.Error("model.GetApprovalByID failed", zap.Error(err))
+				c.Next()
+				return
+			}
+			if approval.Action == define.ApprovalChangeTask {
+				taskData, err := model.GetTaskByID(ctx, approval.TaskID)
+				if err == nil {
+					oldData = *taskData
+				}
+			}
+			c.Next() // 为了获取状态码
+			approval, err = model.GetApprovalByID(ctx, review.ID)
+			if err != nil {
+				log.Error("model.GetApprovalByID failed", zap.Error(err))
+				return
+			}
+			var desc string
+			switch approval.Status {
+			case define.ApprovalApproved:
+				desc = fmt.Sprintf("通过%s的审核请求: %s%s", approval.CreateBy, approval.Action, approval.TaskName)
+			case define.ApprovalRejected:
+				desc = fmt.Sprintf("拒绝%s的审核请求: %s%s", approval.CreateBy, approval.Action, approval.TaskName)
+			case define.ApprovalCanceled:
+				desc = fmt.Sprintf("撤销审核请求: %s%s", approval.Action, approval.TaskName)
+			default:
+				// 请求失败，审核请求没有处理
+				return
+			}
+			if approval.ReviewRemark != "" {
+				desc += " 备注: " + approval.ReviewRemark
+			}
+			if approval.Status == define.ApprovalApproved && c.GetInt("statuscode") != 0 {
+				desc += " 执行失败"
+			}
+			if approval.Status == define.ApprovalApproved && c.GetInt("statuscode") == 0 {
+				switch approval.Action {
+				case define.ApprovalCreateTask:
+					taskData, err := model.GetTaskByName(ctx, approval.TaskName)
+					if err == nil {
+						newData = *taskData
+					}
+				case define.ApprovalChangeTask:
+					taskData, err := model.GetTaskByID(ctx, approval.TaskID)
+					if err == nil {
+						newData = *taskData
+					}
+				}
+				if newData != nil {
+					parseColumn(oldData, newData, &columns, "")
+				}
+			}
+			err = model.SaveOperateLog(ctx, c,
+				uid,
+				username,
+				role,
+				c.Request.Method,
+				module,
+				approval.TaskName,
+				operatetimne,
+				desc, columns)
+			if err != nil {
+				log.Error("model.SaveOperateLog failed", zap.Error(err))
+			}
+			return
+		}
 
 		// get old data
 		switch c.Request.Method {
@@ -285,6 +455,15 @@ func Oprtation() func(c *gin.Context) {
 				}
 				modulename = taskData.Name
 				oldData = *taskData
+			case project:
+				projectData, err := model.GetProjectByID(ctx, id, "")
+				if err != nil {
+					log.Error("model.GetProjectByID", zap.String("id", id), zap.Error(err))
+					c.Next()
+					return
+				}
+				modulename = projectData.Name
+				oldData = *projectData
 			default:
 				log.Debug("can get module name from url", zap.String("url", c.Request.URL.Path))
 				c.Next()
@@ -297,6 +476,30 @@ func Oprtation() func(c *gin.Context) {
 
 		c.Next()
 
+		// 受保护的任务只提交了审核请求，审核通过后记录修改
+		if c.GetInt("statuscode") == resp.ApprovalPending {
+			if modulename == "" {
+				modulename = name
+			}
+			action := define.ApprovalChangeTask
+			if c.Request.Method == http.MethodPost {
+				action = define.ApprovalCreateTask
+			}
+			err = model.SaveOperateLog(ctx, c,
+				uid,
+				username,
+				role,
+				c.Request.Method,
+				module,
+				modulename,
+				operatetimne,
+				fmt.Sprintf("提交审核请求: %s%s", action, modulename), columns)
+			if err != nil {
+				log.Error("model.SaveOperateLog failed", zap.Error(err))
+			}
+			return
+		}
+
 		if c.GetInt("statuscode") != 0 {
 			log.Error("req status code is not 0", zap.Int("statuscode", c.GetInt("statuscode")))
 			return
@@ -333,6 +536,15 @@ func Oprtation() func(c *gin.Context) {
 				}
 				modulename = taskData.Name
 				newData = *taskData
+			case project:
+				projectData, err := model.GetProjectByName(ctx, name, "")
+				if err != nil {
+					log.Error("model.GetProjectByName", zap.String("name", name), zap.Error(err))
+					c.Next()
+					return
+				}
+				modulename = projectData.Name
+				newData = *projectData
 			default:
 				log.Debug("can get module name from url", zap.String("url", c.Request.URL.Path))
 				c.Next()
@@ -372,6 +584,14 @@ func Oprtation() func(c *gin.Context) {
 					return
 				}
 				newData = *taskData
+			case project:
+				projectData, err := model.GetProjectByID(ctx, id, "")
+				if err != nil {
+					log.Error("model.GetProjectByID", zap.String("id", id), zap.Error(err))
+					c.Next()
+					return
+				}
+				newData = *projectData
 			default:
 				log.Debug("can get module name from url", zap.String("url", c.Request.URL.Path))
 				c.Next()
@@ -0,0 +1,256 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// APITokenPrefix api token start with it, so it can be distinguished from jwt token
+	APITokenPrefix = "ct_"
+
+	// last used time is updated at most once per interval
+	lastusedInterval = time.Minute
+)
+
+// ErrAPITokenInvalid api token is not exist, expired or user is forbid
+var ErrAPITokenInvalid = errors.New("api token is invalid, expired or user is forbid")
+
+// CreateAPIToken create api token for user and return it with plain token
+func CreateAPIToken(ctx context.Context, req define.CreateAPIToken, uid string) (*define.APIToken, error) {
+	createsql := `INSERT INTO crocodile_apitoken
+					(id,name,token,uid,scope,taskIDs,expireTime,createTime)
+				VALUES
+					(?,?,?,?,?,?,?,?)`
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, fmt.Errorf("rand.Read failed: %w", err)
+	}
+	createTime := time.Now().Unix()
+	apitoken := &define.APIToken{
+		ID:         utils.GetID(),
+		Name:       req.Name,
+		Token:      APITokenPrefix + hex.EncodeToString(b),
+		UID:        uid,
+		Scope:      req.Scope,
+		TaskIDs:    req.TaskIDs,
+		CreateTime: utils.UnixToStr(createTime),
+	}
+	if apitoken.TaskIDs == nil {
+		apitoken.TaskIDs = []string{}
+	}
+	if req.Expire > 0 {
+		apitoken.ExpireTimeUnix = createTime + req.Expire
+		apitoken.ExpireTime = utils.UnixToStr(apitoken.ExpireTimeUnix)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, createsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx,
+		apitoken.ID,
+		apitoken.Name,
+		hashtoken(apitoken.Token),
+		uid,
+		apitoken.Scope,
+		strings.Join(apitoken.TaskIDs, ","),
+		apitoken.ExpireTimeUnix,
+		createTime,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return apitoken, nil
+}
+
+func getAPITokens(ctx context.Context, id, uid, tokenhash string, offset, limit int) ([]define.APIToken, int, error) {
+	getsql := `SELECT
+					tk.id,
+					tk.name,
+					tk.uid,
+					u.name,
+					u.role,
+					u.forbid,
+					tk.scope,
+					tk.taskIDs,
+					tk.expireTime,
+					tk.lastUsedTime,
+					tk.createTime
+				FROM
+					crocodile_apitoken as tk
+				JOIN crocodile_user as u ON tk.uid = u.id`
+	query := []string{}
+	args := []interface{}{}
+	if id != "" {
+		query = append(query, "tk.id=?")
+		args = append(args, id)
+	}
+	if uid != "" {
+		query = append(query, "tk.uid=?")
+		args = append(args, uid)
+	}
+	if tokenhash != "" {
+		query = append(query, "tk.token=?")
+		args = append(args, tokenhash)
+	}
+	if len(query) > 0 {
+		getsql += " WHERE " + strings.Join(query, " AND ")
+	}
+	var count int
+	if limit > 0 {
+		var err error
+		count, err = countColums(ctx, getsql, args...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("countColums failed: %w", err)
+		}
+		getsql += " ORDER BY tk.createTime DESC LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	apitokens := []define.APIToken{}
+	for rows.Next() {
+		var (
+			tk         define.APIToken
+			taskids    string
+			createTime int64
+		)
+		err = rows.Scan(&tk.ID,
+			&tk.Name,
+			&tk.UID,
+			&tk.UserName,
+			&tk.Role,
+			&tk.Forbid,
+			&tk.Scope,
+			&taskids,
+			&tk.ExpireTimeUnix,
+			&tk.LastUsedUnix,
+			&createTime)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		tk.TaskIDs = []string{}
+		if taskids != "" {
+			tk.TaskIDs = strings.Split(taskids, ",")
+		}
+		if tk.ExpireTimeUnix > 0 {
+			tk.ExpireTime = utils.UnixToStr(tk.ExpireTimeUnix)
+		}
+		if tk.LastUsedUnix > 0 {
+			tk.LastUsedTime = utils.UnixToStr(tk.LastUsedUnix)
+		}
+		tk.CreateTime = utils.UnixToStr(createTime)
+		apitokens = append(apitokens, tk)
+	}
+	return apitokens, count, nil
+}
+
+// GetAPITokens return api tokens of user without plain token
+func GetAPITokens(ctx context.Context, uid string, offset, limit int) ([]define.APIToken, int, error) {
+	return getAPITokens(ctx, "", uid, "", offset, limit)
+}
+
+// GetAPITokenByID return api token by id
+func GetAPITokenByID(ctx context.Context, id string) (*define.APIToken, error) {
+	apitokens, _, err := getAPITokens(ctx, id, "", "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(apitokens) != 1 {
+		return nil, define.ErrNotExist{Value: id}
+	}
+	return &apitokens[0], nil
+}
+
+// DeleteAPIToken delete api token, it can not be used any more
+func DeleteAPIToken(ctx context.Context, id string) error {
+	deletesql := `DELETE FROM crocodile_apitoken WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, deletesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// VerifyAPIToken check api token is valid and update its last used time,
+// if token is not exist, expired or user is forbid, return ErrAPITokenInvalid
+func VerifyAPIToken(ctx context.Context, token string) (*define.APIToken, error) {
+	apitokens, _, err := getAPITokens(ctx, "", "", hashtoken(token), 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(apitokens) != 1 {
+		return nil, ErrAPITokenInvalid
+	}
+	apitoken := &apitokens[0]
+	now := time.Now()
+	if apitoken.ExpireTimeUnix > 0 && apitoken.ExpireTimeUnix < now.Unix() {
+		return nil, ErrAPITokenInvalid
+	}
+	if apitoken.Forbid {
+		return nil, ErrAPITokenInvalid
+	}
+	if now.Sub(time.Unix(apitoken.LastUsedUnix, 0)) < lastusedInterval {
+		return apitoken, nil
+	}
+	usesql := `UPDATE crocodile_apitoken SET lastUsedTime=? WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, usesql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, now.Unix(), apitoken.ID)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	apitoken.LastUsedUnix = now.Unix()
+	apitoken.LastUsedTime = utils.UnixToStr(apitoken.LastUsedUnix)
+	return apitoken, nil
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+)
+
+// 任务审核
+// 受保护的任务或主机组的创建、修改和手动运行先保存为审核请求，审核人通过后再执行
+
+// CreateApproval save new approval request
+func CreateApproval(ctx context.Context, id string, action define.ApprovalAction, taskid, taskname, content string,
+	approverids []string, createbyid string) error {
+	createsql := `INSERT INTO crocodile_approval
+					(id,action,taskID,taskName,content,approverIds,status,createByID,createTime,updateTime)
+				  VALUES(?,?,?,?,?,?,?,?,?,?)`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, createsql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	createTime := time.Now().Unix()
+	_, err = stmt.ExecContext(ctx,
+		id,
+		action,
+		taskid,
+		taskname,
+		content,
+		strings.Join(approverids, ","),
+		define.ApprovalPending,
+		createbyid,
+		createTime,
+		createTime,
+	)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// FinishApproval change pending approval's status, return false if approval is not pending
+func FinishApproval(ctx context.Context, id string, status define.ApprovalStatus, reviewbyid, remark string) (bool, error) {
+	finishsql := `UPDATE crocodile_approval SET status=?,reviewByID=?,reviewRemark=?,updateTime=? WHERE id=? AND status=?`
+	return execaffected(ctx, finishsql, status, reviewbyid, remark, time.Now().Unix(), id, define.ApprovalPending)
+}
+
+// GetApprovalByID get approval request by id
+func GetApprovalByID(ctx context.Context, id string) (*define.Approval, error) {
+	approvals, _, err := getApprovals(ctx, id, "", 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(approvals) != 1 {
+		return nil, define.ErrNotExist{Value: id}
+	}
+	return &approvals[0], nil
+}
+
+// GetApprovals get approval requests, visibleuid filter requests created by user or wait user review
+func GetApprovals(ctx context.Context, visibleuid string, status define.ApprovalStatus, offset, limit int) ([]define.Approval, int, error) {
+	return getApprovals(ctx, "", visibleuid, status, offset, limit)
+}
+
+func getApprovals(ctx context.Context, id, visibleuid string, status define.ApprovalStatus, offset, limit int) ([]define.Approval, int, error) {
+	getsql := `SELECT
+					a.id,
+					a.action,
+					a.taskID,
+					a.taskName,
+					a.content,
+					a.approverIds,
+					a.status,
+					a.createByID,
+					u.name,
+					a.reviewByID,
+					COALESCE(r.name, ''),
+					a.reviewRemark,
+					a.createTime,
+					a.updateTime
+				FROM
+					crocodile_approval as a
+				INNER JOIN crocodile_user as u ON a.createByID = u.id
+				LEFT JOIN crocodile_user as r ON a.reviewByID = r.id
+				WHERE 1=1`
+	args := []interface{}{}
+	if id != "" {
+		getsql += " AND a.id=?"
+		args = append(args, id)
+	}
+	if visibleuid != "" {
+		// 用户ID长度固定，可以直接匹配审核人列表
+		getsql += " AND (a.createByID=? OR a.approverIds LIKE ?)"
+		args = append(args, visibleuid, "%"+visibleuid+"%")
+	}
+	if status != 0 {
+		getsql += " AND a.status=?"
+		args = append(args, status)
+	}
+	approvals := []define.Approval{}
+	var count int
+	if limit > 0 {
+		var err error
+		count, err = countColums(ctx, getsql, args...)
+		if err != nil {
+			return approvals, 0, fmt.Errorf("countColums failed: %w", err)
+		}
+		getsql += " ORDER BY a.createTime DESC LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return approvals, 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return approvals, 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return approvals, 0, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			approval               define.Approval
+			approverids            string
+			createTime, updateTime int64
+		)
+		err = rows.Scan(&approval.ID,
+			&approval.Action,
+			&approval.TaskID,
+			&approval.TaskName,
+			&approval.Content,
+			&approverids,
+			&approval.Status,
+			&approval.CreateByUID,
+			&approval.CreateBy,
+			&approval.ReviewByUID,
+			&approval.ReviewBy,
+			&approval.ReviewRemark,
+			&createTime,
+			&updateTime,
+		)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		approval.ApproverIds = []string{}
+		approval.Approvers = []string{}
+		if approverids != "" {
+			approval.ApproverIds = strings.Split(approverids, ",")
+			approval.Approvers = GetUserNames(ctx, approval.ApproverIds)
+		}
+		approval.ActionDesc = approval.Action.String()
+		approval.StatusDesc = approval.Status.String()
+		approval.CreateTime = utils.UnixToStr(createTime)
+		approval.UpdateTime = utils.UnixToStr(updateTime)
+		approvals = append(approvals, approval)
+	}
+	return approvals, count, nil
+}
@@ -0,0 +1,260 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ErrEnrollTokenInvalid enroll token is not exist, expired or used up
+var ErrEnrollTokenInvalid = errors.New("enroll token is invalid, expired or used up")
+
+// only save token hash, token is only return once when created
+func hashtoken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateEnrollToken create enroll token and return it with plain token
+func CreateEnrollToken(ctx context.Context, req define.CreateEnrollToken, createByID string) (*define.EnrollToken, error) {
+	createsql := `INSERT INTO crocodile_enrolltoken
+					(id,token,hostGroupID,labels,maxUses,expireTime,remark,createByID,createTime)
+				VALUES
+					(?,?,?,?,?,?,?,?,?)`
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, fmt.Errorf("rand.Read failed: %w", err)
+	}
+	createTime := time.Now().Unix()
+	enrolltoken := &define.EnrollToken{
+		ID:          utils.GetID(),
+		Token:       hex.EncodeToString(b),
+		HostGroupID: req.HostGroupID,
+		Labels:      define.FormatLabels(define.ParseLabels(req.Labels)),
+		MaxUses:     req.MaxUses,
+		Remark:      req.Remark,
+		CreateByUID: createByID,
+		CreateTime:  utils.UnixToStr(createTime),
+	}
+	if req.Expire > 0 {
+		enrolltoken.ExpireTimeUnix = createTime + req.Expire
+		enrolltoken.ExpireTime = utils.UnixToStr(enrolltoken.ExpireTimeUnix)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, createsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx,
+		enrolltoken.ID,
+		hashtoken(enrolltoken.Token),
+		enrolltoken.HostGroupID,
+		strings.Join(enrolltoken.Labels, ","),
+		enrolltoken.MaxUses,
+		enrolltoken.ExpireTimeUnix,
+		enrolltoken.Remark,
+		createByID,
+		createTime,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return enrolltoken, nil
+}
+
+func getEnrollTokens(ctx context.Context, id, tokenhash string, offset, limit int) ([]define.EnrollToken, int, error) {
+	getsql := `SELECT
+					et.id,
+					et.hostGroupID,
+					IFNULL(hg.name,''),
+					et.labels,
+					et.maxUses,
+					et.uses,
+					et.expireTime,
+					et.remark,
+					et.createByID,
+					IFNULL(u.name,''),
+					et.createTime
+				FROM
+					crocodile_enrolltoken as et
+				LEFT JOIN crocodile_hostgroup as hg ON et.hostGroupID = hg.id
+				LEFT JOIN crocodile_user as u ON et.createByID = u.id`
+	args := []interface{}{}
+	if id != "" {
+		getsql += " WHERE et.id=?"
+		args = append(args, id)
+	}
+	if tokenhash != "" {
+		getsql += " WHERE et.token=?"
+		args = append(args, tokenhash)
+	}
+	var count int
+	if limit > 0 {
+		var err error
+		count, err = countColums(ctx, getsql, args...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("countColums failed: %w", err)
+		}
+		getsql += " ORDER BY et.createTime DESC LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	enrolltokens := []define.EnrollToken{}
+	for rows.Next() {
+		var (
+			et         define.EnrollToken
+			labels     string
+			createTime int64
+		)
+		err = rows.Scan(&et.ID,
+			&et.HostGroupID,
+			&et.HostGroup,
+			&labels,
+			&et.MaxUses,
+			&et.Uses,
+			&et.ExpireTimeUnix,
+			&et.Remark,
+			&et.CreateByUID,
+			&et.CreateBy,
+			&createTime)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		et.Labels = []string{}
+		if labels != "" {
+			et.Labels = strings.Split(labels, ",")
+		}
+		if et.ExpireTimeUnix > 0 {
+			et.ExpireTime = utils.UnixToStr(et.ExpireTimeUnix)
+		}
+		et.CreateTime = utils.UnixToStr(createTime)
+		enrolltokens = append(enrolltokens, et)
+	}
+	return enrolltokens, count, nil
+}
+
+// GetEnrollTokens return enroll tokens without plain token
+func GetEnrollTokens(ctx context.Context, offset, limit int) ([]define.EnrollToken, int, error) {
+	return getEnrollTokens(ctx, "", "", offset, limit)
+}
+
+// GetEnrollTokenByID return enroll token by id
+func GetEnrollTokenByID(ctx context.Context, id string) (*define.EnrollToken, error) {
+	enrolltokens, _, err := getEnrollTokens(ctx, id, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(enrolltokens) != 1 {
+		return nil, define.ErrNotExist{Value: id}
+	}
+	return &enrolltokens[0], nil
+}
+
+// DeleteEnrollToken delete enroll token, worker registried by it can still use its credential
+func DeleteEnrollToken(ctx context.Context, id string) error {
+	deletesql := `DELETE FROM crocodile_enrolltoken WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, deletesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// CheckEnrollToken check token is valid, token is used after worker registry success,
+// if token is not exist, expired or used up, return ErrEnrollTokenInvalid
+func CheckEnrollToken(ctx context.Context, token string) (*define.EnrollToken, error) {
+	enrolltokens, _, err := getEnrollTokens(ctx, "", hashtoken(token), 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(enrolltokens) != 1 {
+		return nil, ErrEnrollTokenInvalid
+	}
+	enrolltoken := &enrolltokens[0]
+	if enrolltoken.ExpireTimeUnix > 0 && enrolltoken.ExpireTimeUnix < time.Now().Unix() {
+		return nil, ErrEnrollTokenInvalid
+	}
+	if enrolltoken.MaxUses > 0 && enrolltoken.Uses >= enrolltoken.MaxUses {
+		return nil, ErrEnrollTokenInvalid
+	}
+	return enrolltoken, nil
+}
+
+// EnrollHost add uses of enroll token and save credential issued to registried worker in one transaction,
+// if token is used up by others, return ErrEnrollTokenInvalid
+func EnrollHost(ctx context.Context, enrolltokenid, hostid, credential string) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	// 多个调度中心同时使用同一个令牌时只有未超过使用次数的可以更新成功
+	result, err := tx.ExecContext(ctx,
+		`UPDATE crocodile_enrolltoken SET uses=uses+1 WHERE id=? AND (maxUses=0 OR uses<maxUses)`, enrolltokenid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	line, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected failed: %w", err)
+	}
+	if line <= 0 {
+		return ErrEnrollTokenInvalid
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE crocodile_host SET credential=? WHERE id=?`, HashCredential(credential), hostid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return nil
+}
@@ -21,7 +21,7 @@ const (
 
 // RegistryToUpdateHost refistry new host
 func RegistryToUpdateHost(ctx context.Context, req *pb.RegistryReq) error {
-	updatesql := `UPDATE crocodile_host set weight=?,version=?,lastUpdateTimeUnix=?,remark=? WHERE addr=?`
+	updatesql := `UPDATE crocodile_host set weight=?,version=?,lastUpdateTimeUnix=?,remark=?,labels=?,drain=false WHERE addr=?`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("db.GetConn failed: %w", err)
@@ -33,7 +33,7 @@ func RegistryToUpdateHost(ctx context.Context, req *pb.RegistryReq) error {
 	}
 	defer stmt.Close()
 	addr := fmt.Sprintf("%s:%d", req.Ip, req.Port)
-	_, err = stmt.ExecContext(ctx, req.Weight, req.Version, time.Now().Unix(), req.Remark, addr)
+	_, err = stmt.ExecContext(ctx, req.Weight, req.Version, time.Now().Unix(), req.Remark, formatlabels(req.Labels), addr)
 	if err != nil {
 		return fmt.Errorf("stmt.ExecContext faled: %w", err)
 	}
@@ -50,10 +50,11 @@ func RegistryNewHost(ctx context.Context, req *pb.RegistryReq) (string, error) {
 					weight,
 					version,
 					lastUpdateTimeUnix,
-					remark
+					remark,
+					labels
 				)
  			  	VALUES
-					(?,?,?,?,?,?,?)`
+					(?,?,?,?,?,?,?,?)`
 	addr := fmt.Sprintf("%s:%d", req.Ip, req.Port)
 	hosts, _, err := getHosts(ctx, addr, nil, 0, 0)
 	if err != nil {
@@ -82,6 +83,7 @@ func RegistryNewHost(ctx context.Context, req *pb.RegistryReq) (string, error) {
 		req.Version,
 		time.Now().Unix(),
 		req.Remark,
+		formatlabels(req.Labels),
 	)
 	if err != nil {
 		return "", fmt.Errorf("stmt.ExecContext failed: %w", err)
@@ -90,9 +92,28 @@ func RegistryNewHost(ctx context.Context, req *pb.RegistryReq) (string, error) {
 	return id, nil
 }
 
-// UpdateHostHearbeat update host last recv hearbeat time
-func UpdateHostHearbeat(ctx context.Context, ip string, port int32, runningtasks []string) error {
-	updatesql := `UPDATE crocodile_host set lastUpdateTimeUnix=?,runningTasks=? WHERE addr=?`
+// formatlabels normalize worker labels and save as key=value,key=value
+func formatlabels(labels []string) string {
+	return strings.Join(define.FormatLabels(define.ParseLabels(labels)), ",")
+}
+
+// UpdateHostHearbeat update host last recv hearbeat time and resource usage
+func UpdateHostHearbeat(ctx context.Context, ip string, port int32, runningtasks []string, res define.HostResource) error {
+	updatesql := `UPDATE crocodile_host set 
+					lastUpdateTimeUnix=?,
+					runningTasks=?,
+					cpuNum=?,
+					load1=?,
+					load5=?,
+					load15=?,
+					memTotal=?,
+					memAvailable=?,
+					diskTotal=?,
+					diskFree=?,
+					taskSlots=?,
+					runningSlots=?,
+					maxConcurrent=?
+				WHERE addr=?`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("db.GetConn failed: %w", err)
@@ -106,6 +127,17 @@ func UpdateHostHearbeat(ctx context.Context, ip string, port int32, runningtasks
 	result, err := stmt.ExecContext(ctx,
 		time.Now().Unix(),
 		strings.Join(runningtasks, ","),
+		res.CPUNum,
+		res.Load1,
+		res.Load5,
+		res.Load15,
+		res.MemTotal,
+		res.MemAvailable,
+		res.DiskTotal,
+		res.DiskFree,
+		res.TaskSlots,
+		res.RunningSlots,
+		res.MaxConcurrent,
 		fmt.Sprintf("%s:%d", ip, port))
 	if err != nil {
 		return fmt.Errorf("stmt.ExecContext failed: %w", err)
@@ -131,7 +163,21 @@ func getHosts(ctx context.Context, addr string, ids []string, offset, limit int)
 					stop,
 					version,
 					lastUpdateTimeUnix,
-					remark
+					remark,
+					cpuNum,
+					load1,
+					load5,
+					load15,
+					memTotal,
+					memAvailable,
+					diskTotal,
+					diskFree,
+					taskSlots,
+					runningSlots,
+					maxConcurrent,
+					labels,
+					drain,
+					credential
 			   FROM 
 					crocodile_host`
 	var (
@@ -185,8 +231,9 @@ func getHosts(ctx context.Context, addr string, ids []string, offset, limit int)
 	hosts := []*define.Host{}
 	for rows.Next() {
 		var (
-			h     define.Host
-			rtask string
+			h      define.Host
+			rtask  string
+			labels string
 		)
 		err := rows.Scan(&h.ID,
 			&h.Addr,
@@ -196,7 +243,21 @@ func getHosts(ctx context.Context, addr string, ids []string, offset, limit int)
 			&h.Stop,
 			&h.Version,
 			&h.LastUpdateTimeUnix,
-			&h.Remark)
+			&h.Remark,
+			&h.CPUNum,
+			&h.Load1,
+			&h.Load5,
+			&h.Load15,
+			&h.MemTotal,
+			&h.MemAvailable,
+			&h.DiskTotal,
+			&h.DiskFree,
+			&h.TaskSlots,
+			&h.RunningSlots,
+			&h.MaxConcurrent,
+			&labels,
+			&h.Drain,
+			&h.Credential)
 		if err != nil {
 			log.Error("Scan failed", zap.Error(err))
 			continue
@@ -209,6 +270,11 @@ func getHosts(ctx context.Context, addr string, ids []string, offset, limit int)
 			h.Online = true
 		}
 		h.LastUpdateTime = utils.UnixToStr(h.LastUpdateTimeUnix)
+		h.Enrolled = h.Credential != ""
+		h.Labels = map[string]string{}
+		if labels != "" {
+			h.Labels = define.ParseLabels(strings.Split(labels, ","))
+		}
 		hosts = append(hosts, &h)
 	}
 	return hosts, count, nil
@@ -286,6 +352,75 @@ func StopHost(ctx context.Context, hostid string, stop bool) error {
 	return nil
 }
 
+// DrainHost will set host drain, worker stop recv new task after recv drain from heartbeat resp
+func DrainHost(ctx context.Context, hostid string) error {
+	drainsql := `UPDATE crocodile_host SET drain=true WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, drainsql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, hostid)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// HashCredential return hash of credential issued to worker, only hash is saved
+func HashCredential(credential string) string {
+	return hashtoken(credential)
+}
+
+// SetHostCredential save credential hash issued to worker, empty credential will revoke it
+func SetHostCredential(ctx context.Context, hostid, credential string) error {
+	setsql := `UPDATE crocodile_host SET credential=? WHERE id=?`
+	if credential != "" {
+		credential = HashCredential(credential)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, setsql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, credential, hostid)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// UnRegistryHost will mark host offline after worker drain finished,
+// host will not be deleted, so it can join the same hostgroups after registry again
+func UnRegistryHost(ctx context.Context, addr string) error {
+	unregistrysql := `UPDATE crocodile_host SET lastUpdateTimeUnix=0,runningTasks='',runningSlots=0 WHERE addr=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, unregistrysql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, addr)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
 // DeleteHost will delete host
 func DeleteHost(ctx context.Context, hostid string) error {
 	err := StopHost(ctx, hostid, true)
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+)
+
+// CheckHostsOnline check host online status by last heartbeat time,
+// if it is not equal to recorded status, change recorded status and return the event
+// 多个调度节点同时检查时只有一个节点可以修改成功，只有修改成功的节点会返回事件
+func CheckHostsOnline(ctx context.Context) ([]define.HostEvent, error) {
+	getsql := `SELECT id,addr,hostname,lastUpdateTimeUnix,online FROM crocodile_host`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	now := time.Now().Unix()
+	changes := []define.HostEvent{}
+	for rows.Next() {
+		var (
+			event              define.HostEvent
+			lastUpdateTimeUnix int64
+			online             bool
+		)
+		err = rows.Scan(&event.HostID, &event.Addr, &event.HostName, &lastUpdateTimeUnix, &online)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		if (lastUpdateTimeUnix+maxWorkerTTL > now) == online {
+			continue
+		}
+		event.Event = define.HostOffline
+		if !online {
+			event.Event = define.HostOnline
+		}
+		changes = append(changes, event)
+	}
+	rows.Close()
+
+	events := make([]define.HostEvent, 0, len(changes))
+	for _, event := range changes {
+		changed, err := ChangeHostOnline(ctx, event.Addr, event.Event == define.HostOnline)
+		if err != nil {
+			log.Error("ChangeHostOnline failed", zap.String("addr", event.Addr), zap.Error(err))
+			continue
+		}
+		if !changed {
+			continue
+		}
+		event.EventTime = now
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ChangeHostOnline change recorded host online status,
+// return true only if the status is changed by this call
+func ChangeHostOnline(ctx context.Context, addr string, online bool) (bool, error) {
+	updatesql := `UPDATE crocodile_host SET online=? WHERE addr=? AND online=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, updatesql)
+	if err != nil {
+		return false, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	result, err := stmt.ExecContext(ctx, online, addr, !online)
+	if err != nil {
+		return false, fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	line, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("result.RowsAffected failed: %w", err)
+	}
+	return line > 0, nil
+}
+
+// SaveHostEvent save host online status change event
+func SaveHostEvent(ctx context.Context, event define.HostEvent) error {
+	savesql := `INSERT INTO crocodile_hostevent
+				(hostid,addr,hostname,event,eventtime)
+			  VALUES
+				(?,?,?,?,?)`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, savesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx,
+		event.HostID,
+		event.Addr,
+		event.HostName,
+		event.Event,
+		event.EventTime,
+	)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// GetHostEvents return host online status change events, if hostid is empty return all hosts' events
+func GetHostEvents(ctx context.Context, hostid string, offset, limit int) ([]define.HostEvent, int, error) {
+	getsql := `SELECT id,hostid,addr,hostname,event,eventtime FROM crocodile_hostevent`
+	args := []interface{}{}
+	if hostid != "" {
+		getsql += " WHERE hostid=?"
+		args = append(args, hostid)
+	}
+	var count int
+	if limit > 0 {
+		var err error
+		count, err = countColums(ctx, getsql, args...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("countColums failed: %w", err)
+		}
+	}
+	getsql += " ORDER BY id DESC"
+	if limit > 0 {
+		getsql += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	events := []define.HostEvent{}
+	for rows.Next() {
+		var event define.HostEvent
+		err = rows.Scan(&event.ID, &event.HostID, &event.Addr, &event.HostName, &event.Event, &event.EventTime)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		event.EventDesc = event.Event.String()
+		event.EventTimeDesc = utils.UnixToStr(event.EventTime)
+		events = append(events, event)
+	}
+	return events, count, nil
+}
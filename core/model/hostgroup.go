@@ -10,14 +10,15 @@ import (
 	"github.com/labulaka521/crocodile/common/db"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/stats"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 // CreateHostgroup create hostgroup
-func CreateHostgroup(ctx context.Context, name, remark, createByID string, hostids []string) error {
-	createsql := `INSERT INTO crocodile_hostgroup (id,name,remark,createByID,hostIDs,createTime,updateTime) VALUES(?,?,?,?,?,?,?)`
+func CreateHostgroup(ctx context.Context, name, remark, createByID, projectID string, hostids, approverids []string) error {
+	createsql := `INSERT INTO crocodile_hostgroup (id,name,remark,createByID,projectID,hostIDs,approverIds,createTime,updateTime) VALUES(?,?,?,?,?,?,?,?,?)`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("db.Db.GetConn failed: %w", err)
@@ -34,7 +35,9 @@ func CreateHostgroup(ctx context.Context, name, remark, createByID string, hosti
 		name,
 		remark,
 		createByID,
+		projectID,
 		strings.Join(hostids, ","),
+		strings.Join(approverids, ","),
 		createTime,
 		createTime)
 	if err != nil {
@@ -44,8 +47,8 @@ func CreateHostgroup(ctx context.Context, name, remark, createByID string, hosti
 }
 
 // ChangeHostGroup change hostgroup
-func ChangeHostGroup(ctx context.Context, hostids []string, id, remark string) error {
-	changesql := `UPDATE crocodile_hostgroup SET hostIDs=?,remark=?,updateTime=? WHERE id=?`
+func ChangeHostGroup(ctx context.Context, hostids, approverids []string, id, projectID, remark string) error {
+	changesql := `UPDATE crocodile_hostgroup SET hostIDs=?,approverIds=?,projectID=?,remark=?,updateTime=? WHERE id=?`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("db.Db.GetConn failed: %w", err)
@@ -58,6 +61,8 @@ func ChangeHostGroup(ctx context.Context, hostids []string, id, remark string) e
 	defer stmt.Close()
 	_, err = stmt.ExecContext(ctx,
 		strings.Join(hostids, ","),
+		strings.Join(approverids, ","),
+		projectID,
 		remark,
 		time.Now().Unix(),
 		id,
@@ -89,21 +94,26 @@ func DeleteHostGroup(ctx context.Context, id string) error {
 }
 
 // getHostGroups return hostgroup by id or hostgroupname
-func getHostGroups(ctx context.Context, id, hgname string, limit, offset int) ([]define.HostGroup, int, error) {
+// projectid filter hostgroups of project, visibleuid filter hostgroups which user could see
+func getHostGroups(ctx context.Context, id, hgname, projectid, visibleuid string, limit, offset int) ([]define.HostGroup, int, error) {
 	hgs := []define.HostGroup{}
 	getsql := `SELECT 
 					hg.id,
 					hg.name,
 					hg.remark,
 					hg.hostIDs,
+					hg.approverIds,
 					hg.createByID,
 					u.name,
+					hg.projectID,
+					COALESCE(p.name, ''),
 					hg.createTime,
 					hg.updateTime 
 				FROM 
-					crocodile_hostgroup as hg,crocodile_user as u
-				WHERE
-					hg.createByID = u.id`
+					crocodile_hostgroup as hg
+				INNER JOIN crocodile_user as u ON hg.createByID = u.id
+				LEFT JOIN crocodile_project as p ON hg.projectID = p.id
+				WHERE 1=1`
 	var count int
 	args := []interface{}{}
 	if id != "" {
@@ -114,6 +124,14 @@ func getHostGroups(ctx context.Context, id, hgname string, limit, offset int) ([
 		getsql += " AND hg.name=?"
 		args = append(args, hgname)
 	}
+	if projectid != "" {
+		getsql += " AND hg.projectID=?"
+		args = append(args, projectid)
+	}
+	if visibleuid != "" {
+		getsql += " AND " + fmt.Sprintf(visiblequery, "hg")
+		args = append(args, visibleuid)
+	}
 	if limit > 0 {
 		var err error
 		count, err = countColums(ctx, getsql, args...)
@@ -143,10 +161,11 @@ func getHostGroups(ctx context.Context, id, hgname string, limit, offset int) ([
 		var (
 			hg                     define.HostGroup
 			addrs                  string
+			approverids            string
 			createTime, updateTime int64
 		)
 		err := rows.Scan(&hg.ID, &hg.Name, &hg.Remark,
-			&addrs, &hg.CreateByUID, &hg.CreateBy, &createTime, &updateTime)
+			&addrs, &approverids, &hg.CreateByUID, &hg.CreateBy, &hg.ProjectID, &hg.Project, &createTime, &updateTime)
 		if err != nil {
 			log.Info("Scan result failed", zap.Error(err))
 			continue
@@ -156,6 +175,12 @@ func getHostGroups(ctx context.Context, id, hgname string, limit, offset int) ([
 			hg.HostsID = append(hg.HostsID, strings.Split(addrs, ",")...)
 
 		}
+		hg.ApproverIds = []string{}
+		hg.Approvers = []string{}
+		if approverids != "" {
+			hg.ApproverIds = append(hg.ApproverIds, strings.Split(approverids, ",")...)
+			hg.Approvers = GetUserNames(ctx, hg.ApproverIds)
+		}
 		hg.CreateTime = utils.UnixToStr(createTime)
 		hg.UpdateTime = utils.UnixToStr(updateTime)
 
@@ -164,14 +189,14 @@ func getHostGroups(ctx context.Context, id, hgname string, limit, offset int) ([
 	return hgs, count, nil
 }
 
-// GetHostGroups return all hostgroup
-func GetHostGroups(ctx context.Context, limit, offset int) ([]define.HostGroup, int, error) {
-	return getHostGroups(ctx, "", "", limit, offset)
+// GetHostGroups return all hostgroup, filter by project and user if projectid or visibleuid is not empty
+func GetHostGroups(ctx context.Context, limit, offset int, projectid, visibleuid string) ([]define.HostGroup, int, error) {
+	return getHostGroups(ctx, "", "", projectid, visibleuid, limit, offset)
 }
 
 // GetHostGroupByID return hostgroup by id
 func GetHostGroupByID(ctx context.Context, id string) (*define.HostGroup, error) {
-	hostgroups, _, err := getHostGroups(ctx, id, "", 0, 0)
+	hostgroups, _, err := getHostGroups(ctx, id, "", "", "", 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +225,7 @@ func GetHostsByHGID(ctx context.Context, hgid string) ([]*define.Host, error) {
 
 // GetHostGroupByName return hostgroup by name
 func GetHostGroupByName(ctx context.Context, hgname string) (*define.HostGroup, error) {
-	hostgroups, _, err := getHostGroups(ctx, "", hgname, 0, 0)
+	hostgroups, _, err := getHostGroups(ctx, "", hgname, "", "", 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -211,6 +236,35 @@ func GetHostGroupByName(ctx context.Context, hgname string) (*define.HostGroup,
 	return &hostgroups[0], nil
 }
 
+// CountHostGroupWorkers return online and offline worker count of every hostgroup
+func CountHostGroupWorkers(ctx context.Context) ([]stats.HostGroupWorkers, error) {
+	hostgroups, _, err := GetHostGroups(ctx, 0, 0, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("GetHostGroups failed: %w", err)
+	}
+	hosts, _, err := GetHosts(ctx, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("GetHosts failed: %w", err)
+	}
+	online := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		online[host.ID] = host.Online
+	}
+	hgworkers := make([]stats.HostGroupWorkers, 0, len(hostgroups))
+	for _, hg := range hostgroups {
+		hgworker := stats.HostGroupWorkers{HostGroup: hg.Name}
+		for _, hostid := range hg.HostsID {
+			if online[hostid] {
+				hgworker.Online++
+			} else {
+				hgworker.Offline++
+			}
+		}
+		hgworkers = append(hgworkers, hgworker)
+	}
+	return hgworkers, nil
+}
+
 // RandHostID return execute worker ip
 func RandHostID(hg *define.HostGroup) (string, error) {
 	if len(hg.HostsID) == 0 {
@@ -19,7 +19,15 @@ import (
 
 var crcocodileTables = []string{
 	TBHost,
+	TBHostEvent,
 	TBHostgroup,
+	TBEnrollToken,
+	TBAPIToken,
+	TBUserGroup,
+	TBTOTPRecovery,
+	TBApproval,
+	TBProject,
+	TBProjectMember,
 	TBLog,
 	TBNotify,
 	TBOperate,
@@ -84,42 +92,14 @@ func StartInstall(ctx context.Context, username, password string) error {
 		return fmt.Errorf("db.GetConn failed: %w", err)
 	}
 
-	fs := &assetfs.AssetFS{
-		Asset:     asset.Asset,
-		AssetDir:  asset.AssetDir,
-		AssetInfo: asset.AssetInfo,
-	}
-
 	defer conn.Close()
 	for _, tbname := range crcocodileTables {
-		// crocodile_host
-		var name string
-		if tbname != TBCasbin {
-			name = tbname[10:]
-		} else {
-			name = tbname
-		}
-		sqlfilename := "sql/" + name + ".sql"
-		file, err := fs.Open(sqlfilename)
+		execsql, err := readtablesql(tbname)
 		if err != nil {
-			log.Error("fs.Open failed", zap.String("filename", sqlfilename), zap.Error(err))
+			log.Error("readtablesql failed", zap.Error(err))
 			continue
 		}
 
-		content, err := ioutil.ReadAll(file)
-		if err != nil {
-			log.Error("ioutil.ReadAll failed", zap.Error(err))
-			continue
-		}
-		var execsql string
-		if config.CoreConf.Server.DB.Drivename == "sqlite3" {
-			// sqlite3 TODO 的自增字段为AUTOINCREMENT
-			execsql = strings.Replace(string(content), "AUTO_INCREMENT", "AUTOINCREMENT", -1)
-			execsql = strings.Replace(string(content), "COMMENT", "--", -1)
-		} else {
-			execsql = string(content)
-		}
-
 		if tbname == TBCasbin {
 			for _, sql := range strings.Split(execsql, ";\n") {
 				if sql == "" {
@@ -163,3 +143,38 @@ func StartInstall(ctx context.Context, username, password string) error {
 	log.Debug("Success Install Crocodile")
 	return nil
 }
+
+// readtablesql read table's sql file
+func readtablesql(tbname string) (string, error) {
+	fs := &assetfs.AssetFS{
+		Asset:     asset.Asset,
+		AssetDir:  asset.AssetDir,
+		AssetInfo: asset.AssetInfo,
+	}
+	// crocodile_host
+	var name string
+	if tbname != TBCasbin {
+		name = tbname[10:]
+	} else {
+		name = tbname
+	}
+	sqlfilename := "sql/" + name + ".sql"
+	file, err := fs.Open(sqlfilename)
+	if err != nil {
+		return "", fmt.Errorf("fs.Open %s failed: %w", sqlfilename, err)
+	}
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("ioutil.ReadAll failed: %w", err)
+	}
+	var execsql string
+	if config.CoreConf.Server.DB.Drivename == "sqlite3" {
+		// sqlite3 TODO 的自增字段为AUTOINCREMENT
+		execsql = strings.Replace(string(content), "AUTO_INCREMENT", "AUTOINCREMENT", -1)
+		execsql = strings.Replace(string(content), "COMMENT", "--", -1)
+	} else {
+		execsql = string(content)
+	}
+	return execsql, nil
+}
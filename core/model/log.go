@@ -20,7 +20,9 @@ import (
 func SaveLog(ctx context.Context, l *define.Log) error {
 	log.Info("start save tasklog", zap.String("task", l.Name))
 	savesql := `INSERT INTO crocodile_log
-				(name,
+				(runid,
+				traceid,
+				name,
 				taskid,
 				starttime,
 				endtime,
@@ -35,7 +37,7 @@ func SaveLog(ctx context.Context, l *define.Log) error {
 				errtask
 			)
 			VALUES
-			(?,?,?,?,?,?,?,?,?,?,?,?,?)`
+			(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("db.GetConn failed: %w", err)
@@ -50,7 +52,7 @@ func SaveLog(ctx context.Context, l *define.Log) error {
 	if err != nil {
 		return fmt.Errorf("json.Marshal failed: %w", err)
 	}
-	_, err = stmt.ExecContext(ctx, l.Name, l.RunByTaskID,
+	_, err = stmt.ExecContext(ctx, l.RunID, l.TraceID, l.Name, l.RunByTaskID,
 		l.StartTime, l.EndTime, l.TotalRunTime,
 		l.Status, taskresps, l.Trigger, l.ErrCode, l.ErrMsg,
 		l.ErrTasktype, l.ErrTaskID, l.ErrTask)
@@ -61,9 +63,12 @@ func SaveLog(ctx context.Context, l *define.Log) error {
 }
 
 // GetLog get task resp log by taskid
-func GetLog(ctx context.Context, taskname string, status int, offset, limit int) ([]*define.Log, int, error) {
+// visibleuid filter logs of tasks in project which user is not member of
+func GetLog(ctx context.Context, taskname string, status int, offset, limit int, visibleuid string) ([]*define.Log, int, error) {
 	logs := []*define.Log{}
 	getsql := `SELECT 
+					runid,
+					traceid,
 					name,
 					taskid,
 					starttime,
@@ -93,6 +98,15 @@ func GetLog(ctx context.Context, taskname string, status int, offset, limit int)
 
 		args = append(args, status)
 	}
+	if visibleuid != "" {
+		if len(args) > 0 {
+			getsql += ` AND`
+		} else {
+			getsql += ` WHERE`
+		}
+		getsql += ` taskid NOT IN (SELECT t.id FROM crocodile_task as t WHERE NOT ` + fmt.Sprintf(visiblequery, "t") + `)`
+		args = append(args, visibleuid)
+	}
 	count, err := countColums(ctx, getsql, args...)
 	if err != nil {
 		return logs, 0, fmt.Errorf("countColums failed: %w", err)
@@ -120,6 +134,8 @@ func GetLog(ctx context.Context, taskname string, status int, offset, limit int)
 		getlog := define.Log{}
 		taskrepos := []*define.TaskResp{}
 		err = rows.Scan(
+			&getlog.RunID,
+			&getlog.TraceID,
 			&getlog.Name,
 			&getlog.RunByTaskID,
 			&getlog.StartTime,
@@ -147,6 +163,75 @@ func GetLog(ctx context.Context, taskname string, status int, offset, limit int)
 	return logs, count, nil
 }
 
+// GetLogByRunID get task log by run id
+func GetLogByRunID(ctx context.Context, runid string) (*define.Log, error) {
+	getsql := `SELECT 
+					runid,
+					traceid,
+					name,
+					taskid,
+					starttime,
+					endtime,
+					totalruntime,
+					status,
+					taskresps,
+					triggertype,
+					errcode,
+					errmsg,
+					errtasktype,
+					errtaskid,
+					errtask
+				FROM 
+					crocodile_log
+				WHERE
+					runid=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	getlog := define.Log{}
+	var taskreposbyte []byte
+	err = stmt.QueryRowContext(ctx, runid).Scan(
+		&getlog.RunID,
+		&getlog.TraceID,
+		&getlog.Name,
+		&getlog.RunByTaskID,
+		&getlog.StartTime,
+		&getlog.EndTime,
+		&getlog.TotalRunTime,
+		&getlog.Status,
+		&taskreposbyte,
+		&getlog.Trigger,
+		&getlog.ErrCode,
+		&getlog.ErrMsg,
+		&getlog.ErrTasktype,
+		&getlog.ErrTaskID,
+		&getlog.ErrTask,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, define.ErrNotExist{Value: runid}
+		}
+		return nil, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	getlog.TaskResps = []*define.TaskResp{}
+	err = json.Unmarshal(taskreposbyte, &getlog.TaskResps)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal failed: %w", err)
+	}
+	getlog.ErrTaskTypeStr = getlog.ErrTasktype.String()
+	getlog.StartTimeStr = utils.UnixToStr(getlog.StartTime / 1e3)
+	getlog.EndTimeStr = utils.UnixToStr(getlog.EndTime / 1e3)
+	getlog.Triggerstr = getlog.Trigger.String()
+	return &getlog, nil
+}
+
 // GetTreeLog get tree log data
 func GetTreeLog(ctx context.Context, id string, startTime int64) ([]*define.TaskStatusTree, error) {
 	sqlget := `SELECT taskresps FROM crocodile_log WHERE starttime=? AND taskid=?`
@@ -326,10 +411,11 @@ func SaveOperateLog(ctx context.Context,
 			modulename,
 			operatetime,
 			description,
-			columns)
+			columns,
+			apitoken)
 			VALUES
 			(
-				?,?,?,?,?,?,?,?,?
+				?,?,?,?,?,?,?,?,?,?
 			)
 		`
 	conn, err := db.GetConn(ctx)
@@ -343,7 +429,31 @@ func SaveOperateLog(ctx context.Context,
 	}
 	defer stmt.Close()
 	columnsdata, err := json.Marshal(columns)
-	_, err = stmt.ExecContext(ctx, uid, username, role, method, module, modulename, operatetime, desc, columnsdata)
+	// 使用API令牌的请求记录令牌名称
+	_, err = stmt.ExecContext(ctx, uid, username, role, method, module, modulename, operatetime, desc, columnsdata, c.GetString("apitoken"))
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// SaveLoginLog save login attempt to operate log, failed attempt is also saved
+func SaveLoginLog(ctx context.Context, uid, username string, role define.Role, operatetime int64, desc string) error {
+	operatesql := `INSERT INTO crocodile_operate
+			(uid,username,role,method,module,modulename,operatetime,description,columns)
+			VALUES
+			(?,?,?,?,?,?,?,?,?)`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, operatesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, uid, username, role, "POST", "login", username, operatetime, desc, "[]")
 	if err != nil {
 		return fmt.Errorf("stmt.ExecContext failed: %w", err)
 	}
@@ -353,7 +463,7 @@ func SaveOperateLog(ctx context.Context,
 // GetOperate get operate log
 func GetOperate(ctx context.Context, uid, username, method, module string, limit, offset int) ([]define.OperateLog, int, error) {
 	getsql := `SELECT 
-					uid,username,role,method,module,modulename, operatetime,description,columns
+					uid,username,role,method,module,modulename, operatetime,description,columns,apitoken
 			   FROM 
 					crocodile_operate`
 	query := []string{}
@@ -429,6 +539,7 @@ func GetOperate(ctx context.Context, uid, username, method, module string, limit
 			&operatetime,
 			&oplog.Desc,
 			&columnsdata,
+			&oplog.APIToken,
 		)
 		if err != nil {
 			log.Error("rows.Scan failed", zap.Error(err))
@@ -0,0 +1,311 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	"go.uber.org/zap"
+)
+
+// 升级数据库
+// 已经安装的系统升级后启动时执行，创建新增的表，添加新增的字段、索引和权限，可以重复执行
+// sql目录下的表结构新增字段、索引或者casbin_rule新增权限时需要同时添加到这里
+
+type migratecolumn struct {
+	table  string
+	column string
+	define string
+}
+
+type migrateindex struct {
+	table   string
+	name    string
+	columns string
+}
+
+// 新增的字段
+var migratecolumns = []migratecolumn{
+	// 运行ID
+	{TBLog, "runid", `CHAR(18) NOT NULL DEFAULT "" COMMENT "运行ID"`},
+	// 链路追踪
+	{TBLog, "traceid", `CHAR(32) NOT NULL DEFAULT "" COMMENT "链路追踪ID"`},
+	// 主机资源
+	{TBHost, "cpuNum", `INT NOT NULL DEFAULT 0 COMMENT "CPU核数"`},
+	{TBHost, "load1", `DOUBLE NOT NULL DEFAULT 0 COMMENT "1分钟负载"`},
+	{TBHost, "load5", `DOUBLE NOT NULL DEFAULT 0 COMMENT "5分钟负载"`},
+	{TBHost, "load15", `DOUBLE NOT NULL DEFAULT 0 COMMENT "15分钟负载"`},
+	{TBHost, "memTotal", `BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "总内存 字节"`},
+	{TBHost, "memAvailable", `BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "可用内存 字节"`},
+	{TBHost, "diskTotal", `BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "总磁盘 字节"`},
+	{TBHost, "diskFree", `BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "可用磁盘 字节"`},
+	{TBHost, "taskSlots", `INT NOT NULL DEFAULT 0 COMMENT "任务槽位数"`},
+	// 主机标签和任务标签选择器
+	{TBHost, "labels", `VARCHAR(1000) NOT NULL DEFAULT "" COMMENT "标签 key=value,key=value"`},
+	{TBTask, "labelSelector", `VARCHAR(2000) NOT NULL DEFAULT "" COMMENT "标签选择器 JSON"`},
+	// 主机并发任务数
+	{TBHost, "runningSlots", `INT NOT NULL DEFAULT 0 COMMENT "正在运行的任务数"`},
+	{TBHost, "maxConcurrent", `INT NOT NULL DEFAULT 0 COMMENT "最大并发任务数 0为不限制"`},
+	// 广播任务
+	{TBTask, "broadcastSuccess", `INT NOT NULL DEFAULT 0 COMMENT "广播任务成功策略 1:All 2:Any 3:Quorum"`},
+	{TBTask, "broadcastParallel", `INT NOT NULL DEFAULT 0 COMMENT "广播任务同时运行的主机数 0为不限制"`},
+	// 分片任务
+	{TBTask, "shardTotal", `INT NOT NULL DEFAULT 0 COMMENT "分片数 0为不分片"`},
+	// 故障转移
+	{TBTask, "idempotent", `BOOL NOT NULL DEFAULT false COMMENT "任务是否幂等 幂等任务在worker运行中途宕机后可以在其他worker重新运行"`},
+	{TBTask, "failoverLimit", `INT NOT NULL DEFAULT 1 COMMENT "worker宕机时故障转移到其他worker的最大次数 0为默认转移1次 -1为不转移"`},
+	// 主机在线状态
+	{TBHost, "online", `BOOL NOT NULL DEFAULT false COMMENT "在线状态 由调度中心的主机监控记录"`},
+	// 主机排空
+	{TBHost, "drain", `BOOL NOT NULL DEFAULT false COMMENT "排空 不再接收新任务 任务运行结束后下线"`},
+	// 主机凭证
+	{TBHost, "credential", `VARCHAR(100) NOT NULL DEFAULT "" COMMENT "worker使用注册令牌换取的凭证sha256 为空时使用secrettoken认证"`},
+	// API令牌
+	{TBOperate, "apitoken", `VARCHAR(30) NOT NULL DEFAULT "" COMMENT "使用API令牌操作时的令牌名称"`},
+	// 单点登陆
+	{TBUser, "oidcSubject", `VARCHAR(255) NOT NULL DEFAULT "" COMMENT "单点登陆用户标识"`},
+	// LDAP
+	{TBUser, "ldapDN", `VARCHAR(255) NOT NULL DEFAULT "" COMMENT "LDAP用户DN"`},
+	// 项目
+	{TBHostgroup, "projectID", `CHAR(18) NOT NULL DEFAULT "" COMMENT "所属项目ID 为空不属于任何项目"`},
+	{TBTask, "projectID", `CHAR(18) NOT NULL DEFAULT "" COMMENT "所属项目ID 为空不属于任何项目"`},
+	// 密码过期
+	{TBUser, "passwordUpdateTime", `INT NOT NULL DEFAULT 0 COMMENT "密码修改时间"`},
+	// 两步验证
+	{TBUser, "totpSecret", `VARCHAR(64) NOT NULL DEFAULT "" COMMENT "两步验证密钥"`},
+	{TBUser, "totpEnable", `INT(1) NOT NULL DEFAULT 0 COMMENT "是否开启两步验证"`},
+	{TBUser, "totpLastStep", `BIGINT NOT NULL DEFAULT 0 COMMENT "最后使用的两步验证时间步 防止重放"`},
+	// 任务审核
+	{TBHostgroup, "approverIds", `VARCHAR(200) NOT NULL DEFAULT "" COMMENT "审核人 最多设置10个 设置后使用此主机组的任务需要审核"`},
+	{TBTask, "approverIds", `VARCHAR(200) NOT NULL DEFAULT "" COMMENT "审核人 最多设置10个 设置后修改和手动运行任务需要审核"`},
+}
+
+// 新增的索引
+var migrateindexs = []migrateindex{
+	// 运行ID
+	{TBLog, "idx_runid", "`runid`"},
+	// 单点登陆
+	{TBUser, "idx_oidcsubject", "`oidcSubject`"},
+	// 项目
+	{TBHostgroup, "idx_pid", "`projectID`"},
+	{TBTask, "idx_pid", "`projectID`"},
+}
+
+// 添加字段后需要转换的数据
+var migratedatas = []func(ctx context.Context, conn *sql.Conn) error{
+	hashhostcredentials,
+	initpasswordupdatetime,
+}
+
+// 新增的权限 sub obj act
+var migratepolicys = [][]string{
+	// 注销会话
+	{"Admin", "/api/v1/user/admin/sessions", "(DELETE)"},
+	{"Admin", "/api/v1/user/sessions", "(DELETE)"},
+	{"Normal", "/api/v1/user/sessions", "(DELETE)"},
+	{"Guest", "/api/v1/user/sessions", "(DELETE)"},
+	// API令牌
+	{"Admin", "/api/v1/user/apitoken", "(GET)|(POST)|(DELETE)"},
+	{"Normal", "/api/v1/user/apitoken", "(GET)|(POST)|(DELETE)"},
+	{"Guest", "/api/v1/user/apitoken", "(GET)|(POST)|(DELETE)"},
+	// 项目
+	{"Guest", "/api/v1/task/run", "(PUT)"},
+	{"Guest", "/api/v1/task/kill", "(PUT)"},
+	{"Admin", "/api/v1/project*", "(GET)|(POST)|(DELETE)|(PUT)"},
+	{"Normal", "/api/v1/project*", "(GET)|(POST)|(DELETE)|(PUT)"},
+	{"Guest", "/api/v1/project*", "(GET)"},
+	// 密码过期
+	{"Admin", "/api/v1/user/admin/unlock", "(PUT)"},
+	// 两步验证
+	{"Admin", "/api/v1/user/admin/totp", "(DELETE)"},
+	{"Admin", "/api/v1/user/totp", "(GET)|(POST)|(PUT)|(DELETE)"},
+	{"Normal", "/api/v1/user/totp", "(GET)|(POST)|(PUT)|(DELETE)"},
+	{"Guest", "/api/v1/user/totp", "(GET)|(POST)|(PUT)|(DELETE)"},
+	// 任务审核
+	{"Guest", "/api/v1/task/approval", "(PUT)|(DELETE)"},
+}
+
+// Migrate upgrade installed db to newest schema
+func Migrate(ctx context.Context) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+
+	isinstall, err := tableexist(ctx, conn, TBUser)
+	if err != nil {
+		return fmt.Errorf("tableexist failed: %w", err)
+	}
+	// 没有安装的系统安装时创建最新的表
+	if !isinstall {
+		return nil
+	}
+
+	for _, tbname := range crcocodileTables {
+		if tbname == TBCasbin {
+			continue
+		}
+		exist, err := tableexist(ctx, conn, tbname)
+		if err != nil {
+			return fmt.Errorf("tableexist failed: %w", err)
+		}
+		if exist {
+			continue
+		}
+		execsql, err := readtablesql(tbname)
+		if err != nil {
+			return fmt.Errorf("readtablesql failed: %w", err)
+		}
+		_, err = conn.ExecContext(ctx, execsql)
+		if err != nil {
+			return fmt.Errorf("create table %s failed: %w", tbname, err)
+		}
+		log.Info("migrate create table", zap.String("table", tbname))
+	}
+
+	sqlite := config.CoreConf.Server.DB.Drivename == "sqlite3"
+	for _, col := range migratecolumns {
+		exist, err := columnexist(ctx, conn, col.table, col.column)
+		if err != nil {
+			return fmt.Errorf("columnexist failed: %w", err)
+		}
+		if exist {
+			continue
+		}
+		coldefine := col.define
+		if sqlite {
+			// sqlite3 不支持字段注释
+			coldefine = strings.Split(coldefine, " COMMENT ")[0]
+		}
+		altersql := fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", col.table, col.column, coldefine)
+		_, err = conn.ExecContext(ctx, altersql)
+		if err != nil {
+			return fmt.Errorf("add column %s.%s failed: %w", col.table, col.column, err)
+		}
+		log.Info("migrate add column", zap.String("table", col.table), zap.String("column", col.column))
+	}
+
+	for _, idx := range migrateindexs {
+		name := idx.name
+		if sqlite {
+			// sqlite3 的索引名称在库内唯一
+			name = idx.table + "_" + idx.name
+		}
+		exist, err := indexexist(ctx, conn, idx.table, name)
+		if err != nil {
+			return fmt.Errorf("indexexist failed: %w", err)
+		}
+		if exist {
+			continue
+		}
+		indexsql := fmt.Sprintf("ALTER TABLE `%s` ADD INDEX `%s` (%s)", idx.table, name, idx.columns)
+		if sqlite {
+			indexsql = fmt.Sprintf("CREATE INDEX `%s` ON `%s` (%s)", name, idx.table, idx.columns)
+		}
+		_, err = conn.ExecContext(ctx, indexsql)
+		if err != nil {
+			return fmt.Errorf("add index %s.%s failed: %w", idx.table, name, err)
+		}
+		log.Info("migrate add index", zap.String("table", idx.table), zap.String("index", name))
+	}
+
+	for _, migratedata := range migratedatas {
+		err = migratedata(ctx, conn)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 已经存在的权限不会重复添加
+	for _, policy := range migratepolicys {
+		ok, err := enforcer.AddPolicy(policy[0], policy[1], policy[2])
+		if err != nil {
+			return fmt.Errorf("enforcer.AddPolicy failed: %w", err)
+		}
+		if ok {
+			log.Info("migrate add policy", zap.Strings("policy", policy))
+		}
+	}
+	return nil
+}
+
+// hashhostcredentials 旧版本保存的明文凭证转换为sha256，明文凭证格式为hostid.random
+func hashhostcredentials(ctx context.Context, conn *sql.Conn) error {
+	rows, err := conn.QueryContext(ctx, `SELECT id,credential FROM crocodile_host WHERE credential LIKE '%.%'`)
+	if err != nil {
+		return fmt.Errorf("query host credential failed: %w", err)
+	}
+	credentials := map[string]string{}
+	for rows.Next() {
+		var id, credential string
+		err = rows.Scan(&id, &credential)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("rows.Scan failed: %w", err)
+		}
+		credentials[id] = credential
+	}
+	rows.Close()
+	for id, credential := range credentials {
+		_, err = conn.ExecContext(ctx, `UPDATE crocodile_host SET credential=? WHERE id=? AND credential=?`,
+			HashCredential(credential), id, credential)
+		if err != nil {
+			return fmt.Errorf("hash host credential failed: %w", err)
+		}
+		log.Info("migrate hash host credential", zap.String("hostid", id))
+	}
+	return nil
+}
+
+// initpasswordupdatetime 旧版本的用户没有密码修改时间，使用更新时间代替，避免升级后密码立即过期
+func initpasswordupdatetime(ctx context.Context, conn *sql.Conn) error {
+	res, err := conn.ExecContext(ctx, `UPDATE crocodile_user SET passwordUpdateTime=updateTime WHERE passwordUpdateTime=0`)
+	if err != nil {
+		return fmt.Errorf("init user password update time failed: %w", err)
+	}
+	if count, _ := res.RowsAffected(); count > 0 {
+		log.Info("migrate init user password update time", zap.Int64("count", count))
+	}
+	return nil
+}
+
+// tableexist check table is exist
+func tableexist(ctx context.Context, conn *sql.Conn, tbname string) (bool, error) {
+	querysql := `SELECT count(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=?`
+	if config.CoreConf.Server.DB.Drivename == "sqlite3" {
+		querysql = `SELECT count(*) FROM sqlite_master WHERE type="table" AND name=?`
+	}
+	return queryexist(ctx, conn, querysql, tbname)
+}
+
+// columnexist check table's column is exist
+func columnexist(ctx context.Context, conn *sql.Conn, tbname, column string) (bool, error) {
+	querysql := `SELECT count(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND COLUMN_NAME=?`
+	if config.CoreConf.Server.DB.Drivename == "sqlite3" {
+		querysql = `SELECT count(*) FROM pragma_table_info(?) WHERE name=?`
+	}
+	return queryexist(ctx, conn, querysql, tbname, column)
+}
+
+// indexexist check table's index is exist
+func indexexist(ctx context.Context, conn *sql.Conn, tbname, index string) (bool, error) {
+	querysql := `SELECT count(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND INDEX_NAME=?`
+	if config.CoreConf.Server.DB.Drivename == "sqlite3" {
+		querysql = `SELECT count(*) FROM sqlite_master WHERE type="index" AND tbl_name=? AND name=?`
+	}
+	return queryexist(ctx, conn, querysql, tbname, index)
+}
+
+func queryexist(ctx context.Context, conn *sql.Conn, querysql string, args ...interface{}) (bool, error) {
+	var count int
+	err := conn.QueryRowContext(ctx, querysql, args...).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("QueryRowContext failed: %w", err)
+	}
+	return count > 0, nil
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/labulaka521/crocodile/common/db"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/stats"
+	"github.com/labulaka521/crocodile/core/tracing"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -26,6 +28,8 @@ func InitDb() error {
 		db.MaxIdleConnection(dbcfg.MaxIdle),
 		db.MaxOpenConnection(dbcfg.MaxConn),
 		db.MaxQueryTime(dbcfg.MaxQueryTime.Duration),
+		db.Observe(stats.ObserveDB),
+		db.Hook(tracing.DBHook),
 	)
 	if err != nil {
 		return err
@@ -73,10 +77,32 @@ const (
 	TBNotify string = "crocodile_notify"
 	// TBOperate operate table
 	TBOperate string = "crocodile_operate"
+	// TBHostEvent host online status change event table
+	TBHostEvent string = "crocodile_hostevent"
+	// TBEnrollToken worker enroll token table
+	TBEnrollToken string = "crocodile_enrolltoken"
+	// TBAPIToken user api token table
+	TBAPIToken string = "crocodile_apitoken"
+	// TBUserGroup ldap owner group of user table
+	TBUserGroup string = "crocodile_usergroup"
+	// TBProject project table
+	TBProject string = "crocodile_project"
+	// TBProjectMember project member table
+	TBProjectMember string = "crocodile_projectmember"
+	// TBTOTPRecovery totp recovery code table
+	TBTOTPRecovery string = "crocodile_totprecovery"
+	// TBApproval approval request of protected task table
+	TBApproval string = "crocodile_approval"
 	// TBCasbin casbin table
 	TBCasbin string = "casbin_rule"
 )
 
+// ownerquery createByID is uid or in same owner group with uid, need uid twice
+const ownerquery = `(createByID=? OR createByID IN (
+		SELECT g1.uid FROM crocodile_usergroup AS g1
+		JOIN crocodile_usergroup AS g2 ON g1.groupName=g2.groupName
+		WHERE g2.uid=?))`
+
 // Check check some msg is valid
 func Check(ctx context.Context, table string, checkType checkType, args ...interface{}) (bool, error) {
 	check := fmt.Sprintf("select COUNT(id) FROM %s WHERE ", table)
@@ -90,11 +116,14 @@ func Check(ctx context.Context, table string, checkType checkType, args ...inter
 	case IDCreateByUID:
 		// 检查ID的createByUID字段是否位当前登陆用户
 		// 如果当前用户为Admin 则世界返回true
-		check += "id=? AND createByID=?"
+		// 和创建人在同一个LDAP所有权组的用户也是所有者
+		check += "id=? AND " + ownerquery
+		args = append(args, args[1])
 	case NameCreateByUID:
 		// 检查ID的createByUID字段是否位当前登陆用户
 		// 如果当前用户为Admin 则世界返回true
-		check += "name=? AND createByID=?"
+		check += "name=? AND " + ownerquery
+		args = append(args, args[1])
 	case UID:
 		// 检查UID状态是否正常
 		check += "id=? AND forbid=false"
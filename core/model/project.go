@@ -0,0 +1,489 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+)
+
+// 项目
+// 任务和主机组可以属于一个项目，项目成员按项目角色拥有权限:
+// Viewer 查看 Operator 运行和终止任务 Editor 创建修改删除任务和主机组 Owner 修改项目和管理成员
+// 不属于任何项目的任务和主机组保持原来的权限，所有人可见，创建人可以修改
+// 管理员拥有所有项目的权限
+
+// visiblequery resource is not in any project or user is member of its project, %s is table alias, need uid
+const visiblequery = `(%[1]s.projectID='' OR %[1]s.projectID IN (
+		SELECT projectID FROM crocodile_projectmember WHERE uid=?))`
+
+// CreateProject create project, creator is the owner of project
+func CreateProject(ctx context.Context, name, remark, createByID string) (string, error) {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return "", fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	id := utils.GetID()
+	createTime := time.Now().Unix()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO crocodile_project (id,name,remark,createByID,createTime,updateTime) VALUES(?,?,?,?,?,?)`,
+		id, name, remark, createByID, createTime, createTime)
+	if err != nil {
+		return "", fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO crocodile_projectmember (projectID,uid,role,createTime) VALUES(?,?,?,?)`,
+		id, createByID, define.ProjectOwner, createTime)
+	if err != nil {
+		return "", fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return "", fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return id, nil
+}
+
+// ChangeProject change project name and remark
+func ChangeProject(ctx context.Context, id, name, remark string) error {
+	changesql := `UPDATE crocodile_project SET name=?,remark=?,updateTime=? WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, changesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, name, remark, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteProject delete project and its members
+func DeleteProject(ctx context.Context, id string) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `DELETE FROM crocodile_projectmember WHERE projectID=?`, id)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM crocodile_project WHERE id=?`, id)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return nil
+}
+
+// ProjectIsUse check project still own tasks or hostgroups
+func ProjectIsUse(ctx context.Context, id string) (bool, error) {
+	querysql := `SELECT (SELECT COUNT(id) FROM crocodile_task WHERE projectID=?) +
+					(SELECT COUNT(id) FROM crocodile_hostgroup WHERE projectID=?)`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, querysql)
+	if err != nil {
+		return false, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	var count int
+	err = stmt.QueryRowContext(ctx, id, id).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetProjects return projects with role of uid, only return projects uid is member of if member is true
+func GetProjects(ctx context.Context, uid string, member bool, limit, offset int) ([]define.Project, int, error) {
+	getsql := `SELECT p.id,
+					p.name,
+					p.remark,
+					p.createByID,
+					COALESCE(u.name, ''),
+					COALESCE(m.role, 0),
+					p.createTime,
+					p.updateTime
+				FROM
+					crocodile_project as p
+				LEFT JOIN crocodile_user as u ON p.createByID = u.id
+				LEFT JOIN crocodile_projectmember as m ON p.id = m.projectID AND m.uid=?
+				WHERE 1=1`
+	args := []interface{}{uid}
+	if member {
+		getsql += " AND m.uid IS NOT NULL"
+	}
+	getsql += " ORDER BY p.createTime DESC"
+	return getProjects(ctx, getsql, args, limit, offset)
+}
+
+// GetProjectByID return project with role of uid
+func GetProjectByID(ctx context.Context, id, uid string) (*define.Project, error) {
+	return getProject(ctx, "p.id=?", id, uid)
+}
+
+// GetProjectByName return project by name with role of uid
+func GetProjectByName(ctx context.Context, name, uid string) (*define.Project, error) {
+	return getProject(ctx, "p.name=?", name, uid)
+}
+
+func getProject(ctx context.Context, where, value, uid string) (*define.Project, error) {
+	getsql := `SELECT p.id,
+					p.name,
+					p.remark,
+					p.createByID,
+					COALESCE(u.name, ''),
+					COALESCE(m.role, 0),
+					p.createTime,
+					p.updateTime
+				FROM
+					crocodile_project as p
+				LEFT JOIN crocodile_user as u ON p.createByID = u.id
+				LEFT JOIN crocodile_projectmember as m ON p.id = m.projectID AND m.uid=?
+				WHERE ` + where
+	projects, _, err := getProjects(ctx, getsql, []interface{}{uid, value}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) != 1 {
+		return nil, define.ErrNotExist{Value: value}
+	}
+	return &projects[0], nil
+}
+
+func getProjects(ctx context.Context, getsql string, args []interface{}, limit, offset int) ([]define.Project, int, error) {
+	projects := []define.Project{}
+	var count int
+	if limit > 0 {
+		var err error
+		count, err = countColums(ctx, getsql, args...)
+		if err != nil {
+			return projects, 0, fmt.Errorf("countColums failed: %w", err)
+		}
+		getsql += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return projects, 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return projects, 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return projects, 0, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			p                      define.Project
+			createTime, updateTime int64
+		)
+		err = rows.Scan(&p.ID, &p.Name, &p.Remark, &p.CreateByUID, &p.CreateBy, &p.Role, &createTime, &updateTime)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		if p.Role != 0 {
+			p.RoleDesc = p.Role.String()
+		}
+		p.CreateTime = utils.UnixToStr(createTime)
+		p.UpdateTime = utils.UnixToStr(updateTime)
+		projects = append(projects, p)
+	}
+	return projects, count, nil
+}
+
+// GetProjectMembers return members of project
+func GetProjectMembers(ctx context.Context, projectid string) ([]define.ProjectMember, error) {
+	getsql := `SELECT m.uid,COALESCE(u.name, ''),m.role,m.createTime
+				FROM crocodile_projectmember as m
+				LEFT JOIN crocodile_user as u ON m.uid = u.id
+				WHERE m.projectID=?
+				ORDER BY m.role DESC,m.createTime`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, projectid)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	members := []define.ProjectMember{}
+	for rows.Next() {
+		var (
+			m          define.ProjectMember
+			createTime int64
+		)
+		err = rows.Scan(&m.UID, &m.UserName, &m.Role, &createTime)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		m.RoleDesc = m.Role.String()
+		m.CreateTime = utils.UnixToStr(createTime)
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// SetProjectMember add member to project or change role of member
+func SetProjectMember(ctx context.Context, projectid, uid string, role define.ProjectRole) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		`UPDATE crocodile_projectmember SET role=? WHERE projectID=? AND uid=?`, role, projectid, uid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO crocodile_projectmember (projectID,uid,role,createTime) VALUES(?,?,?,?)`,
+			projectid, uid, role, time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("tx.ExecContext failed: %w", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteProjectMember remove member from project, remove from all projects if projectid is empty
+func DeleteProjectMember(ctx context.Context, projectid, uid string) error {
+	deletesql := `DELETE FROM crocodile_projectmember WHERE uid=?`
+	args := []interface{}{uid}
+	if projectid != "" {
+		deletesql += " AND projectID=?"
+		args = append(args, projectid)
+	}
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, deletesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// CountProjectOwners return owner count of project
+func CountProjectOwners(ctx context.Context, projectid string) (int, error) {
+	querysql := `SELECT COUNT(*) FROM crocodile_projectmember WHERE projectID=? AND role=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, querysql)
+	if err != nil {
+		return 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	var count int
+	err = stmt.QueryRowContext(ctx, projectid, define.ProjectOwner).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	return count, nil
+}
+
+// GetProjectRole return role of uid in project, return 0 if uid is not member
+func GetProjectRole(ctx context.Context, projectid, uid string) (define.ProjectRole, error) {
+	querysql := `SELECT role FROM crocodile_projectmember WHERE projectID=? AND uid=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, querysql)
+	if err != nil {
+		return 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	var role define.ProjectRole
+	err = stmt.QueryRowContext(ctx, projectid, uid).Scan(&role)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	return role, nil
+}
+
+// CheckProjectPermission check user has need role in project,
+// everyone could use resource which is not in any project
+func CheckProjectPermission(ctx context.Context, uid string, role define.Role,
+	projectid string, need define.ProjectRole) (bool, error) {
+	if role == define.AdminUser || projectid == "" {
+		return true, nil
+	}
+	prole, err := GetProjectRole(ctx, projectid, uid)
+	if err != nil {
+		return false, err
+	}
+	return prole >= need, nil
+}
+
+// CheckTaskPermission check user has need role of task
+func CheckTaskPermission(ctx context.Context, uid string, role define.Role,
+	taskid string, need define.ProjectRole) (bool, error) {
+	return checkresource(ctx, TBTask, uid, role, taskid, need)
+}
+
+// CheckHostGroupPermission check user has need role of hostgroup
+func CheckHostGroupPermission(ctx context.Context, uid string, role define.Role,
+	hgid string, need define.ProjectRole) (bool, error) {
+	return checkresource(ctx, TBHostgroup, uid, role, hgid, need)
+}
+
+// checkresource check permission of task or hostgroup,
+// resource in project need project role, other resource could be seen by everyone
+// and changed by creator or user in same owner group
+func checkresource(ctx context.Context, table, uid string, role define.Role,
+	id string, need define.ProjectRole) (bool, error) {
+	if role == define.AdminUser {
+		return true, nil
+	}
+	querysql := fmt.Sprintf(`SELECT projectID FROM %s WHERE id=?`, table)
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, querysql)
+	if err != nil {
+		return false, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	var projectid string
+	err = stmt.QueryRowContext(ctx, id).Scan(&projectid)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	if projectid != "" {
+		return CheckProjectPermission(ctx, uid, role, projectid, need)
+	}
+	if need == define.ProjectViewer {
+		return true, nil
+	}
+	return Check(ctx, table, IDCreateByUID, id, uid)
+}
+
+// HostGroupUsedOutOfProject check hostgroup is used by tasks which are not in project
+func HostGroupUsedOutOfProject(ctx context.Context, hgid, projectid string) (bool, error) {
+	querysql := `SELECT COUNT(id) FROM crocodile_task WHERE hostGroupID=? AND projectID!=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, querysql)
+	if err != nil {
+		return false, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	var count int
+	err = stmt.QueryRowContext(ctx, hgid, projectid).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetVisibleNameID return name,id of tasks or hostgroups which user could see, return all if uid is empty
+func GetVisibleNameID(ctx context.Context, t, uid string) ([]define.KlOption, error) {
+	if uid == "" {
+		return GetNameID(ctx, t)
+	}
+	getsql := fmt.Sprintf(`SELECT r.id,r.name FROM %s as r WHERE `, t) + fmt.Sprintf(visiblequery, "r")
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	kloptions := []define.KlOption{}
+	for rows.Next() {
+		kloption := define.KlOption{}
+		err = rows.Scan(&kloption.Value, &kloption.Label)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		kloptions = append(kloptions, kloption)
+	}
+	return kloptions, nil
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/core/utils/define"
+)
+
+const (
+	projectid    = "200000000000000001"
+	projecttask  = "300000000000000001"
+	publictask   = "300000000000000002"
+	creatoruid   = "400000000000000000"
+	vieweruid    = "400000000000000001"
+	operatoruid  = "400000000000000002"
+	editoruid    = "400000000000000003"
+	owneruid     = "400000000000000004"
+	nomemberuid  = "400000000000000005"
+	groupmateuid = "400000000000000006"
+)
+
+// initprojectdb create the tables used by permission check in a temp sqlite3 db,
+// return func to remove the db
+func initprojectdb(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "crocodile")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir failed: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	err = db.NewDb(db.Drivename("sqlite3"), db.Dsn(filepath.Join(dir, "crocodile.db")))
+	if err != nil {
+		cleanup()
+		t.Fatalf("db.NewDb failed: %v", err)
+	}
+	conn, err := db.GetConn(context.Background())
+	if err != nil {
+		cleanup()
+		t.Fatalf("db.GetConn failed: %v", err)
+	}
+	defer conn.Close()
+	execsqls := []string{
+		`CREATE TABLE crocodile_task (id TEXT, projectID TEXT, createByID TEXT)`,
+		`CREATE TABLE crocodile_projectmember (projectID TEXT, uid TEXT, role INTEGER)`,
+		`CREATE TABLE crocodile_usergroup (uid TEXT, groupName TEXT)`,
+		`INSERT INTO crocodile_task VALUES ('` + projecttask + `','` + projectid + `','` + owneruid + `')`,
+		`INSERT INTO crocodile_task VALUES ('` + publictask + `','','` + creatoruid + `')`,
+		`INSERT INTO crocodile_projectmember VALUES ('` + projectid + `','` + vieweruid + `',1)`,
+		`INSERT INTO crocodile_projectmember VALUES ('` + projectid + `','` + operatoruid + `',2)`,
+		`INSERT INTO crocodile_projectmember VALUES ('` + projectid + `','` + editoruid + `',3)`,
+		`INSERT INTO crocodile_projectmember VALUES ('` + projectid + `','` + owneruid + `',4)`,
+		`INSERT INTO crocodile_usergroup VALUES ('` + creatoruid + `','ops')`,
+		`INSERT INTO crocodile_usergroup VALUES ('` + groupmateuid + `','ops')`,
+	}
+	for _, execsql := range execsqls {
+		if _, err = conn.ExecContext(context.Background(), execsql); err != nil {
+			cleanup()
+			t.Fatalf("conn.ExecContext %s failed: %v", execsql, err)
+		}
+	}
+	return cleanup
+}
+
+func Test_CheckTaskPermission(t *testing.T) {
+	defer initprojectdb(t)()
+	ctx := context.Background()
+	needs := []define.ProjectRole{define.ProjectViewer, define.ProjectOperator,
+		define.ProjectEditor, define.ProjectOwner}
+	tests := []struct {
+		name   string
+		uid    string
+		role   define.Role
+		taskid string
+		// allow[i] is the result when need is needs[i]
+		allow [4]bool
+	}{
+		{"viewer", vieweruid, define.NormalUser, projecttask, [4]bool{true, false, false, false}},
+		{"operator", operatoruid, define.NormalUser, projecttask, [4]bool{true, true, false, false}},
+		{"editor", editoruid, define.NormalUser, projecttask, [4]bool{true, true, true, false}},
+		{"owner", owneruid, define.NormalUser, projecttask, [4]bool{true, true, true, true}},
+		{"not member", nomemberuid, define.NormalUser, projecttask, [4]bool{false, false, false, false}},
+		{"admin not member", nomemberuid, define.AdminUser, projecttask, [4]bool{true, true, true, true}},
+		{"not exist task", owneruid, define.NormalUser, "300000000000000009", [4]bool{false, false, false, false}},
+
+		// 不属于任何项目的任务所有人可见，只有创建人和同一所有权组的用户可以修改
+		{"public creator", creatoruid, define.NormalUser, publictask, [4]bool{true, true, true, true}},
+		{"public groupmate", groupmateuid, define.NormalUser, publictask, [4]bool{true, true, true, true}},
+		{"public other", vieweruid, define.NormalUser, publictask, [4]bool{true, false, false, false}},
+	}
+	for _, test := range tests {
+		for i, need := range needs {
+			allow, err := CheckTaskPermission(ctx, test.uid, test.role, test.taskid, need)
+			if err != nil {
+				t.Fatalf("%s: CheckTaskPermission failed: %v", test.name, err)
+			}
+			if allow != test.allow[i] {
+				t.Errorf("%s: need %s want allow %v, but get %v", test.name, need, test.allow[i], allow)
+			}
+		}
+	}
+}
+
+func Test_CheckProjectPermission(t *testing.T) {
+	defer initprojectdb(t)()
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		uid       string
+		projectid string
+		need      define.ProjectRole
+		allow     bool
+	}{
+		{"no project", nomemberuid, "", define.ProjectOwner, true},
+		{"viewer view", vieweruid, projectid, define.ProjectViewer, true},
+		{"viewer run", vieweruid, projectid, define.ProjectOperator, false},
+		{"operator run", operatoruid, projectid, define.ProjectOperator, true},
+		{"operator change", operatoruid, projectid, define.ProjectEditor, false},
+		{"editor change", editoruid, projectid, define.ProjectEditor, true},
+		{"editor manage", editoruid, projectid, define.ProjectOwner, false},
+		{"owner manage", owneruid, projectid, define.ProjectOwner, true},
+		{"not member view", nomemberuid, projectid, define.ProjectViewer, false},
+	}
+	for _, test := range tests {
+		allow, err := CheckProjectPermission(ctx, test.uid, define.NormalUser, test.projectid, test.need)
+		if err != nil {
+			t.Fatalf("%s: CheckProjectPermission failed: %v", test.name, err)
+		}
+		if allow != test.allow {
+			t.Errorf("%s: want allow %v, but get %v", test.name, test.allow, allow)
+		}
+	}
+}
@@ -19,8 +19,10 @@ import (
 // CreateTask create task
 func CreateTask(ctx context.Context, id, name string, tasktype define.TaskType, taskData interface{}, run bool,
 	parentTaskIds []string, parentRunParallel bool, childTaskIds []string, childRunParallel bool,
-	cronExpr string, timeout int, alarmUserIds []string, routePolicy define.RoutePolicy, expectCode int,
-	expectContent string, alarmStatus define.AlarmStatus, createByID, hostGroupID, remark string) error {
+	cronExpr string, timeout int, alarmUserIds, approverIds []string, routePolicy define.RoutePolicy, expectCode int,
+	expectContent string, alarmStatus define.AlarmStatus, createByID, hostGroupID, projectID string,
+	labelSelector define.LabelSelector, broadcastSuccess define.BroadcastSuccess, broadcastParallel int,
+	shardTotal int, idempotent bool, failoverLimit int, remark string) error {
 	createsql := `INSERT INTO crocodile_task 
 					(id,
 					name,
@@ -34,16 +36,24 @@ func CreateTask(ctx context.Context, id, name string, tasktype define.TaskType,
 					cronExpr,
 					timeout,
 					alarmUserIds,
+					approverIds,
 					routePolicy,
+					broadcastSuccess,
+					broadcastParallel,
+					shardTotal,
+					idempotent,
+					failoverLimit,
 					expectCode,
 					expectContent,
 					alarmStatus,
 					createByID,
 					hostGroupID,
+					projectID,
+					labelSelector,
 					remark,
 					createTime,
 					updateTime)
-				VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
+				VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("db.GetConn failed: %w", err)
@@ -56,6 +66,10 @@ func CreateTask(ctx context.Context, id, name string, tasktype define.TaskType,
 	defer stmt.Close()
 	createTime := time.Now().Unix()
 	taskdata, _ := json.Marshal(taskData)
+	selector, err := marshalselector(labelSelector)
+	if err != nil {
+		return err
+	}
 	_, err = stmt.ExecContext(ctx,
 		id,
 		name,
@@ -69,12 +83,20 @@ func CreateTask(ctx context.Context, id, name string, tasktype define.TaskType,
 		cronExpr,
 		timeout,
 		strings.Join(alarmUserIds, ","),
+		strings.Join(approverIds, ","),
 		routePolicy,
+		broadcastSuccess,
+		broadcastParallel,
+		shardTotal,
+		idempotent,
+		failoverLimit,
 		expectCode,
 		expectContent,
 		alarmStatus,
 		createByID,
 		hostGroupID,
+		projectID,
+		selector,
 		remark,
 		createTime,
 		createTime,
@@ -89,10 +111,14 @@ func CreateTask(ctx context.Context, id, name string, tasktype define.TaskType,
 // ChangeTask change task
 func ChangeTask(ctx context.Context, id string, run bool, tasktype define.TaskType, taskData interface{},
 	parentTaskIds []string, parentRunParallel bool, childTaskIds []string, childRunParallel bool,
-	cronExpr string, timeout int, alarmUserIds []string, routePolicy define.RoutePolicy, expectCode int,
-	expectContent string, alarmStatus define.AlarmStatus, hostGroupID, remark string) error {
+	cronExpr string, timeout int, alarmUserIds, approverIds []string, routePolicy define.RoutePolicy, expectCode int,
+	expectContent string, alarmStatus define.AlarmStatus, hostGroupID, projectID string,
+	labelSelector define.LabelSelector, broadcastSuccess define.BroadcastSuccess, broadcastParallel int,
+	shardTotal int, idempotent bool, failoverLimit int, remark string) error {
 	changesql := `UPDATE crocodile_task 
 					SET hostGroupID=?,
+						projectID=?,
+						labelSelector=?,
 						run=?,
 						taskType=?,
 						taskData=?,
@@ -103,7 +129,13 @@ func ChangeTask(ctx context.Context, id string, run bool, tasktype define.TaskTy
 						cronExpr=?,
 						timeout=?,
 						alarmUserIds=?,
+						approverIds=?,
 						routePolicy=?,
+						broadcastSuccess=?,
+						broadcastParallel=?,
+						shardTotal=?,
+						idempotent=?,
+						failoverLimit=?,
 						expectCode=?,
 						expectContent=?,
 						alarmStatus=?,
@@ -122,9 +154,15 @@ func ChangeTask(ctx context.Context, id string, run bool, tasktype define.TaskTy
 	defer stmt.Close()
 	updateTime := time.Now().Unix()
 	taskdata, _ := json.Marshal(taskData)
+	selector, err := marshalselector(labelSelector)
+	if err != nil {
+		return err
+	}
 
 	_, err = stmt.ExecContext(ctx,
 		hostGroupID,
+		projectID,
+		selector,
 		run,
 		tasktype,
 		fmt.Sprintf("%s", taskdata),
@@ -135,7 +173,13 @@ func ChangeTask(ctx context.Context, id string, run bool, tasktype define.TaskTy
 		cronExpr,
 		timeout,
 		strings.Join(alarmUserIds, ","),
+		strings.Join(approverIds, ","),
 		routePolicy,
+		broadcastSuccess,
+		broadcastParallel,
+		shardTotal,
+		idempotent,
+		failoverLimit,
 		expectCode,
 		expectContent,
 		alarmStatus,
@@ -149,6 +193,18 @@ func ChangeTask(ctx context.Context, id string, run bool, tasktype define.TaskTy
 	return nil
 }
 
+// marshalselector save label selector as json, empty selector save as ""
+func marshalselector(labelSelector define.LabelSelector) (string, error) {
+	if len(labelSelector) == 0 {
+		return "", nil
+	}
+	selector, err := json.Marshal(labelSelector)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal failed: %w", err)
+	}
+	return string(selector), nil
+}
+
 // DeleteTask delete task
 func DeleteTask(ctx context.Context, id string) error {
 	deletesql := `DELETE FROM crocodile_task WHERE id=?`
@@ -191,14 +247,14 @@ func TaskIsUse(ctx context.Context, taskid string) (int, error) {
 	return count, nil
 }
 
-// GetTasks get all tasks
-func GetTasks(ctx context.Context, offset, limit int, name, presearchname, createby string) ([]define.GetTask, int, error) {
-	return getTasks(ctx, nil, name, offset, limit, true, presearchname, createby)
+// GetTasks get all tasks, filter by project and user if projectid or visibleuid is not empty
+func GetTasks(ctx context.Context, offset, limit int, name, presearchname, createby, projectid, visibleuid string) ([]define.GetTask, int, error) {
+	return getTasks(ctx, nil, name, offset, limit, true, presearchname, createby, projectid, visibleuid)
 }
 
 // GetTaskByID get task by id
 func GetTaskByID(ctx context.Context, id string) (*define.GetTask, error) {
-	tasks, _, err := getTasks(ctx, []string{id}, "", 0, 0, true, "", "")
+	tasks, _, err := getTasks(ctx, []string{id}, "", 0, 0, true, "", "", "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +268,7 @@ func GetTaskByID(ctx context.Context, id string) (*define.GetTask, error) {
 
 // GetTaskByName get task by id
 func GetTaskByName(ctx context.Context, name string) (*define.GetTask, error) {
-	tasks, _, err := getTasks(ctx, nil, name, 0, 0, true, "", "")
+	tasks, _, err := getTasks(ctx, nil, name, 0, 0, true, "", "", "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +288,9 @@ func getTasks(ctx context.Context,
 	limit int,
 	first bool, /*Preventing endless loops*/
 	presearchname,
-	createbyid string) ([]define.GetTask, int, error) {
+	createbyid,
+	projectid,
+	visibleuid string) ([]define.GetTask, int, error) {
 	getsql := `SELECT t.id,
 					t.name,
 					t.tasktype,
@@ -245,21 +303,32 @@ func getTasks(ctx context.Context,
 					t.cronExpr,
 					t.timeout,
 					t.alarmUserIds,
+					t.approverIds,
 					t.routePolicy,
+					t.broadcastSuccess,
+					t.broadcastParallel,
+					t.shardTotal,
+					t.idempotent,
+					t.failoverLimit,
 					t.expectCode,
 					t.expectContent,
 					t.alarmStatus,
 					u.name,
 					t.createByID,
-					hg.name,
+					COALESCE(hg.name, ''),
 					t.hostGroupID,
+					t.projectID,
+					COALESCE(p.name, ''),
+					t.labelSelector,
 					t.remark,
 					t.createTime,
 					t.updateTime
 				FROM 
-					crocodile_task as t,crocodile_user as u,crocodile_hostgroup as hg 
-				WHERE
-					t.createByID = u.id AND t.hostGroupID = hg.id`
+					crocodile_task as t
+				INNER JOIN crocodile_user as u ON t.createByID = u.id
+				LEFT JOIN crocodile_hostgroup as hg ON t.hostGroupID = hg.id
+				LEFT JOIN crocodile_project as p ON t.projectID = p.id
+				WHERE 1=1`
 	args := []interface{}{}
 	var count int
 	if len(ids) != 0 {
@@ -284,6 +353,14 @@ func getTasks(ctx context.Context,
 		getsql += " AND t.createByID=?"
 		args = append(args, createbyid)
 	}
+	if projectid != "" {
+		getsql += " AND t.projectID=?"
+		args = append(args, projectid)
+	}
+	if visibleuid != "" {
+		getsql += " AND " + fmt.Sprintf(visiblequery, "t")
+		args = append(args, visibleuid)
+	}
 	tasks := []define.GetTask{}
 	if limit > 0 {
 		var err error
@@ -317,6 +394,8 @@ func getTasks(ctx context.Context,
 			createTime, updateTime      int64
 			taskdata                    string
 			alarmUserids                string
+			approverIds                 string
+			labelSelector               string
 		)
 
 		err = rows.Scan(&t.ID,
@@ -331,7 +410,13 @@ func getTasks(ctx context.Context,
 			&t.Cronexpr,
 			&t.Timeout,
 			&alarmUserids,
+			&approverIds,
 			&t.RoutePolicy,
+			&t.BroadcastSuccess,
+			&t.BroadcastParallel,
+			&t.ShardTotal,
+			&t.Idempotent,
+			&t.FailoverLimit,
 			&t.ExpectCode,
 			&t.ExpectContent,
 			&t.AlarmStatus,
@@ -339,6 +424,9 @@ func getTasks(ctx context.Context,
 			&t.CreateByUID,
 			&t.HostGroup,
 			&t.HostGroupID,
+			&t.ProjectID,
+			&t.Project,
+			&labelSelector,
 			&t.Remark,
 			&createTime,
 			&updateTime,
@@ -361,12 +449,25 @@ func getTasks(ctx context.Context,
 				t.AlarmUserIdsDesc = append(t.AlarmUserIdsDesc, user.Name)
 			}
 		}
+		t.ApproverIds = []string{}
+		t.ApproverIdsDesc = []string{}
+		if approverIds != "" {
+			t.ApproverIds = append(t.ApproverIds, strings.Split(approverIds, ",")...)
+			t.ApproverIdsDesc = GetUserNames(ctx, t.ApproverIds)
+		}
+		t.LabelSelector = define.LabelSelector{}
+		if labelSelector != "" {
+			err = json.Unmarshal([]byte(labelSelector), &t.LabelSelector)
+			if err != nil {
+				log.Error("json.Unmarshal labelSelector failed", zap.String("task", t.ID), zap.Error(err))
+			}
+		}
 		t.ParentTaskIds = []string{}
 		t.ParentTaskIdsDesc = []string{}
 		if parentTaskIds != "" {
 			t.ParentTaskIds = append(t.ParentTaskIds, strings.Split(parentTaskIds, ",")...)
 			if first {
-				ptasks, _, err := getTasks(ctx, t.ParentTaskIds, "", 0, 0, false, "", "")
+				ptasks, _, err := getTasks(ctx, t.ParentTaskIds, "", 0, 0, false, "", "", "", "")
 				if err != nil {
 					log.Error("getTasks failed", zap.Error(err))
 				}
@@ -381,7 +482,7 @@ func getTasks(ctx context.Context,
 		if childTaskIds != "" {
 			t.ChildTaskIds = append(t.ChildTaskIds, strings.Split(childTaskIds, ",")...)
 			if first {
-				ctasks, _, err := getTasks(ctx, t.ChildTaskIds, "", 0, 0, false, "", "")
+				ctasks, _, err := getTasks(ctx, t.ChildTaskIds, "", 0, 0, false, "", "", "", "")
 				if err != nil {
 					log.Error("getTasks failed", zap.Error(err))
 				}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/db"
+)
+
+// 两步验证
+// 生成密钥后未开启，使用验证码确认后才开启，开启时生成新的恢复码，
+// 验证码的时间步只能使用一次，恢复码使用后删除
+
+// GetUserTOTP get totp secret and whether totp is enabled
+func GetUserTOTP(ctx context.Context, uid string) (string, bool, error) {
+	var (
+		secret string
+		enable bool
+	)
+	getsql := `SELECT totpSecret,totpEnable FROM crocodile_user WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return "", false, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(ctx, uid).Scan(&secret, &enable)
+	if err != nil {
+		return "", false, fmt.Errorf("stmt.QueryRowContext failed: %w", err)
+	}
+	return secret, enable, nil
+}
+
+// SetTOTPSecret save new secret of user, totp is enabled after confirmed by EnableTOTP
+func SetTOTPSecret(ctx context.Context, uid, secret string) error {
+	setsql := `UPDATE crocodile_user SET totpSecret=?,totpLastStep=0 WHERE id=? AND totpEnable=false`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+
+	stmt, err := conn.PrepareContext(ctx, setsql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, secret, uid)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// EnableTOTP enable totp of user and replace recovery codes
+func EnableTOTP(ctx context.Context, uid string, hashcodes []string) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `UPDATE crocodile_user SET totpEnable=true WHERE id=?`, uid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM crocodile_totprecovery WHERE uid=?`, uid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	now := time.Now().Unix()
+	for _, code := range hashcodes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO crocodile_totprecovery (uid,code,createTime) VALUES (?,?,?)`,
+			uid, code, now)
+		if err != nil {
+			return fmt.Errorf("tx.ExecContext failed: %w", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return nil
+}
+
+// DisableTOTP disable totp of user, clear secret and recovery codes
+func DisableTOTP(ctx context.Context, uid string) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx,
+		`UPDATE crocodile_user SET totpSecret='',totpEnable=false,totpLastStep=0 WHERE id=?`, uid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM crocodile_totprecovery WHERE uid=?`, uid)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep mark time step of user is used, return false if step or later step is used
+func UseTOTPStep(ctx context.Context, uid string, step int64) (bool, error) {
+	usesql := `UPDATE crocodile_user SET totpLastStep=? WHERE id=? AND totpLastStep<?`
+	return execaffected(ctx, usesql, step, uid, step)
+}
+
+// UseRecoveryCode delete recovery code of user, return false if code is not exist
+func UseRecoveryCode(ctx context.Context, uid, hashcode string) (bool, error) {
+	usesql := `DELETE FROM crocodile_totprecovery WHERE uid=? AND code=?`
+	return execaffected(ctx, usesql, uid, hashcode)
+}
+
+// CountRecoveryCodes return count of unused recovery codes
+func CountRecoveryCodes(ctx context.Context, uid string) (int, error) {
+	return countColums(ctx, `SELECT code FROM crocodile_totprecovery WHERE uid=?`, uid)
+}
+
+// execaffected exec sql and return whether one row is affected
+func execaffected(ctx context.Context, execsql string, args ...interface{}) (bool, error) {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+
+	stmt, err := conn.PrepareContext(ctx, execsql)
+	if err != nil {
+		return false, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return false, fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("result.RowsAffected failed: %w", err)
+	}
+	return affected == 1, nil
+}
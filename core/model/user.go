@@ -2,7 +2,9 @@ package model
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
@@ -11,93 +13,221 @@ import (
 	"github.com/labulaka521/crocodile/common/jwt"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/loginguard"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 // LoginUser login user
-func LoginUser(ctx context.Context, name string, password string) (string, error) {
+func LoginUser(ctx context.Context, name string, password string) (*define.LoginToken, error) {
+	uid, passwordupdatetime, err := checkuserpass(ctx, name, password)
+	if err != nil {
+		return nil, err
+	}
+	if loginguard.PasswordExpired(passwordupdatetime) {
+		return nil, fmt.Errorf("check password expire failed: %w", define.ErrPasswordExpired{Name: name})
+	}
+	return NewLoginToken(uid, name)
+}
+
+// CheckUserPassword check local user's password and return uid
+func CheckUserPassword(ctx context.Context, name string, password string) (string, error) {
+	uid, _, err := checkuserpass(ctx, name, password)
+	return uid, err
+}
+
+// ChangeExpiredPassword change password of user whose password is expired and login
+func ChangeExpiredPassword(ctx context.Context, name, password, newpassword string) (*define.LoginToken, error) {
+	uid, _, err := checkuserpass(ctx, name, password)
+	if err != nil {
+		return nil, err
+	}
+	hashpassword, err := utils.GenerateHashPass(newpassword)
+	if err != nil {
+		return nil, fmt.Errorf("GenerateHashPass failed: %w", err)
+	}
+	changesql := `UPDATE crocodile_user SET hashpassword=?,passwordUpdateTime=?,updateTime=? WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.Db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+
+	stmt, err := conn.PrepareContext(ctx, changesql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().Unix()
+	_, err = stmt.ExecContext(ctx, hashpassword, now, now, uid)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return NewLoginToken(uid, name)
+}
+
+// checkuserpass check local user's password, return uid and password update time
+func checkuserpass(ctx context.Context, name string, password string) (string, int64, error) {
 	var (
-		hashpassword string
-		uid          string
-		forbid       bool
+		hashpassword       string
+		uid                string
+		forbid             bool
+		passwordupdatetime int64
 	)
-	loguser := `SELECT id,hashpassword,forbid FROM crocodile_user WHERE name=?`
+	loguser := `SELECT id,hashpassword,forbid,passwordUpdateTime FROM crocodile_user WHERE name=?`
 
 	conn, err := db.GetConn(ctx)
 	if err != nil {
-		return "", fmt.Errorf("db.Db.GetConn failed: %w", err)
+		return "", 0, fmt.Errorf("db.Db.GetConn failed: %w", err)
 	}
 	defer conn.Close()
 
 	stmt, err := conn.PrepareContext(ctx, loguser)
 	if err != nil {
-		return "", fmt.Errorf("conn.PrepareContext failed: %w", err)
+		return "", 0, fmt.Errorf("conn.PrepareContext failed: %w", err)
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, name).Scan(&uid, &hashpassword, &forbid)
+	err = stmt.QueryRowContext(ctx, name).Scan(&uid, &hashpassword, &forbid, &passwordupdatetime)
 	if err != nil && err != sql.ErrNoRows {
-		return "", fmt.Errorf("stmt.QueryRowContext Scan failed: %w", err)
+		return "", 0, fmt.Errorf("stmt.QueryRowContext Scan failed: %w", err)
 	}
 	if forbid {
-		return "", fmt.Errorf(" failed: %w", define.ErrForbid{Name: name})
+		return "", 0, fmt.Errorf(" failed: %w", define.ErrForbid{Name: name})
 	}
 
 	err = utils.CheckHashPass(hashpassword, password)
 	if err != nil {
-		return "", fmt.Errorf("utils.CheckHashPass failed: %w", define.ErrUserPass{Err: err})
+		return "", 0, fmt.Errorf("utils.CheckHashPass failed: %w", define.ErrUserPass{Err: err})
 	}
-	token, err := jwt.GenerateToken(uid, name)
+	return uid, passwordupdatetime, nil
+}
+
+// NewLoginToken generate access token and refresh token for user
+func NewLoginToken(uid, name string) (*define.LoginToken, error) {
+	accesstoken, err := jwt.GenerateToken(uid, name)
 	if err != nil {
-		return "", fmt.Errorf("jwt.GenerateToken failed: %w", err)
+		return nil, fmt.Errorf("jwt.GenerateToken failed: %w", err)
 	}
-
-	return token, nil
+	refreshtoken, err := jwt.GenerateRefreshToken(uid, name)
+	if err != nil {
+		return nil, fmt.Errorf("jwt.GenerateRefreshToken failed: %w", err)
+	}
+	return &define.LoginToken{
+		AccessToken:  accesstoken,
+		RefreshToken: refreshtoken,
+		ExpiresIn:    int64(jwt.AccessTTL().Seconds()),
+	}, nil
 }
 
 // AddUser add new user
 func AddUser(ctx context.Context, name, hashpassword string, role define.Role) error {
+	_, err := adduser(ctx, name, hashpassword, role, "", "")
+	return err
+}
+
+// AddOIDCUser add user which login by oidc, it has a random password so can not login by password
+func AddOIDCUser(ctx context.Context, name, subject string, role define.Role) (string, error) {
+	hashpassword, err := randomhashpass()
+	if err != nil {
+		return "", err
+	}
+	return adduser(ctx, name, hashpassword, role, subject, "")
+}
+
+// AddLDAPUser add user which login by ldap, password is checked by ldap server
+func AddLDAPUser(ctx context.Context, name, dn string, role define.Role) (string, error) {
+	hashpassword, err := randomhashpass()
+	if err != nil {
+		return "", err
+	}
+	return adduser(ctx, name, hashpassword, role, "", dn)
+}
+
+// randomhashpass return hash of random password which nobody knows
+func randomhashpass() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand.Read failed: %w", err)
+	}
+	hashpassword, err := utils.GenerateHashPass(hex.EncodeToString(b))
+	if err != nil {
+		return "", fmt.Errorf("GenerateHashPass failed: %w", err)
+	}
+	return hashpassword, nil
+}
+
+func adduser(ctx context.Context, name, hashpassword string, role define.Role, subject, dn string) (string, error) {
 	adduser := `INSERT INTO crocodile_user (
 					id,
 					name,
 					hashpassword,
 					role,
 					forbid,
+					oidcSubject,
+					ldapDN,
+					passwordUpdateTime,
 					createTime,
 					updateTime
 				)
 				VALUES
-				(?,?,?,?,?,?,?)`
+				(?,?,?,?,?,?,?,?,?,?)`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
-		return fmt.Errorf("db.Db.GetConn failed: %w", err)
+		return "", fmt.Errorf("db.Db.GetConn failed: %w", err)
 	}
 	defer conn.Close()
 
 	stmt, err := conn.PrepareContext(ctx, adduser)
 	if err != nil {
-		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+		return "", fmt.Errorf("conn.PrepareContext failed: %w", err)
 	}
 	defer stmt.Close()
 
 	now := time.Now().Unix()
 	id := utils.GetID()
-	_, err = stmt.ExecContext(ctx, id, name, hashpassword, role, false, now, now)
+	_, err = stmt.ExecContext(ctx, id, name, hashpassword, role, false, subject, dn, now, now, now)
 	if err != nil {
-		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+		return "", fmt.Errorf("stmt.ExecContext failed: %w", err)
 	}
 
 	ok, err := enforcer.AddRoleForUser(id, role.String())
 	if err != nil {
-		return err
+		return "", err
 	}
 	if !ok {
-		return errors.New("AddRoleForUser failed")
+		return "", errors.New("AddRoleForUser failed")
 	}
 
-	return nil
+	return id, nil
+}
+
+// GetUserByOIDCSubject get user which is provisioned by oidc login
+func GetUserByOIDCSubject(ctx context.Context, subject string) (*define.User, error) {
+	getsql := `SELECT id FROM crocodile_user WHERE oidcSubject=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.Db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	var uid string
+	err = stmt.QueryRowContext(ctx, subject).Scan(&uid)
+	if err == sql.ErrNoRows {
+		return nil, define.ErrNotExist{Value: subject}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stmt.QueryRowContext Scan failed: %w", err)
+	}
+	return GetUserByID(ctx, uid)
 }
 
 func getusers(ctx context.Context, uids []string, name string, offset, limit int) ([]define.User, int, error) {
@@ -112,6 +242,8 @@ func getusers(ctx context.Context, uids []string, name string, offset, limit int
 					dingphone,
 					telegram,
 					remark,
+					ldapDN,
+					totpEnable,
 					createTime,
 					updateTime
 				FROM 
@@ -177,6 +309,8 @@ func getusers(ctx context.Context, uids []string, name string, offset, limit int
 			&user.DingPhone,
 			&user.Telegram,
 			&user.Remark,
+			&user.LDAPDN,
+			&user.TOTP,
 			&createTime,
 			&updateTime,
 		)
@@ -226,6 +360,20 @@ func GetUsers(ctx context.Context, uids []string, offset, limit int) ([]define.U
 	return getusers(ctx, uids, "", offset, limit)
 }
 
+// GetUserNames return names of users, used to show user ids
+func GetUserNames(ctx context.Context, uids []string) []string {
+	names := make([]string, 0, len(uids))
+	users, _, err := GetUsers(ctx, uids, 0, 0)
+	if err != nil {
+		log.Error("GetUsers ids failed", zap.Strings("uids", uids), zap.Error(err))
+		return names
+	}
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names
+}
+
 // AdminChangeUser admin change user some column define.AdminChangeUser
 // func AdminChangeUser(ctx context.Context, adminuser *define.AdminChangeUser) error {
 func AdminChangeUser(ctx context.Context, id string, role define.Role, forbid bool, password, remark string) error {
@@ -259,10 +407,12 @@ func AdminChangeUser(ctx context.Context, id string, role define.Role, forbid bo
 	}
 
 	// 普通管理员可以修改 password，role，forbid，
+	// 修改密码时更新密码修改时间
 	changeuser = `UPDATE crocodile_user 
 	SET role=?,
 		forbid=?,
 		hashpassword=?,
+		passwordUpdateTime=IF(?,?,passwordUpdateTime),
 		updateTime=?,
 		remark=?
 	WHERE id=?`
@@ -297,6 +447,8 @@ func AdminChangeUser(ctx context.Context, id string, role define.Role, forbid bo
 	_, err = stmt.ExecContext(ctx, role,
 		forbid,
 		hashpassword,
+		password != "",
+		updateTime,
 		updateTime,
 		remark,
 		id,
@@ -334,6 +486,7 @@ func ChangeUserInfo(ctx context.Context, id, name, email, wechat, dingding, tele
 	}
 	changeuser = `UPDATE crocodile_user 
 					SET hashpassword=?,
+						passwordUpdateTime=IF(?,?,passwordUpdateTime),
 					    name=?,
 						email=?,
 						wechat=?,
@@ -351,6 +504,8 @@ func ChangeUserInfo(ctx context.Context, id, name, email, wechat, dingding, tele
 	defer stmt.Close()
 
 	_, err = stmt.ExecContext(ctx, hashpassword,
+		password != "",
+		updateTime,
 		name,
 		email,
 		wechat,
@@ -368,6 +523,18 @@ func ChangeUserInfo(ctx context.Context, id, name, email, wechat, dingding, tele
 
 // DeleteUser will delete user msg
 func DeleteUser(ctx context.Context, id string) error {
+	err := SetUserGroups(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+	err = DeleteProjectMember(ctx, "", id)
+	if err != nil {
+		return err
+	}
+	err = DisableTOTP(ctx, id)
+	if err != nil {
+		return err
+	}
 	delsql := `DELETE FROM crocodile_user WHERE id=?`
 	conn, err := db.GetConn(ctx)
 	if err != nil {
@@ -385,3 +552,87 @@ func DeleteUser(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// GetLDAPUsers get all users which login by ldap
+func GetLDAPUsers(ctx context.Context) ([]define.User, error) {
+	getsql := `SELECT id FROM crocodile_user WHERE ldapDN!=''`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db.Db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, getsql)
+	if err != nil {
+		return nil, fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stmt.QueryContext failed: %w", err)
+	}
+	defer rows.Close()
+	uids := []string{}
+	for rows.Next() {
+		var uid string
+		err = rows.Scan(&uid)
+		if err != nil {
+			log.Error("rows.Scan failed", zap.Error(err))
+			continue
+		}
+		uids = append(uids, uid)
+	}
+	if len(uids) == 0 {
+		return []define.User{}, nil
+	}
+	users, _, err := getusers(ctx, uids, "", 0, 0)
+	return users, err
+}
+
+// ChangeUserLDAPDN change dn of ldap user, user may be moved in directory
+func ChangeUserLDAPDN(ctx context.Context, id, dn string) error {
+	changesql := `UPDATE crocodile_user SET ldapDN=?,updateTime=? WHERE id=?`
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.Db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, changesql)
+	if err != nil {
+		return fmt.Errorf("conn.PrepareContext failed: %w", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, dn, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("stmt.ExecContext failed: %w", err)
+	}
+	return nil
+}
+
+// SetUserGroups replace owner groups of user
+func SetUserGroups(ctx context.Context, id string, groups []string) error {
+	conn, err := db.GetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.Db.GetConn failed: %w", err)
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("conn.BeginTx failed: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `DELETE FROM crocodile_usergroup WHERE uid=?`, id)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext failed: %w", err)
+	}
+	for _, group := range groups {
+		_, err = tx.ExecContext(ctx, `INSERT INTO crocodile_usergroup (uid,groupName) VALUES (?,?)`, id, group)
+		if err != nil {
+			return fmt.Errorf("tx.ExecContext failed: %w", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit failed: %w", err)
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ type TaskReq struct {
 	TaskId               string   `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
 	TaskType             int32    `protobuf:"varint,2,opt,name=task_type,json=taskType,proto3" json:"task_type,omitempty"`
 	TaskData             []byte   `protobuf:"bytes,3,opt,name=task_data,json=taskData,proto3" json:"task_data,omitempty"`
+	ShardIndex           int32    `protobuf:"varint,4,opt,name=shard_index,json=shardIndex,proto3" json:"shard_index,omitempty"`
+	ShardTotal           int32    `protobuf:"varint,5,opt,name=shard_total,json=shardTotal,proto3" json:"shard_total,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -80,6 +82,20 @@ func (m *TaskReq) GetTaskData() []byte {
 	return nil
 }
 
+func (m *TaskReq) GetShardIndex() int32 {
+	if m != nil {
+		return m.ShardIndex
+	}
+	return 0
+}
+
+func (m *TaskReq) GetShardTotal() int32 {
+	if m != nil {
+		return m.ShardTotal
+	}
+	return 0
+}
+
 // task reso stream
 type TaskResp struct {
 	Resp                 []byte   `protobuf:"bytes,3,opt,name=resp,proto3" json:"resp,omitempty"`
@@ -183,6 +199,7 @@ type RegistryReq struct {
 	Version              string   `protobuf:"bytes,5,opt,name=version,proto3" json:"version,omitempty"`
 	Hostgroup            string   `protobuf:"bytes,6,opt,name=hostgroup,proto3" json:"hostgroup,omitempty"`
 	Remark               string   `protobuf:"bytes,7,opt,name=remark,proto3" json:"remark,omitempty"`
+	Labels               []string `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -262,13 +279,22 @@ func (m *RegistryReq) GetRemark() string {
 	return ""
 }
 
+func (m *RegistryReq) GetLabels() []string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
 type HeartbeatReq struct {
 	// string ip = 1;
-	Port                 int32    `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
-	RunningTask          []string `protobuf:"bytes,3,rep,name=running_task,json=runningTask,proto3" json:"running_task,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Port                 int32     `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	RunningTask          []string  `protobuf:"bytes,3,rep,name=running_task,json=runningTask,proto3" json:"running_task,omitempty"`
+	Resource             *Resource `protobuf:"bytes,4,opt,name=resource,proto3" json:"resource,omitempty"`
+	Draining             bool      `protobuf:"varint,5,opt,name=draining,proto3" json:"draining,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *HeartbeatReq) Reset()         { *m = HeartbeatReq{} }
@@ -310,6 +336,20 @@ func (m *HeartbeatReq) GetRunningTask() []string {
 	return nil
 }
 
+func (m *HeartbeatReq) GetResource() *Resource {
+	if m != nil {
+		return m.Resource
+	}
+	return nil
+}
+
+func (m *HeartbeatReq) GetDraining() bool {
+	if m != nil {
+		return m.Draining
+	}
+	return false
+}
+
 type Empty struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -341,6 +381,404 @@ func (m *Empty) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Empty proto.InternalMessageInfo
 
+// worker resource usage
+type Resource struct {
+	CpuNum               int32    `protobuf:"varint,1,opt,name=cpu_num,json=cpuNum,proto3" json:"cpu_num,omitempty"`
+	Load1                float64  `protobuf:"fixed64,2,opt,name=load1,proto3" json:"load1,omitempty"`
+	Load5                float64  `protobuf:"fixed64,3,opt,name=load5,proto3" json:"load5,omitempty"`
+	Load15               float64  `protobuf:"fixed64,4,opt,name=load15,proto3" json:"load15,omitempty"`
+	MemTotal             uint64   `protobuf:"varint,5,opt,name=mem_total,json=memTotal,proto3" json:"mem_total,omitempty"`
+	MemAvailable         uint64   `protobuf:"varint,6,opt,name=mem_available,json=memAvailable,proto3" json:"mem_available,omitempty"`
+	DiskTotal            uint64   `protobuf:"varint,7,opt,name=disk_total,json=diskTotal,proto3" json:"disk_total,omitempty"`
+	DiskFree             uint64   `protobuf:"varint,8,opt,name=disk_free,json=diskFree,proto3" json:"disk_free,omitempty"`
+	TaskSlots            int32    `protobuf:"varint,9,opt,name=task_slots,json=taskSlots,proto3" json:"task_slots,omitempty"`
+	RunningSlots         int32    `protobuf:"varint,10,opt,name=running_slots,json=runningSlots,proto3" json:"running_slots,omitempty"`
+	MaxConcurrent        int32    `protobuf:"varint,11,opt,name=max_concurrent,json=maxConcurrent,proto3" json:"max_concurrent,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Resource) Reset()         { *m = Resource{} }
+func (m *Resource) String() string { return proto.CompactTextString(m) }
+func (*Resource) ProtoMessage()    {}
+func (*Resource) Descriptor() ([]byte, []int) {
+	return fileDescriptor_80ea9561f1d738ba, []int{6}
+}
+
+func (m *Resource) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Resource.Unmarshal(m, b)
+}
+func (m *Resource) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Resource.Marshal(b, m, deterministic)
+}
+func (m *Resource) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Resource.Merge(m, src)
+}
+func (m *Resource) XXX_Size() int {
+	return xxx_messageInfo_Resource.Size(m)
+}
+func (m *Resource) XXX_DiscardUnknown() {
+	xxx_messageInfo_Resource.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Resource proto.InternalMessageInfo
+
+func (m *Resource) GetCpuNum() int32 {
+	if m != nil {
+		return m.CpuNum
+	}
+	return 0
+}
+
+func (m *Resource) GetLoad1() float64 {
+	if m != nil {
+		return m.Load1
+	}
+	return 0
+}
+
+func (m *Resource) GetLoad5() float64 {
+	if m != nil {
+		return m.Load5
+	}
+	return 0
+}
+
+func (m *Resource) GetLoad15() float64 {
+	if m != nil {
+		return m.Load15
+	}
+	return 0
+}
+
+func (m *Resource) GetMemTotal() uint64 {
+	if m != nil {
+		return m.MemTotal
+	}
+	return 0
+}
+
+func (m *Resource) GetMemAvailable() uint64 {
+	if m != nil {
+		return m.MemAvailable
+	}
+	return 0
+}
+
+func (m *Resource) GetDiskTotal() uint64 {
+	if m != nil {
+		return m.DiskTotal
+	}
+	return 0
+}
+
+func (m *Resource) GetDiskFree() uint64 {
+	if m != nil {
+		return m.DiskFree
+	}
+	return 0
+}
+
+func (m *Resource) GetTaskSlots() int32 {
+	if m != nil {
+		return m.TaskSlots
+	}
+	return 0
+}
+
+func (m *Resource) GetRunningSlots() int32 {
+	if m != nil {
+		return m.RunningSlots
+	}
+	return 0
+}
+
+func (m *Resource) GetMaxConcurrent() int32 {
+	if m != nil {
+		return m.MaxConcurrent
+	}
+	return 0
+}
+
+type HeartbeatResp struct {
+	Drain                bool     `protobuf:"varint,1,opt,name=drain,proto3" json:"drain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeartbeatResp) Reset()         { *m = HeartbeatResp{} }
+func (m *HeartbeatResp) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatResp) ProtoMessage()    {}
+func (*HeartbeatResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_80ea9561f1d738ba, []int{7}
+}
+
+func (m *HeartbeatResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatResp.Unmarshal(m, b)
+}
+func (m *HeartbeatResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatResp.Marshal(b, m, deterministic)
+}
+func (m *HeartbeatResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatResp.Merge(m, src)
+}
+func (m *HeartbeatResp) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatResp.Size(m)
+}
+func (m *HeartbeatResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatResp proto.InternalMessageInfo
+
+func (m *HeartbeatResp) GetDrain() bool {
+	if m != nil {
+		return m.Drain
+	}
+	return false
+}
+
+// worker -> scheduler
+type TunnelUp struct {
+	Port                 int32    `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	Seq                  int64    `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Resp                 []byte   `protobuf:"bytes,3,opt,name=resp,proto3" json:"resp,omitempty"`
+	End                  bool     `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`
+	Code                 int32    `protobuf:"varint,5,opt,name=code,proto3" json:"code,omitempty"`
+	ErrMsg               string   `protobuf:"bytes,6,opt,name=err_msg,json=errMsg,proto3" json:"err_msg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TunnelUp) Reset()         { *m = TunnelUp{} }
+func (m *TunnelUp) String() string { return proto.CompactTextString(m) }
+func (*TunnelUp) ProtoMessage()    {}
+func (*TunnelUp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_80ea9561f1d738ba, []int{8}
+}
+
+func (m *TunnelUp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TunnelUp.Unmarshal(m, b)
+}
+func (m *TunnelUp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TunnelUp.Marshal(b, m, deterministic)
+}
+func (m *TunnelUp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TunnelUp.Merge(m, src)
+}
+func (m *TunnelUp) XXX_Size() int {
+	return xxx_messageInfo_TunnelUp.Size(m)
+}
+func (m *TunnelUp) XXX_DiscardUnknown() {
+	xxx_messageInfo_TunnelUp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TunnelUp proto.InternalMessageInfo
+
+func (m *TunnelUp) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *TunnelUp) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *TunnelUp) GetResp() []byte {
+	if m != nil {
+		return m.Resp
+	}
+	return nil
+}
+
+func (m *TunnelUp) GetEnd() bool {
+	if m != nil {
+		return m.End
+	}
+	return false
+}
+
+func (m *TunnelUp) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *TunnelUp) GetErrMsg() string {
+	if m != nil {
+		return m.ErrMsg
+	}
+	return ""
+}
+
+// scheduler -> worker
+type TunnelDown struct {
+	Seq                  int64       `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Task                 *TaskReq    `protobuf:"bytes,2,opt,name=task,proto3" json:"task,omitempty"`
+	Cancel               bool        `protobuf:"varint,3,opt,name=cancel,proto3" json:"cancel,omitempty"`
+	Ack                  int64       `protobuf:"varint,4,opt,name=ack,proto3" json:"ack,omitempty"`
+	Metadata             []*Metadata `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *TunnelDown) Reset()         { *m = TunnelDown{} }
+func (m *TunnelDown) String() string { return proto.CompactTextString(m) }
+func (*TunnelDown) ProtoMessage()    {}
+func (*TunnelDown) Descriptor() ([]byte, []int) {
+	return fileDescriptor_80ea9561f1d738ba, []int{9}
+}
+
+func (m *TunnelDown) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TunnelDown.Unmarshal(m, b)
+}
+func (m *TunnelDown) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TunnelDown.Marshal(b, m, deterministic)
+}
+func (m *TunnelDown) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TunnelDown.Merge(m, src)
+}
+func (m *TunnelDown) XXX_Size() int {
+	return xxx_messageInfo_TunnelDown.Size(m)
+}
+func (m *TunnelDown) XXX_DiscardUnknown() {
+	xxx_messageInfo_TunnelDown.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TunnelDown proto.InternalMessageInfo
+
+func (m *TunnelDown) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *TunnelDown) GetTask() *TaskReq {
+	if m != nil {
+		return m.Task
+	}
+	return nil
+}
+
+func (m *TunnelDown) GetCancel() bool {
+	if m != nil {
+		return m.Cancel
+	}
+	return false
+}
+
+func (m *TunnelDown) GetAck() int64 {
+	if m != nil {
+		return m.Ack
+	}
+	return 0
+}
+
+func (m *TunnelDown) GetMetadata() []*Metadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+type Metadata struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Metadata) Reset()         { *m = Metadata{} }
+func (m *Metadata) String() string { return proto.CompactTextString(m) }
+func (*Metadata) ProtoMessage()    {}
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_80ea9561f1d738ba, []int{10}
+}
+
+func (m *Metadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Metadata.Unmarshal(m, b)
+}
+func (m *Metadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Metadata.Marshal(b, m, deterministic)
+}
+func (m *Metadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Metadata.Merge(m, src)
+}
+func (m *Metadata) XXX_Size() int {
+	return xxx_messageInfo_Metadata.Size(m)
+}
+func (m *Metadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_Metadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Metadata proto.InternalMessageInfo
+
+func (m *Metadata) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Metadata) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+// registry resp, credential is issued when worker registry by enroll token
+type RegistryResp struct {
+	Credential           string   `protobuf:"bytes,1,opt,name=credential,proto3" json:"credential,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RegistryResp) Reset()         { *m = RegistryResp{} }
+func (m *RegistryResp) String() string { return proto.CompactTextString(m) }
+func (*RegistryResp) ProtoMessage()    {}
+func (*RegistryResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_80ea9561f1d738ba, []int{11}
+}
+
+func (m *RegistryResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RegistryResp.Unmarshal(m, b)
+}
+func (m *RegistryResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RegistryResp.Marshal(b, m, deterministic)
+}
+func (m *RegistryResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RegistryResp.Merge(m, src)
+}
+func (m *RegistryResp) XXX_Size() int {
+	return xxx_messageInfo_RegistryResp.Size(m)
+}
+func (m *RegistryResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_RegistryResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RegistryResp proto.InternalMessageInfo
+
+func (m *RegistryResp) GetCredential() string {
+	if m != nil {
+		return m.Credential
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*TaskReq)(nil), "crocodile.task.TaskReq")
 	proto.RegisterType((*TaskResp)(nil), "crocodile.task.TaskResp")
@@ -348,39 +786,73 @@ func init() {
 	proto.RegisterType((*RegistryReq)(nil), "crocodile.task.RegistryReq")
 	proto.RegisterType((*HeartbeatReq)(nil), "crocodile.task.HeartbeatReq")
 	proto.RegisterType((*Empty)(nil), "crocodile.task.Empty")
+	proto.RegisterType((*Resource)(nil), "crocodile.task.Resource")
+	proto.RegisterType((*HeartbeatResp)(nil), "crocodile.task.HeartbeatResp")
+	proto.RegisterType((*TunnelUp)(nil), "crocodile.task.TunnelUp")
+	proto.RegisterType((*TunnelDown)(nil), "crocodile.task.TunnelDown")
+	proto.RegisterType((*Metadata)(nil), "crocodile.task.Metadata")
+	proto.RegisterType((*RegistryResp)(nil), "crocodile.task.RegistryResp")
 }
 
 func init() { proto.RegisterFile("core/proto/core.proto", fileDescriptor_80ea9561f1d738ba) }
 
 var fileDescriptor_80ea9561f1d738ba = []byte{
-	// 419 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0xc1, 0x6e, 0xd3, 0x40,
-	0x10, 0xc5, 0x49, 0x6c, 0xc7, 0x13, 0xab, 0x87, 0x95, 0x4a, 0x57, 0x69, 0x85, 0x82, 0x4f, 0x39,
-	0xa5, 0xa8, 0xdc, 0xe1, 0xd2, 0xa2, 0x70, 0x40, 0x48, 0x4b, 0x25, 0x2e, 0x48, 0xd1, 0x26, 0x1e,
-	0xb9, 0x56, 0x1a, 0xef, 0xb2, 0xbb, 0x01, 0xe5, 0x37, 0xf8, 0x17, 0xfe, 0x0f, 0xcd, 0xd8, 0x09,
-	0x6d, 0x94, 0xde, 0x66, 0xde, 0x1b, 0xcf, 0x7b, 0xf3, 0xbc, 0x70, 0xbe, 0x32, 0x0e, 0xaf, 0xad,
-	0x33, 0xc1, 0x5c, 0x53, 0x39, 0xe3, 0x52, 0x9c, 0xad, 0x9c, 0x59, 0x99, 0xb2, 0x7e, 0xc4, 0x59,
-	0xd0, 0x7e, 0x5d, 0xfc, 0x80, 0xf4, 0x5e, 0xfb, 0xb5, 0xc2, 0x9f, 0xe2, 0x02, 0x52, 0x82, 0x16,
-	0x75, 0x29, 0xa3, 0x49, 0x34, 0xcd, 0x54, 0x42, 0xed, 0xe7, 0x52, 0x5c, 0x42, 0xc6, 0x44, 0xd8,
-	0x59, 0x94, 0xbd, 0x49, 0x34, 0x8d, 0xd5, 0x90, 0x80, 0xfb, 0x9d, 0xc5, 0x03, 0x59, 0xea, 0xa0,
-	0x65, 0x7f, 0x12, 0x4d, 0xf3, 0x96, 0xbc, 0xd5, 0x41, 0x17, 0x6f, 0x60, 0xd8, 0x6e, 0xf7, 0x56,
-	0x08, 0x18, 0x38, 0xf4, 0xb6, 0x9b, 0xe1, 0xba, 0xf8, 0x0e, 0xa3, 0x3d, 0xff, 0xf5, 0xb1, 0xa4,
-	0x91, 0x95, 0x29, 0x91, 0xe5, 0x63, 0xc5, 0x35, 0xb9, 0x42, 0xe7, 0x16, 0x1b, 0x5f, 0xb1, 0x74,
-	0xae, 0x12, 0x74, 0xee, 0x8b, 0xaf, 0x48, 0x98, 0x76, 0x3c, 0x13, 0x26, 0x80, 0x85, 0xff, 0x46,
-	0x30, 0x52, 0x58, 0xd5, 0x3e, 0xb8, 0x1d, 0xdd, 0x76, 0x06, 0xbd, 0xda, 0x76, 0x67, 0xf5, 0x6a,
-	0x36, 0x63, 0x8d, 0x0b, 0xdd, 0x35, 0x5c, 0x8b, 0xd7, 0x90, 0xfc, 0xc6, 0xba, 0x7a, 0x08, 0xbc,
-	0x2d, 0x56, 0x5d, 0x27, 0xc6, 0x30, 0x7c, 0x30, 0x3e, 0x34, 0x7a, 0x83, 0x72, 0xc0, 0x1b, 0x0e,
-	0xbd, 0x90, 0x90, 0xfe, 0x42, 0xe7, 0x6b, 0xd3, 0xc8, 0x98, 0xa9, 0x7d, 0x2b, 0xae, 0x20, 0xa3,
-	0xa9, 0xca, 0x99, 0xad, 0x95, 0x09, 0x73, 0xff, 0x01, 0xd2, 0x72, 0xb8, 0xd1, 0x6e, 0x2d, 0xd3,
-	0x36, 0xea, 0xb6, 0x2b, 0xee, 0x20, 0x9f, 0xa3, 0x76, 0x61, 0x89, 0x3a, 0x90, 0xef, 0x53, 0x3e,
-	0xdf, 0x42, 0xee, 0xb6, 0x4d, 0x53, 0x37, 0xd5, 0x82, 0x82, 0x96, 0xfd, 0x49, 0x7f, 0x9a, 0xa9,
-	0x51, 0x87, 0x51, 0x9e, 0x45, 0x0a, 0xf1, 0xdd, 0xc6, 0x86, 0xdd, 0xcd, 0x27, 0x18, 0x10, 0x20,
-	0x3e, 0x40, 0xaa, 0xb6, 0x0d, 0x97, 0x17, 0xb3, 0xe7, 0x4f, 0x60, 0xd6, 0xfd, 0xff, 0xb1, 0x3c,
-	0x4d, 0x78, 0xfb, 0x2e, 0xba, 0xf9, 0x13, 0x41, 0x76, 0x30, 0x26, 0x6e, 0x21, 0xdf, 0x87, 0x3b,
-	0x37, 0x3e, 0x88, 0xcb, 0xe3, 0x2f, 0x9f, 0x44, 0x3f, 0x3e, 0x3f, 0x26, 0xd9, 0x59, 0xf1, 0x4a,
-	0x7c, 0x84, 0xe4, 0x1b, 0x36, 0xe5, 0x7c, 0x29, 0xae, 0x8e, 0x47, 0x9e, 0x66, 0xf0, 0xe2, 0x82,
-	0x65, 0xc2, 0x4f, 0xfa, 0xfd, 0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x8a, 0xc4, 0xc1, 0x9d, 0xeb,
-	0x02, 0x00, 0x00,
+	// 879 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x55, 0xdb, 0x6e, 0xdb, 0x46,
+	0x13, 0x0e, 0x75, 0xa4, 0x46, 0xb2, 0xf1, 0x63, 0xf1, 0xa7, 0x21, 0xe4, 0x24, 0x55, 0x59, 0x04,
+	0x30, 0x50, 0x40, 0x49, 0xd5, 0xfa, 0xb6, 0x40, 0xe1, 0x34, 0x75, 0x50, 0xb8, 0x05, 0x36, 0x0e,
+	0x7a, 0x29, 0xac, 0xc8, 0xa9, 0x4c, 0x88, 0x5c, 0x32, 0xbb, 0x4b, 0xc7, 0xba, 0xee, 0x43, 0xf4,
+	0xae, 0x2f, 0xd0, 0x67, 0x29, 0xd0, 0xe7, 0xe8, 0x53, 0x14, 0x33, 0x4b, 0x1d, 0x1c, 0x2b, 0xb9,
+	0x9b, 0xf9, 0x66, 0x38, 0x3b, 0xf3, 0xcd, 0x81, 0xf0, 0x30, 0x29, 0x0d, 0x3e, 0xaf, 0x4c, 0xe9,
+	0xca, 0xe7, 0x24, 0x4e, 0x59, 0x14, 0xc7, 0x89, 0x29, 0x93, 0x32, 0xcd, 0x72, 0x9c, 0x3a, 0x65,
+	0x57, 0xf1, 0x9f, 0x01, 0xf4, 0xaf, 0x94, 0x5d, 0x49, 0x7c, 0x27, 0x1e, 0x41, 0x9f, 0xb0, 0x79,
+	0x96, 0x46, 0xc1, 0x24, 0x38, 0x1d, 0xc8, 0x1e, 0xa9, 0xaf, 0x53, 0x71, 0x02, 0x03, 0x36, 0xb8,
+	0x75, 0x85, 0x51, 0x6b, 0x12, 0x9c, 0x76, 0x65, 0x48, 0xc0, 0xd5, 0xba, 0xc2, 0xad, 0x31, 0x55,
+	0x4e, 0x45, 0xed, 0x49, 0x70, 0x3a, 0xf2, 0xc6, 0x97, 0xca, 0x29, 0xf1, 0x39, 0x0c, 0xed, 0xb5,
+	0x32, 0xe9, 0x3c, 0xd3, 0x29, 0xde, 0x46, 0x1d, 0xfe, 0x16, 0x18, 0x7a, 0x4d, 0xc8, 0xce, 0xc1,
+	0x95, 0x4e, 0xe5, 0x51, 0x77, 0xcf, 0xe1, 0x8a, 0x90, 0xf8, 0x29, 0x84, 0x3e, 0x3f, 0x5b, 0x09,
+	0x01, 0x1d, 0x83, 0xb6, 0x6a, 0x5e, 0x61, 0x39, 0xfe, 0x15, 0x86, 0x1b, 0xfb, 0x2f, 0x79, 0x4a,
+	0x2e, 0x49, 0x99, 0x22, 0x17, 0xd0, 0x95, 0x2c, 0x53, 0x5d, 0x68, 0xcc, 0xbc, 0xb0, 0x4b, 0x4e,
+	0x7e, 0x24, 0x7b, 0x68, 0xcc, 0xa5, 0x5d, 0x52, 0xea, 0x14, 0xe3, 0x4e, 0xea, 0x04, 0x50, 0xea,
+	0xf1, 0xdf, 0x01, 0x0c, 0x25, 0x2e, 0x33, 0xeb, 0xcc, 0x9a, 0xd8, 0x39, 0x86, 0x56, 0x56, 0x35,
+	0xc4, 0xb4, 0x32, 0x4e, 0xa6, 0x2a, 0x8d, 0x6b, 0xf8, 0x60, 0x59, 0x7c, 0x06, 0xbd, 0xf7, 0x98,
+	0x2d, 0xaf, 0x1d, 0x47, 0xeb, 0xca, 0x46, 0x13, 0x63, 0x08, 0xaf, 0x4b, 0xeb, 0xb4, 0x2a, 0x90,
+	0x39, 0x18, 0xc8, 0xad, 0x2e, 0x22, 0xe8, 0xdf, 0xa0, 0xb1, 0x59, 0xa9, 0xb9, 0xfa, 0x81, 0xdc,
+	0xa8, 0xe2, 0x31, 0x0c, 0xc8, 0x6b, 0x69, 0xca, 0xba, 0x8a, 0x7a, 0x6c, 0xdb, 0x01, 0xf4, 0x96,
+	0xc1, 0x42, 0x99, 0x55, 0xd4, 0xf7, 0xcd, 0xf2, 0x1a, 0xe1, 0xb9, 0x5a, 0x60, 0x6e, 0xa3, 0x70,
+	0xd2, 0x26, 0xdc, 0x6b, 0xf1, 0x1f, 0x01, 0x8c, 0x2e, 0x50, 0x19, 0xb7, 0x40, 0xe5, 0xa8, 0xa0,
+	0x43, 0x05, 0x7c, 0x01, 0x23, 0x53, 0x6b, 0x9d, 0xe9, 0xe5, 0x9c, 0x7a, 0x18, 0xb5, 0x39, 0xc4,
+	0xb0, 0xc1, 0x88, 0x68, 0xf1, 0x2d, 0x10, 0x47, 0x65, 0x6d, 0x12, 0x5f, 0xcb, 0x70, 0x16, 0x4d,
+	0xef, 0x0e, 0xd5, 0x54, 0x36, 0x76, 0xb9, 0xf5, 0x24, 0x06, 0x52, 0xa3, 0x32, 0x8a, 0xc2, 0x65,
+	0x86, 0x72, 0xab, 0xc7, 0x7d, 0xe8, 0xfe, 0x50, 0x54, 0x6e, 0x1d, 0xff, 0xd3, 0x82, 0x70, 0xf3,
+	0x2d, 0x75, 0x2d, 0xa9, 0xea, 0xb9, 0xae, 0x8b, 0xa6, 0x99, 0xbd, 0xa4, 0xaa, 0x7f, 0xae, 0x0b,
+	0xf1, 0x7f, 0xe8, 0xe6, 0xa5, 0x4a, 0xbf, 0xe6, 0xc4, 0x03, 0xe9, 0x95, 0x0d, 0x7a, 0x16, 0xb5,
+	0x77, 0xe8, 0x19, 0x93, 0x41, 0xe6, 0x33, 0x4e, 0x35, 0x90, 0x8d, 0x46, 0x9d, 0x2f, 0xb0, 0xd8,
+	0x1b, 0xba, 0x8e, 0x0c, 0x0b, 0x2c, 0x78, 0xe4, 0xc4, 0x97, 0x70, 0x44, 0x46, 0x75, 0xa3, 0xb2,
+	0x5c, 0x2d, 0x72, 0x64, 0xee, 0x3b, 0x72, 0x54, 0x60, 0xf1, 0xfd, 0x06, 0x13, 0x4f, 0x00, 0xd2,
+	0x8c, 0x76, 0x82, 0x43, 0xf4, 0xd9, 0x63, 0x40, 0x88, 0x8f, 0x71, 0x02, 0xac, 0xcc, 0x7f, 0x33,
+	0x88, 0x51, 0xe8, 0x1f, 0x20, 0xe0, 0x95, 0x41, 0xfe, 0x96, 0x57, 0xc6, 0xe6, 0xa5, 0xb3, 0xd1,
+	0x80, 0xab, 0xe3, 0x25, 0x7a, 0x43, 0x00, 0xbd, 0xbf, 0x69, 0x82, 0xf7, 0x00, 0xf6, 0xd8, 0x74,
+	0xc6, 0x3b, 0x3d, 0x83, 0xe3, 0x42, 0xdd, 0xce, 0x93, 0x52, 0x27, 0xb5, 0x31, 0xa8, 0x5d, 0x34,
+	0x64, 0xaf, 0xa3, 0x42, 0xdd, 0x9e, 0x6f, 0xc1, 0xf8, 0x19, 0x1c, 0xed, 0x35, 0xdd, 0x56, 0xc4,
+	0x13, 0x13, 0xcf, 0xa4, 0x86, 0xd2, 0x2b, 0xf1, 0xef, 0x01, 0x84, 0x57, 0xb5, 0xd6, 0x98, 0xbf,
+	0xdd, 0x4d, 0x76, 0xb0, 0x37, 0x18, 0xff, 0x83, 0xb6, 0xc5, 0x77, 0x4c, 0x79, 0x5b, 0x92, 0x78,
+	0x68, 0x19, 0xc9, 0x0b, 0x75, 0xca, 0x5c, 0x87, 0x92, 0xc4, 0xed, 0x3e, 0x76, 0x0f, 0xef, 0xa3,
+	0x9f, 0xea, 0x66, 0x1f, 0xe3, 0xbf, 0x02, 0x00, 0x9f, 0xc5, 0xcb, 0xf2, 0xbd, 0xde, 0xbc, 0x19,
+	0xec, 0xde, 0xfc, 0x0a, 0x3a, 0x3c, 0x96, 0x2d, 0x9e, 0xbb, 0x47, 0x1f, 0xce, 0x5d, 0x73, 0xc8,
+	0x24, 0x3b, 0x51, 0xef, 0x13, 0xa5, 0x13, 0xcc, 0x39, 0xc5, 0x50, 0x36, 0x1a, 0x85, 0x55, 0xc9,
+	0x8a, 0x93, 0x6c, 0x4b, 0x12, 0x69, 0xa4, 0x0b, 0x74, 0x8a, 0xcf, 0x40, 0x77, 0xd2, 0x3e, 0x34,
+	0xd2, 0x97, 0x8d, 0x5d, 0x6e, 0x3d, 0xe3, 0x19, 0x84, 0x1b, 0x94, 0x62, 0xae, 0x70, 0xdd, 0x5c,
+	0x07, 0x12, 0x89, 0xe7, 0x1b, 0x95, 0xd7, 0xfe, 0x5e, 0x0e, 0xa4, 0x57, 0xe2, 0x29, 0x8c, 0x76,
+	0x37, 0xc5, 0x56, 0xe2, 0x29, 0x40, 0x62, 0x30, 0x45, 0xed, 0x32, 0x95, 0x37, 0x9f, 0xef, 0x21,
+	0xb3, 0x57, 0xd0, 0xe1, 0xa5, 0xfb, 0x0e, 0xfa, 0xb2, 0xd6, 0x2c, 0x7e, 0xac, 0xea, 0x71, 0x74,
+	0xd8, 0x60, 0xab, 0x17, 0xc1, 0xec, 0xdf, 0x00, 0x06, 0xdb, 0x39, 0x10, 0x3f, 0xed, 0xb2, 0xb8,
+	0x28, 0xad, 0x13, 0x27, 0xf7, 0x17, 0x78, 0x7b, 0xf7, 0xc6, 0x8f, 0x3f, 0x6e, 0xb4, 0x55, 0xfc,
+	0x40, 0xfc, 0x08, 0xbd, 0x37, 0xa8, 0xd3, 0x8b, 0x85, 0xb8, 0xe7, 0xb9, 0x7f, 0x6e, 0xc6, 0x4f,
+	0x3e, 0x61, 0x6d, 0x02, 0x1d, 0xbf, 0xd5, 0x77, 0xf2, 0xfa, 0x74, 0xc0, 0x87, 0x1f, 0x5a, 0xfd,
+	0x11, 0x79, 0x30, 0xbb, 0x84, 0x9e, 0x9f, 0x22, 0x71, 0x0e, 0xfd, 0xf3, 0x52, 0x6b, 0x4c, 0x9c,
+	0xb8, 0xcf, 0x4e, 0x33, 0xee, 0xe3, 0xf1, 0x61, 0x0b, 0x8d, 0xe0, 0x69, 0xf0, 0x22, 0x58, 0xf4,
+	0xf8, 0xcf, 0xf9, 0xcd, 0x7f, 0x03, 0x00, 0x33, 0xa7, 0x6e, 0xd8, 0x52, 0x07, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -496,10 +968,12 @@ var _Task_serviceDesc = grpc.ServiceDesc{
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type HeartbeatClient interface {
-	// registry host
-	RegistryHost(ctx context.Context, in *RegistryReq, opts ...grpc.CallOption) (*Empty, error)
+	// registry host, worker registry by enroll token will get its own credential
+	RegistryHost(ctx context.Context, in *RegistryReq, opts ...grpc.CallOption) (*RegistryResp, error)
 	// SendHb send to server req to itself alive
-	SendHb(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*Empty, error)
+	SendHb(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatResp, error)
+	// UnRegistryHost worker drain finished, mark itself offline
+	UnRegistryHost(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type heartbeatClient struct {
@@ -510,8 +984,8 @@ func NewHeartbeatClient(cc *grpc.ClientConn) HeartbeatClient {
 	return &heartbeatClient{cc}
 }
 
-func (c *heartbeatClient) RegistryHost(ctx context.Context, in *RegistryReq, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *heartbeatClient) RegistryHost(ctx context.Context, in *RegistryReq, opts ...grpc.CallOption) (*RegistryResp, error) {
+	out := new(RegistryResp)
 	err := c.cc.Invoke(ctx, "/crocodile.task.Heartbeat/RegistryHost", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -519,8 +993,8 @@ func (c *heartbeatClient) RegistryHost(ctx context.Context, in *RegistryReq, opt
 	return out, nil
 }
 
-func (c *heartbeatClient) SendHb(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *heartbeatClient) SendHb(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatResp, error) {
+	out := new(HeartbeatResp)
 	err := c.cc.Invoke(ctx, "/crocodile.task.Heartbeat/SendHb", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -528,24 +1002,38 @@ func (c *heartbeatClient) SendHb(ctx context.Context, in *HeartbeatReq, opts ...
 	return out, nil
 }
 
+func (c *heartbeatClient) UnRegistryHost(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/crocodile.task.Heartbeat/UnRegistryHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HeartbeatServer is the server API for Heartbeat service.
 type HeartbeatServer interface {
-	// registry host
-	RegistryHost(context.Context, *RegistryReq) (*Empty, error)
+	// registry host, worker registry by enroll token will get its own credential
+	RegistryHost(context.Context, *RegistryReq) (*RegistryResp, error)
 	// SendHb send to server req to itself alive
-	SendHb(context.Context, *HeartbeatReq) (*Empty, error)
+	SendHb(context.Context, *HeartbeatReq) (*HeartbeatResp, error)
+	// UnRegistryHost worker drain finished, mark itself offline
+	UnRegistryHost(context.Context, *HeartbeatReq) (*Empty, error)
 }
 
 // UnimplementedHeartbeatServer can be embedded to have forward compatible implementations.
 type UnimplementedHeartbeatServer struct {
 }
 
-func (*UnimplementedHeartbeatServer) RegistryHost(ctx context.Context, req *RegistryReq) (*Empty, error) {
+func (*UnimplementedHeartbeatServer) RegistryHost(ctx context.Context, req *RegistryReq) (*RegistryResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegistryHost not implemented")
 }
-func (*UnimplementedHeartbeatServer) SendHb(ctx context.Context, req *HeartbeatReq) (*Empty, error) {
+func (*UnimplementedHeartbeatServer) SendHb(ctx context.Context, req *HeartbeatReq) (*HeartbeatResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendHb not implemented")
 }
+func (*UnimplementedHeartbeatServer) UnRegistryHost(ctx context.Context, req *HeartbeatReq) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnRegistryHost not implemented")
+}
 
 func RegisterHeartbeatServer(s *grpc.Server, srv HeartbeatServer) {
 	s.RegisterService(&_Heartbeat_serviceDesc, srv)
@@ -587,6 +1075,24 @@ func _Heartbeat_SendHb_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Heartbeat_UnRegistryHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeartbeatServer).UnRegistryHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/crocodile.task.Heartbeat/UnRegistryHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeartbeatServer).UnRegistryHost(ctx, req.(*HeartbeatReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Heartbeat_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "crocodile.task.Heartbeat",
 	HandlerType: (*HeartbeatServer)(nil),
@@ -599,7 +1105,117 @@ var _Heartbeat_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SendHb",
 			Handler:    _Heartbeat_SendHb_Handler,
 		},
+		{
+			MethodName: "UnRegistryHost",
+			Handler:    _Heartbeat_UnRegistryHost_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "core/proto/core.proto",
 }
+
+// TunnelClient is the client API for Tunnel service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type TunnelClient interface {
+	// Connect worker send task output, scheduler send task dispatch, cancel and output ack
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Tunnel_ConnectClient, error)
+}
+
+type tunnelClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTunnelClient(cc *grpc.ClientConn) TunnelClient {
+	return &tunnelClient{cc}
+}
+
+func (c *tunnelClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Tunnel_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Tunnel_serviceDesc.Streams[0], "/crocodile.task.Tunnel/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tunnelConnectClient{stream}
+	return x, nil
+}
+
+type Tunnel_ConnectClient interface {
+	Send(*TunnelUp) error
+	Recv() (*TunnelDown, error)
+	grpc.ClientStream
+}
+
+type tunnelConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *tunnelConnectClient) Send(m *TunnelUp) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tunnelConnectClient) Recv() (*TunnelDown, error) {
+	m := new(TunnelDown)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TunnelServer is the server API for Tunnel service.
+type TunnelServer interface {
+	// Connect worker send task output, scheduler send task dispatch, cancel and output ack
+	Connect(Tunnel_ConnectServer) error
+}
+
+// UnimplementedTunnelServer can be embedded to have forward compatible implementations.
+type UnimplementedTunnelServer struct {
+}
+
+func (*UnimplementedTunnelServer) Connect(srv Tunnel_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+
+func RegisterTunnelServer(s *grpc.Server, srv TunnelServer) {
+	s.RegisterService(&_Tunnel_serviceDesc, srv)
+}
+
+func _Tunnel_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TunnelServer).Connect(&tunnelConnectServer{stream})
+}
+
+type Tunnel_ConnectServer interface {
+	Send(*TunnelDown) error
+	Recv() (*TunnelUp, error)
+	grpc.ServerStream
+}
+
+type tunnelConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *tunnelConnectServer) Send(m *TunnelDown) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tunnelConnectServer) Recv() (*TunnelUp, error) {
+	m := new(TunnelUp)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Tunnel_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "crocodile.task.Tunnel",
+	HandlerType: (*TunnelServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Tunnel_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "core/proto/core.proto",
+}
@@ -0,0 +1,200 @@
+package host
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/schedule"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// only admin can manage enroll token
+func isadmin(c *gin.Context) bool {
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	return role == define.AdminUser
+}
+
+// GetEnrollTokens return enroll tokens
+// @Summary get enroll tokens
+// @Tags Host
+// @Description get worker enroll tokens, plain token is only return when created
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/host/enrolltoken [get]
+// @Security ApiKeyAuth
+func GetEnrollTokens(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	if !isadmin(c) {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	var (
+		q   define.Query
+		err error
+	)
+	err = c.BindQuery(&q)
+	if err != nil {
+		log.Error("BindQuery offset failed", zap.Error(err))
+	}
+	if q.Limit == 0 {
+		q.Limit = define.DefaultLimit
+	}
+	enrolltokens, count, err := model.GetEnrollTokens(ctx, q.Offset, q.Limit)
+	if err != nil {
+		log.Error("model.GetEnrollTokens failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, enrolltokens, count)
+}
+
+// CreateEnrollToken create enroll token
+// @Summary create enroll token
+// @Tags Host
+// @Description create worker enroll token, worker exchange it for its own credential when registry
+// @Param EnrollToken body define.CreateEnrollToken true "EnrollToken"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/host/enrolltoken [post]
+// @Security ApiKeyAuth
+func CreateEnrollToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	if !isadmin(c) {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	req := define.CreateEnrollToken{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		log.Error("c.ShouldBindJSON", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if req.HostGroupID != "" {
+		_, err = model.GetHostGroupByID(ctx, req.HostGroupID)
+		switch err.(type) {
+		case nil:
+		case define.ErrNotExist:
+			resp.JSON(c, resp.ErrHostgroupNotExist, nil)
+			return
+		default:
+			log.Error("model.GetHostGroupByID failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+	}
+	enrolltoken, err := model.CreateEnrollToken(ctx, req, c.GetString("uid"))
+	if err != nil {
+		log.Error("model.CreateEnrollToken failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, enrolltoken)
+}
+
+// DeleteEnrollToken delete enroll token
+// @Summary delete enroll token
+// @Tags Host
+// @Description delete enroll token, worker registried by it can still use its credential
+// @Param EnrollToken body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/host/enrolltoken [delete]
+// @Security ApiKeyAuth
+func DeleteEnrollToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	if !isadmin(c) {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	getid := define.GetID{}
+	err := c.ShouldBindJSON(&getid)
+	if err != nil {
+		log.Error("c.ShouldBindJSON", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if utils.CheckID(getid.ID) != nil {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	_, err = model.GetEnrollTokenByID(ctx, getid.ID)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		resp.JSON(c, resp.ErrEnrollTokenNotExist, nil)
+		return
+	default:
+		log.Error("model.GetEnrollTokenByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	err = model.DeleteEnrollToken(ctx, getid.ID)
+	if err != nil {
+		log.Error("model.DeleteEnrollToken failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// RevokeHostCredential revoke credential of host
+// @Summary revoke host credential
+// @Tags Host
+// @Description revoke credential issued to worker, worker need registry again by enroll token
+// @Param Host body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/host/revoke [put]
+// @Security ApiKeyAuth
+func RevokeHostCredential(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	gethost := define.GetID{}
+	err := c.ShouldBindJSON(&gethost)
+	if err != nil {
+		log.Error("c.ShouldBindJSON", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if utils.CheckID(gethost.ID) != nil {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	_, err = model.GetHostByID(ctx, gethost.ID)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		resp.JSON(c, resp.ErrHostNotExist, nil)
+		return
+	default:
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	err = model.SetHostCredential(ctx, gethost.ID, "")
+	if err != nil {
+		log.Error("model.SetHostCredential failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	schedule.InvalidHostCredential(gethost.ID)
+	resp.JSON(c, resp.Success, nil)
+}
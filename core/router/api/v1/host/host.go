@@ -99,6 +99,51 @@ Next:
 	resp.JSON(c, resp.Success, nil)
 }
 
+// DrainHost drain host worker
+// @Summary drain host worker
+// @Tags Host
+// @Description worker stop recv new task, offline after running tasks finished
+// @Param DrainHost body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/host/drain [put]
+// @Security ApiKeyAuth
+func DrainHost(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	gethost := define.GetID{}
+	err := c.ShouldBindJSON(&gethost)
+	if err != nil {
+		log.Error("c.ShouldBindJSON", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if utils.CheckID(gethost.ID) != nil {
+		log.Error("CheckID failed")
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	_, err = model.GetHostByID(ctx, gethost.ID)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		resp.JSON(c, resp.ErrHostNotExist, nil)
+		return
+	default:
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	// worker在下次心跳时收到排空请求
+	err = model.DrainHost(ctx, gethost.ID)
+	if err != nil {
+		log.Error("model.DrainHost", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
 // DeleteHost delete host
 // @Summary delete host
 // @Tags Host
@@ -123,7 +168,7 @@ func DeleteHost(c *gin.Context) {
 		return
 	}
 
-	hostgroups, _, err := model.GetHostGroups(ctx, 0, 0)
+	hostgroups, _, err := model.GetHostGroups(ctx, 0, 0, "", "")
 	if err != nil {
 		resp.JSON(c, resp.ErrInternalServer, nil)
 		return
@@ -165,3 +210,43 @@ func GetSelect(c *gin.Context) {
 	}
 	resp.JSON(c, resp.Success, data)
 }
+
+// GetHostEvents return host online and offline events
+// @Summary get host events
+// @Tags Host
+// @Description get host online and offline events, if id is empty return all hosts' events
+// @Param id query string false "ID"
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/host/events [get]
+// @Security ApiKeyAuth
+func GetHostEvents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	var (
+		q   define.Query
+		err error
+	)
+	err = c.BindQuery(&q)
+	if err != nil {
+		log.Error("BindQuery offset failed", zap.Error(err))
+	}
+	if q.Limit == 0 {
+		q.Limit = define.DefaultLimit
+	}
+	hostid := c.Query("id")
+	if hostid != "" && utils.CheckID(hostid) != nil {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	events, count, err := model.GetHostEvents(ctx, hostid, q.Offset, q.Limit)
+	if err != nil {
+		log.Error("model.GetHostEvents failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, events, count)
+}
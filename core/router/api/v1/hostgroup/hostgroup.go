@@ -46,8 +46,12 @@ func CreateHostGroup(c *gin.Context) {
 		resp.JSON(c, resp.ErrHostgroupExist, nil)
 		return
 	}
+	if code := checkhostgroupproject(ctx, c, hg.ProjectID); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
 
-	err = model.CreateHostgroup(ctx, hg.Name, hg.Remark, c.GetString("uid"), hg.HostsID)
+	err = model.CreateHostgroup(ctx, hg.Name, hg.Remark, c.GetString("uid"), hg.ProjectID, hg.HostsID, hg.ApproverIds)
 	if err != nil {
 		log.Error("CreateHostgroup failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -98,23 +102,48 @@ func ChangeHostGroup(c *gin.Context) {
 		role = v.(define.Role)
 	}
 
-	// 这里只需要确定如果rule的用户类型是否为Admin
-	if role != define.AdminUser {
-		// 判断ID的创建人是否为uid
-		exist, err = model.Check(ctx, model.TBHostgroup, model.IDCreateByUID, hg.ID, uid)
+	// 项目中的主机组需要Editor角色，其他主机组需要是创建人
+	exist, err = model.CheckHostGroupPermission(ctx, uid, role, hg.ID, define.ProjectEditor)
+	if err != nil {
+		log.Error("model.CheckHostGroupPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+
+	if !exist {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	if code := checkhostgroupproject(ctx, c, hg.ProjectID); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	// 已设置审核人的主机组只有管理员可以修改审核人，防止绕过审核
+	oldhg, err := model.GetHostGroupByID(ctx, hg.ID)
+	if err != nil {
+		log.Error("model.GetHostGroupByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if role != define.AdminUser && len(oldhg.ApproverIds) != 0 && !sameids(oldhg.ApproverIds, hg.ApproverIds) {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	// 移动到项目中时，不能被其他项目的任务使用
+	if hg.ProjectID != "" {
+		used, err := model.HostGroupUsedOutOfProject(ctx, hg.ID, hg.ProjectID)
 		if err != nil {
-			log.Error("IsExist failed", zap.Error(err))
+			log.Error("model.HostGroupUsedOutOfProject failed", zap.Error(err))
 			resp.JSON(c, resp.ErrInternalServer, nil)
 			return
 		}
-
-		if !exist {
-			resp.JSON(c, resp.ErrUnauthorized, nil)
+		if used {
+			resp.JSON(c, resp.ErrProjectHostGroup, nil)
 			return
 		}
 	}
 
-	err = model.ChangeHostGroup(ctx, hg.HostsID, hg.ID, hg.Remark)
+	err = model.ChangeHostGroup(ctx, hg.HostsID, hg.ApproverIds, hg.ID, hg.ProjectID, hg.Remark)
 	if err != nil {
 		log.Error("ChangeHostGroup failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -169,20 +198,17 @@ func DeleteHostGroup(c *gin.Context) {
 		role = v.(define.Role)
 	}
 
-	// 这里只需要确定如果rule的用户类型是否为Admin
-	if role != define.AdminUser {
-		// 判断ID的创建人是否为uid
-		exist, err = model.Check(ctx, model.TBHostgroup, model.IDCreateByUID, hostgroup.ID, uid)
-		if err != nil {
-			log.Error("IsExist failed", zap.Error(err))
-			resp.JSON(c, resp.ErrInternalServer, nil)
-			return
-		}
+	// 项目中的主机组需要Editor角色，其他主机组需要是创建人
+	exist, err = model.CheckHostGroupPermission(ctx, uid, role, hostgroup.ID, define.ProjectEditor)
+	if err != nil {
+		log.Error("model.CheckHostGroupPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 
-		if !exist {
-			resp.JSON(c, resp.ErrUnauthorized, nil)
-			return
-		}
+	if !exist {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
 	}
 	ok, err := model.Check(ctx, model.TBTask, model.HostGroupID, hostgroup.ID)
 	if err != nil {
@@ -211,6 +237,7 @@ func DeleteHostGroup(c *gin.Context) {
 // @Description get all hostgroup
 // @Param offset query int false "Offset"
 // @Param limit query int false "Limit"
+// @Param project_id query string false "ProjectID"
 // @Produce json
 // @Success 200 {object} resp.Response
 // @Router /api/v1/hostgroup [get]
@@ -219,8 +246,12 @@ func GetHostGroups(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(),
 		config.CoreConf.Server.DB.MaxQueryTime.Duration)
 	defer cancel()
+	type GetQuery struct {
+		define.Query
+		ProjectID string `form:"project_id"`
+	}
 	var (
-		q   define.Query
+		q   GetQuery
 		err error
 	)
 
@@ -235,7 +266,12 @@ func GetHostGroups(c *gin.Context) {
 		q.Limit = define.DefaultLimit
 	}
 
-	hgs, count, err := model.GetHostGroups(ctx, q.Limit, q.Offset)
+	// 只返回不属于任何项目和用户所在项目的主机组
+	var visibleuid string
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		visibleuid = c.GetString("uid")
+	}
+	hgs, count, err := model.GetHostGroups(ctx, q.Limit, q.Offset, q.ProjectID, visibleuid)
 	if err != nil {
 		log.Error("GetHostGroup failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -256,7 +292,11 @@ func GetSelect(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(),
 		config.CoreConf.Server.DB.MaxQueryTime.Duration)
 	defer cancel()
-	data, err := model.GetNameID(ctx, model.TBHostgroup)
+	var visibleuid string
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		visibleuid = c.GetString("uid")
+	}
+	data, err := model.GetVisibleNameID(ctx, model.TBHostgroup, visibleuid)
 	if err != nil {
 		log.Error("model.GetNameID failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -284,6 +324,20 @@ func GetHostsByIHGID(c *gin.Context) {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	ok, err := model.CheckHostGroupPermission(ctx, c.GetString("uid"), role, getid.ID, define.ProjectViewer)
+	if err != nil {
+		log.Error("model.CheckHostGroupPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !ok {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
 	hosts, err := model.GetHostsByHGID(ctx, getid.ID)
 	switch err.(type) {
 	case nil:
@@ -295,3 +349,48 @@ func GetHostsByIHGID(c *gin.Context) {
 		resp.JSON(c, resp.ErrInternalServer, nil)
 	}
 }
+
+// checkhostgroupproject check project is exist and user could create hostgroup in project, return resp code
+func checkhostgroupproject(ctx context.Context, c *gin.Context, projectid string) int {
+	if projectid == "" {
+		return resp.Success
+	}
+	exist, err := model.Check(ctx, model.TBProject, model.ID, projectid)
+	if err != nil {
+		log.Error("model.Check failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !exist {
+		return resp.ErrProjectNotExist
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	ok, err := model.CheckProjectPermission(ctx, c.GetString("uid"), role, projectid, define.ProjectEditor)
+	if err != nil {
+		log.Error("model.CheckProjectPermission failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !ok {
+		return resp.ErrUnauthorized
+	}
+	return resp.Success
+}
+
+// sameids check two id list contain same ids
+func sameids(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := make(map[string]struct{}, len(a))
+	for _, id := range a {
+		ids[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := ids[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/loginguard"
 	"github.com/labulaka521/crocodile/core/model"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/labulaka521/crocodile/core/utils/resp"
@@ -55,6 +56,12 @@ func StartInstall(c *gin.Context) {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
+	err = loginguard.CheckPassword(adminuser.Password)
+	if err != nil {
+		log.Error("loginguard.CheckPassword failed", zap.Error(err))
+		resp.JSON(c, resp.ErrPasswordPolicy, err.Error())
+		return
+	}
 
 	err = model.StartInstall(ctx, adminuser.Name, adminuser.Password)
 	if err != nil {
@@ -0,0 +1,389 @@
+package project
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// CreateProject create project
+// @Summary create project
+// @Tags Project
+// @Description create new project, creator is the owner
+// @Produce json
+// @Param Project body define.CreateProject true "Project"
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project [post]
+// @Security ApiKeyAuth
+func CreateProject(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	project := define.CreateProject{}
+	err := c.ShouldBindJSON(&project)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	exist, err := model.Check(ctx, model.TBProject, model.Name, project.Name)
+	if err != nil {
+		log.Error("model.Check failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if exist {
+		resp.JSON(c, resp.ErrProjectExist, nil)
+		return
+	}
+	_, err = model.CreateProject(ctx, project.Name, project.Remark, c.GetString("uid"))
+	if err != nil {
+		log.Error("model.CreateProject failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// ChangeProject change project
+// @Summary change project
+// @Tags Project
+// @Description change project name and remark, need owner role
+// @Produce json
+// @Param Project body define.ChangeProject true "Project"
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project [put]
+// @Security ApiKeyAuth
+func ChangeProject(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	project := define.ChangeProject{}
+	err := c.ShouldBindJSON(&project)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if code := checkproject(ctx, c, project.ID, define.ProjectOwner); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	old, err := model.GetProjectByName(ctx, project.Name, "")
+	switch err.(type) {
+	case nil:
+		if old.ID != project.ID {
+			resp.JSON(c, resp.ErrProjectExist, nil)
+			return
+		}
+	case define.ErrNotExist:
+	default:
+		log.Error("model.GetProjectByName failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	err = model.ChangeProject(ctx, project.ID, project.Name, project.Remark)
+	if err != nil {
+		log.Error("model.ChangeProject failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// DeleteProject delete project
+// @Summary delete project
+// @Tags Project
+// @Description delete project which has no tasks and hostgroups, need owner role
+// @Produce json
+// @Param Project body define.GetID true "Project"
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project [delete]
+// @Security ApiKeyAuth
+func DeleteProject(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	getid := define.GetID{}
+	err := c.ShouldBindJSON(&getid)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if code := checkproject(ctx, c, getid.ID, define.ProjectOwner); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	isuse, err := model.ProjectIsUse(ctx, getid.ID)
+	if err != nil {
+		log.Error("model.ProjectIsUse failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if isuse {
+		resp.JSON(c, resp.ErrProjectIsUse, nil)
+		return
+	}
+	err = model.DeleteProject(ctx, getid.ID)
+	if err != nil {
+		log.Error("model.DeleteProject failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// GetProjects get projects
+// @Summary get projects
+// @Tags Project
+// @Description admin get all projects, other users get projects they are member of
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project [get]
+// @Security ApiKeyAuth
+func GetProjects(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	q := define.Query{}
+	err := c.BindQuery(&q)
+	if err != nil {
+		log.Error("BindQuery offset failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if q.Limit == 0 {
+		q.Limit = define.DefaultLimit
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	projects, count, err := model.GetProjects(ctx, c.GetString("uid"), role != define.AdminUser, q.Limit, q.Offset)
+	if err != nil {
+		log.Error("model.GetProjects failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, projects, count)
+}
+
+// GetSelect return name,id of projects which user could create task or hostgroup in
+// @Summary get name,id
+// @Tags Project
+// @Description get select option
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project/select [get]
+// @Security ApiKeyAuth
+func GetSelect(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	projects, _, err := model.GetProjects(ctx, c.GetString("uid"), role != define.AdminUser, 0, 0)
+	if err != nil {
+		log.Error("model.GetProjects failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	data := []define.KlOption{}
+	for _, project := range projects {
+		if role != define.AdminUser && project.Role < define.ProjectEditor {
+			continue
+		}
+		data = append(data, define.KlOption{Label: project.Name, Value: project.ID})
+	}
+	resp.JSON(c, resp.Success, data)
+}
+
+// GetMembers get members of project
+// @Summary get members of project
+// @Tags Project
+// @Param id query string true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project/member [get]
+// @Security ApiKeyAuth
+func GetMembers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	getid := define.GetID{}
+	err := c.BindQuery(&getid)
+	if err != nil {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if code := checkproject(ctx, c, getid.ID, define.ProjectViewer); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	members, err := model.GetProjectMembers(ctx, getid.ID)
+	if err != nil {
+		log.Error("model.GetProjectMembers failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, members)
+}
+
+// SetMember add member to project or change member's role
+// @Summary add or change member of project
+// @Tags Project
+// @Description role 1:Viewer 2:Operator 3:Editor 4:Owner, need owner role
+// @Produce json
+// @Param Member body define.SetProjectMember true "Member"
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project/member [put]
+// @Security ApiKeyAuth
+func SetMember(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	member := define.SetProjectMember{}
+	err := c.ShouldBindJSON(&member)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	if code := checkproject(ctx, c, member.ProjectID, define.ProjectOwner); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	exist, err := model.Check(ctx, model.TBUser, model.ID, member.UID)
+	if err != nil {
+		log.Error("model.Check failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !exist {
+		resp.JSON(c, resp.ErrUserNotExist, nil)
+		return
+	}
+	if member.Role != define.ProjectOwner {
+		if code := checklastowner(ctx, member.ProjectID, member.UID); code != resp.Success {
+			resp.JSON(c, code, nil)
+			return
+		}
+	}
+	err = model.SetProjectMember(ctx, member.ProjectID, member.UID, member.Role)
+	if err != nil {
+		log.Error("model.SetProjectMember failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	log.Info("set project member", zap.String("project", member.ProjectID), zap.String("uid", member.UID),
+		zap.String("role", member.Role.String()), zap.String("by", c.GetString("username")))
+	resp.JSON(c, resp.Success, nil)
+}
+
+// DeleteMember remove member from project
+// @Summary remove member of project
+// @Tags Project
+// @Description need owner role, member could remove self
+// @Produce json
+// @Param Member body define.DeleteProjectMember true "Member"
+// @Success 200 {object} resp.Response
+// @Router /api/v1/project/member [delete]
+// @Security ApiKeyAuth
+func DeleteMember(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	member := define.DeleteProjectMember{}
+	err := c.ShouldBindJSON(&member)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	need := define.ProjectOwner
+	if member.UID == c.GetString("uid") {
+		need = define.ProjectViewer
+	}
+	if code := checkproject(ctx, c, member.ProjectID, need); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	if code := checklastowner(ctx, member.ProjectID, member.UID); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	err = model.DeleteProjectMember(ctx, member.ProjectID, member.UID)
+	if err != nil {
+		log.Error("model.DeleteProjectMember failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	log.Info("delete project member", zap.String("project", member.ProjectID), zap.String("uid", member.UID),
+		zap.String("by", c.GetString("username")))
+	resp.JSON(c, resp.Success, nil)
+}
+
+// checkproject check project is exist and user has need role, return resp code
+func checkproject(ctx context.Context, c *gin.Context, projectid string, need define.ProjectRole) int {
+	exist, err := model.Check(ctx, model.TBProject, model.ID, projectid)
+	if err != nil {
+		log.Error("model.Check failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !exist {
+		return resp.ErrProjectNotExist
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	ok, err := model.CheckProjectPermission(ctx, c.GetString("uid"), role, projectid, need)
+	if err != nil {
+		log.Error("model.CheckProjectPermission failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !ok {
+		return resp.ErrUnauthorized
+	}
+	return resp.Success
+}
+
+// checklastowner project must have at least one owner, check uid is not the last owner
+func checklastowner(ctx context.Context, projectid, uid string) int {
+	role, err := model.GetProjectRole(ctx, projectid, uid)
+	if err != nil {
+		log.Error("model.GetProjectRole failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if role != define.ProjectOwner {
+		return resp.Success
+	}
+	count, err := model.CountProjectOwners(ctx, projectid)
+	if err != nil {
+		log.Error("model.CountProjectOwners failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if count <= 1 {
+		return resp.ErrProjectLastOwner
+	}
+	return resp.Success
+}
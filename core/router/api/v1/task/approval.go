@@ -0,0 +1,324 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/alarm"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// 任务审核
+// 任务或者主机组设置了审核人后为受保护的，创建、修改和手动运行受保护的任务时保存为审核请求，
+// 由审核人通过后使用申请人提交的数据执行，审核人自已的操作不需要审核
+
+// GetApprovals get approval requests
+// @Summary get approval requests
+// @Tags Task
+// @Description admin get all requests, other users get requests created by self or wait self review
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Param status query int false "Status"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/task/approval [get]
+// @Security ApiKeyAuth
+func GetApprovals(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	type GetQuery struct {
+		define.Query
+		Status define.ApprovalStatus `form:"status"`
+	}
+	var q GetQuery
+	err := c.BindQuery(&q)
+	if err != nil {
+		log.Error("BindQuery failed", zap.Error(err))
+	}
+	if q.Limit == 0 {
+		q.Limit = define.DefaultLimit
+	}
+	var visibleuid string
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		visibleuid = c.GetString("uid")
+	}
+	approvals, count, err := model.GetApprovals(ctx, visibleuid, q.Status, q.Offset, q.Limit)
+	if err != nil {
+		log.Error("model.GetApprovals failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, approvals, count)
+}
+
+// ReviewApproval approve or reject approval request
+// @Summary review approval request
+// @Tags Task
+// @Description approver approve or reject request, approved request will be done at once
+// @Param Approval body define.ReviewApproval true "review"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/task/approval [put]
+// @Security ApiKeyAuth
+func ReviewApproval(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	review := define.ReviewApproval{}
+	err := c.ShouldBindJSON(&review)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	approval, code := getpendingapproval(ctx, review.ID)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	uid := c.GetString("uid")
+	if !containsid(approval.ApproverIds, uid) {
+		resp.JSON(c, resp.ErrNotApprover, nil)
+		return
+	}
+	status := define.ApprovalRejected
+	if review.Approve {
+		status = define.ApprovalApproved
+		// 执行前先检查任务状态，避免通过后执行失败
+		if code := checkapproval(ctx, approval); code != resp.Success {
+			resp.JSON(c, code, nil)
+			return
+		}
+	}
+	ok, err := model.FinishApproval(ctx, approval.ID, status, uid, review.Remark)
+	if err != nil {
+		log.Error("model.FinishApproval failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	// 其他审核人已经处理
+	if !ok {
+		resp.JSON(c, resp.ErrApprovalDone, nil)
+		return
+	}
+	code = resp.Success
+	if review.Approve {
+		code = doapproval(ctx, approval)
+	}
+	notifyapproval(ctx, approval.ID, []string{approval.CreateByUID})
+	resp.JSON(c, code, nil)
+}
+
+// CancelApproval requester cancel pending approval request
+// @Summary cancel approval request
+// @Tags Task
+// @Param Approval body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/task/approval [delete]
+// @Security ApiKeyAuth
+func CancelApproval(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	getid := define.GetID{}
+	err := c.ShouldBindJSON(&getid)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	approval, code := getpendingapproval(ctx, getid.ID)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	uid := c.GetString("uid")
+	if approval.CreateByUID != uid {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	ok, err := model.FinishApproval(ctx, approval.ID, define.ApprovalCanceled, uid, "")
+	if err != nil {
+		log.Error("model.FinishApproval failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !ok {
+		resp.JSON(c, resp.ErrApprovalDone, nil)
+		return
+	}
+	notifyapproval(ctx, approval.ID, approval.ApproverIds)
+	resp.JSON(c, resp.Success, nil)
+}
+
+// changetaskapproval content of change task request
+type changetaskapproval struct {
+	define.ChangeTask
+	TaskUpdateTime string `json:"task_updatetime"` // 提交时任务的更新时间
+}
+
+// taskapprovers return approvers of task and host groups
+func taskapprovers(ctx context.Context, approverids []string, hostgroupids ...string) ([]string, error) {
+	approvers := []string{}
+	approvers = append(approvers, approverids...)
+	for _, hgid := range hostgroupids {
+		if hgid == "" {
+			continue
+		}
+		hg, err := model.GetHostGroupByID(ctx, hgid)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range hg.ApproverIds {
+			if !containsid(approvers, id) {
+				approvers = append(approvers, id)
+			}
+		}
+	}
+	return approvers, nil
+}
+
+// needapproval check user's operate need approval
+func needapproval(uid string, approverids []string) bool {
+	return len(approverids) != 0 && !containsid(approverids, uid)
+}
+
+// submitapproval save approval request and notify approvers, return resp code
+func submitapproval(ctx context.Context, c *gin.Context, action define.ApprovalAction, taskid, taskname string,
+	data interface{}, approverids []string) int {
+	content, err := json.Marshal(data)
+	if err != nil {
+		log.Error("json.Marshal failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	id := utils.GetID()
+	err = model.CreateApproval(ctx, id, action, taskid, taskname, string(content), approverids, c.GetString("uid"))
+	if err != nil {
+		log.Error("model.CreateApproval failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	notifyapproval(ctx, id, approverids)
+	return resp.ApprovalPending
+}
+
+// getpendingapproval get approval request which wait review
+func getpendingapproval(ctx context.Context, id string) (*define.Approval, int) {
+	approval, err := model.GetApprovalByID(ctx, id)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		return nil, resp.ErrApprovalNotExist
+	default:
+		log.Error("model.GetApprovalByID failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	if approval.Status != define.ApprovalPending {
+		return nil, resp.ErrApprovalDone
+	}
+	return approval, resp.Success
+}
+
+// checkapproval check the request could be done
+func checkapproval(ctx context.Context, approval *define.Approval) int {
+	var (
+		exist bool
+		err   error
+	)
+	if approval.Action == define.ApprovalCreateTask {
+		exist, err = model.Check(ctx, model.TBTask, model.Name, approval.TaskName)
+	} else {
+		exist, err = model.Check(ctx, model.TBTask, model.ID, approval.TaskID)
+	}
+	if err != nil {
+		log.Error("model.Check failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	switch {
+	case approval.Action == define.ApprovalCreateTask && exist:
+		return resp.ErrTaskExist
+	case approval.Action != define.ApprovalCreateTask && !exist:
+		return resp.ErrTaskNotExist
+	case approval.Action == define.ApprovalChangeTask:
+		return checkchangeapproval(ctx, approval)
+	}
+	return resp.Success
+}
+
+// checkchangeapproval check the task is not changed after the change request submitted,
+// otherwise the request's data would overwrite the newer change
+func checkchangeapproval(ctx context.Context, approval *define.Approval) int {
+	change := changetaskapproval{}
+	err := json.Unmarshal([]byte(approval.Content), &change)
+	if err != nil {
+		log.Error("json.Unmarshal failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	task, err := model.GetTaskByID(ctx, approval.TaskID)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		return resp.ErrTaskNotExist
+	default:
+		log.Error("model.GetTaskByID failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if change.TaskUpdateTime != task.UpdateTime {
+		return resp.ErrApprovalTaskChanged
+	}
+	return resp.Success
+}
+
+// doapproval do the approved request by requester's data
+func doapproval(ctx context.Context, approval *define.Approval) int {
+	switch approval.Action {
+	case define.ApprovalCreateTask:
+		task := define.CreateTask{}
+		err := json.Unmarshal([]byte(approval.Content), &task)
+		if err != nil {
+			log.Error("json.Unmarshal failed", zap.Error(err))
+			return resp.ErrInternalServer
+		}
+		return createtask(ctx, &task, approval.CreateByUID)
+	case define.ApprovalChangeTask:
+		change := changetaskapproval{}
+		err := json.Unmarshal([]byte(approval.Content), &change)
+		if err != nil {
+			log.Error("json.Unmarshal failed", zap.Error(err))
+			return resp.ErrInternalServer
+		}
+		return changetask(ctx, &change.ChangeTask)
+	case define.ApprovalRunTask:
+		return runtasknow(approval.TaskID)
+	default:
+		return resp.ErrBadRequest
+	}
+}
+
+// notifyapproval send newest approval status to users
+func notifyapproval(ctx context.Context, id string, uids []string) {
+	approval, err := model.GetApprovalByID(ctx, id)
+	if err != nil {
+		log.Error("model.GetApprovalByID failed", zap.Error(err))
+		return
+	}
+	go alarm.ApprovalNotify(approval, uids)
+}
+
+// containsid check id is in ids
+func containsid(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/db"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+)
+
+const (
+	approvalid   = "500000000000000001"
+	approvaltask = "300000000000000001"
+	requesteruid = "400000000000000001"
+	approveruid  = "400000000000000002"
+	approver2uid = "400000000000000003"
+	// 任务的更新时间
+	taskupdatetime = 1600000000
+)
+
+// initapprovaldb create the tables used by approval in a temp sqlite3 db,
+// return func to remove the db
+func initapprovaldb(t *testing.T) func() {
+	log.InitLog(log.Level("error"))
+	_, err := toml.Decode("[server.db]\nmaxquerytime = \"3s\"", &config.CoreConf)
+	if err != nil {
+		t.Fatalf("toml.Decode failed: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "crocodile")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir failed: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	err = db.NewDb(db.Drivename("sqlite3"), db.Dsn(filepath.Join(dir, "crocodile.db")))
+	if err != nil {
+		cleanup()
+		t.Fatalf("db.NewDb failed: %v", err)
+	}
+	conn, err := db.GetConn(context.Background())
+	if err != nil {
+		cleanup()
+		t.Fatalf("db.GetConn failed: %v", err)
+	}
+	defer conn.Close()
+	execsqls := []string{
+		`CREATE TABLE crocodile_user (id TEXT, name TEXT, role INTEGER DEFAULT 1, forbid BOOLEAN DEFAULT false,
+			hashpassword TEXT DEFAULT '', email TEXT DEFAULT '', wechat TEXT DEFAULT '', dingphone TEXT DEFAULT '',
+			telegram TEXT DEFAULT '', remark TEXT DEFAULT '', ldapDN TEXT DEFAULT '', totpEnable BOOLEAN DEFAULT false,
+			createTime INTEGER DEFAULT 0, updateTime INTEGER DEFAULT 0)`,
+		`CREATE TABLE crocodile_approval (id TEXT, action INTEGER, taskID TEXT, taskName TEXT, content TEXT,
+			approverIds TEXT, status INTEGER, createByID TEXT, reviewByID TEXT DEFAULT '',
+			reviewRemark TEXT DEFAULT '', createTime INTEGER, updateTime INTEGER)`,
+		`CREATE TABLE crocodile_task (id TEXT, name TEXT, tasktype INTEGER DEFAULT 1,
+			taskdata TEXT DEFAULT '{"lang":1,"code":"echo"}', run BOOLEAN DEFAULT true, parentTaskIds TEXT DEFAULT '',
+			parentRunParallel BOOLEAN DEFAULT false, childTaskIds TEXT DEFAULT '', childRunParallel BOOLEAN DEFAULT false,
+			cronExpr TEXT DEFAULT '* * * * *', timeout INTEGER DEFAULT -1, alarmUserIds TEXT DEFAULT '',
+			approverIds TEXT DEFAULT '', routePolicy INTEGER DEFAULT 1, broadcastSuccess INTEGER DEFAULT 0,
+			broadcastParallel INTEGER DEFAULT 0, shardTotal INTEGER DEFAULT 0, idempotent BOOLEAN DEFAULT false,
+			failoverLimit INTEGER DEFAULT 0, expectCode INTEGER DEFAULT 0, expectContent TEXT DEFAULT '',
+			alarmStatus INTEGER DEFAULT 1, createByID TEXT, hostGroupID TEXT DEFAULT '', projectID TEXT DEFAULT '',
+			labelSelector TEXT DEFAULT '', remark TEXT DEFAULT '', createTime INTEGER DEFAULT 0, updateTime INTEGER)`,
+		`CREATE TABLE crocodile_hostgroup (id TEXT, name TEXT)`,
+		`CREATE TABLE crocodile_project (id TEXT, name TEXT)`,
+		`INSERT INTO crocodile_user (id,name) VALUES ('` + requesteruid + `','requester')`,
+		`INSERT INTO crocodile_user (id,name) VALUES ('` + approveruid + `','approver')`,
+		`INSERT INTO crocodile_user (id,name) VALUES ('` + approver2uid + `','approver2')`,
+		`INSERT INTO crocodile_task (id,name,createByID,updateTime) VALUES ('` + approvaltask + `','task','` +
+			requesteruid + `',` + strconv.Itoa(taskupdatetime) + `)`,
+	}
+	for _, execsql := range execsqls {
+		if _, err = conn.ExecContext(context.Background(), execsql); err != nil {
+			cleanup()
+			t.Fatalf("conn.ExecContext %s failed: %v", execsql, err)
+		}
+	}
+	return cleanup
+}
+
+// createapproval save pending approval request of approvaltask
+func createapproval(t *testing.T, action define.ApprovalAction, content string) {
+	err := model.CreateApproval(context.Background(), approvalid, action, approvaltask, "task", content,
+		[]string{approveruid, approver2uid}, requesteruid)
+	if err != nil {
+		t.Fatalf("model.CreateApproval failed: %v", err)
+	}
+}
+
+// callapproval call handler by uid and return resp code
+func callapproval(t *testing.T, handler gin.HandlerFunc, method, uid, body string) int {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/task/approval", strings.NewReader(body))
+	c.Set("uid", uid)
+	c.Set("role", define.NormalUser)
+	handler(c)
+	res := resp.Response{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("json.Unmarshal %s failed: %v", w.Body.String(), err)
+	}
+	return res.Code
+}
+
+func reviewbody(approve bool) string {
+	return `{"id":"` + approvalid + `","approve":` + strconv.FormatBool(approve) + `}`
+}
+
+func Test_ReviewApproval(t *testing.T) {
+	defer initapprovaldb(t)()
+	createapproval(t, define.ApprovalRunTask, "{}")
+	tests := []struct {
+		name string
+		uid  string
+		code int
+	}{
+		{"requester review", requesteruid, resp.ErrNotApprover},
+		{"other review", "400000000000000009", resp.ErrNotApprover},
+		{"approver reject", approveruid, resp.Success},
+		// 其他审核人已经处理
+		{"approver2 review again", approver2uid, resp.ErrApprovalDone},
+		{"approver review again", approveruid, resp.ErrApprovalDone},
+	}
+	for _, test := range tests {
+		code := callapproval(t, ReviewApproval, http.MethodPut, test.uid, reviewbody(false))
+		if code != test.code {
+			t.Errorf("%s: want code %d, but get %d", test.name, test.code, code)
+		}
+	}
+	approval, err := model.GetApprovalByID(context.Background(), approvalid)
+	if err != nil {
+		t.Fatalf("model.GetApprovalByID failed: %v", err)
+	}
+	if approval.Status != define.ApprovalRejected || approval.ReviewByUID != approveruid {
+		t.Errorf("want rejected by %s, but get status %s review by %s", approveruid, approval.Status, approval.ReviewByUID)
+	}
+}
+
+func Test_CancelApproval(t *testing.T) {
+	defer initapprovaldb(t)()
+	createapproval(t, define.ApprovalRunTask, "{}")
+	body := `{"id":"` + approvalid + `"}`
+	if code := callapproval(t, CancelApproval, http.MethodDelete, approveruid, body); code != resp.ErrUnauthorized {
+		t.Errorf("approver cancel: want code %d, but get %d", resp.ErrUnauthorized, code)
+	}
+	if code := callapproval(t, CancelApproval, http.MethodDelete, requesteruid, body); code != resp.Success {
+		t.Errorf("requester cancel: want code %d, but get %d", resp.Success, code)
+	}
+	if code := callapproval(t, CancelApproval, http.MethodDelete, requesteruid, body); code != resp.ErrApprovalDone {
+		t.Errorf("requester cancel again: want code %d, but get %d", resp.ErrApprovalDone, code)
+	}
+	if code := callapproval(t, ReviewApproval, http.MethodPut, approveruid, reviewbody(true)); code != resp.ErrApprovalDone {
+		t.Errorf("approve canceled: want code %d, but get %d", resp.ErrApprovalDone, code)
+	}
+}
+
+func Test_checkchangeapproval(t *testing.T) {
+	defer initapprovaldb(t)()
+	tests := []struct {
+		name       string
+		taskid     string
+		updatetime string
+		code       int
+	}{
+		{"not changed", approvaltask, utils.UnixToStr(taskupdatetime), resp.Success},
+		{"changed", approvaltask, utils.UnixToStr(taskupdatetime - 1), resp.ErrApprovalTaskChanged},
+		{"no update time", approvaltask, "", resp.ErrApprovalTaskChanged},
+		{"task not exist", "300000000000000009", utils.UnixToStr(taskupdatetime), resp.ErrTaskNotExist},
+	}
+	for _, test := range tests {
+		change := changetaskapproval{TaskUpdateTime: test.updatetime}
+		change.ID = test.taskid
+		content, err := json.Marshal(change)
+		if err != nil {
+			t.Fatalf("json.Marshal failed: %v", err)
+		}
+		approval := &define.Approval{Action: define.ApprovalChangeTask, TaskID: test.taskid, Content: string(content)}
+		if code := checkchangeapproval(context.Background(), approval); code != test.code {
+			t.Errorf("%s: want code %d, but get %d", test.name, test.code, code)
+		}
+	}
+
+	// 提交后任务被修改，不能通过审核
+	change := changetaskapproval{TaskUpdateTime: utils.UnixToStr(taskupdatetime - 1)}
+	change.ID = approvaltask
+	content, err := json.Marshal(change)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	createapproval(t, define.ApprovalChangeTask, string(content))
+	if code := callapproval(t, ReviewApproval, http.MethodPut, approveruid, reviewbody(true)); code != resp.ErrApprovalTaskChanged {
+		t.Errorf("approve changed task: want code %d, but get %d", resp.ErrApprovalTaskChanged, code)
+	}
+	approval, err := model.GetApprovalByID(context.Background(), approvalid)
+	if err != nil {
+		t.Fatalf("model.GetApprovalByID failed: %v", err)
+	}
+	if approval.Status != define.ApprovalPending {
+		t.Errorf("want approval still pending, but get %s", approval.Status)
+	}
+	// 审核人可以拒绝过期的请求
+	if code := callapproval(t, ReviewApproval, http.MethodPut, approveruid, reviewbody(false)); code != resp.Success {
+		t.Errorf("reject changed task: want code %d, but get %d", resp.Success, code)
+	}
+}
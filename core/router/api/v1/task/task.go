@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -53,6 +54,14 @@ func CreateTask(c *gin.Context) {
 		resp.JSON(c, resp.ErrCronExpr, nil)
 		return
 	}
+	if task.HostGroupID == "" && len(task.LabelSelector) == 0 {
+		resp.JSON(c, resp.ErrTaskNoHostTarget, nil)
+		return
+	}
+	if task.ShardTotal > 0 && task.RoutePolicy == define.Broadcast {
+		resp.JSON(c, resp.ErrTaskShardBroadcast, nil)
+		return
+	}
 
 	// TODO 检查任务数据
 	exist, err := model.Check(ctx, model.TBTask, model.Name, task.Name)
@@ -65,17 +74,38 @@ func CreateTask(c *gin.Context) {
 		resp.JSON(c, resp.ErrTaskExist, nil)
 		return
 	}
+	if code := checktaskproject(ctx, c, task.ProjectID, task.HostGroupID); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
 	// task.CreateByUID = c.GetString("uid")
 	task.Run = true
+	// 使用受保护的主机组创建任务需要审核
+	approverids, err := taskapprovers(ctx, nil, task.HostGroupID)
+	if err != nil {
+		log.Error("taskapprovers failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if needapproval(c.GetString("uid"), approverids) {
+		code := submitapproval(ctx, c, define.ApprovalCreateTask, "", task.Name, task, approverids)
+		resp.JSON(c, code, nil)
+		return
+	}
+	resp.JSON(c, createtask(ctx, &task, c.GetString("uid")), nil)
+}
+
+// createtask save new task and add it to schedule, return resp code
+func createtask(ctx context.Context, task *define.CreateTask, createbyuid string) int {
 	id := utils.GetID()
-	err = model.CreateTask(ctx, id, task.Name, task.TaskType, task.TaskData, true, task.ParentTaskIds, task.ParentRunParallel,
-		task.ChildTaskIds, task.ChildRunParallel, task.Cronexpr, task.Timeout, task.AlarmUserIds, task.RoutePolicy,
-		task.ExpectCode, task.ExpectContent, task.AlarmStatus, c.GetString("uid"), task.HostGroupID, task.Remark,
+	err := model.CreateTask(ctx, id, task.Name, task.TaskType, task.TaskData, task.Run, task.ParentTaskIds, task.ParentRunParallel,
+		task.ChildTaskIds, task.ChildRunParallel, task.Cronexpr, task.Timeout, task.AlarmUserIds, task.ApproverIds, task.RoutePolicy,
+		task.ExpectCode, task.ExpectContent, task.AlarmStatus, createbyuid, task.HostGroupID, task.ProjectID, task.LabelSelector,
+		task.BroadcastSuccess, task.BroadcastParallel, task.ShardTotal, task.Idempotent, task.FailoverLimit, task.Remark,
 	)
 	if err != nil {
 		log.Error("CreateTask failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
-		return
+		return resp.ErrInternalServer
 	}
 	event := schedule.EventData{
 		TaskID: id,
@@ -84,14 +114,13 @@ func CreateTask(c *gin.Context) {
 	res, err := json.Marshal(event)
 	if err != nil {
 		log.Error("json.Marshal failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
-		return
+		return resp.ErrInternalServer
 	}
 	schedule.Cron2.PubTaskEvent(res)
 	//log.Debug("start Add Schedule Cron", zap.String("taskid", id))
 	//schedule.Cron.Add(id, task.Name, task.Cronexpr,
-	//	schedule.GetRoutePolicy(task.HostGroupID, task.RoutePolicy))
-	resp.JSON(c, resp.Success, nil)
+	//	schedule.GetRoutePolicy(task.ID, task.HostGroupID, task.LabelSelector, task.RoutePolicy))
+	return resp.Success
 }
 
 // ChangeTask change task
@@ -121,6 +150,14 @@ func ChangeTask(c *gin.Context) {
 		resp.JSON(c, resp.ErrCronExpr, nil)
 		return
 	}
+	if task.HostGroupID == "" && len(task.LabelSelector) == 0 {
+		resp.JSON(c, resp.ErrTaskNoHostTarget, nil)
+		return
+	}
+	if task.ShardTotal > 0 && task.RoutePolicy == define.Broadcast {
+		resp.JSON(c, resp.ErrTaskShardBroadcast, nil)
+		return
+	}
 
 	exist, err := model.Check(ctx, model.TBTask, model.ID, task.ID)
 	if err != nil {
@@ -142,30 +179,56 @@ func ChangeTask(c *gin.Context) {
 		role = v.(define.Role)
 	}
 
-	// 这里只需要确定如果rule的用户类型是否为Admin
-	if role != define.AdminUser {
-		// 判断ID的创建人是否为uid
-		exist, err = model.Check(ctx, model.TBTask, model.IDCreateByUID, task.ID, uid)
-		if err != nil {
-			log.Error("IsExist failed", zap.Error(err))
-			resp.JSON(c, resp.ErrInternalServer, nil)
-			return
-		}
+	// 项目中的任务需要Editor角色，其他任务需要是创建人
+	exist, err = model.CheckTaskPermission(ctx, uid, role, task.ID, define.ProjectEditor)
+	if err != nil {
+		log.Error("model.CheckTaskPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 
-		if !exist {
-			resp.JSON(c, resp.ErrUnauthorized, nil)
-			return
-		}
+	if !exist {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	// 移动到其他项目时同样需要新项目的Editor角色
+	if code := checktaskproject(ctx, c, task.ProjectID, task.HostGroupID); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
 	}
+	// 受保护的任务或者移动到受保护的主机组需要审核
+	oldtask, err := model.GetTaskByID(ctx, task.ID)
+	if err != nil {
+		log.Error("model.GetTaskByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	approverids, err := taskapprovers(ctx, oldtask.ApproverIds, oldtask.HostGroupID, task.HostGroupID)
+	if err != nil {
+		log.Error("taskapprovers failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if needapproval(uid, approverids) {
+		// 保存提交时任务的更新时间，审核通过时任务已经被修改则拒绝执行
+		change := changetaskapproval{ChangeTask: task, TaskUpdateTime: oldtask.UpdateTime}
+		code := submitapproval(ctx, c, define.ApprovalChangeTask, task.ID, oldtask.Name, change, approverids)
+		resp.JSON(c, code, nil)
+		return
+	}
+	resp.JSON(c, changetask(ctx, &task), nil)
+}
 
-	err = model.ChangeTask(ctx, task.ID, task.Run, task.TaskType, task.TaskData, task.ParentTaskIds, task.ParentRunParallel,
-		task.ChildTaskIds, task.ChildRunParallel, task.Cronexpr, task.Timeout, task.AlarmUserIds, task.RoutePolicy,
-		task.ExpectCode, task.ExpectContent, task.AlarmStatus, task.HostGroupID, task.Remark,
+// changetask save task change and reload it in schedule, return resp code
+func changetask(ctx context.Context, task *define.ChangeTask) int {
+	err := model.ChangeTask(ctx, task.ID, task.Run, task.TaskType, task.TaskData, task.ParentTaskIds, task.ParentRunParallel,
+		task.ChildTaskIds, task.ChildRunParallel, task.Cronexpr, task.Timeout, task.AlarmUserIds, task.ApproverIds, task.RoutePolicy,
+		task.ExpectCode, task.ExpectContent, task.AlarmStatus, task.HostGroupID, task.ProjectID, task.LabelSelector,
+		task.BroadcastSuccess, task.BroadcastParallel, task.ShardTotal, task.Idempotent, task.FailoverLimit, task.Remark,
 	)
 	if err != nil {
 		log.Error("ChangeTask failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
-		return
+		return resp.ErrInternalServer
 	}
 	event := schedule.EventData{
 		TaskID: task.ID,
@@ -174,14 +237,12 @@ func ChangeTask(c *gin.Context) {
 	res, err := json.Marshal(event)
 	if err != nil {
 		log.Error("json.Marshal failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
-		return
+		return resp.ErrInternalServer
 	}
 	schedule.Cron2.PubTaskEvent(res)
 	//schedule.Cron.Add(task.ID, task.Name, task.Cronexpr,
-	//	schedule.GetRoutePolicy(task.HostGroupID, task.RoutePolicy))
-
-	resp.JSON(c, resp.Success, nil)
+	//	schedule.GetRoutePolicy(task.ID, task.HostGroupID, task.LabelSelector, task.RoutePolicy))
+	return resp.Success
 }
 
 // DeleteTask delete task
@@ -227,20 +288,17 @@ func DeleteTask(c *gin.Context) {
 		role = v.(define.Role)
 	}
 
-	// 这里只需要确定如果rule的用户类型是否为Admin
-	if role != define.AdminUser {
-		// 判断ID的创建人是否为uid
-		exist, err = model.Check(ctx, model.TBTask, model.IDCreateByUID, deletetask.ID, uid)
-		if err != nil {
-			log.Error("model.Check failed", zap.Error(err))
-			resp.JSON(c, resp.ErrInternalServer, nil)
-			return
-		}
+	// 项目中的任务需要Editor角色，其他任务需要是创建人
+	exist, err = model.CheckTaskPermission(ctx, uid, role, deletetask.ID, define.ProjectEditor)
+	if err != nil {
+		log.Error("model.CheckTaskPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 
-		if !exist {
-			resp.JSON(c, resp.ErrUnauthorized, nil)
-			return
-		}
+	if !exist {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
 	}
 
 	usecount, err := model.TaskIsUse(ctx, deletetask.ID)
@@ -290,6 +348,7 @@ func DeleteTask(c *gin.Context) {
 // @Param limit query int false "Limit"
 // @Param psname query string false "PreSearchName"
 // @Param self query bool false "Self Create Task"
+// @Param project_id query string false "ProjectID"
 // @Produce json
 // @Success 200 {object} resp.Response
 // @Router /api/v1/task [get]
@@ -300,8 +359,9 @@ func GetTasks(c *gin.Context) {
 	defer cancel()
 	type GetQuery struct {
 		define.Query
-		PSName string `form:"psname"`
-		Self   bool   `form:"self"`
+		PSName    string `form:"psname"`
+		Self      bool   `form:"self"`
+		ProjectID string `form:"project_id"`
 	}
 	var (
 		q   GetQuery
@@ -320,7 +380,12 @@ func GetTasks(c *gin.Context) {
 	if q.Self {
 		createby = c.GetString("uid")
 	}
-	hgs, count, err := model.GetTasks(ctx, q.Offset, q.Limit, "", q.PSName, createby)
+	// 只返回不属于任何项目和用户所在项目的任务
+	var visibleuid string
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		visibleuid = c.GetString("uid")
+	}
+	hgs, count, err := model.GetTasks(ctx, q.Offset, q.Limit, "", q.PSName, createby, q.ProjectID, visibleuid)
 	if err != nil {
 		log.Error("GetTasks failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -356,6 +421,16 @@ func GetTask(c *gin.Context) {
 
 	switch err.(type) {
 	case nil:
+		ok, err := checkviewtask(ctx, c, t)
+		if err != nil {
+			log.Error("checkviewtask failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+		if !ok {
+			resp.JSON(c, resp.ErrUnauthorized, nil)
+			return
+		}
 		resp.JSON(c, resp.Success, t)
 	case define.ErrNotExist:
 		resp.JSON(c, resp.ErrTaskNotExist, nil)
@@ -396,35 +471,59 @@ func RunTask(c *gin.Context) {
 		role = v.(define.Role)
 	}
 
-	// 这里只需要确定如果rule的用户类型是否为Admin
-	if role != define.AdminUser {
-		// 判断ID的创建人是否为uid
-		exist, err := model.Check(ctx, model.TBHostgroup, model.IDCreateByUID, runtask.ID, uid)
-		if err != nil {
-			log.Error("model.Check failed", zap.Error(err))
-			resp.JSON(c, resp.ErrInternalServer, nil)
-			return
-		}
+	// 项目中的任务需要Operator角色，其他任务需要是创建人
+	ok, err := model.CheckTaskPermission(ctx, uid, role, runtask.ID, define.ProjectOperator)
+	if err != nil {
+		log.Error("model.CheckTaskPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 
-		if !exist {
-			resp.JSON(c, resp.ErrUnauthorized, nil)
-			return
-		}
+	if !ok {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	// 手动运行受保护的任务需要审核
+	t, err := model.GetTaskByID(ctx, runtask.ID)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		resp.JSON(c, resp.ErrTaskNotExist, nil)
+		return
+	default:
+		log.Error("model.GetTaskByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
 	}
+	approverids, err := taskapprovers(ctx, t.ApproverIds, t.HostGroupID)
+	if err != nil {
+		log.Error("taskapprovers failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if needapproval(uid, approverids) {
+		code := submitapproval(ctx, c, define.ApprovalRunTask, t.ID, t.Name, runtask, approverids)
+		resp.JSON(c, code, nil)
+		return
+	}
+	resp.JSON(c, runtasknow(runtask.ID), nil)
+}
+
+// runtasknow start run task now, return resp code
+func runtasknow(id string) int {
 	//go schedule.Cron.RunTask(runtask.ID, define.Manual)
 
 	event := schedule.EventData{
-		TaskID: runtask.ID,
+		TaskID: id,
 		TE:     schedule.RunEvent,
 	}
 	res, err := json.Marshal(event)
 	if err != nil {
 		log.Error("json.Marshal failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
-		return
+		return resp.ErrInternalServer
 	}
 	schedule.Cron2.PubTaskEvent(res)
-	resp.JSON(c, resp.Success, nil)
+	return resp.Success
 }
 
 // KillTask kill running task
@@ -436,6 +535,10 @@ func RunTask(c *gin.Context) {
 // @Router /api/v1/task/kill [put]
 // @Security ApiKeyAuth
 func KillTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
 	runtask := define.GetID{}
 	err := c.ShouldBindJSON(&runtask)
 	if err != nil {
@@ -446,6 +549,21 @@ func KillTask(c *gin.Context) {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	// 项目中的任务需要Operator角色，其他任务需要是创建人
+	ok, err := model.CheckTaskPermission(ctx, c.GetString("uid"), role, runtask.ID, define.ProjectOperator)
+	if err != nil {
+		log.Error("model.CheckTaskPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !ok {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
 	event := schedule.EventData{
 		TaskID: runtask.ID,
 		TE:     schedule.KillEvent,
@@ -489,6 +607,29 @@ func GetRunningTask(c *gin.Context) {
 	if err != nil {
 		resp.JSON(c, resp.ErrInternalServer, nil)
 	}
+	// 只返回不属于任何项目和用户所在项目的任务
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			config.CoreConf.Server.DB.MaxQueryTime.Duration)
+		defer cancel()
+		visibletasks, err := model.GetVisibleNameID(ctx, model.TBTask, c.GetString("uid"))
+		if err != nil {
+			log.Error("model.GetVisibleNameID failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+		visible := make(map[string]bool, len(visibletasks))
+		for _, t := range visibletasks {
+			visible[t.Value] = true
+		}
+		tasks := make([]*define.RunTask, 0, len(allrunningtasks))
+		for _, t := range allrunningtasks {
+			if visible[t.ID] {
+				tasks = append(tasks, t)
+			}
+		}
+		allrunningtasks = tasks
+	}
 	if len(runningtasks) < q.Offset {
 		runningtasks = []*define.RunTask{}
 	} else if len(allrunningtasks) >= q.Offset && len(allrunningtasks) < q.Offset+q.Limit {
@@ -539,7 +680,12 @@ func LogTask(c *gin.Context) {
 	if q.Limit == 0 {
 		q.Limit = define.DefaultLimit
 	}
-	logs, count, err := model.GetLog(ctx, name, status, q.Offset, q.Limit)
+	// 不返回用户看不到的项目中任务的日志
+	var visibleuid string
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		visibleuid = c.GetString("uid")
+	}
+	logs, count, err := model.GetLog(ctx, name, status, q.Offset, q.Limit, visibleuid)
 	if err != nil {
 		log.Error("GetLog failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -581,6 +727,26 @@ func LogTreeData(c *gin.Context) {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
+	t, err := model.GetTaskByID(ctx, getid.ID)
+	switch err.(type) {
+	case nil:
+		ok, err := checkviewtask(ctx, c, t)
+		if err != nil {
+			log.Error("checkviewtask failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+		if !ok {
+			resp.JSON(c, resp.ErrUnauthorized, nil)
+			return
+		}
+	case define.ErrNotExist:
+		// 已删除任务的日志
+	default:
+		log.Error("model.GetTaskByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 	TaskTreeStatus, err := model.GetTreeLog(ctx, getid.ID, starttimeint)
 	if err != nil {
 		log.Error("model.GetTreeLog", zap.Error(err))
@@ -600,87 +766,464 @@ var upgrade = websocket.Upgrader{
 
 var (
 	defaultSendTTL = 2 * time.Second
+	// interval of wait new log when no log can read
+	defaultLogWait = time.Second
+	// interval of send ping to client
+	defaultPingInterval = 10 * time.Second
+	// client must send pong or other message in this time
+	defaultPongWait = 3 * defaultPingInterval
 )
 
-// RealRunTaskLog return real time log
-// GET /api/v1/task/log/websocket?id=manid&realid=ididididid&type=
-func RealRunTaskLog(c *gin.Context) {
-	conn, err := upgrade.Upgrade(c.Writer, c.Request, nil)
+// reallogquery real log request params
+// id read the log of task's current run
+// runid read the log of the run whether it is running or finished
+type reallogquery struct {
+	ID      string `form:"id" binding:"omitempty,len=18"`
+	RunID   string `form:"runid" binding:"omitempty,len=18"`
+	RealID  string `form:"realid" binding:"required"`
+	RunType int    `form:"type" binding:"required"`
+	Offset  int64  `form:"offset"`
+}
+
+// parsereallogquery parse real log request params
+// sse client reconnect will send Last-Event-ID, it is the last read offset
+func parsereallogquery(c *gin.Context) (*reallogquery, error) {
+	query := reallogquery{}
+	err := c.ShouldBindQuery(&query)
 	if err != nil {
-		log.Error("Upgrade failed", zap.Error(err))
-		return
+		return nil, err
 	}
-	defer conn.Close()
-	getid := define.GetID{}
-	err = c.BindQuery(&getid)
+	if query.ID == "" && query.RunID == "" {
+		return nil, errors.New("id or runid is required")
+	}
+	if lastid := c.GetHeader("Last-Event-ID"); lastid != "" {
+		query.Offset, err = strconv.ParseInt(lastid, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("strconv.ParseInt failed: %w", err)
+		}
+	}
+	if query.Offset < 0 {
+		return nil, errors.New("offset must be greater than or equal to 0")
+	}
+	return &query, nil
+}
+
+// checkwatchtask check user could watch the task's log or status,
+// same as checkviewtask, the user alarm to could also watch the task
+func checkwatchtask(ctx context.Context, c *gin.Context, taskid string) (bool, error) {
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if role == define.AdminUser {
+		return true, nil
+	}
+	task, err := model.GetTaskByID(ctx, taskid)
 	if err != nil {
-		resp.JSON(c, resp.ErrBadRequest, nil)
-		return
+		return false, fmt.Errorf("model.GetTaskByID failed: %w", err)
+	}
+	uid := c.GetString("uid")
+	for _, alarmuid := range task.AlarmUserIds {
+		if alarmuid == uid {
+			return true, nil
+		}
 	}
-	realid := c.Query("realid")
-	taskruntype, err := strconv.Atoi(c.Query("type"))
+	return checkviewtask(ctx, c, task)
+}
+
+// checkviewtask check user could view the task's info,
+// task not in any project could be seen by everyone
+func checkviewtask(ctx context.Context, c *gin.Context, task *define.GetTask) (bool, error) {
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	return model.CheckProjectPermission(ctx, c.GetString("uid"), role, task.ProjectID, define.ProjectViewer)
+}
+
+// checktaskproject check user could create task in project and the task could use the hostgroup,
+// return resp code
+func checktaskproject(ctx context.Context, c *gin.Context, projectid, hostgroupid string) int {
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if projectid != "" {
+		exist, err := model.Check(ctx, model.TBProject, model.ID, projectid)
+		if err != nil {
+			log.Error("model.Check failed", zap.Error(err))
+			return resp.ErrInternalServer
+		}
+		if !exist {
+			return resp.ErrProjectNotExist
+		}
+	}
+	ok, err := model.CheckProjectPermission(ctx, c.GetString("uid"), role, projectid, define.ProjectEditor)
 	if err != nil {
-		log.Error("can get valid task type", zap.Error(err))
-		conn.WriteMessage(websocket.TextMessage, []byte("can get task type"))
-		return
+		log.Error("model.CheckProjectPermission failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !ok {
+		return resp.ErrUnauthorized
+	}
+	if hostgroupid == "" {
+		return resp.Success
+	}
+	hg, err := model.GetHostGroupByID(ctx, hostgroupid)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		return resp.ErrHostgroupNotExist
+	default:
+		log.Error("model.GetHostGroupByID failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	// 只能使用同一项目或者不属于任何项目的主机组
+	if hg.ProjectID != "" && hg.ProjectID != projectid {
+		return resp.ErrProjectHostGroup
 	}
+	return resp.Success
+}
 
-	task, ok := schedule.Cron2.GetTask(getid.ID)
+// checklogquery fill the task id of runid and check user could watch it
+func checklogquery(c *gin.Context, query *reallogquery) error {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	if query.RunID != "" {
+		taskid, err := schedule.Cron2.GetRunTaskID(ctx, query.RunID)
+		if err != nil {
+			return fmt.Errorf("Cron2.GetRunTaskID failed: %w", err)
+		}
+		query.ID = taskid
+	}
+	ok, err := checkwatchtask(ctx, c, query.ID)
+	if err != nil {
+		return fmt.Errorf("checkwatchtask failed: %w", err)
+	}
 	if !ok {
-		log.Error("can get taskid", zap.String("taskid", getid.ID))
-		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("can get taskid %s", getid.ID)))
-		return
+		return errors.New(resp.GetMsg(resp.ErrUnauthorized))
+	}
+	return nil
+}
+
+// logreader read log start from byte offset
+// return io.EOF if log is read over, return schedule.ErrNoGetLog if need wait new log
+type logreader func(ctx context.Context, offset int64) ([]byte, error)
+
+// newlogreader return logreader by query
+func newlogreader(query *reallogquery) (logreader, error) {
+	taskruntype := define.TaskRespType(query.RunType)
+	if query.RunID != "" {
+		return func(ctx context.Context, offset int64) ([]byte, error) {
+			ctx, cancel := context.WithTimeout(ctx,
+				config.CoreConf.Server.DB.MaxQueryTime.Duration)
+			defer cancel()
+			return schedule.Cron2.GetRunLog(ctx, query.RunID, taskruntype, query.RealID, offset)
+		}, nil
+	}
+
+	task, ok := schedule.Cron2.GetTask(query.ID)
+	if !ok {
+		return nil, fmt.Errorf("can get taskid %s", query.ID)
+	}
+	return func(ctx context.Context, offset int64) ([]byte, error) {
+		output, err := task.GetTaskRealLog(taskruntype, query.RealID, offset)
+		if !errors.Is(err, schedule.ErrNoGetLog) {
+			return output, err
+		}
+		// if can get data,check task is running ,is task is stop then close
+		ok, err := schedule.Cron2.IsRunning(query.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Cron2.IsRunning failed: %w", err)
+		}
+		if !ok {
+			log.Warn("task is not running ", zap.String("taskid", query.ID))
+			return nil, io.EOF
+		}
+		return nil, schedule.ErrNoGetLog
+	}, nil
+}
+
+// logerrmsg return read log error msg send to client
+func logerrmsg(err error) string {
+	if errors.Is(err, redis.Nil) {
+		return "task is run finished"
+	}
+	if errors.As(err, &define.ErrNotExist{}) {
+		return resp.GetMsg(resp.ErrTaskLogNotExist)
+	}
+	return err.Error()
+}
+
+// tailtasklog read task real log from query.Offset and send it by send
+// until task run finished or ctx is done
+func tailtasklog(ctx context.Context, query *reallogquery, send func(*define.LogFrame) error) error {
+	offset := query.Offset
+	read, err := newlogreader(query)
+	if err != nil {
+		log.Error("newlogreader failed", zap.Error(err))
+		return send(&define.LogFrame{
+			Type:   define.LogFrameError,
+			Offset: offset,
+			Data:   err.Error(),
+		})
 	}
-	var offset int64
 	for {
-		output, err := task.GetTaskRealLog(define.TaskRespType(taskruntype), realid, offset)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		output, err := read(ctx, offset)
 		if err == nil {
-			offset++
-			err = conn.WriteMessage(websocket.TextMessage, output)
+			offset += int64(len(output))
+			err = send(&define.LogFrame{
+				Type:   define.LogFrameData,
+				Offset: offset,
+				Data:   string(output),
+			})
 			if err != nil {
-				log.Error("WriteMessage failed", zap.Error(err))
-				return
+				return fmt.Errorf("send log failed: %w", err)
 			}
-			_, _, err := conn.ReadMessage()
-			if err != nil {
-				log.Error("ReadMessage failed", zap.Error(err))
-				return
-			}
-			time.Sleep(time.Millisecond * 10)
 			continue
 		}
 		if errors.Is(err, io.EOF) {
 			log.Debug("read task log over")
-			// conn.WriteMessage(websocket.TextMessage, []byte("task run finished"))
-			return
+			return send(&define.LogFrame{Type: define.LogFrameEnd, Offset: offset})
 		} else if errors.Is(err, schedule.ErrNoGetLog) {
 			log.Debug("can not get new data, please wait some time")
-			// if can get data,check task is running ,is task is stop then close websocket
-			ok, err := schedule.Cron2.IsRunning(getid.ID)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(defaultLogWait):
+			}
+		} else {
+			log.Error("read task log failed", zap.Error(err))
+			return send(&define.LogFrame{
+				Type:   define.LogFrameError,
+				Offset: offset,
+				Data:   logerrmsg(err),
+			})
+		}
+	}
+}
+
+// ReadTaskLog read task log once start from offset
+// @Summary read task log by run id
+// @Tags Task
+// @Param runid query string false "RunID"
+// @Param id query string false "ID"
+// @Param realid query string true "RealID"
+// @Param type query int true "Task Run Type"
+// @Param offset query int false "Read Log Byte Offset"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/task/log/read [get]
+// @Security ApiKeyAuth
+func ReadTaskLog(c *gin.Context) {
+	query, err := parsereallogquery(c)
+	if err != nil {
+		log.Error("parsereallogquery failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	err = checklogquery(c, query)
+	if err != nil {
+		log.Error("checklogquery failed", zap.Error(err))
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	read, err := newlogreader(query)
+	if err != nil {
+		log.Error("newlogreader failed", zap.Error(err))
+		resp.JSON(c, resp.ErrTaskNotExist, nil)
+		return
+	}
+	output, err := read(context.Background(), query.Offset)
+	switch {
+	case err == nil:
+		resp.JSON(c, resp.Success, &define.LogFrame{
+			Type:   define.LogFrameData,
+			Offset: query.Offset + int64(len(output)),
+			Data:   string(output),
+		})
+	case errors.Is(err, io.EOF):
+		resp.JSON(c, resp.Success, &define.LogFrame{Type: define.LogFrameEnd, Offset: query.Offset})
+	case errors.Is(err, schedule.ErrNoGetLog):
+		// no new log now,client should read again later with the same offset
+		resp.JSON(c, resp.Success, &define.LogFrame{Type: define.LogFrameData, Offset: query.Offset})
+	case errors.As(err, &define.ErrNotExist{}):
+		resp.JSON(c, resp.ErrTaskLogNotExist, nil)
+	default:
+		log.Error("read task log failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+	}
+}
+
+// RealRunTaskLog return real time log
+// GET /api/v1/task/log/websocket?id=manid&realid=ididididid&type=&offset=0
+// GET /api/v1/task/log/websocket?runid=runid&realid=ididididid&type=&offset=0
+// server push log frame define.LogFrame,client could reconnect with last frame offset to resume read log
+func RealRunTaskLog(c *gin.Context) {
+	conn, err := upgrade.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Error("Upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	err = middleware.CheckWebsocketAuth(c, conn)
+	if err != nil {
+		log.Error("CheckWebsocketAuth failed", zap.Error(err))
+		conn.WriteJSON(&define.LogFrame{Type: define.LogFrameError, Data: resp.GetMsg(resp.ErrUnauthorized)})
+		return
+	}
+
+	query, err := parsereallogquery(c)
+	if err != nil {
+		log.Error("parsereallogquery failed", zap.Error(err))
+		conn.WriteJSON(&define.LogFrame{Type: define.LogFrameError, Data: err.Error()})
+		return
+	}
+	err = checklogquery(c, query)
+	if err != nil {
+		log.Error("checklogquery failed", zap.Error(err))
+		conn.WriteJSON(&define.LogFrame{Type: define.LogFrameError, Data: logerrmsg(err)})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// read client message,client don't need send ack after recv log frame
+	// if client close conn or no pong in defaultPongWait, stop send log
+	conn.SetReadDeadline(time.Now().Add(defaultPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(defaultPongWait))
+	})
+	go func() {
+		defer cancel()
+		for {
+			_, _, err := conn.ReadMessage()
 			if err != nil {
-				log.Error("Cron2.IsRunning failed", zap.Error(err))
+				log.Debug("ReadMessage failed", zap.Error(err))
 				return
 			}
-			if !ok {
-				log.Warn("task is not running ", zap.String("taskid", getid.ID))
+			conn.SetReadDeadline(time.Now().Add(defaultPongWait))
+		}
+	}()
+
+	// heartbeat
+	go func() {
+		ticker := time.NewTicker(defaultPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
 				return
+			case <-ticker.C:
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(defaultSendTTL))
+				if err != nil {
+					log.Error("send ping failed", zap.Error(err))
+					cancel()
+					return
+				}
 			}
-			time.Sleep(time.Second)
-		} else {
-			var erroutput []byte
-			if errors.Is(err, redis.Nil) {
-				erroutput = []byte("task is run finished")
-			} else {
-				log.Error("read task log failed", zap.Error(err))
-				erroutput = []byte(err.Error())
-			}
-			err = conn.WriteMessage(websocket.TextMessage, erroutput)
-			if err != nil {
-				log.Error("WriteMessage failed", zap.Error(err))
+		}
+	}()
+
+	err = tailtasklog(ctx, query, func(frame *define.LogFrame) error {
+		return conn.WriteJSON(frame)
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Error("tailtasklog failed", zap.Error(err))
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(defaultSendTTL))
+}
+
+// RealRunTaskLogSSE return real time log by server-sent events
+// @Summary get task real log by server-sent events
+// @Tags Task
+// @Param runid query string false "RunID"
+// @Param id query string false "ID"
+// @Param realid query string true "RealID"
+// @Param type query int true "Task Run Type"
+// @Param offset query int false "Read Log Byte Offset"
+// @Param token query string false "Token, browser EventSource can not set Authorization header"
+// @Produce text/event-stream
+// @Success 200 {object} define.LogFrame
+// @Router /api/v1/task/log/sse [get]
+// @Security ApiKeyAuth
+func RealRunTaskLogSSE(c *gin.Context) {
+	query, err := parsereallogquery(c)
+	if err != nil {
+		log.Error("parsereallogquery failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	err = checklogquery(c, query)
+	if err != nil {
+		log.Error("checklogquery failed", zap.Error(err))
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
 
+	// heartbeat and log frame write in different goroutine
+	var lock sync.Mutex
+	go func() {
+		ticker := time.NewTicker(defaultPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				lock.Lock()
+				// sse comment line,client will ignore it
+				_, err := io.WriteString(c.Writer, ": ping\n\n")
+				if err == nil {
+					c.Writer.Flush()
+				}
+				lock.Unlock()
+				if err != nil {
+					log.Error("send ping failed", zap.Error(err))
+					cancel()
+					return
+				}
 			}
-			return
 		}
+	}()
+
+	err = tailtasklog(ctx, query, func(frame *define.LogFrame) error {
+		lock.Lock()
+		defer lock.Unlock()
+		data, err := json.Marshal(frame)
+		if err != nil {
+			return fmt.Errorf("json.Marshal failed: %w", err)
+		}
+		// event id is next read offset,so browser EventSource will resume from it by Last-Event-ID
+		_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", frame.Offset, frame.Type, data)
+		if err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Error("tailtasklog failed", zap.Error(err))
 	}
 }
 
@@ -703,14 +1246,19 @@ func RealRunTaskStatus(c *gin.Context) {
 
 	log.Debug("start get real task status", zap.String("taskid", getid.ID))
 
-	_, token, err := conn.ReadMessage()
+	err = middleware.CheckWebsocketAuth(c, conn)
 	if err != nil {
-		conn.WriteMessage(websocket.TextMessage, []byte("get token fail"))
+		log.Error("CheckWebsocketAuth failed", zap.Error(err))
+		conn.WriteMessage(websocket.TextMessage, []byte("check token auth fail"))
 		return
 	}
-	_, _, pass := middleware.CheckToken(string(token))
-	if !pass {
-		conn.WriteMessage(websocket.TextMessage, []byte("check token auth fail"))
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	ok, err := checkwatchtask(ctx, c, getid.ID)
+	if err != nil || !ok {
+		log.Error("checkwatchtask failed", zap.String("taskid", getid.ID), zap.Error(err))
+		conn.WriteMessage(websocket.TextMessage, []byte(resp.GetMsg(resp.ErrUnauthorized)))
 		return
 	}
 	task, ok := schedule.Cron2.GetTask(getid.ID)
@@ -793,7 +1341,11 @@ func GetSelect(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(),
 		config.CoreConf.Server.DB.MaxQueryTime.Duration)
 	defer cancel()
-	data, err := model.GetNameID(ctx, model.TBTask)
+	var visibleuid string
+	if v, ok := c.Get("role"); ok && v.(define.Role) != define.AdminUser {
+		visibleuid = c.GetString("uid")
+	}
+	data, err := model.GetVisibleNameID(ctx, model.TBTask, visibleuid)
 	if err != nil {
 		log.Error("model.GetNameID failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
@@ -848,49 +1400,62 @@ func CloneTask(c *gin.Context) {
 		return
 	}
 Next:
-	id := utils.GetID()
-	if id == "" {
-		log.Error("utils.GetID return empty", zap.Error(err))
+	// 克隆的任务和原任务属于同一个项目
+	ok, err := checkviewtask(ctx, c, task)
+	if err != nil {
+		log.Error("checkviewtask failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
 		return
 	}
-	err = model.CreateTask(ctx,
-		id,
-		clonetask.Name,
-		task.TaskType,
-		task.TaskData,
-		task.Run,
-		task.ParentTaskIds,
-		task.ParentRunParallel,
-		task.ChildTaskIds,
-		task.ChildRunParallel,
-		task.Cronexpr,
-		task.Timeout,
-		task.AlarmUserIds,
-		task.RoutePolicy,
-		task.ExpectCode,
-		task.ExpectContent,
-		task.AlarmStatus,
-		c.GetString("uid"),
-		task.HostGroupID,
-		fmt.Sprintf("从任务%s克隆", task.Name))
-	if err != nil {
-		log.Error(" model.CreateTask failed", zap.Error(err))
-		resp.JSON(c, resp.ErrInternalServer, nil)
+	if !ok {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
 		return
 	}
-	event := schedule.EventData{
-		TaskID: id,
-		TE:     schedule.AddEvent,
+	if code := checktaskproject(ctx, c, task.ProjectID, task.HostGroupID); code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
 	}
-	res, err := json.Marshal(event)
+	newtask := define.CreateTask{}
+	newtask.Name = clonetask.Name
+	newtask.Task = define.Task{
+		TaskType:          task.TaskType,
+		TaskData:          task.TaskData,
+		Run:               task.Run,
+		ParentTaskIds:     task.ParentTaskIds,
+		ParentRunParallel: task.ParentRunParallel,
+		ChildTaskIds:      task.ChildTaskIds,
+		ChildRunParallel:  task.ChildRunParallel,
+		HostGroupID:       task.HostGroupID,
+		ProjectID:         task.ProjectID,
+		Cronexpr:          task.Cronexpr,
+		Timeout:           task.Timeout,
+		AlarmUserIds:      task.AlarmUserIds,
+		ApproverIds:       task.ApproverIds,
+		LabelSelector:     task.LabelSelector,
+		RoutePolicy:       task.RoutePolicy,
+		BroadcastSuccess:  task.BroadcastSuccess,
+		BroadcastParallel: task.BroadcastParallel,
+		ShardTotal:        task.ShardTotal,
+		Idempotent:        task.Idempotent,
+		FailoverLimit:     task.FailoverLimit,
+		ExpectCode:        task.ExpectCode,
+		ExpectContent:     task.ExpectContent,
+		AlarmStatus:       task.AlarmStatus,
+		Remark:            fmt.Sprintf("从任务%s克隆", task.Name),
+	}
+	// 和创建任务一样，使用受保护的主机组需要审核
+	approverids, err := taskapprovers(ctx, nil, task.HostGroupID)
 	if err != nil {
-		log.Error("json.Marshal failed", zap.Error(err))
+		log.Error("taskapprovers failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
 		return
 	}
-	schedule.Cron2.PubTaskEvent(res)
-	resp.JSON(c, resp.Success, nil)
+	if needapproval(c.GetString("uid"), approverids) {
+		code := submitapproval(ctx, c, define.ApprovalCreateTask, "", newtask.Name, newtask, approverids)
+		resp.JSON(c, code, nil)
+		return
+	}
+	resp.JSON(c, createtask(ctx, &newtask, c.GetString("uid")), nil)
 }
 
 // CleanTaskLog clean old task log
@@ -934,20 +1499,23 @@ func CleanTaskLog(c *gin.Context) {
 		role = v.(define.Role)
 	}
 
-	// 这里只需要确定如果rule的用户类型是否为Admin
-	if role != define.AdminUser {
-		// 判断任务的创建人是否为当前用户
-		exist, err = model.Check(ctx, model.TBTask, model.NameCreateByUID, cleanlog.Name, c.GetString("uid"))
-		if err != nil {
-			log.Error("IsExist failed", zap.Error(err))
-			resp.JSON(c, resp.ErrInternalServer, nil)
-			return
-		}
+	// 项目中的任务需要Editor角色，其他任务需要是创建人
+	task, err := model.GetTaskByName(ctx, cleanlog.Name)
+	if err != nil {
+		log.Error("model.GetTaskByName failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	exist, err = model.CheckTaskPermission(ctx, c.GetString("uid"), role, task.ID, define.ProjectEditor)
+	if err != nil {
+		log.Error("model.CheckTaskPermission failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 
-		if !exist {
-			resp.JSON(c, resp.ErrUnauthorized, nil)
-			return
-		}
+	if !exist {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
 	}
 
 	deletetime := (time.Now().UnixNano() - int64(time.Hour)*24*cleanlog.PreDay) / 1e6
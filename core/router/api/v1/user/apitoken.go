@@ -0,0 +1,142 @@
+package user
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/utils"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// GetAPITokens return api tokens of self
+// @Summary get api tokens
+// @Tags User
+// @Description get self api tokens with last used time, plain token is only return when created
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/apitoken [get]
+// @Security ApiKeyAuth
+func GetAPITokens(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	var (
+		q   define.Query
+		err error
+	)
+	err = c.BindQuery(&q)
+	if err != nil {
+		log.Error("BindQuery offset failed", zap.Error(err))
+	}
+	if q.Limit == 0 {
+		q.Limit = define.DefaultLimit
+	}
+	apitokens, count, err := model.GetAPITokens(ctx, c.GetString("uid"), q.Offset, q.Limit)
+	if err != nil {
+		log.Error("model.GetAPITokens failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, apitokens, count)
+}
+
+// CreateAPIToken create api token
+// @Summary create api token
+// @Tags User
+// @Description create api token for automation, scope is read, run or write, can be limited to tasks
+// @Param APIToken body define.CreateAPIToken true "APIToken"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/apitoken [post]
+// @Security ApiKeyAuth
+func CreateAPIToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	req := define.CreateAPIToken{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		log.Error("c.ShouldBindJSON", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	for _, taskid := range req.TaskIDs {
+		if utils.CheckID(taskid) != nil {
+			resp.JSON(c, resp.ErrBadRequest, nil)
+			return
+		}
+		exist, err := model.Check(ctx, model.TBTask, model.ID, taskid)
+		if err != nil {
+			log.Error("model.Check failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+		if !exist {
+			resp.JSON(c, resp.ErrTaskNotExist, nil)
+			return
+		}
+	}
+	apitoken, err := model.CreateAPIToken(ctx, req, c.GetString("uid"))
+	if err != nil {
+		log.Error("model.CreateAPIToken failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	apitoken.UserName = c.GetString("username")
+	resp.JSON(c, resp.Success, apitoken)
+}
+
+// DeleteAPIToken delete api token
+// @Summary delete api token
+// @Tags User
+// @Description delete api token of self, admin can delete any user's api token
+// @Param APIToken body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/apitoken [delete]
+// @Security ApiKeyAuth
+func DeleteAPIToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	getid := define.GetID{}
+	err := c.ShouldBindJSON(&getid)
+	if err != nil {
+		log.Error("c.ShouldBindJSON", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	apitoken, err := model.GetAPITokenByID(ctx, getid.ID)
+	switch err.(type) {
+	case nil:
+	case define.ErrNotExist:
+		resp.JSON(c, resp.ErrAPITokenNotExist, nil)
+		return
+	default:
+		log.Error("model.GetAPITokenByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if apitoken.UID != c.GetString("uid") && role != define.AdminUser {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	err = model.DeleteAPIToken(ctx, getid.ID)
+	if err != nil {
+		log.Error("model.DeleteAPIToken failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
@@ -0,0 +1,238 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/ldapauth"
+	"github.com/labulaka521/crocodile/core/loginguard"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// 登陆保护
+// 登陆前检查用户名和IP的登陆频率以及用户是否被锁定，密码错误时累计失败次数，
+// 所有登陆请求都记录到操作日志中
+
+// ChangeExpiredPassword change expired password and login
+// @Summary change expired password
+// @Tags User
+// @Description user whose password is expired change password by old password and login
+// @Param Password body define.ChangeExpiredPassword true "New Password"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/login/password [put]
+// @Security BasicAuth
+func ChangeExpiredPassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	if passwordLoginDisabled() {
+		resp.JSON(c, resp.ErrPasswordLoginDisabled, nil)
+		return
+	}
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	req := define.ChangeExpiredPassword{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	code := checklogin(username, remoteip(c))
+	if code != resp.Success {
+		savelogin(ctx, c, username, "修改过期密码", code)
+		resp.JSON(c, code, nil)
+		return
+	}
+	if req.Password == password {
+		resp.JSON(c, resp.ErrPasswordReuse, nil)
+		return
+	}
+	err = loginguard.CheckPassword(req.Password)
+	if err != nil {
+		log.Error("loginguard.CheckPassword failed", zap.Error(err))
+		resp.JSON(c, resp.ErrPasswordPolicy, err.Error())
+		return
+	}
+	// 修改密码前先校验密码和两步验证码
+	_, err = model.CheckUserPassword(ctx, username, password)
+	if err != nil {
+		log.Error("model.CheckUserPassword failed", zap.Error(err))
+	}
+	code = loginresult(ctx, username, err, req.TOTPCode)
+	if code != resp.Success {
+		savelogin(ctx, c, username, "修改过期密码", code)
+		resp.JSON(c, code, nil)
+		return
+	}
+	token, err := model.ChangeExpiredPassword(ctx, username, password, req.Password)
+	if err != nil {
+		log.Error("model.ChangeExpiredPassword failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	savelogin(ctx, c, username, "修改过期密码", resp.Success)
+	resp.JSON(c, resp.Success, token)
+}
+
+// AdminUnlockUser unlock user which is locked by too many failed logins
+// @Summary admin unlock user
+// @Tags User
+// @Description unlock user which is locked by too many failed logins
+// @Param User body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/admin/unlock [put]
+// @Security ApiKeyAuth
+func AdminUnlockUser(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	user := define.GetID{}
+	err := c.ShouldBindJSON(&user)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if role != define.AdminUser {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	userinfo, err := model.GetUserByID(ctx, user.ID)
+	if err != nil {
+		log.Error("model.GetUserByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrUserNotExist, nil)
+		return
+	}
+	err = loginguard.Unlock(userinfo.Name)
+	if err != nil {
+		log.Error("loginguard.Unlock failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// checklogin check login rate limit and whether user is locked
+func checklogin(name, ip string) int {
+	allow, err := loginguard.Allow(name, ip)
+	if err != nil {
+		log.Error("loginguard.Allow failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !allow {
+		log.Warn("login too frequent", zap.String("name", name), zap.String("ip", ip))
+		return resp.ErrLoginTooFrequent
+	}
+	locktime, err := loginguard.Locked(name)
+	if err != nil {
+		log.Error("loginguard.Locked failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if locktime > 0 {
+		return resp.ErrUserLocked
+	}
+	return resp.Success
+}
+
+// remoteip get ip of the connection, X-Forwarded-For and X-Real-Ip can be forged by client,
+// so c.ClientIP() can not be used to limit login frequency
+func remoteip(c *gin.Context) string {
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return ip
+}
+
+// authenticate check password of user by ldap or local password
+func authenticate(ctx context.Context, name, password string) (*define.LoginToken, error) {
+	if ldapauth.Enabled() {
+		return ldapauth.Login(ctx, name, password)
+	}
+	return model.LoginUser(ctx, name, password)
+}
+
+// loginresult get resp code of login err, check totp code after password is right,
+// count failures when password or totp code is wrong
+func loginresult(ctx context.Context, name string, err error, totpcode string) int {
+	switch err := errors.Unwrap(err); err.(type) {
+	case nil:
+		code := checklogintotp(ctx, name, totpcode)
+		switch code {
+		case resp.Success:
+			err = loginguard.Succeed(name)
+			if err != nil {
+				log.Error("loginguard.Succeed failed", zap.Error(err))
+			}
+		case resp.ErrTOTPInvalid:
+			return loginfail(name, code)
+		}
+		return code
+	case define.ErrUserPass:
+		return loginfail(name, resp.ErrUserPassword)
+	case define.ErrForbid:
+		return resp.ErrUserForbid
+	case define.ErrPasswordExpired:
+		return resp.ErrPasswordExpired
+	default:
+		return resp.ErrInternalServer
+	}
+}
+
+// loginfail count failed login, return ErrUserLocked if user is locked by this failure
+func loginfail(name string, code int) int {
+	locked, err := loginguard.Fail(name)
+	if err != nil {
+		log.Error("loginguard.Fail failed", zap.Error(err))
+	}
+	if locked {
+		log.Warn("user is locked by too many failed logins", zap.String("name", name))
+		return resp.ErrUserLocked
+	}
+	return code
+}
+
+// savelogin save login attempt to operate log
+func savelogin(ctx context.Context, c *gin.Context, name, action string, code int) {
+	var (
+		uid  string
+		role define.Role
+	)
+	// 用户名由请求传入，超过字段长度时截断
+	if r := []rune(name); len(r) > 30 {
+		name = string(r[:30])
+	}
+	user, err := model.GetUserByName(ctx, name)
+	if err == nil {
+		uid = user.ID
+		role = user.Role
+	}
+	desc := fmt.Sprintf("从%s%s成功", remoteip(c), action)
+	if code != resp.Success {
+		desc = fmt.Sprintf("从%s%s失败: %s", remoteip(c), action, resp.GetMsg(code))
+	}
+	err = model.SaveLoginLog(ctx, uid, name, role, time.Now().Unix(), desc)
+	if err != nil {
+		log.Error("model.SaveLoginLog failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,337 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/oidc"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// OpenID Connect单点登陆
+// 登陆时跳转到认证服务，回调时校验state和id token，根据claim映射用户角色，
+// 第一次登陆的用户自动创建，之后每次登陆同步角色，最后和密码登陆一样签发token
+// 两步验证由认证服务负责，需要两步验证的用户只有认证服务进行了多因素认证才能登陆
+
+const (
+	oidcCookie     = "crocodile_oidc"
+	oidcCookiePath = "/api/v1/user/oidc"
+	// login should be finished in this time
+	oidcCookieMaxAge = 600
+	// web page
+	webpath = "/crocodile/"
+)
+
+// amr value of multi-factor authentication, RFC 8176
+var defaultMFAValues = []string{"mfa"}
+
+var (
+	provider     *oidc.Provider
+	providerOnce sync.Once
+)
+
+func getprovider() *oidc.Provider {
+	providerOnce.Do(func() {
+		conf := config.CoreConf.Server.OIDC
+		provider = oidc.New(oidc.Config{
+			Issuer:       conf.Issuer,
+			ClientID:     conf.ClientID,
+			ClientSecret: conf.ClientSecret,
+			RedirectURL:  conf.RedirectURL,
+			Scopes:       conf.Scopes,
+		})
+	})
+	return provider
+}
+
+func passwordLoginDisabled() bool {
+	return config.CoreConf.Server.OIDC.Enable && config.CoreConf.Server.OIDC.DisablePasswordLogin
+}
+
+// GetOIDCConfig return whether sso login is enabled
+// @Summary get sso login config
+// @Tags User
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/oidc/config [get]
+func GetOIDCConfig(c *gin.Context) {
+	resp.JSON(c, resp.Success, define.OIDCConfig{
+		Enable:               config.CoreConf.Server.OIDC.Enable,
+		DisablePasswordLogin: passwordLoginDisabled(),
+	})
+}
+
+// OIDCLogin redirect to oidc provider
+// @Summary sso login
+// @Tags User
+// @Success 302
+// @Router /api/v1/user/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	if !config.CoreConf.Server.OIDC.Enable {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	state, err := randstr()
+	if err != nil {
+		log.Error("randstr failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	nonce, err := randstr()
+	if err != nil {
+		log.Error("randstr failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	authurl, err := getprovider().AuthCodeURL(ctx, state, nonce)
+	if err != nil {
+		log.Error("AuthCodeURL failed", zap.Error(err))
+		ssoerror(c, "认证服务不可用")
+		return
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    state + "." + nonce,
+		Path:     oidcCookiePath,
+		MaxAge:   oidcCookieMaxAge,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	c.Redirect(http.StatusFound, authurl)
+}
+
+// OIDCCallback oidc provider redirect back with code
+// @Summary sso login callback
+// @Tags User
+// @Param code query string true "Code"
+// @Param state query string true "State"
+// @Success 302
+// @Router /api/v1/user/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if !config.CoreConf.Server.OIDC.Enable {
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	cookie, err := c.Cookie(oidcCookie)
+	// state and nonce can only be used once
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:   oidcCookie,
+		Path:   oidcCookiePath,
+		MaxAge: -1,
+	})
+	if errmsg := c.Query("error"); errmsg != "" {
+		log.Error("oidc provider return error", zap.String("error", errmsg),
+			zap.String("description", c.Query("error_description")))
+		ssoerror(c, "认证失败: "+errmsg)
+		return
+	}
+	state := strings.SplitN(cookie, ".", 2)
+	if err != nil || len(state) != 2 || state[0] == "" || state[0] != c.Query("state") {
+		log.Error("oidc state is not match", zap.Error(err))
+		ssoerror(c, "登陆已过期，请重新登陆")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	claims, err := getprovider().Exchange(ctx, c.Query("code"), state[1])
+	if err != nil {
+		log.Error("oidc Exchange failed", zap.Error(err))
+		ssoerror(c, "认证失败")
+		return
+	}
+	user, err := oidcuser(ctx, claims)
+	if err != nil {
+		log.Error("oidcuser failed", zap.Error(err))
+		ssoerror(c, err.Error())
+		return
+	}
+	err = checkoidcmfa(ctx, user, claims)
+	if err != nil {
+		ssoerror(c, err.Error())
+		return
+	}
+	token, err := model.NewLoginToken(user.ID, user.Name)
+	if err != nil {
+		log.Error("model.NewLoginToken failed", zap.Error(err))
+		ssoerror(c, "服务端错误")
+		return
+	}
+	log.Info("user login by oidc", zap.String("user", user.Name), zap.String("subject", claims.String("sub")))
+	// web read token from cookie like password login
+	for name, value := range map[string]string{
+		"crocodile":         token.AccessToken,
+		"crocodile_refresh": token.RefreshToken,
+	} {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     "/",
+			Secure:   c.Request.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	c.Redirect(http.StatusFound, webpath)
+}
+
+// checkoidcmfa check provider did multi-factor authentication for user who enabled totp
+// or must enable totp, returned error message is shown to user
+func checkoidcmfa(ctx context.Context, user *define.User, claims oidc.Claims) error {
+	_, enable, err := model.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		log.Error("model.GetUserTOTP failed", zap.Error(err))
+		return errors.New("服务端错误")
+	}
+	if !enable && !requiretotp(user.Role) {
+		return nil
+	}
+	if oidcmfa(claims) {
+		return nil
+	}
+	log.Warn("oidc login without multi-factor authentication", zap.String("user", user.Name),
+		zap.Strings("amr", claims.Strings("amr")))
+	return errors.New("需要在认证服务中进行多因素认证后登陆")
+}
+
+// oidcmfa return whether amr claim contains the value of multi-factor authentication
+func oidcmfa(claims oidc.Claims) bool {
+	values := config.CoreConf.Server.OIDC.MFAValues
+	if len(values) == 0 {
+		values = defaultMFAValues
+	}
+	for _, amr := range claims.Strings("amr") {
+		for _, value := range values {
+			if amr == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// oidcuser get or provision user of oidc claims and sync its role,
+// returned error message is shown to user
+func oidcuser(ctx context.Context, claims oidc.Claims) (*define.User, error) {
+	conf := config.CoreConf.Server.OIDC
+	subject := claims.String("sub")
+	if subject == "" {
+		return nil, errors.New("认证信息中没有用户标识")
+	}
+	role, ok := oidcrole(claims)
+	if !ok {
+		return nil, errors.New("没有登陆权限，请联系管理员")
+	}
+	user, err := model.GetUserByOIDCSubject(ctx, subject)
+	switch err.(type) {
+	case nil:
+		if user.Forbid {
+			return nil, errors.New("用户已被禁止登陆")
+		}
+		if user.Role != role {
+			err = model.AdminChangeUser(ctx, user.ID, role, user.Forbid, "", user.Remark)
+			if err != nil {
+				log.Error("model.AdminChangeUser failed", zap.Error(err))
+				return nil, errors.New("服务端错误")
+			}
+			log.Info("sync oidc user role", zap.String("user", user.Name),
+				zap.String("from", user.Role.String()), zap.String("to", role.String()))
+			user.Role = role
+		}
+		return user, nil
+	case define.ErrNotExist:
+	default:
+		log.Error("model.GetUserByOIDCSubject failed", zap.Error(err))
+		return nil, errors.New("服务端错误")
+	}
+
+	usernameclaim := conf.UserNameClaim
+	if usernameclaim == "" {
+		usernameclaim = "preferred_username"
+	}
+	name := claims.String(usernameclaim)
+	if name == "" || len(name) > 30 {
+		return nil, fmt.Errorf("用户名%s不符合要求", usernameclaim)
+	}
+	// local user with same name can not be taken over
+	exist, err := model.Check(ctx, model.TBUser, model.Name, name)
+	if err != nil {
+		log.Error("model.Check failed", zap.Error(err))
+		return nil, errors.New("服务端错误")
+	}
+	if exist {
+		return nil, fmt.Errorf("用户名%s已存在", name)
+	}
+	uid, err := model.AddOIDCUser(ctx, name, subject, role)
+	if err != nil {
+		log.Error("model.AddOIDCUser failed", zap.Error(err))
+		return nil, errors.New("服务端错误")
+	}
+	log.Info("provision oidc user", zap.String("user", name), zap.String("role", role.String()))
+	user = &define.User{Role: role}
+	user.ID = uid
+	user.Name = name
+	return user, nil
+}
+
+// oidcrole map role claim to role, admin > normal > guest,
+// if not match use default role, return false if user can not login
+func oidcrole(claims oidc.Claims) (define.Role, bool) {
+	conf := config.CoreConf.Server.OIDC
+	if conf.RoleClaim != "" {
+		values := claims.Strings(conf.RoleClaim)
+		for _, m := range []struct {
+			role   define.Role
+			values []string
+		}{
+			{define.AdminUser, conf.AdminValues},
+			{define.NormalUser, conf.NormalValues},
+			{define.GuestUser, conf.GuestValues},
+		} {
+			for _, v := range m.values {
+				for _, value := range values {
+					if v == value {
+						return m.role, true
+					}
+				}
+			}
+		}
+	}
+	switch conf.DefaultRole {
+	case "normal":
+		return define.NormalUser, true
+	case "guest":
+		return define.GuestUser, true
+	default:
+		return 0, false
+	}
+}
+
+// ssoerror redirect to login page with error message
+func ssoerror(c *gin.Context, msg string) {
+	c.Redirect(http.StatusFound, webpath+"#/login?sso_error="+url.QueryEscape(msg))
+}
+
+func randstr() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand.Read failed: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
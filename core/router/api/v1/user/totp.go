@@ -0,0 +1,445 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/totp"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/loginguard"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.uber.org/zap"
+)
+
+// 两步验证(TOTP)
+// 用户在个人设置中生成密钥并使用验证码确认后开启，开启时返回一次恢复码，
+// 开启后登陆需要验证码或者恢复码，配置requireadmin后管理员必须开启，未开启的管理员在登陆页面绑定验证器
+// 单点登陆和API令牌不需要两步验证
+
+const (
+	recoveryCodeCount = 10
+	// 允许前后一个时间步的时钟误差
+	totpSkew = 1
+)
+
+// GetTOTP get self totp status
+// @Summary get totp status
+// @Tags User
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/totp [get]
+// @Security ApiKeyAuth
+func GetTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	uid := c.GetString("uid")
+	_, enable, err := model.GetUserTOTP(ctx, uid)
+	if err != nil {
+		log.Error("model.GetUserTOTP failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	status := define.TOTPStatus{
+		Enable:  enable,
+		Require: requiretotp(role),
+	}
+	if enable {
+		status.RecoveryCodes, err = model.CountRecoveryCodes(ctx, uid)
+		if err != nil {
+			log.Error("model.CountRecoveryCodes failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+	}
+	resp.JSON(c, resp.Success, status)
+}
+
+// CreateTOTPSecret generate new totp secret
+// @Summary generate totp secret
+// @Tags User
+// @Description generate new totp secret, totp is enabled after confirmed by code
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/totp [post]
+// @Security ApiKeyAuth
+func CreateTOTPSecret(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	secret, code := newtotpsecret(ctx, c.GetString("uid"), c.GetString("username"))
+	resp.JSON(c, code, secret)
+}
+
+// EnableTOTP confirm totp secret by code and enable totp
+// @Summary enable totp
+// @Tags User
+// @Description enable totp and return recovery codes, recovery codes are only return once
+// @Param Code body define.TOTPCode true "TOTP Code"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/totp [put]
+// @Security ApiKeyAuth
+func EnableTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	req := define.TOTPCode{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	codes, code := enabletotp(ctx, c.GetString("uid"), req.Code)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, define.TOTPEnabled{RecoveryCodes: codes})
+}
+
+// DisableTOTP disable self totp by code or recovery code
+// @Summary disable totp
+// @Tags User
+// @Param Code body define.TOTPCode true "TOTP Code"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/totp [delete]
+// @Security ApiKeyAuth
+func DisableTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	req := define.TOTPCode{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if requiretotp(role) {
+		resp.JSON(c, resp.ErrTOTPNotEnrolled, nil)
+		return
+	}
+	uid := c.GetString("uid")
+	secret, enable, err := model.GetUserTOTP(ctx, uid)
+	if err != nil {
+		log.Error("model.GetUserTOTP failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !enable {
+		resp.JSON(c, resp.Success, nil)
+		return
+	}
+	ok, err := verifytotp(ctx, uid, secret, req.Code)
+	if err != nil {
+		log.Error("verifytotp failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !ok {
+		resp.JSON(c, resp.ErrTOTPInvalid, nil)
+		return
+	}
+	err = model.DisableTOTP(ctx, uid)
+	if err != nil {
+		log.Error("model.DisableTOTP failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// AdminResetTOTP disable totp of user who lost authenticator and recovery codes
+// @Summary admin reset user totp
+// @Tags User
+// @Param User body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/admin/totp [delete]
+// @Security ApiKeyAuth
+func AdminResetTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	user := define.GetID{}
+	err := c.ShouldBindJSON(&user)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if role != define.AdminUser {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	exist, err := model.Check(ctx, model.TBUser, model.ID, user.ID)
+	if err != nil {
+		log.Error("IsExist failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !exist {
+		resp.JSON(c, resp.ErrUserNotExist, nil)
+		return
+	}
+	err = model.DisableTOTP(ctx, user.ID)
+	if err != nil {
+		log.Error("model.DisableTOTP failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// LoginCreateTOTPSecret generate totp secret on login page for admin who must enable totp
+// @Summary generate totp secret when login
+// @Tags User
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/login/totp [post]
+// @Security BasicAuth
+func LoginCreateTOTPSecret(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	user, _, code := loginenroll(ctx, c)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	secret, code := newtotpsecret(ctx, user.ID, user.Name)
+	resp.JSON(c, code, secret)
+}
+
+// LoginEnableTOTP enable totp on login page and login
+// @Summary enable totp when login
+// @Tags User
+// @Description enable totp and return recovery codes and login token
+// @Param Code body define.TOTPCode true "TOTP Code"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/login/totp [put]
+// @Security BasicAuth
+func LoginEnableTOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	req := define.TOTPCode{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	user, expired, code := loginenroll(ctx, c)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	codes, code := enabletotp(ctx, user.ID, req.Code)
+	if code == resp.ErrTOTPInvalid {
+		code = loginfail(user.Name, code)
+	}
+	savelogin(ctx, c, user.Name, "绑定两步验证", code)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	err = loginguard.Succeed(user.Name)
+	if err != nil {
+		log.Error("loginguard.Succeed failed", zap.Error(err))
+	}
+	// 密码已过期的用户绑定后还需要修改密码才能登陆
+	if expired {
+		resp.JSON(c, resp.Success, define.TOTPEnabled{RecoveryCodes: codes})
+		return
+	}
+	token, err := model.NewLoginToken(user.ID, user.Name)
+	if err != nil {
+		log.Error("model.NewLoginToken failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, define.TOTPEnabled{RecoveryCodes: codes, Token: token})
+}
+
+// requiretotp return whether user must enable totp
+func requiretotp(role define.Role) bool {
+	return config.CoreConf.Server.TOTP.RequireAdmin && role == define.AdminUser
+}
+
+// loginenroll check password of user who must enable totp before login
+func loginenroll(ctx context.Context, c *gin.Context) (*define.User, bool, int) {
+	if passwordLoginDisabled() {
+		return nil, false, resp.ErrPasswordLoginDisabled
+	}
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return nil, false, resp.ErrBadRequest
+	}
+	code := checklogin(username, remoteip(c))
+	if code != resp.Success {
+		savelogin(ctx, c, username, "绑定两步验证", code)
+		return nil, false, code
+	}
+	_, err := authenticate(ctx, username, password)
+	if err != nil {
+		log.Error("login user failed", zap.Error(err))
+	}
+	// 密码过期时密码是正确的，允许先绑定验证器再修改密码
+	var experr define.ErrPasswordExpired
+	expired := errors.As(err, &experr)
+	if expired {
+		err = nil
+	}
+	// 只有必须开启但是还未开启两步验证的用户可以在登陆时绑定
+	code = loginresult(ctx, username, err, "")
+	if code != resp.ErrTOTPNotEnrolled {
+		if code == resp.Success {
+			code = resp.ErrBadRequest
+		}
+		savelogin(ctx, c, username, "绑定两步验证", code)
+		return nil, false, code
+	}
+	user, err := model.GetUserByName(ctx, username)
+	if err != nil {
+		log.Error("model.GetUserByName failed", zap.Error(err))
+		return nil, false, resp.ErrInternalServer
+	}
+	return user, expired, resp.Success
+}
+
+// checklogintotp check totp code or recovery code of user whose password is right
+func checklogintotp(ctx context.Context, name, code string) int {
+	user, err := model.GetUserByName(ctx, name)
+	if err != nil {
+		log.Error("model.GetUserByName failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	secret, enable, err := model.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		log.Error("model.GetUserTOTP failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !enable {
+		if requiretotp(user.Role) {
+			return resp.ErrTOTPNotEnrolled
+		}
+		return resp.Success
+	}
+	if code == "" {
+		return resp.ErrTOTPRequired
+	}
+	ok, err := verifytotp(ctx, user.ID, secret, code)
+	if err != nil {
+		log.Error("verifytotp failed", zap.Error(err))
+		return resp.ErrInternalServer
+	}
+	if !ok {
+		return resp.ErrTOTPInvalid
+	}
+	return resp.Success
+}
+
+// verifytotp check totp code or recovery code, code and recovery code can only be used once
+func verifytotp(ctx context.Context, uid, secret, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(secret, code, time.Now(), totpSkew); ok {
+		return model.UseTOTPStep(ctx, uid, step)
+	}
+	return model.UseRecoveryCode(ctx, uid, totp.HashRecoveryCode(code))
+}
+
+// newtotpsecret generate and save new totp secret for user which is not enable totp
+func newtotpsecret(ctx context.Context, uid, name string) (*define.TOTPSecret, int) {
+	_, enable, err := model.GetUserTOTP(ctx, uid)
+	if err != nil {
+		log.Error("model.GetUserTOTP failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	if enable {
+		return nil, resp.ErrTOTPEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("totp.GenerateSecret failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	err = model.SetTOTPSecret(ctx, uid, secret)
+	if err != nil {
+		log.Error("model.SetTOTPSecret failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	issuer := config.CoreConf.Server.TOTP.Issuer
+	if issuer == "" {
+		issuer = "crocodile"
+	}
+	return &define.TOTPSecret{
+		Secret: secret,
+		URL:    totp.URL(issuer, name, secret),
+	}, resp.Success
+}
+
+// enabletotp confirm secret by code and enable totp, return new recovery codes
+func enabletotp(ctx context.Context, uid, code string) ([]string, int) {
+	secret, enable, err := model.GetUserTOTP(ctx, uid)
+	if err != nil {
+		log.Error("model.GetUserTOTP failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	if enable {
+		return nil, resp.ErrTOTPEnabled
+	}
+	if secret == "" {
+		return nil, resp.ErrTOTPNoSecret
+	}
+	step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, resp.ErrTOTPInvalid
+	}
+	ok, err = model.UseTOTPStep(ctx, uid, step)
+	if err != nil {
+		log.Error("model.UseTOTPStep failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	if !ok {
+		return nil, resp.ErrTOTPInvalid
+	}
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Error("totp.GenerateRecoveryCodes failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	hashcodes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashcodes = append(hashcodes, totp.HashRecoveryCode(code))
+	}
+	err = model.EnableTOTP(ctx, uid, hashcodes)
+	if err != nil {
+		log.Error("model.EnableTOTP failed", zap.Error(err))
+		return nil, resp.ErrInternalServer
+	}
+	return codes, resp.Success
+}
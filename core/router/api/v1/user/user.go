@@ -2,13 +2,15 @@ package user
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/labulaka521/crocodile/common/jwt"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/common/utils"
 	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/loginguard"
 	"github.com/labulaka521/crocodile/core/model"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/labulaka521/crocodile/core/utils/resp"
@@ -38,6 +40,12 @@ func RegistryUser(c *gin.Context) {
 		return
 	}
 	// TODO only admin
+	err = loginguard.CheckPassword(ruser.Password)
+	if err != nil {
+		log.Error("loginguard.CheckPassword failed", zap.Error(err))
+		resp.JSON(c, resp.ErrPasswordPolicy, err.Error())
+		return
+	}
 
 	hashpassword, err = utils.GenerateHashPass(ruser.Password)
 	if err != nil {
@@ -144,6 +152,11 @@ func GetUsers(c *gin.Context) {
 	// remove password
 	for i, user := range users {
 		user.Password = ""
+		locktime, err := loginguard.Locked(user.Name)
+		if err != nil {
+			log.Error("loginguard.Locked failed", zap.Error(err))
+		}
+		user.Locked = locktime > 0
 		users[i] = user
 	}
 
@@ -171,10 +184,13 @@ func ChangeUserInfo(c *gin.Context) {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
-	if len(newinfo.Password) > 0 && len(newinfo.Password) < 8 {
-		log.Error("password is short 8")
-		resp.JSON(c, resp.ErrBadRequest, nil)
-		return
+	if len(newinfo.Password) > 0 {
+		err = loginguard.CheckPassword(newinfo.Password)
+		if err != nil {
+			log.Error("loginguard.CheckPassword failed", zap.Error(err))
+			resp.JSON(c, resp.ErrPasswordPolicy, err.Error())
+			return
+		}
 	}
 	uid := c.GetString("uid")
 	if uid != newinfo.ID {
@@ -231,10 +247,13 @@ func AdminChangeUser(c *gin.Context) {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
-	if len(user.Password) > 0 && len(user.Password) < 8 {
-		log.Error("password is short 8")
-		resp.JSON(c, resp.ErrBadRequest, nil)
-		return
+	if len(user.Password) > 0 {
+		err = loginguard.CheckPassword(user.Password)
+		if err != nil {
+			log.Error("loginguard.CheckPassword failed", zap.Error(err))
+			resp.JSON(c, resp.ErrPasswordPolicy, err.Error())
+			return
+		}
 	}
 	// TODO only admin
 	exist, err := model.Check(ctx, model.TBUser, model.ID, user.ID)
@@ -261,6 +280,15 @@ func AdminChangeUser(c *gin.Context) {
 		resp.JSON(c, resp.ErrInternalServer, nil)
 		return
 	}
+	// 禁止登陆或者重置密码后注销用户所有会话
+	if user.Forbid || user.Password != "" {
+		err = jwt.RevokeUser(user.ID)
+		if err != nil {
+			log.Error("jwt.RevokeUser failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+	}
 
 	resp.JSON(c, resp.Success, nil)
 }
@@ -345,6 +373,8 @@ func AdminDeleteUser(c *gin.Context) {
 // LoginUser login user
 // @Summary login user
 // @Tags User
+// @Description user enabled totp need totp code or recovery code in body
+// @Param TOTP body define.LoginTOTP false "TOTP Code"
 // @Produce json
 // @Success 200 {object} resp.Response
 // @Router /api/v1/user/login [post]
@@ -353,35 +383,210 @@ func LoginUser(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(),
 		config.CoreConf.Server.DB.MaxQueryTime.Duration)
 	defer cancel()
+	if passwordLoginDisabled() {
+		resp.JSON(c, resp.ErrPasswordLoginDisabled, nil)
+		return
+	}
 	username, password, ok := c.Request.BasicAuth()
 	if !ok {
 		resp.JSON(c, resp.ErrBadRequest, nil)
 		return
 	}
-	token, err := model.LoginUser(ctx, username, password)
+	req := define.LoginTOTP{}
+	if c.Request.ContentLength > 0 {
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			log.Error("ShouldBindJSON failed", zap.Error(err))
+			resp.JSON(c, resp.ErrBadRequest, nil)
+			return
+		}
+	}
+	code := checklogin(username, remoteip(c))
+	if code != resp.Success {
+		savelogin(ctx, c, username, "登陆", code)
+		resp.JSON(c, code, nil)
+		return
+	}
+	token, err := authenticate(ctx, username, password)
+	if err != nil {
+		log.Error("login user failed", zap.Error(err))
+	}
+	code = loginresult(ctx, username, err, req.TOTPCode)
+	savelogin(ctx, c, username, "登陆", code)
+	if code != resp.Success {
+		resp.JSON(c, code, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, token)
+}
+
+// RefreshToken get new token by refresh token
+// @Summary refresh token
+// @Tags User
+// @Description get new access token and refresh token, refresh token can only be used once
+// @Param Token body define.RefreshToken true "Refresh Token"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/refresh [post]
+func RefreshToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	req := define.RefreshToken{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil || req.RefreshToken == "" {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	claims, err := jwt.ParseToken(req.RefreshToken)
+	if err != nil || claims.Type != jwt.TypeRefresh {
+		log.Error("parse refresh token failed", zap.Error(err))
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	revoked, err := jwt.IsRevoked(claims)
+	if err != nil {
+		log.Error("jwt.IsRevoked failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if revoked {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	// 用户被删除或者禁止登陆后不能再刷新
+	user, err := model.GetUserByID(ctx, claims.UID)
 	if err != nil {
-		log.Error("model.LoginUser failed", zap.Error(err))
+		log.Error("model.GetUserByID failed", zap.Error(err))
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
 	}
-	switch err := errors.Unwrap(err); err.(type) {
-	case nil:
-		resp.JSON(c, resp.Success, token)
-	case define.ErrUserPass:
-		resp.JSON(c, resp.ErrUserPassword, nil)
-	case define.ErrForbid:
+	if user.Forbid {
 		resp.JSON(c, resp.ErrUserForbid, nil)
-	default:
+		return
+	}
+	// 刷新后旧的refresh token失效, 同一个refresh token并发刷新只有一个成功
+	ok, err := jwt.Revoke(claims)
+	if err != nil {
+		log.Error("jwt.Revoke failed", zap.Error(err))
 		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
 	}
+	if !ok {
+		log.Error("refresh token is reused", zap.String("uid", claims.UID), zap.String("jti", claims.Id))
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	token, err := model.NewLoginToken(user.ID, user.Name)
+	if err != nil {
+		log.Error("model.NewLoginToken failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, token)
 }
 
 // LogoutUser logout user
 // @Summary logout user
 // @Tags User
+// @Description revoke access token in header and refresh token in body
+// @Param Token body define.RefreshToken false "Refresh Token"
 // @Produce json
 // @Success 200 {object} resp.Response
 // @Router /api/v1/user/logout [post]
-// @Security BasicAuth
+// @Security ApiKeyAuth
 func LogoutUser(c *gin.Context) {
+	req := define.RefreshToken{}
+	if c.Request.ContentLength > 0 {
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			log.Error("ShouldBindJSON failed", zap.Error(err))
+		}
+	}
+	accesstoken := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	for _, token := range []string{accesstoken, req.RefreshToken} {
+		if token == "" {
+			continue
+		}
+		claims, err := jwt.ParseToken(token)
+		if err != nil {
+			// token is invalid or expired
+			continue
+		}
+		_, err = jwt.Revoke(claims)
+		if err != nil {
+			log.Error("jwt.Revoke failed", zap.Error(err))
+			resp.JSON(c, resp.ErrInternalServer, nil)
+			return
+		}
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// LogoutSessions logout all sessions of self
+// @Summary logout all sessions
+// @Tags User
+// @Description revoke all tokens of self, all logined client need login again
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/sessions [delete]
+// @Security ApiKeyAuth
+func LogoutSessions(c *gin.Context) {
+	err := jwt.RevokeUser(c.GetString("uid"))
+	if err != nil {
+		log.Error("jwt.RevokeUser failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	resp.JSON(c, resp.Success, nil)
+}
+
+// AdminLogoutSessions logout all sessions of user
+// @Summary admin logout all sessions of user
+// @Tags User
+// @Description admin revoke all tokens of user
+// @Param User body define.GetID true "ID"
+// @Produce json
+// @Success 200 {object} resp.Response
+// @Router /api/v1/user/admin/sessions [delete]
+// @Security ApiKeyAuth
+func AdminLogoutSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+
+	user := define.GetID{}
+	err := c.ShouldBindJSON(&user)
+	if err != nil {
+		log.Error("ShouldBindJSON failed", zap.Error(err))
+		resp.JSON(c, resp.ErrBadRequest, nil)
+		return
+	}
+	var role define.Role
+	if v, ok := c.Get("role"); ok {
+		role = v.(define.Role)
+	}
+	if role != define.AdminUser {
+		resp.JSON(c, resp.ErrUnauthorized, nil)
+		return
+	}
+	exist, err := model.Check(ctx, model.TBUser, model.ID, user.ID)
+	if err != nil {
+		log.Error("IsExist failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
+	if !exist {
+		resp.JSON(c, resp.ErrUserNotExist, nil)
+		return
+	}
+	err = jwt.RevokeUser(user.ID)
+	if err != nil {
+		log.Error("jwt.RevokeUser failed", zap.Error(err))
+		resp.JSON(c, resp.ErrInternalServer, nil)
+		return
+	}
 	resp.JSON(c, resp.Success, nil)
 }
 
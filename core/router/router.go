@@ -22,9 +22,12 @@ import (
 	"github.com/labulaka521/crocodile/core/router/api/v1/hostgroup"
 	"github.com/labulaka521/crocodile/core/router/api/v1/install"
 	"github.com/labulaka521/crocodile/core/router/api/v1/notify"
+	"github.com/labulaka521/crocodile/core/router/api/v1/project"
 	"github.com/labulaka521/crocodile/core/router/api/v1/task"
 	"github.com/labulaka521/crocodile/core/router/api/v1/user"
 	"github.com/labulaka521/crocodile/core/schedule"
+	"github.com/labulaka521/crocodile/core/stats"
+	"github.com/labulaka521/crocodile/core/tracing"
 	"github.com/labulaka521/crocodile/core/utils/asset"
 	"github.com/labulaka521/crocodile/core/utils/define"
 
@@ -65,6 +68,11 @@ func NewHTTPRouter() *http.Server {
 
 	pprof.Register(router)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// metrics serve on main port
+	metricscfg := config.CoreConf.Metrics
+	if metricscfg.Enable && metricscfg.Port == 0 {
+		router.GET(metricspath(), gin.WrapH(stats.Handler()))
+	}
 	//gin.SetMode(gin.ReleaseMode)
 	//,
 	router.Use(gin.Recovery(), middleware.ZapLogger(), middleware.PermissionControl(), middleware.Oprtation())
@@ -79,10 +87,28 @@ func NewHTTPRouter() *http.Server {
 		ru.DELETE("/admin", user.AdminDeleteUser) // only admin  // 管理员删除普通用户
 		ru.PUT("/info", user.ChangeUserInfo)      // 某某修改了个人信息
 		ru.POST("/login", user.LoginUser)
+		ru.PUT("/login/password", user.ChangeExpiredPassword) // 密码过期后修改密码并登陆
+		ru.POST("/login/totp", user.LoginCreateTOTPSecret)    // 必须开启两步验证的用户登陆时绑定验证器
+		ru.PUT("/login/totp", user.LoginEnableTOTP)
 		ru.POST("/logout", user.LogoutUser) // 某某注销登陆
+		ru.POST("/refresh", user.RefreshToken)
+		ru.GET("/oidc/config", user.GetOIDCConfig)
+		ru.GET("/oidc/login", user.OIDCLogin)
+		ru.GET("/oidc/callback", user.OIDCCallback)
+		ru.DELETE("/sessions", user.LogoutSessions)            // 注销自己的所有会话
+		ru.DELETE("/admin/sessions", user.AdminLogoutSessions) // only admin 注销某用户的所有会话
+		ru.PUT("/admin/unlock", user.AdminUnlockUser)          // only admin 解锁登陆失败次数过多的用户
+		ru.DELETE("/admin/totp", user.AdminResetTOTP)          // only admin 重置用户的两步验证
 		ru.GET("/select", user.GetSelect)
 		ru.GET("/alarmstatus", user.GetAlarmStatus)
 		ru.GET("/operate", user.GetOperateLog)
+		ru.GET("/apitoken", user.GetAPITokens)
+		ru.POST("/apitoken", user.CreateAPIToken)
+		ru.DELETE("/apitoken", user.DeleteAPIToken)
+		ru.GET("/totp", user.GetTOTP)
+		ru.POST("/totp", user.CreateTOTPSecret)
+		ru.PUT("/totp", user.EnableTOTP)
+		ru.DELETE("/totp", user.DisableTOTP)
 	}
 	rhg := v1.Group("/hostgroup")
 	{
@@ -93,6 +119,17 @@ func NewHTTPRouter() *http.Server {
 		rhg.GET("/select", hostgroup.GetSelect)
 		rhg.GET("/hosts", hostgroup.GetHostsByIHGID)
 	}
+	rp := v1.Group("/project")
+	{
+		rp.GET("", project.GetProjects)
+		rp.POST("", project.CreateProject)
+		rp.PUT("", project.ChangeProject)
+		rp.DELETE("", project.DeleteProject)
+		rp.GET("/select", project.GetSelect)
+		rp.GET("/member", project.GetMembers)
+		rp.PUT("/member", project.SetMember)
+		rp.DELETE("/member", project.DeleteMember)
+	}
 	rt := v1.Group("/task")
 	{
 		rt.GET("", task.GetTasks)
@@ -103,11 +140,16 @@ func NewHTTPRouter() *http.Server {
 		rt.DELETE("", task.DeleteTask)
 		rt.PUT("/run", task.RunTask)
 		rt.PUT("/kill", task.KillTask)
+		rt.GET("/approval", task.GetApprovals)
+		rt.PUT("/approval", task.ReviewApproval)
+		rt.DELETE("/approval", task.CancelApproval)
 		rt.GET("/running", task.GetRunningTask)
 		rt.DELETE("/log", task.CleanTaskLog)
 		rt.GET("/log", task.LogTask)
 		rt.GET("/log/tree", task.LogTreeData)
+		rt.GET("/log/read", task.ReadTaskLog)
 		rt.GET("/log/websocket", task.RealRunTaskLog)
+		rt.GET("/log/sse", task.RealRunTaskLogSSE)
 		rt.GET("/status/websocket", task.RealRunTaskStatus)
 
 		rt.GET("/cron", task.ParseCron)
@@ -117,8 +159,14 @@ func NewHTTPRouter() *http.Server {
 	{
 		rh.GET("", host.GetHost)
 		rh.PUT("/stop", host.ChangeHostState)
+		rh.PUT("/drain", host.DrainHost)
 		rh.DELETE("", host.DeleteHost)
 		rh.GET("/select", host.GetSelect)
+		rh.GET("/events", host.GetHostEvents)
+		rh.PUT("/revoke", host.RevokeHostCredential)
+		rh.GET("/enrolltoken", host.GetEnrollTokens)
+		rh.POST("/enrolltoken", host.CreateEnrollToken)
+		rh.DELETE("/enrolltoken", host.DeleteEnrollToken)
 	}
 
 	rn := v1.Group("/notify")
@@ -145,6 +193,14 @@ func NewHTTPRouter() *http.Server {
 	return httpSrv
 }
 
+// metricspath return metrics url path
+func metricspath() string {
+	if config.CoreConf.Metrics.Path != "" {
+		return config.CoreConf.Metrics.Path
+	}
+	return stats.DefaultPath
+}
+
 // GetListen get listen addr by server or client
 func GetListen(mode define.RunMode) (net.Listener, error) {
 	var (
@@ -184,11 +240,28 @@ func Run(mode define.RunMode, lis net.Listener) error {
 	}
 
 	m = cmux.New(lis)
+	metricscfg := config.CoreConf.Metrics
 	if mode == define.Server {
 		httpServer = NewHTTPRouter()
 		httpL := m.Match(cmux.HTTP1Fast())
 		go httpServer.Serve(httpL)
 		log.Info("start run http server", zap.String("addr", lis.Addr().String()))
+	} else if metricscfg.Enable && metricscfg.Port == 0 {
+		// client only serve grpc on main port,so serve metrics by a http server
+		mux := http.NewServeMux()
+		mux.Handle(metricspath(), stats.Handler())
+		httpServer = &http.Server{Handler: mux}
+		httpL := m.Match(cmux.HTTP1Fast())
+		go httpServer.Serve(httpL)
+		log.Info("start run metrics server", zap.String("addr", lis.Addr().String()))
+	}
+	if metricscfg.Enable && metricscfg.Port != 0 {
+		go func() {
+			err := stats.Stats(metricscfg.Port, metricspath())
+			if err != nil {
+				log.Error("stats.Stats failed", zap.Error(err))
+			}
+		}()
 	}
 	////
 	grpcL := m.Match(cmux.Any())
@@ -222,11 +295,16 @@ func tryDisConn(gRPCServer *grpc.Server, httpServer *http.Server, mode define.Ru
 
 	signal.Notify(signals, os.Interrupt, os.Kill, syscall.SIGKILL,
 		syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGILL, syscall.SIGTRAP,
-		syscall.SIGABRT,
+		syscall.SIGABRT, syscall.SIGTERM,
 	)
 
 	select {
 	case sig := <-signals:
+		// worker收到SIGTERM时先排空，等待正在运行的任务结束后再退出
+		if sig == syscall.SIGTERM && mode == define.Client {
+			log.Info("get signal SIGTERM, worker start drain")
+			schedule.DrainWorker()
+		}
 		go func() {
 			select {
 			case <-time.After(time.Second * 10):
@@ -240,10 +318,14 @@ func tryDisConn(gRPCServer *grpc.Server, httpServer *http.Server, mode define.Ru
 		// g := errgroup.Group{}
 		log.Debug("Start Stop GrpcServer")
 		gRPCServer.Stop()
-		if mode == define.Server {
+		if httpServer != nil {
 			log.Debug("Start Stop HttpServer")
 			httpServer.Shutdown(context.Background())
 		}
+		err := tracing.Shutdown(context.Background())
+		if err != nil {
+			log.Error("tracing.Shutdown failed", zap.Error(err))
+		}
 		// g.Wait()
 		//time.Sleep(time.Second * 11)
 		os.Exit(0)
@@ -0,0 +1,220 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	pb "github.com/labulaka521/crocodile/core/proto"
+	"github.com/labulaka521/crocodile/core/stats"
+	"github.com/labulaka521/crocodile/core/tasktype"
+	"github.com/labulaka521/crocodile/core/tracing"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+// 广播任务
+// 任务在所有在线的worker上运行，每个worker上的运行使用realid taskid@hostid保存日志、状态和结果
+// 在任务树中作为任务的子节点，任务本身的日志记录分发情况，任务是否成功由BroadcastSuccess决定
+
+const realidsep = "@"
+
+// broadcastrealid return the realid of task run on host
+func broadcastrealid(taskid, hostid string) string {
+	return taskid + realidsep + hostid
+}
+
+// splitrealid return task id and sub id of realid,
+// sub id is host id of broadcast task run on host or shard of task, otherwise it is empty
+func splitrealid(realid string) (string, string) {
+	sp := strings.SplitN(realid, realidsep, 2)
+	if len(sp) != 2 {
+		return realid, ""
+	}
+	return sp[0], sp[1]
+}
+
+// runbroadcast run task on every online host in parallel,
+// return err if the count of success hosts not match task's BroadcastSuccess
+func (t *task2) runbroadcast(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType, id string) error {
+	hosts, err := getOnlineHosts(taskdata.HostGroupID, taskdata.LabelSelector, nil)
+	if err != nil {
+		log.Error("getOnlineHosts failed", zap.String("taskid", id), zap.Error(err))
+		t.writelogt(ctx, taskruntype, id, "Get Online Hosts Failed From Hostgroup %s[%s] Err: %v",
+			taskdata.HostGroup, taskdata.HostGroupID, err)
+		return err
+	}
+	parallel := taskdata.BroadcastParallel
+	if parallel <= 0 || parallel > len(hosts) {
+		parallel = len(hosts)
+	}
+	success := taskdata.BroadcastSuccess
+	if success == 0 {
+		success = define.BroadcastAll
+	}
+
+	addrs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		realid := broadcastrealid(id, host.ID)
+		err = t.addtaskinfo(ctx, taskruntype, realid)
+		if err != nil {
+			log.Error("t.addtaskinfo failed", zap.String("realid", realid), zap.Error(err))
+			return err
+		}
+		// 先保存运行的worker，任务树中使用它显示子节点
+		t.setdata(ctx, taskruntype, realid, define.TaskResp{
+			TaskID:   realid,
+			Task:     taskdata.Name,
+			Code:     tasktype.DefaultExitCode,
+			TaskType: taskruntype,
+			RunHost:  host.Addr,
+		}, taskresp)
+		addrs = append(addrs, host.Addr)
+	}
+	t.writelogt(ctx, taskruntype, id, "broadcast task %s[%s] to %d hosts %v, parallel %d, success policy %s",
+		taskdata.Name, id, len(hosts), addrs, parallel, success.String())
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, parallel)
+		succeed int32
+	)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host *define.Host) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				// 未运行的worker状态为等待，保存日志时会标记为取消
+				return
+			}
+			defer func() { <-sem }()
+			if t.runonhost(ctx, taskdata, taskruntype, id, host) == nil {
+				atomic.AddInt32(&succeed, 1)
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	t.writelogt(ctx, taskruntype, id, "broadcast task %s[%s] finished, %d/%d hosts run success",
+		taskdata.Name, id, succeed, len(hosts))
+	if !success.Judge(int(succeed), len(hosts)) {
+		return fmt.Errorf("%s task %s[%s] run success on %d/%d hosts, not match success policy %s",
+			taskruntype.String(), id, taskdata.Name, succeed, len(hosts), success.String())
+	}
+	return nil
+}
+
+// runonhost run broadcast task on host, save log, status and resp by realid taskid@hostid
+func (t *task2) runonhost(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	id string, host *define.Host) (reterr error) {
+	realid := broadcastrealid(id, host.ID)
+	ctx, span := tracing.Start(ctx, "task.runOnHost",
+		attribute.String("task.id", id),
+		attribute.String("worker.host", host.Addr),
+	)
+	defer func() {
+		tracing.End(span, reterr)
+	}()
+
+	t.setdata(ctx, taskruntype, realid, define.TsRun, taskstatus)
+	code, output, err := t.runtaskonhost(ctx, taskdata, taskruntype, realid, host)
+	return t.savesubrun(ctx, taskdata, taskruntype, id, realid, taskdata.Name, host.Addr, code, output, err)
+}
+
+// runtaskonhost run task on host and return resp code and output
+func (t *task2) runtaskonhost(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	realid string, host *define.Host) (int, []byte, error) {
+	conn, err := getworkerconn(ctx, host.Addr)
+	if err != nil {
+		return tasktype.DefaultExitCode, nil, fmt.Errorf("getworkerconn failed: %w", err)
+	}
+	code, output, err := t.runtaskonconn(ctx, taskdata, taskruntype, realid, conn, 0, 0)
+	if err != nil {
+		return code, output, DealRPCErr(err)
+	}
+	return code, output, nil
+}
+
+// runtaskonconn run task on worker of conn, the grpc err will be returned directly,
+// so caller can check status code of err
+func (t *task2) runtaskonconn(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	realid string, conn workerconn, shardindex, shardtotal int) (int, []byte, error) {
+	tdata, err := json.Marshal(taskdata.TaskData)
+	if err != nil {
+		return tasktype.DefaultExitCode, nil, fmt.Errorf("json.Marshal failed: %w", err)
+	}
+	// 同一个worker上可能运行同一任务的多个分片，使用realid作为worker上运行的任务ID
+	taskreq := &pb.TaskReq{
+		TaskId:     realid,
+		TaskType:   int32(taskdata.TaskType),
+		TaskData:   tdata,
+		ShardIndex: int32(shardindex),
+		ShardTotal: int32(shardtotal),
+	}
+	var (
+		taskctx   context.Context
+		ctxcancel context.CancelFunc
+	)
+	if taskdata.Timeout > 0 {
+		taskctx, ctxcancel = context.WithTimeout(ctx, time.Second*time.Duration(taskdata.Timeout))
+	} else {
+		taskctx, ctxcancel = context.WithCancel(ctx)
+	}
+	defer ctxcancel()
+
+	t.writelogt(ctx, taskruntype, realid, "start run task %s[%s] on host %s", taskdata.Name, taskdata.ID, conn.Target())
+	taskrespstream, err := conn.RunTask(tracing.InjectGRPC(taskctx), taskreq)
+	if err != nil {
+		return tasktype.DefaultExitCode, nil, err
+	}
+	t.writelogt(ctx, taskruntype, realid, "task %s[%s]  output----------------", taskdata.Name, taskdata.ID)
+	output, err := t.recvtaskresp(ctx, taskruntype, realid, taskrespstream)
+	if err != nil {
+		stats.GRPCStreamError("RunTask", status.Code(err).String())
+		return tasktype.DefaultExitCode, output, err
+	}
+	code, err := t.getreturncode(ctx, taskruntype, realid)
+	return code, output, err
+}
+
+// savesubrun save resp and status of broadcast task run on host or task shard,
+// check resp code and content and return err if run fail
+func (t *task2) savesubrun(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	id, realid, name, runhost string, code int, output []byte, err error) error {
+	t.setdata(ctx, taskruntype, realid, define.TaskResp{
+		TaskID:   realid,
+		Task:     name,
+		Code:     code,
+		TaskType: taskruntype,
+		RunHost:  runhost,
+	}, taskresp)
+	if err != nil {
+		select {
+		case <-ctx.Done():
+			t.writelogt(ctx, taskruntype, realid, "task %s[%s] is canceled", name, id)
+			t.setdata(ctx, taskruntype, realid, define.TsCancel, taskstatus)
+			return err
+		default:
+		}
+	} else {
+		err = judgeresp(taskdata, taskruntype, id, code, output)
+	}
+	if err != nil {
+		log.Error("task run fail", zap.String("task", name),
+			zap.String("host", runhost), zap.Error(err))
+		t.writelogt(ctx, taskruntype, realid, "Task %s[%s] Run Fail On Host %s: %v", name, id, runhost, err)
+		t.setdata(ctx, taskruntype, realid, define.TsFail, taskstatus)
+		return err
+	}
+	t.setdata(ctx, taskruntype, realid, define.TsFinish, taskstatus)
+	return nil
+}
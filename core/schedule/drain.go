@@ -0,0 +1,98 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"go.uber.org/zap"
+)
+
+// 排空worker
+// 通过API在主机列表排空或者worker收到SIGTERM时开始排空，排空中的worker拒绝运行新任务，调度中心也不再选择此worker
+// 等待正在运行的任务全部结束(或者超时)后，worker通知调度中心下线并停止发送心跳
+// 排空进度(正在运行的任务)随心跳上报，在主机列表中展示
+
+const (
+	// default max time wait running tasks finish
+	defaultDrainTimeout = time.Minute * 5
+)
+
+var (
+	drainonce sync.Once
+	// closed when running tasks finished or wait timeout
+	draindone = make(chan struct{})
+	// closed when worker unregistry from server
+	unregistried = make(chan struct{})
+)
+
+// hostdrain return whether host need drain,
+// if worker start drain by itself, set host drain so scheduler will not select it
+func hostdrain(ctx context.Context, addr string, draining bool) bool {
+	host, err := model.GetHostByAddr(ctx, addr)
+	if err != nil {
+		log.Error("model.GetHostByAddr failed", zap.String("addr", addr), zap.Error(err))
+		return draining
+	}
+	if draining && !host.Drain {
+		err = model.DrainHost(ctx, host.ID)
+		if err != nil {
+			log.Error("model.DrainHost failed", zap.String("addr", addr), zap.Error(err))
+		}
+	}
+	return host.Drain || draining
+}
+
+func draintimeout() time.Duration {
+	if config.CoreConf.Client.DrainTimeout.Duration > 0 {
+		return config.CoreConf.Client.DrainTimeout.Duration
+	}
+	return defaultDrainTimeout
+}
+
+// startdrain worker stop recv new task and wait running tasks finish
+func startdrain() {
+	drainonce.Do(func() {
+		runningtask.SetDrain()
+		running, _ := runningtask.Slots()
+		log.Info("worker start drain", zap.Int("running", running), zap.Duration("timeout", draintimeout()))
+		go waitdrain(draintimeout())
+	})
+}
+
+// waitdrain wait running tasks finish or timeout, then close draindone
+func waitdrain(timeout time.Duration) {
+	defer close(draindone)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		running, _ := runningtask.Slots()
+		if running == 0 {
+			log.Info("worker drain finished")
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			log.Warn("wait running tasks finish timeout",
+				zap.Int("running", running), zap.Strings("tasks", runningtask.GetRunningTasks()))
+			return
+		}
+	}
+}
+
+// DrainWorker start drain and wait worker unregistry from server,
+// if server is unavailable, it will return after drain timeout
+func DrainWorker() {
+	startdrain()
+	select {
+	case <-unregistried:
+	case <-time.After(draintimeout() + defaultRPCTimeout*2):
+		log.Warn("wait worker unregistry timeout")
+	}
+}
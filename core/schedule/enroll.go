@@ -0,0 +1,292 @@
+package schedule
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/middleware"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 注册令牌
+// 管理员创建一次性或有过期时间的注册令牌(可以绑定主机组和标签)，worker首次注册时使用令牌换取自己的凭证，
+// 凭证保存在worker本地，之后worker和调度中心之间的请求使用凭证认证，在主机列表中可以按主机吊销凭证
+// secrettoken为空时禁用共享密钥，泄露某台机器上的凭证只影响这台worker
+// 调度中心缓存主机的凭证hash，吊销或重新注册时通过redis pubsub通知所有的调度节点删除缓存
+
+const (
+	mdEnrollToken    = "enroll_token"
+	mdHostCredential = "host_credential"
+
+	registryMethod = "/crocodile.task.Heartbeat/RegistryHost"
+
+	defaultCredentialFile = "crocodile.credential"
+
+	credentialChannel = "host.credential"
+	// max time of cached host credential, avoid use revoked credential if pubsub message is lost
+	credentialCacheTTL = time.Minute
+)
+
+// credentialstore worker's own credential, save in local file
+type credentialstore struct {
+	sync.RWMutex
+	enable     bool // only worker load credential
+	file       string
+	credential string
+}
+
+var workercredential = &credentialstore{}
+
+// load credential from file, file not exist means worker is not enrolled
+func (cs *credentialstore) load(file string) error {
+	if file == "" {
+		file = defaultCredentialFile
+	}
+	cs.Lock()
+	defer cs.Unlock()
+	cs.enable = true
+	cs.file = file
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("ioutil.ReadFile failed: %w", err)
+	}
+	cs.credential = strings.TrimSpace(string(data))
+	return nil
+}
+
+func (cs *credentialstore) get() string {
+	cs.RLock()
+	defer cs.RUnlock()
+	return cs.credential
+}
+
+func (cs *credentialstore) isworker() bool {
+	cs.RLock()
+	defer cs.RUnlock()
+	return cs.enable
+}
+
+// save credential issued by scheduler
+func (cs *credentialstore) save(credential string) error {
+	cs.Lock()
+	defer cs.Unlock()
+	err := ioutil.WriteFile(cs.file, []byte(credential), 0600)
+	if err != nil {
+		return fmt.Errorf("ioutil.WriteFile failed: %w", err)
+	}
+	cs.credential = credential
+	return nil
+}
+
+// credentialcache cache hosts with credential hash on scheduler, so rpc request need not query db
+type credentialcache struct {
+	sync.RWMutex
+	byid   map[string]*cachedhost
+	byaddr map[string]*cachedhost
+}
+
+type cachedhost struct {
+	host   *define.Host
+	expire time.Time
+}
+
+var hostcredentials = &credentialcache{
+	byid:   make(map[string]*cachedhost),
+	byaddr: make(map[string]*cachedhost),
+}
+
+func (cc *credentialcache) cached(ch *cachedhost, ok bool) (*define.Host, bool) {
+	if !ok || time.Now().After(ch.expire) {
+		return nil, false
+	}
+	return ch.host, true
+}
+
+func (cc *credentialcache) set(host *define.Host) {
+	cc.Lock()
+	defer cc.Unlock()
+	ch := &cachedhost{host: host, expire: time.Now().Add(credentialCacheTTL)}
+	cc.byid[host.ID] = ch
+	cc.byaddr[host.Addr] = ch
+}
+
+// getbyid return host by id from cache or db
+func (cc *credentialcache) getbyid(ctx context.Context, hostid string) (*define.Host, error) {
+	cc.RLock()
+	ch, ok := cc.byid[hostid]
+	cc.RUnlock()
+	if host, ok := cc.cached(ch, ok); ok {
+		return host, nil
+	}
+	host, err := model.GetHostByID(ctx, hostid)
+	if err != nil {
+		return nil, err
+	}
+	cc.set(host)
+	return host, nil
+}
+
+// getbyaddr return host by addr from cache or db
+func (cc *credentialcache) getbyaddr(ctx context.Context, addr string) (*define.Host, error) {
+	cc.RLock()
+	ch, ok := cc.byaddr[addr]
+	cc.RUnlock()
+	if host, ok := cc.cached(ch, ok); ok {
+		return host, nil
+	}
+	host, err := model.GetHostByAddr(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	cc.set(host)
+	return host, nil
+}
+
+func (cc *credentialcache) delete(hostid string) {
+	cc.Lock()
+	defer cc.Unlock()
+	ch, ok := cc.byid[hostid]
+	if !ok {
+		return
+	}
+	delete(cc.byid, hostid)
+	if cc.byaddr[ch.host.Addr] == ch {
+		delete(cc.byaddr, ch.host.Addr)
+	}
+}
+
+// InvalidHostCredential delete cached credential of host on all scheduler after credential changed
+func InvalidHostCredential(hostid string) {
+	hostcredentials.delete(hostid)
+	if Cron2 == nil || Cron2.redis == nil {
+		return
+	}
+	err := Cron2.redis.Publish(credentialChannel, hostid).Err()
+	if err != nil {
+		log.Error("publish credential event failed", zap.String("hostid", hostid), zap.Error(err))
+	}
+}
+
+// newcredential generate credential like hostid.random, so scheduler can find host by credential
+func newcredential(hostid string) (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand.Read failed: %w", err)
+	}
+	return hostid + "." + hex.EncodeToString(b), nil
+}
+
+func mdget(md metadata.MD, key string) string {
+	v := md.Get(key)
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+type authhostkey struct{}
+
+// checkauth check rpc request which is not authed by secret token
+// scheduler: worker registry by enroll token or use credential issued by scheduler
+// worker: scheduler use credential issued to this worker
+func checkauth(mode define.RunMode) middleware.AuthFunc {
+	return func(ctx context.Context, method string) (context.Context, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		credential := mdget(md, mdHostCredential)
+		switch mode {
+		case define.Server:
+			// 注册令牌在RegistryHost中校验
+			if method == registryMethod && mdget(md, mdEnrollToken) != "" {
+				return ctx, nil
+			}
+			if credential == "" {
+				break
+			}
+			host, err := verifycredential(ctx, credential)
+			if err != nil {
+				log.Error("verifycredential failed", zap.String("method", method), zap.Error(err))
+				break
+			}
+			return context.WithValue(ctx, authhostkey{}, host), nil
+		case define.Client:
+			// 调度中心只保存凭证的hash，请求worker时使用hash认证
+			local := workercredential.get()
+			if local != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(model.HashCredential(local))) == 1 {
+				return ctx, nil
+			}
+		}
+		return ctx, status.Errorf(codes.Unauthenticated, "secrettoken or credential auth failed")
+	}
+}
+
+// verifycredential return host of credential
+func verifycredential(ctx context.Context, credential string) (*define.Host, error) {
+	hostid := strings.SplitN(credential, ".", 2)[0]
+	host, err := hostcredentials.getbyid(ctx, hostid)
+	if err != nil {
+		return nil, err
+	}
+	if host.Credential == "" ||
+		subtle.ConstantTimeCompare([]byte(model.HashCredential(credential)), []byte(host.Credential)) != 1 {
+		return nil, fmt.Errorf("credential of host %s is invalid or revoked", hostid)
+	}
+	return host, nil
+}
+
+// checkauthhost worker authed by credential can only request as itself
+func checkauthhost(ctx context.Context, addr string) error {
+	host, ok := ctx.Value(authhostkey{}).(*define.Host)
+	if !ok {
+		// authed by secret token
+		return nil
+	}
+	if host.Addr != addr {
+		return status.Errorf(codes.PermissionDenied, "credential of host %s can not be used by %s", host.Addr, addr)
+	}
+	return nil
+}
+
+// enrollhost check enroll token in request, worker will registry with labels and hostgroup of token
+func enrollhost(ctx context.Context) (*define.EnrollToken, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := mdget(md, mdEnrollToken)
+	if token == "" {
+		return nil, nil
+	}
+	enrolltoken, err := model.CheckEnrollToken(ctx, token)
+	if err != nil {
+		if err == model.ErrEnrollTokenInvalid {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, err
+	}
+	return enrolltoken, nil
+}
+
+// registryctx add enroll token to registry request if worker need exchange it for credential
+func registryctx(ctx context.Context, needenroll bool) context.Context {
+	token := config.CoreConf.Client.EnrollToken
+	if token == "" || (!needenroll && workercredential.get() != "") {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, mdEnrollToken, token)
+}
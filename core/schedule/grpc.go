@@ -11,6 +11,7 @@ import (
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/common/sysinfo"
 	"github.com/labulaka521/crocodile/core/cert"
 	"github.com/labulaka521/crocodile/core/config"
 	"github.com/labulaka521/crocodile/core/middleware"
@@ -34,6 +35,8 @@ const (
 	defaultLastFailHearBeatInterval = time.Second * 3
 	// max retry get host time for func Next
 	defaultMaxRetryGetWorkerHost = 3
+	// max skip busy host time for func Next
+	defaultMaxSkipBusyWorkerHost = 10
 )
 
 var (
@@ -76,6 +79,17 @@ func (cg *cachegRPCConn) addgRPCClientConn(addr string, conn *grpc.ClientConn) {
 	cg.Unlock()
 }
 
+// delgRPCClientConn close and delete conn of addr
+func (cg *cachegRPCConn) delgRPCClientConn(addr string) {
+	cg.Lock()
+	conn, exist := cg.conn[addr]
+	delete(cg.conn, addr)
+	cg.Unlock()
+	if exist {
+		conn.Close()
+	}
+}
+
 // getgRPCConn Get Grpc Client Conn
 func getgRPCConn(ctx context.Context, addr string) (*grpc.ClientConn, error) {
 	conn := cachegRPCConnM.getgRPCClientConn(addr)
@@ -89,7 +103,7 @@ func getgRPCConn(ctx context.Context, addr string) (*grpc.ClientConn, error) {
 
 	dialoptions := []grpc.DialOption{
 		grpc.WithPerRPCCredentials(
-			&Auth{SecretToken: config.CoreConf.SecretToken},
+			&Auth{SecretToken: config.CoreConf.SecretToken, addr: addr},
 		),
 		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(16 * 1024 * 1024)), // 16M
 		grpc.WithBlock(),
@@ -97,7 +111,15 @@ func getgRPCConn(ctx context.Context, addr string) (*grpc.ClientConn, error) {
 		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{MaxDelay: time.Second * 2}, MinConnectTimeout: time.Second * 2}),
 	}
 
-	if config.CoreConf.Cert.Enable {
+	if config.CoreConf.Cert.Enable && config.CoreConf.Cert.CAFile != "" {
+		tlsconf, err := cert.NewClientTLSConfig(config.CoreConf.Cert.CertFile, config.CoreConf.Cert.KeyFile,
+			config.CoreConf.Cert.CAFile, config.CoreConf.Cert.CRLFile)
+		if err != nil {
+			log.Error("cert.NewClientTLSConfig failed", zap.Error(err))
+			return nil, err
+		}
+		dialoptions = append(dialoptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsconf)))
+	} else if config.CoreConf.Cert.Enable {
 		c, err = credentials.NewClientTLSFromFile(config.CoreConf.Cert.CertFile, cert.ServerName)
 		if err != nil {
 			log.Error("credentials.NewClientTLSFromFile failed", zap.Error(err))
@@ -119,33 +141,81 @@ func getgRPCConn(ctx context.Context, addr string) (*grpc.ClientConn, error) {
 	return conn, nil
 }
 
+// workerconn run task on worker, it is a grpc conn dialed by scheduler or a tunnel opened by worker
+type workerconn interface {
+	RunTask(ctx context.Context, in *pb.TaskReq, opts ...grpc.CallOption) (pb.Task_RunTaskClient, error)
+	Target() string
+}
+
+// dialconn run task on worker by grpc conn dialed by scheduler
+type dialconn struct {
+	*grpc.ClientConn
+	pb.TaskClient
+}
+
+// getworkerconn return tunnel if worker open tunnel to this scheduler, otherwise dial worker
+func getworkerconn(ctx context.Context, addr string) (workerconn, error) {
+	if session := tunnels.get(addr); session != nil {
+		return session, nil
+	}
+	conn, err := getgRPCConn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &dialconn{ClientConn: conn, TaskClient: pb.NewTaskClient(conn)}, nil
+}
+
 // NewgRPCServer new gRPC server
 func NewgRPCServer(mode define.RunMode) (*grpc.Server, error) {
-	serveroptions := []grpc.ServerOption{
-		grpc_middleware.WithUnaryServerChain(
-			middleware.RecoveryInterceptor,
-			middleware.LoggerInterceptor,
-			middleware.CheckSecretInterceptor,
-		),
-		grpc.MaxRecvMsgSize(16 * 1024 * 1024),
-		// grpc.KeepaliveParams(keepalive.ServerParameters{
-		// 	MaxConnectionIdle: 5 * time.Minute, // <--- This fixes it!
-		// }),
+	unaryinterceptors := []grpc.UnaryServerInterceptor{
+		middleware.RecoveryInterceptor,
+		middleware.LoggerInterceptor,
+		middleware.CheckSecretInterceptor(checkauth(mode)),
 	}
-	if config.CoreConf.Cert.Enable {
+	streaminterceptors := []grpc.StreamServerInterceptor{
+		middleware.CheckSecretStreamInterceptor(checkauth(mode)),
+	}
+	var creds credentials.TransportCredentials
+	if config.CoreConf.Cert.Enable && config.CoreConf.Cert.CAFile != "" {
+		tlsconf, err := cert.NewServerTLSConfig(config.CoreConf.Cert.CertFile, config.CoreConf.Cert.KeyFile,
+			config.CoreConf.Cert.CAFile, config.CoreConf.Cert.CRLFile)
+		if err != nil {
+			log.Error("cert.NewServerTLSConfig failed", zap.Error(err))
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsconf)
+		// 调度中心只接受worker证书, worker只接受调度中心证书
+		peerrole := cert.RoleWorker
+		if mode == define.Client {
+			peerrole = cert.RoleScheduler
+		}
+		unaryinterceptors = append(unaryinterceptors, middleware.CheckCertInterceptor(peerrole))
+		streaminterceptors = append(streaminterceptors, middleware.CheckCertStreamInterceptor(peerrole))
+	} else if config.CoreConf.Cert.Enable {
 		c, err := credentials.NewServerTLSFromFile(config.CoreConf.Cert.CertFile, config.CoreConf.Cert.KeyFile)
 		if err != nil {
 			log.Error("credentials.NewServerTLSFromFile failed", zap.Error(err))
 			return nil, err
 		}
-		serveroptions = append(serveroptions, grpc.Creds(c))
-
+		creds = c
+	}
+	serveroptions := []grpc.ServerOption{
+		grpc_middleware.WithUnaryServerChain(unaryinterceptors...),
+		grpc_middleware.WithStreamServerChain(streaminterceptors...),
+		grpc.MaxRecvMsgSize(16 * 1024 * 1024),
+		// grpc.KeepaliveParams(keepalive.ServerParameters{
+		// 	MaxConnectionIdle: 5 * time.Minute, // <--- This fixes it!
+		// }),
+	}
+	if creds != nil {
+		serveroptions = append(serveroptions, grpc.Creds(creds))
 	}
 	auth := Auth{SecretToken: config.CoreConf.SecretToken}
 	grpcserver := grpc.NewServer(serveroptions...)
 	switch mode {
 	case define.Server:
 		pb.RegisterHeartbeatServer(grpcserver, &HeartbeatService{Auth: auth})
+		pb.RegisterTunnelServer(grpcserver, &TunnelService{Auth: auth})
 	case define.Client:
 		pb.RegisterTaskServer(grpcserver, &TaskService{Auth: auth})
 	}
@@ -155,13 +225,35 @@ func NewgRPCServer(mode define.RunMode) (*grpc.Server, error) {
 // Auth check rpc request valid
 type Auth struct {
 	SecretToken string
+	addr        string // dial addr
 }
 
 // GetRequestMetadata implement PerRPCCredentials interface
 func (a *Auth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
-	return map[string]string{
+	md := map[string]string{
 		"secret_token": a.SecretToken,
-	}, nil
+	}
+	if credential := a.credential(ctx); credential != "" {
+		md[mdHostCredential] = credential
+	}
+	return md, nil
+}
+
+// credential return worker's own credential on worker,
+// or the credential hash issued to the dialed worker on scheduler
+func (a *Auth) credential(ctx context.Context) string {
+	if workercredential.isworker() {
+		return workercredential.get()
+	}
+	if a.addr == "" {
+		return ""
+	}
+	host, err := hostcredentials.getbyaddr(ctx, a.addr)
+	if err != nil {
+		log.Error("get host credential failed", zap.String("addr", a.addr), zap.Error(err))
+		return ""
+	}
+	return host.Credential
 }
 
 // RequireTransportSecurity indicates whether the credentials requires
@@ -174,34 +266,86 @@ func (a *Auth) RequireTransportSecurity() bool {
 // Scheduling Algorithm
 // - random
 // - LeastTask
+// - LeastLoad
+// - ConsistentHash
 // - Weight
 // - roundRobin
 // get rpc conn
-func tryGetRCCConn(ctx context.Context, next Next) (*grpc.ClientConn, error) {
+// exclude is the worker addrs which rejected this run because running tasks reach max concurrent
+// or which is down during this run, Next will not return excluded worker,
+// busy worker will be skipped and do not count as a retry, down worker will count as a retry
+func tryGetRCCConn(ctx context.Context, next Next, exclude map[string]bool) (workerconn, error) {
 	var (
 		err  error
-		conn *grpc.ClientConn
+		conn workerconn
+		skip int
 	)
 	for i := 0; i < defaultMaxRetryGetWorkerHost; i++ {
-		host := next()
+		host := next(exclude)
 		if host == nil {
 			err = errors.New("Can't Get Valid Worker Host")
 			continue
 		}
-		conn, err = getgRPCConn(ctx, host.Addr)
+		if hostbusy(host) {
+			log.Debug("worker is busy, try next host", zap.String("addr", host.Addr))
+			err = resp.GetMsgErr(resp.ErrRPCWorkerBusy)
+			if skip < defaultMaxSkipBusyWorkerHost {
+				skip++
+				i--
+			}
+			continue
+		}
+		conn, err = getworkerconn(ctx, host.Addr)
 		if err != nil {
 			log.Error("GetRpcConn failed", zap.Error(err))
 			continue
 		}
+		dc, ok := conn.(*dialconn)
+		if !ok {
+			// tunnel is always ready until it is closed
+			return conn, nil
+		}
 		// when only conn is Ready, direct return this conn,otherse
-		if conn.GetState() == connectivity.Ready {
+		if dc.GetState() == connectivity.Ready {
 			return conn, nil
 		}
-		conn.Close()
+		dc.Close()
 	}
 	return nil, err
 }
 
+// hostbusy return whether worker running tasks reach max concurrent by last heartbeat
+func hostbusy(host *define.Host) bool {
+	return host.MaxConcurrent > 0 && host.RunningSlots >= host.MaxConcurrent
+}
+
+// getresource return worker resource usage send by heartbeat
+func getresource() *pb.Resource {
+	// 任务代码保存在临时目录中，上报临时目录所在磁盘的使用情况
+	info := sysinfo.Get(os.TempDir())
+	running, maxconcurrent := runningtask.Slots()
+	slots := config.CoreConf.Client.TaskSlots
+	if slots <= 0 {
+		slots = maxconcurrent
+	}
+	if slots <= 0 {
+		slots = info.CPUNum
+	}
+	return &pb.Resource{
+		CpuNum:        int32(info.CPUNum),
+		Load1:         info.Load1,
+		Load5:         info.Load5,
+		Load15:        info.Load15,
+		MemTotal:      info.MemTotal,
+		MemAvailable:  info.MemAvailable,
+		DiskTotal:     info.DiskTotal,
+		DiskFree:      info.DiskFree,
+		TaskSlots:     int32(slots),
+		RunningSlots:  int32(running),
+		MaxConcurrent: int32(maxconcurrent),
+	}
+}
+
 // RegistryClient registry client to server
 func RegistryClient(version string, port int) {
 	rand.Seed(time.Now().UnixNano())
@@ -209,6 +353,8 @@ func RegistryClient(version string, port int) {
 		// cancel   context.CancelFunc
 		// ctx      context.Context
 		lastaddr string
+		// registry with enroll token even if worker has credential
+		needenroll bool
 	)
 
 	for {
@@ -219,6 +365,14 @@ func RegistryClient(version string, port int) {
 			// cancel()
 			return
 		}
+		// 排空结束后不再重新注册
+		select {
+		case <-draindone:
+			log.Info("worker drain finished, stop registry")
+			close(unregistried)
+			return
+		default:
+		}
 		// do not get last addr
 		for {
 			getaddr := addrs[rand.Int()%len(addrs)]
@@ -245,14 +399,28 @@ func RegistryClient(version string, port int) {
 			Hostgroup: config.CoreConf.Client.HostGroup,
 			Weight:    int32(config.CoreConf.Client.Weight),
 			Remark:    config.CoreConf.Client.Remark,
+			Labels:    config.CoreConf.Client.Labels,
 		}
-		_, err = hbClient.RegistryHost(context.Background(), &regHost)
+		regresp, err := hbClient.RegistryHost(registryctx(context.Background(), needenroll), &regHost)
 		if err != nil {
+			// 凭证被吊销或者不是本机的凭证时使用注册令牌重新注册
+			if code := status.Code(err); code == codes.Unauthenticated || code == codes.PermissionDenied {
+				needenroll = true
+			}
 			log.Error("registry client failed", zap.Error(err))
 			time.Sleep(time.Second)
 			continue
 
 		}
+		needenroll = false
+		if credential := regresp.GetCredential(); credential != "" {
+			err = workercredential.save(credential)
+			if err != nil {
+				log.Error("save credential failed", zap.Error(err))
+			} else {
+				log.Info("worker enroll success, credential is saved", zap.String("file", workercredential.file))
+			}
+		}
 
 		log.Info("host registry success", zap.String("server", lastaddr))
 		timer := time.NewTimer(defaultHearbeatInterval)
@@ -264,11 +432,18 @@ func RegistryClient(version string, port int) {
 				hbreq := &pb.HeartbeatReq{
 					Port:        int32(port),
 					RunningTask: runningtask.GetRunningTasks(),
+					Resource:    getresource(),
+					Draining:    runningtask.IsDrain(),
 				}
 
-				_, err := hbClient.SendHb(ctx, hbreq)
+				hbresp, err := hbClient.SendHb(ctx, hbreq)
 				if err != nil {
 					cancel()
+					if code := status.Code(err); code == codes.Unauthenticated || code == codes.PermissionDenied {
+						log.Error("credential is revoked, registry again", zap.Error(err))
+						needenroll = true
+						goto Next
+					}
 					err := DealRPCErr(err)
 					if err.Error() == resp.GetMsgErr(resp.ErrRPCUnavailable).Error() {
 						if cannotconn > 1 {
@@ -289,7 +464,22 @@ func RegistryClient(version string, port int) {
 				cannotconn = 0
 				cancel()
 				log.Debug("send hearbeat success", zap.String("server", lastaddr))
+				if hbresp.GetDrain() {
+					startdrain()
+				}
 				timer.Reset(defaultHearbeatInterval)
+			case <-draindone:
+				ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+				_, err := hbClient.UnRegistryHost(ctx, &pb.HeartbeatReq{Port: int32(port), Draining: true})
+				cancel()
+				if err != nil {
+					log.Error("client.UnRegistryHost failed", zap.Error(DealRPCErr(err)))
+				} else {
+					log.Info("worker unregistry success", zap.String("server", lastaddr))
+				}
+				timer.Stop()
+				close(unregistried)
+				return
 			case <-clentstophb:
 				log.Info("Stop Send HearBeat")
 				timer.Stop()
@@ -362,6 +552,8 @@ func DealRPCErr(err error) error {
 			return resp.GetMsgErr(resp.ErrRPCUnauthenticated)
 		case codes.Unavailable:
 			return resp.GetMsgErr(resp.ErrRPCUnavailable)
+		case codes.ResourceExhausted:
+			return resp.GetMsgErr(resp.ErrRPCWorkerBusy)
 		}
 	}
 	return err
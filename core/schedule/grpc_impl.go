@@ -11,12 +11,20 @@ import (
 	"github.com/labulaka521/crocodile/core/utils/resp"
 
 	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/cert"
+	"github.com/labulaka521/crocodile/core/config"
 	"github.com/labulaka521/crocodile/core/model"
 	pb "github.com/labulaka521/crocodile/core/proto"
+	"github.com/labulaka521/crocodile/core/stats"
 	"github.com/labulaka521/crocodile/core/tasktype"
+	"github.com/labulaka521/crocodile/core/tracing"
+	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -31,25 +39,67 @@ var (
 	runningtask *runningcache
 )
 
+// errWorkerBusy worker running tasks reach max concurrent
+var errWorkerBusy = status.Error(codes.ResourceExhausted, "worker running tasks reach max concurrent")
+
+// errWorkerDraining worker is draining, scheduler will skip it like busy worker
+var errWorkerDraining = status.Error(codes.ResourceExhausted, "worker is draining")
+
 // InitWorker will set task running and save context.CancelFunc
 func InitWorker() {
 	runningtask = &runningcache{
 		running: make(map[string]context.CancelFunc),
+		max:     config.CoreConf.Client.MaxConcurrent,
+	}
+	err := workercredential.load(config.CoreConf.Client.CredentialFile)
+	if err != nil {
+		log.Error("load credential failed", zap.Error(err))
 	}
 }
 
 type runningcache struct {
 	sync.RWMutex
 	running map[string]context.CancelFunc
+	max     int  // max running tasks, 0 is unlimited
+	drain   bool // worker is draining, do not recv new task
 }
 
-// Add set task is running in runningtask
-func (t *runningcache) Add(id string, taskcancel context.CancelFunc) {
+// TryAdd set task is running in runningtask,
+// if running tasks reach max concurrent or worker is draining return false
+func (t *runningcache) TryAdd(id string, taskcancel context.CancelFunc) bool {
 	t.Lock()
+	defer t.Unlock()
+	if t.drain {
+		return false
+	}
+	if t.max > 0 && len(t.running) >= t.max {
+		return false
+	}
 	t.running[id] = taskcancel
+	return true
+}
+
+// Slots return running tasks and max concurrent
+func (t *runningcache) Slots() (int, int) {
+	t.RLock()
+	defer t.RUnlock()
+	return len(t.running), t.max
+}
+
+// SetDrain reject all new tasks
+func (t *runningcache) SetDrain() {
+	t.Lock()
+	t.drain = true
 	t.Unlock()
 }
 
+// IsDrain return whether worker is draining
+func (t *runningcache) IsDrain() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.drain
+}
+
 // Del will delete task from tskrunning
 func (t *runningcache) Del(id string) {
 	t.Lock()
@@ -97,9 +147,25 @@ func (ts *TaskService) RunTask(req *pb.TaskReq, stream pb.Task_RunTaskServer) er
 		return nil
 	}
 	log.Info("recv new task", zap.Any("taskid", req.GetTaskId()), zap.String("codetype", r.Type()))
-	taskctx, taskcancel := context.WithCancel(stream.Context())
+	// 从grpc metadata中取出server的trace context, worker执行作为server端runTask的子span
+	ctx, span := tracing.Start(tracing.ExtractGRPC(stream.Context()), "worker.RunTask",
+		attribute.String("task.id", req.GetTaskId()),
+		attribute.String("task.codetype", r.Type()),
+	)
+	defer span.End()
+	taskctx, taskcancel := context.WithCancel(ctx)
 
-	runningtask.Add(req.GetTaskId(), taskcancel)
+	// 运行任务数达到上限或者正在排空，返回ResourceExhausted，调度中心会选择其他worker运行
+	if !runningtask.TryAdd(req.GetTaskId(), taskcancel) {
+		taskcancel()
+		rejecterr := errWorkerBusy
+		if runningtask.IsDrain() {
+			rejecterr = errWorkerDraining
+		}
+		log.Warn("reject task", zap.String("taskid", req.GetTaskId()), zap.Error(rejecterr))
+		tracing.SetError(span, rejecterr)
+		return rejecterr
+	}
 	defer runningtask.Del(req.GetTaskId())
 
 	out := r.Run(taskctx)
@@ -116,6 +182,8 @@ func (ts *TaskService) RunTask(req *pb.TaskReq, stream pb.Task_RunTaskServer) er
 			err = stream.Send(&pb.TaskResp{Resp: []byte(err.Error() + fmt.Sprintf("%3d", tasktype.DefaultExitCode))})
 			if err != nil {
 				log.Error("Send failed", zap.Error(err))
+				stats.GRPCStreamError("RunTask", status.Code(err).String())
+				tracing.SetError(span, err)
 			}
 			return nil
 		}
@@ -124,6 +192,8 @@ func (ts *TaskService) RunTask(req *pb.TaskReq, stream pb.Task_RunTaskServer) er
 			err = stream.Send(&resp)
 			if err != nil {
 				log.Error("stream.Send failed", zap.Error(err))
+				stats.GRPCStreamError("RunTask", status.Code(err).String())
+				tracing.SetError(span, err)
 				return nil
 			}
 		}
@@ -136,44 +206,73 @@ type HeartbeatService struct {
 }
 
 // RegistryHost client registry
-func (hs *HeartbeatService) RegistryHost(ctx context.Context, req *pb.RegistryReq) (*pb.Empty, error) {
+func (hs *HeartbeatService) RegistryHost(ctx context.Context, req *pb.RegistryReq) (*pb.RegistryResp, error) {
 	var (
 		id string
 	)
 
 	p, ok := peer.FromContext(ctx)
 	if !ok {
-		return &pb.Empty{}, errors.New("Registry failed")
+		return &pb.RegistryResp{}, errors.New("Registry failed")
 	}
 	ip, _, _ := net.SplitHostPort(p.Addr.String())
 	log.Debug("registryHost new worker", zap.Any("req", req))
+	// 启用双向认证时worker证书的CN必须为worker的主机名
+	if config.CoreConf.Cert.Enable && config.CoreConf.Cert.CAFile != "" {
+		peercert, err := cert.PeerCert(ctx)
+		if err != nil {
+			log.Error("cert.PeerCert failed", zap.String("ip", ip), zap.Error(err))
+			return &pb.RegistryResp{}, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if peercert.Subject.CommonName != req.Hostname {
+			log.Error("worker cert is not issued for this host", zap.String("ip", ip),
+				zap.String("cn", peercert.Subject.CommonName), zap.String("hostname", req.Hostname))
+			return &pb.RegistryResp{}, status.Errorf(codes.PermissionDenied,
+				"cert %s is not issued for host %s", peercert.Subject.CommonName, req.Hostname)
+		}
+	}
 	req.Ip = ip
 	addr := fmt.Sprintf("%s:%d", req.Ip, req.Port)
 
 	isinstall, err := model.QueryIsInstall(ctx)
 	if err != nil {
 		log.Error("model.QueryIsInstall failed", zap.Error(err))
-		return &pb.Empty{}, err
+		return &pb.RegistryResp{}, err
 	}
 
 	if !isinstall {
-		return &pb.Empty{}, resp.GetMsgErr(resp.NeedInstall)
+		return &pb.RegistryResp{}, resp.GetMsgErr(resp.NeedInstall)
+	}
+
+	enrolltoken, err := enrollhost(ctx)
+	if err != nil {
+		log.Error("enrollhost failed", zap.String("addr", addr), zap.Error(err))
+		return &pb.RegistryResp{}, err
+	}
+	if enrolltoken != nil {
+		// 令牌绑定的标签覆盖worker配置的同名标签
+		req.Labels = append(req.Labels, enrolltoken.Labels...)
+	} else {
+		err = checkauthhost(ctx, addr)
+		if err != nil {
+			return &pb.RegistryResp{}, err
+		}
 	}
 
 	host, exist, err := model.ExistAddr(ctx, addr)
 	if err != nil {
-		return &pb.Empty{}, err
+		return &pb.RegistryResp{}, err
 	}
 	if !exist {
 		id, err = model.RegistryNewHost(ctx, req)
 		if err != nil {
-			return &pb.Empty{}, err
+			return &pb.RegistryResp{}, err
 		}
 	} else {
 		id = host.ID
 		err := model.RegistryToUpdateHost(ctx, req)
 		if err != nil {
-			return &pb.Empty{}, err
+			return &pb.RegistryResp{}, err
 		}
 	}
 
@@ -181,30 +280,110 @@ func (hs *HeartbeatService) RegistryHost(ctx context.Context, req *pb.RegistryRe
 		hg, err := model.GetHostGroupByName(ctx, req.Hostgroup)
 		if err != nil {
 			log.Error("hostgroup not exist,ignore add host to hostrgoup", zap.String("hostgroup", req.Hostgroup))
-			return &pb.Empty{}, nil
+		} else {
+			err = addhosttogroup(ctx, hg, id)
+			if err != nil {
+				return &pb.RegistryResp{}, err
+			}
 		}
+	}
 
-		if !strings.Contains(strings.Join(hg.HostsID, ""), id) {
-			hg.HostsID = append(hg.HostsID, id)
-			err = model.ChangeHostGroup(ctx, hg.HostsID, hg.ID, hg.Remark)
+	regresp := &pb.RegistryResp{}
+	if enrolltoken != nil {
+		if enrolltoken.HostGroupID != "" {
+			hg, err := model.GetHostGroupByID(ctx, enrolltoken.HostGroupID)
 			if err != nil {
-				return &pb.Empty{}, err
+				log.Error("hostgroup of enroll token not exist,ignore add host to hostrgoup",
+					zap.String("hostgroupid", enrolltoken.HostGroupID))
+			} else {
+				err = addhosttogroup(ctx, hg, id)
+				if err != nil {
+					return &pb.RegistryResp{}, err
+				}
+			}
+		}
+		regresp.Credential, err = newcredential(id)
+		if err != nil {
+			return &pb.RegistryResp{}, err
+		}
+		// 注册成功后再使用令牌，注册失败时令牌不会被消耗
+		err = model.EnrollHost(ctx, enrolltoken.ID, id, regresp.Credential)
+		if err != nil {
+			if err == model.ErrEnrollTokenInvalid {
+				return &pb.RegistryResp{}, status.Error(codes.Unauthenticated, err.Error())
 			}
+			return &pb.RegistryResp{}, err
 		}
+		InvalidHostCredential(id)
+		log.Info("worker enroll success", zap.String("addr", addr), zap.String("enrolltoken", enrolltoken.ID))
 	}
 	log.Info("New Worker Registry Success", zap.String("addr", addr))
-	return &pb.Empty{}, err
+	return regresp, nil
+}
+
+// addhosttogroup add registry host to hostgroup if it is not in hostgroup
+func addhosttogroup(ctx context.Context, hg *define.HostGroup, hostid string) error {
+	if strings.Contains(strings.Join(hg.HostsID, ""), hostid) {
+		return nil
+	}
+	hg.HostsID = append(hg.HostsID, hostid)
+	return model.ChangeHostGroup(ctx, hg.HostsID, hg.ApproverIds, hg.ID, hg.ProjectID, hg.Remark)
 }
 
 // SendHb recv heatneat from client
-func (hs *HeartbeatService) SendHb(ctx context.Context, hb *pb.HeartbeatReq) (*pb.Empty, error) {
+func (hs *HeartbeatService) SendHb(ctx context.Context, hb *pb.HeartbeatReq) (*pb.HeartbeatResp, error) {
 
 	p, ok := peer.FromContext(ctx)
 	if !ok {
-		return &pb.Empty{}, errors.New("get peer failed")
+		return &pb.HeartbeatResp{}, errors.New("get peer failed")
 	}
 	ip, _, _ := net.SplitHostPort(p.Addr.String())
 	log.Debug("recv hearbeat", zap.String("addr", fmt.Sprintf("%s:%d", ip, hb.Port)))
-	err := model.UpdateHostHearbeat(ctx, ip, hb.GetPort(), hb.GetRunningTask())
-	return &pb.Empty{}, err
+	// 旧版本的worker不上报资源使用，此时全部为0
+	res := hb.GetResource()
+	hostres := define.HostResource{
+		CPUNum:        int(res.GetCpuNum()),
+		Load1:         res.GetLoad1(),
+		Load5:         res.GetLoad5(),
+		Load15:        res.GetLoad15(),
+		MemTotal:      res.GetMemTotal(),
+		MemAvailable:  res.GetMemAvailable(),
+		DiskTotal:     res.GetDiskTotal(),
+		DiskFree:      res.GetDiskFree(),
+		TaskSlots:     int(res.GetTaskSlots()),
+		RunningSlots:  int(res.GetRunningSlots()),
+		MaxConcurrent: int(res.GetMaxConcurrent()),
+	}
+	addr := fmt.Sprintf("%s:%d", ip, hb.Port)
+	err := checkauthhost(ctx, addr)
+	if err != nil {
+		return &pb.HeartbeatResp{}, err
+	}
+	err = model.UpdateHostHearbeat(ctx, ip, hb.GetPort(), hb.GetRunningTask(), hostres)
+	if err != nil {
+		return &pb.HeartbeatResp{}, err
+	}
+	hostheartbeat(ctx, addr)
+	return &pb.HeartbeatResp{Drain: hostdrain(ctx, addr, hb.GetDraining())}, nil
+}
+
+// UnRegistryHost recv from client after drain finished, mark host offline immediately
+func (hs *HeartbeatService) UnRegistryHost(ctx context.Context, hb *pb.HeartbeatReq) (*pb.Empty, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return &pb.Empty{}, errors.New("get peer failed")
+	}
+	ip, _, _ := net.SplitHostPort(p.Addr.String())
+	addr := fmt.Sprintf("%s:%d", ip, hb.Port)
+	err := checkauthhost(ctx, addr)
+	if err != nil {
+		return &pb.Empty{}, err
+	}
+	err = model.UnRegistryHost(ctx, addr)
+	if err != nil {
+		return &pb.Empty{}, err
+	}
+	hostunregistry(ctx, addr)
+	log.Info("worker unregistry success", zap.String("addr", addr))
+	return &pb.Empty{}, nil
 }
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labulaka521/crocodile/core/utils/define"
+)
+
+// 一致性哈希
+// 每个worker按权重生成虚拟节点，任务按任务ID落在环上顺时针的第一个虚拟节点
+// worker上下线时只有落在此worker上的任务会被重新分配
+// 环上只保存worker地址，主机信息使用每次查询的在线主机，worker繁忙时顺时针查找下一个worker
+
+const (
+	// default virtual node number of a worker with weight defaultWeight
+	defaultVirtualNodes = 100
+	defaultWeight       = 100
+)
+
+type hashring struct {
+	key    string // worker addrs and weights build this ring
+	hashes []uint32
+	addrs  map[uint32]string
+}
+
+// newhashring build hash ring from hosts, virtual node number is proportional to host weight
+func newhashring(hosts []*define.Host) *hashring {
+	ring := &hashring{
+		key:   ringkey(hosts),
+		addrs: make(map[uint32]string),
+	}
+	for _, host := range hosts {
+		for i := 0; i < virtualnodes(host.Weight); i++ {
+			hash := crc32.ChecksumIEEE([]byte(host.Addr + "#" + strconv.Itoa(i)))
+			// 哈希冲突时保留地址较小的worker, 保证每次构建的环相同
+			if exist, ok := ring.addrs[hash]; ok {
+				if exist < host.Addr {
+					continue
+				}
+			} else {
+				ring.hashes = append(ring.hashes, hash)
+			}
+			ring.addrs[hash] = host.Addr
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+	return ring
+}
+
+// get return the worker addr which key is mapped, walk clockwise past the worker if skip return true,
+// return empty if all workers are skipped
+func (r *hashring) get(key string, skip func(addr string) bool) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	for n := 0; n < len(r.hashes); n++ {
+		addr := r.addrs[r.hashes[(i+n)%len(r.hashes)]]
+		if skip == nil || !skip(addr) {
+			return addr
+		}
+	}
+	return ""
+}
+
+// ringkey return a key of hosts, if key is not changed, ring need not rebuild
+func ringkey(hosts []*define.Host) string {
+	keys := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		keys = append(keys, host.Addr+"@"+strconv.Itoa(host.Weight))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// virtualnodes return virtual node number of host weight
+func virtualnodes(weight int) int {
+	if weight <= 0 {
+		return 1
+	}
+	n := defaultVirtualNodes * weight / defaultWeight
+	if n < 1 {
+		return 1
+	}
+	return n
+}
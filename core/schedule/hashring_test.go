@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/labulaka521/crocodile/core/utils/define"
+)
+
+func Test_hashring(t *testing.T) {
+	hosts := []*define.Host{
+		{Addr: "10.0.0.1:8080", Weight: 100},
+		{Addr: "10.0.0.2:8080", Weight: 100},
+		{Addr: "10.0.0.3:8080", Weight: 200},
+	}
+	ring := newhashring(hosts)
+	before := map[string]string{}
+	count := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		taskid := strconv.Itoa(i)
+		host := ring.get(taskid, nil)
+		before[taskid] = host
+		count[host]++
+	}
+	// 权重为200的worker分配的任务数大约为其他worker的两倍
+	if count["10.0.0.3:8080"] < count["10.0.0.1:8080"] || count["10.0.0.3:8080"] < count["10.0.0.2:8080"] {
+		t.Errorf("want weight host get more tasks, but get %v", count)
+	}
+	if newhashring(hosts).key != ringkey([]*define.Host{hosts[2], hosts[0], hosts[1]}) {
+		t.Error("want ring key not changed with hosts order")
+	}
+
+	// 10.0.0.2下线，只有原来分配在10.0.0.2上的任务会重新分配
+	ring = newhashring([]*define.Host{hosts[0], hosts[2]})
+	for taskid, addr := range before {
+		host := ring.get(taskid, nil)
+		if addr != "10.0.0.2:8080" && host != addr {
+			t.Fatalf("task %s move from %s to %s", taskid, addr, host)
+		}
+	}
+
+	// 新worker加入，任务只会移动到新worker上
+	ring = newhashring(append(hosts, &define.Host{Addr: "10.0.0.4:8080", Weight: 100}))
+	moved := 0
+	for taskid, addr := range before {
+		host := ring.get(taskid, nil)
+		if host != addr {
+			if host != "10.0.0.4:8080" {
+				t.Fatalf("task %s move from %s to %s", taskid, addr, host)
+			}
+			moved++
+		}
+	}
+	if moved > 3000 {
+		t.Errorf("too many tasks moved: %d", moved)
+	}
+
+	// worker繁忙时顺时针跳过，全部跳过时返回空
+	ring = newhashring(hosts)
+	for taskid, addr := range before {
+		host := ring.get(taskid, func(addr string) bool { return addr == before[taskid] })
+		if host == "" || host == addr {
+			t.Fatalf("want task %s skip %s, but get %s", taskid, addr, host)
+		}
+	}
+	if host := ring.get("1", func(string) bool { return true }); host != "" {
+		t.Errorf("want get empty when all workers skipped, but get %s", host)
+	}
+
+	if host := newhashring(nil).get("1", nil); host != "" {
+		t.Errorf("want get empty, but get %s", host)
+	}
+}
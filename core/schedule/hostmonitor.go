@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/alarm"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/model"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.uber.org/zap"
+)
+
+// 主机监控
+// 调度中心定时检查worker最后一次心跳时间，worker下线或重新上线时记录事件、通知报警用户
+// 并通过redis pubsub通知所有的调度节点，调度节点收到下线事件后不再选择此worker运行任务
+// 多个调度节点通过数据库中记录的在线状态保证每次状态变化只会被一个节点处理
+
+const (
+	hostEventChannel = "host.event"
+	// check host online status interval
+	hostCheckInterval = time.Second * 5
+)
+
+// offlinehosts save offline host id and offline time recv from host event
+var offlinehosts = &offlinecache{hosts: make(map[string]int64)}
+
+type offlinecache struct {
+	sync.RWMutex
+	hosts map[string]int64
+}
+
+func (oc *offlinecache) set(event define.HostEvent) {
+	oc.Lock()
+	defer oc.Unlock()
+	if event.Event == define.HostOffline {
+		oc.hosts[event.HostID] = event.EventTime
+	} else {
+		delete(oc.hosts, event.HostID)
+	}
+}
+
+// isoffline return whether host is offline by host event,
+// if host send heartbeat after offline event, host is not offline even if online event is lost
+func (oc *offlinecache) isoffline(host *define.Host) bool {
+	oc.RLock()
+	defer oc.RUnlock()
+	offlinetime, ok := oc.hosts[host.ID]
+	return ok && host.LastUpdateTimeUnix <= offlinetime
+}
+
+// monitorhosts check host online status every hostCheckInterval
+func monitorhosts() {
+	ticker := time.NewTicker(hostCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			config.CoreConf.Server.DB.MaxQueryTime.Duration)
+		events, err := model.CheckHostsOnline(ctx)
+		if err != nil {
+			log.Error("model.CheckHostsOnline failed", zap.Error(err))
+		}
+		for _, event := range events {
+			emithostevent(ctx, event)
+		}
+		cancel()
+	}
+}
+
+// hostheartbeat change host online when recv heartbeat, so host online event will emit immediately
+func hostheartbeat(ctx context.Context, addr string) {
+	changehostonline(ctx, addr, true)
+}
+
+// hostunregistry change host offline when worker unregistry after drain finished
+func hostunregistry(ctx context.Context, addr string) {
+	changehostonline(ctx, addr, false)
+}
+
+func changehostonline(ctx context.Context, addr string, online bool) {
+	changed, err := model.ChangeHostOnline(ctx, addr, online)
+	if err != nil {
+		log.Error("model.ChangeHostOnline failed", zap.String("addr", addr), zap.Error(err))
+		return
+	}
+	if !changed {
+		return
+	}
+	host, err := model.GetHostByAddr(ctx, addr)
+	if err != nil {
+		log.Error("model.GetHostByAddr failed", zap.String("addr", addr), zap.Error(err))
+		return
+	}
+	event := define.HostOffline
+	if online {
+		event = define.HostOnline
+	}
+	emithostevent(ctx, define.HostEvent{
+		HostID:    host.ID,
+		Addr:      host.Addr,
+		HostName:  host.HostName,
+		Event:     event,
+		EventTime: time.Now().Unix(),
+	})
+}
+
+// emithostevent save host event, publish it to all scheduler and notify alarm users
+func emithostevent(ctx context.Context, event define.HostEvent) {
+	log.Warn("host online status changed", zap.String("addr", event.Addr), zap.String("event", event.Event.String()))
+	err := model.SaveHostEvent(ctx, event)
+	if err != nil {
+		log.Error("model.SaveHostEvent failed", zap.Error(err))
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Error("json.Marshal failed", zap.Error(err))
+	} else {
+		Cron2.redis.Publish(hostEventChannel, data)
+	}
+	go alarm.HostNotify(event)
+}
+
+// dealHostEvent update route cache when recv host event
+func dealHostEvent(data []byte) {
+	var event define.HostEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Error("json.Unmarshal host event failed", zap.Error(err))
+		return
+	}
+	offlinehosts.set(event)
+	if event.Event == define.HostOffline {
+		// 关闭到下线worker的连接，重新上线后重新建立连接
+		cachegRPCConnM.delgRPCClientConn(event.Addr)
+	}
+}
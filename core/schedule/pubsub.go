@@ -40,11 +40,19 @@ type EventData struct {
 	TE     TaskEvent // task event: add change delete stop task
 }
 
-// RecvEvent recv task event
+// RecvEvent recv task event and host event
 func RecvEvent() {
-	sub := Cron2.redis.Subscribe(pubsubChannel)
+	sub := Cron2.redis.Subscribe(pubsubChannel, hostEventChannel, credentialChannel)
 	for msg := range sub.Channel() {
-		log.Debug("recv event", zap.String("data", msg.Payload))
+		log.Debug("recv event", zap.String("channel", msg.Channel), zap.String("data", msg.Payload))
+		if msg.Channel == hostEventChannel {
+			go dealHostEvent([]byte(msg.Payload))
+			continue
+		}
+		if msg.Channel == credentialChannel {
+			hostcredentials.delete(msg.Payload)
+			continue
+		}
 		go dealEvent([]byte(msg.Payload))
 	}
 }
@@ -67,7 +75,7 @@ func dealEvent(data []byte) {
 			log.Error("model.GetTaskByID failed", zap.Error(err))
 			return
 		}
-		Cron2.addtask(task.ID, task.Name, task.Cronexpr, GetRoutePolicy(task.HostGroupID, task.RoutePolicy), task.Run)
+		Cron2.addtask(task.ID, task.Name, task.Cronexpr, GetRoutePolicy(task.ID, task.HostGroupID, task.LabelSelector, task.RoutePolicy), task.Run)
 	case DeleteEvent:
 		Cron2.deletetask(subdata.TaskID)
 	case RunEvent:
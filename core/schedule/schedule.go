@@ -22,7 +22,6 @@ import (
 	"github.com/labulaka521/crocodile/core/tasktype"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 )
 
 var (
@@ -75,14 +74,14 @@ func Init() error {
 		log.Debug("Crocodile is Not Install")
 		return nil
 	}
-	eps, _, err := model.GetTasks(ctx, 0, 0, "", "", "")
+	eps, _, err := model.GetTasks(ctx, 0, 0, "", "", "", "", "")
 	if err != nil {
 		log.Error("GetTasks failed", zap.Error(err))
 		return err
 	}
 
 	for _, t := range eps {
-		Cron.Add(t.ID, t.Name, t.Cronexpr, GetRoutePolicy(t.HostGroupID, t.RoutePolicy))
+		Cron.Add(t.ID, t.Name, t.Cronexpr, GetRoutePolicy(t.ID, t.HostGroupID, t.LabelSelector, t.RoutePolicy))
 	}
 	log.Info("init task success", zap.Int("Total", len(eps)))
 	return nil
@@ -440,7 +439,7 @@ func (s *cacheSchedule) runTask(ctx context.Context, id, /*real run task id*/
 		// task data
 		taskdata *define.GetTask
 		// worker conn
-		conn *grpc.ClientConn
+		conn workerconn
 		// task run data
 		tdata []byte
 		// recv grpc stream
@@ -504,7 +503,7 @@ func (s *cacheSchedule) runTask(ctx context.Context, id, /*real run task id*/
 	logcache.WriteStringf("Start Prepare Task %s[%s]", taskdata.Name, id)
 	logcache.WriteStringf("Start Conn Worker Host For Task %s[%s]", taskdata.Name, id)
 
-	conn, err = tryGetRCCConn(ctx, realtask.next)
+	conn, err = tryGetRCCConn(ctx, realtask.next, nil)
 	if err != nil {
 		log.Error("tryGetRpcConn failed", zap.Error(err))
 		err = fmt.Errorf("Get Rpc Conn Failed From Hostgroup %s[%s] Err: %v",
@@ -542,7 +541,7 @@ func (s *cacheSchedule) runTask(ctx context.Context, id, /*real run task id*/
 	}
 
 	defer ctxcancel()
-	taskclient = pb.NewTaskClient(conn)
+	taskclient = conn
 
 	taskrespstream, err = taskclient.RunTask(taskctx, taskreq)
 	if err != nil {
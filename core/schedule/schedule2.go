@@ -11,23 +11,30 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"errors"
 
 	"github.com/go-redis/redis"
 	"github.com/gorhill/cronexpr"
 	"github.com/labulaka521/crocodile/common/errgroup"
+	"github.com/labulaka521/crocodile/common/jwt"
 	"github.com/labulaka521/crocodile/common/log"
 	"github.com/labulaka521/crocodile/common/utils"
 	"github.com/labulaka521/crocodile/core/alarm"
 	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/loginguard"
 	"github.com/labulaka521/crocodile/core/model"
 	pb "github.com/labulaka521/crocodile/core/proto"
+	"github.com/labulaka521/crocodile/core/stats"
 	"github.com/labulaka521/crocodile/core/tasktype"
+	"github.com/labulaka521/crocodile/core/tracing"
 	"github.com/labulaka521/crocodile/core/utils/define"
 	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -40,6 +47,11 @@ var (
 	ErrNoGetLog = errors.New("no read data from cache")
 )
 
+const (
+	// maxLogChunkSize max bytes read from real log once
+	maxLogChunkSize int64 = 16 * 1024
+)
+
 // task running status
 // redis key name:
 type task2 struct {
@@ -63,6 +75,11 @@ type task2 struct {
 	errTasktype define.TaskRespType // failed task type
 }
 
+// rc return redis client, command will be traced if ctx has a recording span
+func (t *task2) rc(ctx context.Context) *redis.Client {
+	return tracing.Redis(ctx, t.redis)
+}
+
 const (
 	// task
 	taskstatus      string = "status"
@@ -70,20 +87,20 @@ const (
 	taskrealtasklog string = "reallog"
 )
 
-func (t *task2) getdata(taskruntype define.TaskRespType, realid string, setdata string) (interface{}, error) {
+func (t *task2) getdata(ctx context.Context, taskruntype define.TaskRespType, realid string, setdata string) (interface{}, error) {
 	keyname := fmt.Sprintf("task:%s:%d:%s:%s", t.id, taskruntype, realid, setdata)
 
 	switch setdata {
 	case taskstatus:
 		// 任务状态
-		status, err := t.redis.Get(keyname).Int()
+		status, err := t.rc(ctx).Get(keyname).Int()
 		if err != nil {
 			return nil, err
 		}
 		return define.TaskStatus(status), nil
 	case taskresp:
 		// 任务数据
-		res, err := t.redis.Get(keyname).Bytes()
+		res, err := t.rc(ctx).Get(keyname).Bytes()
 		var tmptaskresp define.TaskResp
 		err = json.Unmarshal(res, &tmptaskresp)
 		if err != nil {
@@ -92,13 +109,11 @@ func (t *task2) getdata(taskruntype define.TaskRespType, realid string, setdata
 		return tmptaskresp, nil
 	case taskrealtasklog:
 		// 获取任务的全部日志
-		var res []string
-		err := t.redis.LRange(keyname, 0, -1).ScanSlice(&res)
-		if err != nil {
+		res, err := t.rc(ctx).Get(keyname).Result()
+		if err != nil && err != redis.Nil {
 			return nil, err
 		}
-
-		return strings.Join(res, ""), nil
+		return res, nil
 	default:
 		return nil, errors.New("unknow setdata")
 	}
@@ -108,10 +123,10 @@ func (t *task2) getdata(taskruntype define.TaskRespType, realid string, setdata
 func (t *task2) SetData(tasrunktype define.TaskRespType, realid string,
 	value interface{}, setdata string) error {
 
-	return t.setdata(tasrunktype, realid, value, setdata)
+	return t.setdata(context.Background(), tasrunktype, realid, value, setdata)
 }
 
-func (t *task2) setdata(tasrunktype define.TaskRespType, realid string,
+func (t *task2) setdata(ctx context.Context, tasrunktype define.TaskRespType, realid string,
 	value interface{}, setdata string) error {
 	keyname := fmt.Sprintf("task:%s:%d:%s:%s", t.id, tasrunktype, realid, setdata)
 	switch setdata {
@@ -122,7 +137,7 @@ func (t *task2) setdata(tasrunktype define.TaskRespType, realid string,
 		// 	log.Error("value not can change int", zap.Any("data", taskstatus))
 		// 	return errors.New("value type error")
 		// }
-		err := t.redis.Set(keyname, int(value.(define.TaskStatus)), 0).Err()
+		err := t.rc(ctx).Set(keyname, int(value.(define.TaskStatus)), 0).Err()
 		if err != nil {
 			log.Error("t.redis.Set", zap.Error(err))
 			return fmt.Errorf("t.redis.Set failed: %w", err)
@@ -132,14 +147,24 @@ func (t *task2) setdata(tasrunktype define.TaskRespType, realid string,
 		if err != nil {
 			return fmt.Errorf("json.Marshal failed: %w", err)
 		}
-		err = t.redis.Set(keyname, content, 0).Err()
+		err = t.rc(ctx).Set(keyname, content, 0).Err()
 		if err != nil {
 			return fmt.Errorf("t.redis.Set failed: %w", err)
 		}
 	case taskrealtasklog:
-		err := t.redis.RPush(keyname, value).Err()
+		// 日志使用APPEND追加到字符串中，读取时可以直接按照字节偏移量读取
+		var content string
+		switch v := value.(type) {
+		case []byte:
+			content = string(v)
+		case string:
+			content = v
+		default:
+			return fmt.Errorf("unsupport log value type %T", value)
+		}
+		err := t.rc(ctx).Append(keyname, content).Err()
 		if err != nil {
-			return fmt.Errorf("t.redis.RPush failed: %w", err)
+			return fmt.Errorf("t.redis.Append failed: %w", err)
 		}
 
 	default:
@@ -151,7 +176,8 @@ func (t *task2) setdata(tasrunktype define.TaskRespType, realid string,
 
 // GetTaskTreeStatatus return task tree status data
 func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
-	dependtasks, err := t.gettaskinfos()
+	ctx := context.Background()
+	dependtasks, err := t.gettaskinfos(ctx)
 
 	if err != nil {
 		return nil, false, fmt.Errorf("t.gettaskinfos failed: %w", err)
@@ -164,6 +190,8 @@ func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
 		// childset  bool
 		// task is run finish
 		finish = true
+		// taskruntype:realid -> node, broadcast task run on host and task shard will be added to node's children
+		nodes = make(map[string]*define.TaskStatusTree)
 	)
 	for _, keyname := range dependtasks {
 		// keyname
@@ -180,7 +208,7 @@ func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
 			continue
 		}
 
-		statusres, err := t.getdata(define.TaskRespType(taskruntype), id, taskstatus)
+		statusres, err := t.getdata(ctx, define.TaskRespType(taskruntype), id, taskstatus)
 		if err != nil {
 			log.Error("t.getdata failed", zap.Error(err))
 			continue
@@ -191,10 +219,11 @@ func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
 			finish = false
 		}
 
-		task, exist := Cron2.gettask(id)
+		taskid, subid := splitrealid(id)
+		task, exist := Cron2.gettask(taskid)
 		if !exist {
 			log.Error("get task failed from cacheSchedule",
-				zap.String("taskid", id), zap.Error(err))
+				zap.String("taskid", taskid), zap.Error(err))
 			continue
 		}
 		taskTree := define.TaskStatusTree{
@@ -203,6 +232,21 @@ func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
 			TaskType: define.TaskRespType(taskruntype),
 			Status:   statusres.(define.TaskStatus).String(),
 		}
+		if subid != "" {
+			// 广播任务在每个worker上的运行和任务分片作为任务的子节点
+			if res, err := t.getdata(ctx, define.TaskRespType(taskruntype), id, taskresp); err == nil {
+				tr := res.(define.TaskResp)
+				taskTree.Name = tr.Task
+				if tr.RunHost != "" {
+					taskTree.Name += "@" + tr.RunHost
+				}
+			}
+			if node, ok := nodes[sp[2]+":"+taskid]; ok {
+				node.Children = append(node.Children, &taskTree)
+			}
+			continue
+		}
+		nodes[sp[2]+":"+id] = &taskTree
 		switch define.TaskRespType(taskruntype) {
 		case define.ParentTask:
 			// 如果有任务是run或者fail或者取消状态就设置任务的状态为这
@@ -223,6 +267,7 @@ func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
 			retTasksStatus[1].Status = taskTree.Status
 			retTasksStatus[1].ID = taskTree.ID
 			retTasksStatus[1].Name = taskTree.Name
+			nodes[sp[2]+":"+id] = retTasksStatus[1]
 			setStatus = false
 		case define.ChildTask:
 			if !setStatus {
@@ -243,77 +288,94 @@ func (t *task2) GetTaskTreeStatatus() ([]*define.TaskStatusTree, bool, error) {
 	return retTasksStatus, finish, nil
 }
 
-// GetTaskRealLog return a channel task real log
+// GetTaskRealLog return task real log start from byte offset
 func (t *task2) GetTaskRealLog(taskruntype define.TaskRespType, realid string, offset int64) ([]byte, error) {
-	// 返回一个日志的channel
-	// 循环读取记录任务日志的列表然后将日志写到channel中
-	// offset 为日志的偏移量每次取日志的offset,offset+1
-	// 如果取到了日志就直接返回，如果取出的日志为空并且任务还未运行结束(完成、失败、取消）则返回io.EOF
+	// offset 为日志的字节偏移量，每次最多读取maxLogChunkSize字节
+	// 调用方将offset加上返回数据的长度作为下次读取的偏移量，断线重连时也可以从该偏移量继续读取
+	// 如果取到了日志就直接返回，如果取出的日志为空并且任务已经运行结束(完成、失败、取消）则返回io.EOF
 
+	ctx := context.Background()
 	keyname := fmt.Sprintf("task:%s:%d:%s:%s", t.id, taskruntype, realid, taskrealtasklog)
-	var output []byte
-	err := t.redis.LIndex(keyname, offset).Scan(&output)
+	output, err := t.redis.GetRange(keyname, offset, offset+maxLogChunkSize-1).Bytes()
 	if err != nil {
-		// 如果不为nil则直接返回错误
-		if err != redis.Nil {
-			return nil, err
-		}
-		// 此时未取到新的日志，接下来判断任务的状态
-		// 如果任务状态不是运行状态则此次取日志结束，返回io.EOF
-		// 获取任务状态
-		tsret, tserr := t.getdata(taskruntype, realid, taskstatus)
-		if tserr != nil {
-			return nil, fmt.Errorf("getdata failed: %w", tserr)
-		}
-
-		switch tsret.(define.TaskStatus) {
-		case define.TsFinish, define.TsCancel, define.TsFail:
-			// 任务已经运行结束，返回结束标志EOF
+		return nil, err
+	}
+	// 获取任务状态
+	tsret, tserr := t.getdata(ctx, taskruntype, realid, taskstatus)
+	if tserr != nil {
+		return nil, fmt.Errorf("getdata failed: %w", tserr)
+	}
+	var finish bool
+	switch tsret.(define.TaskStatus) {
+	case define.TsFinish, define.TsCancel, define.TsFail:
+		finish = true
+	}
+	// 任务还在运行时不返回末尾不完整的utf8字符，防止被截断的字符在推送时出现乱码
+	if !finish {
+		output = output[:cutrune(output)]
+	}
+	if len(output) == 0 {
+		// 此时未取到新的日志，如果任务已经运行结束则此次取日志结束，返回io.EOF
+		if finish {
 			return nil, io.EOF
-		default:
-			return nil, ErrNoGetLog
 		}
+		return nil, ErrNoGetLog
 	}
 	return output, nil
 }
 
+// cutrune return the length of p without the trailing incomplete utf8 rune
+func cutrune(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(p[i]) {
+			continue
+		}
+		if !utf8.FullRune(p[i:]) {
+			return i
+		}
+		break
+	}
+	return len(p)
+}
+
 // cleantaskinfos return task's parent child id
-func (t *task2) cleantaskinfos() {
+func (t *task2) cleantaskinfos(ctx context.Context) {
 	log.Debug("start clean old key data", zap.String("task", t.name))
 	taskinfos := "task:" + t.id
 	var res []string
-	err := t.redis.LRange(taskinfos, 0, 1).ScanSlice(&res)
+	rc := t.rc(ctx)
+	err := rc.LRange(taskinfos, 0, 1).ScanSlice(&res)
 	if err != nil {
 		log.Error("t.redis.LRange failed:", zap.Error(err))
 		return
 	}
 	for _, key := range res {
-		t.redis.Del(key + ":" + taskrealtasklog)
-		t.redis.Del(key + ":" + taskresp)
-		t.redis.Del(key + ":" + taskstatus)
+		rc.Del(key + ":" + taskrealtasklog)
+		rc.Del(key + ":" + taskresp)
+		rc.Del(key + ":" + taskstatus)
 	}
-	t.redis.Del(taskinfos)
+	rc.Del(taskinfos)
 	return
 }
 
 // gettaskinfos return task's parent child id
-func (t *task2) gettaskinfos() ([]string, error) {
+func (t *task2) gettaskinfos(ctx context.Context) ([]string, error) {
 	taskinfos := "task:" + t.id
 	var res []string
-	err := t.redis.LRange(taskinfos, 0, -1).ScanSlice(&res)
+	err := t.rc(ctx).LRange(taskinfos, 0, -1).ScanSlice(&res)
 	if err != nil {
 		return nil, fmt.Errorf("t.redis.LRange failed: %w", err)
 	}
 	if len(res) == 0 {
 		fmt.Println("--------------")
-		fmt.Println(t.redis.Keys("task*").Val())
+		fmt.Println(t.rc(ctx).Keys("task*").Val())
 		fmt.Println("--------------")
 		return nil, errors.New("get key taskinfos is empty")
 	}
 	return res, nil
 }
 
-func (t *task2) addtaskinfo(taskruntype define.TaskRespType, realid string) error {
+func (t *task2) addtaskinfo(ctx context.Context, taskruntype define.TaskRespType, realid string) error {
 	// 初始化任务状态
 	// key格式为task:主任务ID:任务的类型:运行任务ID
 	// 主任务ID就是触发此次运行任务的ID
@@ -325,24 +387,24 @@ func (t *task2) addtaskinfo(taskruntype define.TaskRespType, realid string) erro
 	keyname := fmt.Sprintf("task:%s:%d:%s", t.id, taskruntype, realid)
 	t.once.Do(func() {
 		// 清除运行的任务
-		err := t.redis.Del(taskinfos).Err()
+		err := t.rc(ctx).Del(taskinfos).Err()
 		if err != nil {
 			log.Error("once.Do t.redis.Del failed", zap.Error(err))
 		}
 	})
-	err := t.redis.RPush(taskinfos, keyname).Err()
+	err := t.rc(ctx).RPush(taskinfos, keyname).Err()
 	if err != nil {
 		return fmt.Errorf("t.redis.SAdd failed: %w", err)
 	}
 
 	// 初始化任务状态
-	err = t.setdata(taskruntype, realid, define.TsWait, taskstatus)
+	err = t.setdata(ctx, taskruntype, realid, define.TsWait, taskstatus)
 	if err != nil {
 		return fmt.Errorf("t.setdata failed: %w", err)
 	}
 
 	// 清空存储日志list
-	err = t.resettasklog(taskruntype, realid)
+	err = t.resettasklog(ctx, taskruntype, realid)
 	if err != nil {
 		return fmt.Errorf("t.resettasklog failed: %w", err)
 	}
@@ -350,19 +412,19 @@ func (t *task2) addtaskinfo(taskruntype define.TaskRespType, realid string) erro
 }
 
 // resettasklog delete log list
-func (t *task2) resettasklog(tasrunktype define.TaskRespType, realid string) error {
+func (t *task2) resettasklog(ctx context.Context, tasrunktype define.TaskRespType, realid string) error {
 	keyname := fmt.Sprintf("task:%s:%d:%s:%s", t.id, tasrunktype, realid, taskrealtasklog)
-	return t.redis.Del(keyname).Err()
+	return t.rc(ctx).Del(keyname).Err()
 }
 
 // getruntaskdata get runningtask
-func (t *task2) getruntaskdata() (*define.RunTask, error) {
+func (t *task2) getruntaskdata(ctx context.Context) (*define.RunTask, error) {
 	// task:running
 	rtasks := "task:running"
 
 	// task:running:id
 	rtask := rtasks + ":" + t.id
-	res, err := t.redis.Get(rtask).Bytes()
+	res, err := t.rc(ctx).Get(rtask).Bytes()
 	if err != nil {
 		return nil, fmt.Errorf("t.redis.Get failed: %w", err)
 	}
@@ -375,14 +437,16 @@ func (t *task2) getruntaskdata() (*define.RunTask, error) {
 }
 
 // savetasklog save running task
-func (t *task2) savetasklog() error {
-	runtask, err := t.getruntaskdata()
+func (t *task2) savetasklog(ctx context.Context) error {
+	runtask, err := t.getruntaskdata(ctx)
 	if err != nil {
 		log.Error("get task info failed", zap.Error(err))
 		return fmt.Errorf("t.gettaskinfo failed: %w", err)
 	}
 
 	tasklogres := &define.Log{
+		RunID:       runtask.RunID,
+		TraceID:     runtask.TraceID,
 		Name:        t.name,
 		RunByTaskID: t.id,
 		StartTime:   runtask.StartTime,
@@ -404,7 +468,7 @@ func (t *task2) savetasklog() error {
 		tasklogres.Status = -1
 	}
 
-	tasks, err := t.gettaskinfos()
+	tasks, err := t.gettaskinfos(ctx)
 	if err != nil {
 		log.Error("t.getttaskinfos failed", zap.Error(err))
 		return err
@@ -427,19 +491,19 @@ func (t *task2) savetasklog() error {
 			continue
 		}
 
-		taskresp, err := t.getdata(define.TaskRespType(i), sp[3], taskresp)
+		taskresp, err := t.getdata(ctx, define.TaskRespType(i), sp[3], taskresp)
 		if err != nil {
 			log.Error("t.getdata task resp failed", zap.Error(err))
 			continue
 		}
 
-		taskstatus, err := t.getdata(define.TaskRespType(i), sp[3], taskstatus)
+		taskstatus, err := t.getdata(ctx, define.TaskRespType(i), sp[3], taskstatus)
 		if err != nil {
 			log.Error("t.getdata task status failed", zap.Error(err))
 			continue
 		}
 
-		tasklog, err := t.getdata(define.TaskRespType(i), sp[3], taskrealtasklog)
+		tasklog, err := t.getdata(ctx, define.TaskRespType(i), sp[3], taskrealtasklog)
 		if err != nil {
 			log.Error("t.getdata task log failed", zap.Error(err))
 			continue
@@ -457,38 +521,40 @@ func (t *task2) savetasklog() error {
 		tasklogres.TaskResps = append(tasklogres.TaskResps, &tr)
 	}
 
-	t.cleantaskinfos()
+	// 先保存日志再清理redis中的数据，保证按运行ID读取日志时总能从redis或者数据库中读取到
+	queryctx, cancel := context.WithTimeout(ctx,
+		config.CoreConf.Server.DB.MaxQueryTime.Duration)
+	defer cancel()
+	err = model.SaveLog(queryctx, tasklogres)
+	if err != nil {
+		log.Error("save task log failed", zap.Error(err))
+	}
+	t.cleantaskinfos(ctx)
 	go alarm.JudgeNotify(tasklogres)
-	go func() {
-		err = model.SaveLog(context.Background(), tasklogres)
-		if err != nil {
-			log.Error("save task log failed", zap.Error(err))
-		}
-	}()
 	return nil
 }
 
-func (t *task2) writelog(tasrunktype define.TaskRespType, realid string, value []byte) {
-	err := t.setdata(tasrunktype, realid, value, taskrealtasklog)
+func (t *task2) writelog(ctx context.Context, tasrunktype define.TaskRespType, realid string, value []byte) {
+	err := t.setdata(ctx, tasrunktype, realid, value, taskrealtasklog)
 	if err != nil {
 		log.Error("t.setdata failed", zap.Error(err))
 	}
 }
 
 // writelogt save log with time
-func (t *task2) writelogt(tasrunktype define.TaskRespType, realid, tmpl string, args ...interface{}) {
+func (t *task2) writelogt(ctx context.Context, tasrunktype define.TaskRespType, realid, tmpl string, args ...interface{}) {
 	value := time.Now().Local().Format("2006-01-02 15:04:05: ") + fmt.Sprintf(tmpl, args...) + "\n"
-	err := t.setdata(tasrunktype, realid, value, taskrealtasklog)
+	err := t.setdata(ctx, tasrunktype, realid, value, taskrealtasklog)
 	if err != nil {
 		log.Error("t.setdata failed", zap.Error(err))
 	}
 }
 
 // getreturncode get task resp code
-func (t *task2) getreturncode(tasrunktype define.TaskRespType, realid string) (int, error) {
+func (t *task2) getreturncode(ctx context.Context, tasrunktype define.TaskRespType, realid string) (int, error) {
 	keyname := fmt.Sprintf("task:%s:%d:%s:%s", t.id, tasrunktype, realid, taskrealtasklog)
-	// 返回最右的值取后5位，然后放入
-	res, err := t.redis.LIndex(keyname, -1).Bytes()
+	// 返回日志的最后5位，然后放入
+	res, err := t.rc(ctx).GetRange(keyname, -5, -1).Bytes()
 	if err != nil {
 		return tasktype.DefaultExitCode, err
 	}
@@ -515,12 +581,15 @@ func (t *task2) getlock(randstr string) (bool, error) {
 	set, err := t.redis.SetNX(lockid, randstr, t.cronsub).Result()
 	if err != nil {
 		log.Error("redis.SetNX failed", zap.Error(err))
+		stats.TaskLock(stats.LockError)
 		return false, err
 	}
 	if !set {
 		log.Warn("can get run lock", zap.String("taskid", t.id))
+		stats.TaskLock(stats.LockContended)
 		return false, nil
 	}
+	stats.TaskLock(stats.LockAcquired)
 	return true, nil
 }
 
@@ -614,25 +683,37 @@ func (t *task2) StartRun(trigger define.Trigger) {
 		close(stopexpire)
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// 一次运行为一个trace, 父任务、主任务、子任务以及redis、db调用都是它的子span
+	ctx, span := tracing.Start(context.Background(), "task.run",
+		attribute.String("task.id", t.id),
+		attribute.String("task.name", t.name),
+		attribute.String("task.trigger", trigger.String()),
+	)
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
 	// save control ctx
 	t.ctxcancel = cancel
 	defer cancel()
 	// 保存运行中的任务
 	runningtask := define.RunTask{
 		ID:        t.id,
+		RunID:     utils.GetID(),
+		TraceID:   tracing.TraceID(ctx),
 		Name:      t.name,
 		Cronexpr:  t.cronexpr,
 		StartTime: time.Now().UnixNano() / 1e6,
 		Trigger:   trigger,
 	}
+	span.SetAttributes(attribute.String("task.run_id", runningtask.RunID))
 
 	Cron2.saverunningtask(&runningtask)
 	defer func() {
 		Cron2.removerunningtask(&runningtask)
 	}()
+	stats.TaskRunStarted(t.name, trigger.String())
 
-	task, err := model.GetTaskByID(context.Background(), t.id)
+	task, err := model.GetTaskByID(ctx, t.id)
 	switch err.(type) {
 	case nil:
 		goto Next
@@ -646,7 +727,7 @@ func (t *task2) StartRun(trigger define.Trigger) {
 Next:
 
 	// 保存一个任务的父子任务的信息
-	// 实时日志 :reallog list
+	// 实时日志 :reallog string
 	// 状态 :status set
 	// 任务返回数据 :taskresp set
 	t.once = sync.Once{}
@@ -654,21 +735,21 @@ Next:
 	// 初始化所有的任务
 	pos := 1
 	for _, parenttaskid := range task.ParentTaskIds {
-		err = t.addtaskinfo(define.ParentTask, parenttaskid)
+		err = t.addtaskinfo(ctx, define.ParentTask, parenttaskid)
 		if err != nil {
 			log.Error("t.addtaskinfo failed", zap.Error(err))
 			return
 		}
 		pos++
 	}
-	err = t.addtaskinfo(define.MasterTask, t.id)
+	err = t.addtaskinfo(ctx, define.MasterTask, t.id)
 	if err != nil {
 		log.Error("t.addtaskinfo failed", zap.Error(err))
 		return
 	}
 	pos++
 	for _, childtaskid := range task.ChildTaskIds {
-		err = t.addtaskinfo(define.ChildTask, childtaskid)
+		err = t.addtaskinfo(ctx, define.ChildTask, childtaskid)
 		if err != nil {
 			log.Error("t.addtaskinfo failed", zap.Error(err))
 			return
@@ -700,9 +781,13 @@ Next:
 	err = g.Wait()
 	if err != nil {
 		log.Error("task run failed", zap.String("taskid", t.id), zap.Error(err))
+		tracing.SetError(span, err)
 	}
+	stats.TaskRunFinished(t.name, trigger.String(), t.errTaskID == "",
+		time.Since(time.Unix(0, runningtask.StartTime*int64(time.Millisecond))))
 
-	err = t.savetasklog()
+	// 任务被取消时ctx也已经被取消,保存日志不能使用它
+	err = t.savetasklog(tracing.Detach(ctx))
 	if err != nil {
 		log.Error("t.savetasklog failed", zap.Error(err))
 	}
@@ -736,7 +821,7 @@ func (t *task2) runMultiTasks(ctx context.Context, RunParallel bool,
 
 // runTask start run task,log will store
 func (t *task2) runTask(ctx context.Context, /*real run task id*/
-	id string, taskruntype define.TaskRespType) error {
+	id string, taskruntype define.TaskRespType) (reterr error) {
 	var (
 		// error
 		err error
@@ -746,25 +831,34 @@ func (t *task2) runTask(ctx context.Context, /*real run task id*/
 		ok       bool
 
 		tdata []byte
-		conn  *grpc.ClientConn
+		conn  workerconn
 		// recv grpc stream
 		taskrespstream pb.Task_RunTaskClient
 		// grpc client
 		taskclient pb.TaskClient
 		taskreq    *pb.TaskReq
-		// recv grpc stream
-		pbtaskresp *pb.TaskResp
 
 		ctxcancel context.CancelFunc
 		taskctx   context.Context
 		output    []byte
+		// worker which reject task because running tasks reach max concurrent or is down during this run
+		exclude = map[string]bool{}
+		// failover times when worker is down
+		failover int
 
 		taskrespcode = tasktype.DefaultExitCode
 	)
-	// TODO 故障转移
+
+	ctx, span := tracing.Start(ctx, "task.runTask",
+		attribute.String("task.id", id),
+		attribute.String("task.runtype", taskruntype.String()),
+	)
+	defer func() {
+		tracing.End(span, reterr)
+	}()
 
 	// set task is running
-	t.setdata(taskruntype, id, define.TsRun, taskstatus)
+	t.setdata(ctx, taskruntype, id, define.TsRun, taskstatus)
 
 	queryctx, querycancel := context.WithTimeout(ctx,
 		config.CoreConf.Server.DB.MaxQueryTime.Duration)
@@ -774,7 +868,7 @@ func (t *task2) runTask(ctx context.Context, /*real run task id*/
 	if err != nil {
 		log.Error("model.GetTaskByID failed", zap.String("taskid", id),
 			zap.Error(err))
-		t.writelogt(taskruntype, id, "Get %s Task id %s from db failed: %v",
+		t.writelogt(ctx, taskruntype, id, "Get %s Task id %s from db failed: %v",
 			taskruntype.String(), id, err)
 		goto Check
 	}
@@ -783,25 +877,38 @@ func (t *task2) runTask(ctx context.Context, /*real run task id*/
 	realtask, ok = Cron2.gettask(id)
 	if !ok {
 		log.Error("can not get task", zap.String("taskid", id))
-		t.writelogt(taskruntype, id, "Get %s Task id %s from cacheSchedule failed: %v",
+		t.writelogt(ctx, taskruntype, id, "Get %s Task id %s from cacheSchedule failed: %v",
 			taskruntype.String(), id, err)
 		goto Check
 	}
 
-	conn, err = tryGetRCCConn(ctx, realtask.next)
+	if taskdata.RoutePolicy == define.Broadcast {
+		// 广播任务在所有在线worker上运行，每个worker的日志和状态单独保存
+		err = t.runbroadcast(ctx, taskdata, taskruntype, id)
+		goto Check
+	}
+	if taskdata.ShardTotal > 0 {
+		// 分片任务的每个分片按路由策略选择worker运行，每个分片的日志和状态单独保存
+		err = t.runshard(ctx, taskdata, taskruntype, id, realtask.next)
+		goto Check
+	}
+
+Retry:
+	conn, err = tryGetRCCConn(ctx, realtask.next, exclude)
 	if err != nil {
 		log.Error("tryGetRpcConn failed", zap.String("hostgroup", taskdata.HostGroup), zap.Error(err))
-		t.writelogt(taskruntype, id, "Get Rpc Conn Failed From Hostgroup %s[%s] Err: %v",
+		t.writelogt(ctx, taskruntype, id, "Get Rpc Conn Failed From Hostgroup %s[%s] Err: %v",
 			taskdata.HostGroup, taskdata.HostGroupID, err)
 		goto Check
 	}
 	// defer conn.Close()
+	span.SetAttributes(attribute.String("worker.host", conn.Target()))
 
-	t.writelogt(taskruntype, id, "start run task %s[%s] on host %s", taskdata.Name, taskdata.ID, conn.Target())
+	t.writelogt(ctx, taskruntype, id, "start run task %s[%s] on host %s", taskdata.Name, taskdata.ID, conn.Target())
 	tdata, err = json.Marshal(taskdata.TaskData)
 	if err != nil {
 		log.Error("json.Marshal", zap.Error(err))
-		t.writelogt(taskruntype, id, "task %s json.Marshal value:%+v failed :%+v", taskdata.Name, taskdata.TaskData, err)
+		t.writelogt(ctx, taskruntype, id, "task %s json.Marshal value:%+v failed :%+v", taskdata.Name, taskdata.TaskData, err)
 		goto Check
 	}
 
@@ -818,48 +925,59 @@ func (t *task2) runTask(ctx context.Context, /*real run task id*/
 	} else {
 		taskctx, ctxcancel = context.WithCancel(ctx)
 	}
-	defer ctxcancel()
-
-	// defer ctxcancel()
-	taskclient = pb.NewTaskClient(conn)
+	// 每次运行结束时取消，重试前已经取消了上一次运行的taskctx
+	taskclient = conn
 
-	taskrespstream, err = taskclient.RunTask(taskctx, taskreq)
+	// trace context通过grpc metadata传递给worker
+	taskrespstream, err = taskclient.RunTask(tracing.InjectGRPC(taskctx), taskreq)
 	if err != nil {
 		log.Error("Run task failed", zap.Error(err))
-		t.writelogt(taskruntype, id, "Run Task %s[%s] TaskData [%v] failed:%v", taskdata.Name, id, taskreq, err)
+		t.writelogt(ctx, taskruntype, id, "Run Task %s[%s] TaskData [%v] failed:%v", taskdata.Name, id, taskreq, err)
+		if status.Code(err) == codes.Unavailable &&
+			t.canfailover(ctx, taskdata, taskruntype, id, conn.Target(), failover, false) {
+			failover++
+			exclude[conn.Target()] = true
+			ctxcancel()
+			goto Retry
+		}
 		goto Check
 	}
 
-	t.writelogt(taskruntype, id, "task %s[%s]  output----------------", taskdata.Name, id)
-	for {
-		// Recv return err is nil or io.EOF
-		// the last lastrecv must be return code 3 byte
-		pbtaskresp, err = taskrespstream.Recv()
-		if err != nil {
-			if err == io.EOF {
-				err = nil
-				// 获取返回码
-				taskrespcode, err = t.getreturncode(taskruntype, id)
-				goto Check
-			}
-			log.Error("recv task stream failed", zap.Error(err))
-			err = DealRPCErr(err)
-			if err.Error() == resp.GetMsgErr(resp.ErrRPCUnavailable).Error() {
-				// worker host is down,so we need run this fail task again
-				log.Error("worker host is down, run task again", zap.String("taskid", id))
-				t.writelogt(taskruntype, id, "worker host %s is down,so run task %s again", conn.Target(), taskdata.Name)
-				return t.runTask(ctx, id, taskruntype)
-			}
-			t.writelogt(taskruntype, id, "Task %s[%s] Run Fail: %v", taskdata.Name, id, err.Error())
-			// Alarm
-			log.Error("recv failed", zap.Error(err))
-			// err = resp.GetMsgErr(taskrespcode)
-			goto Check
-		}
-		t.writelog(taskruntype, id, pbtaskresp.GetResp())
-		output = append(output, pbtaskresp.GetResp()...)
+	t.writelogt(ctx, taskruntype, id, "task %s[%s]  output----------------", taskdata.Name, id)
+	output, err = t.recvtaskresp(ctx, taskruntype, id, taskrespstream)
+	if err == nil {
+		// 获取返回码
+		taskrespcode, err = t.getreturncode(ctx, taskruntype, id)
+		goto Check
 	}
+	if status.Code(err) == codes.ResourceExhausted && len(output) == 0 {
+		// worker运行任务数已达上限，选择其他worker运行
+		log.Warn("worker host is busy, try next host", zap.String("taskid", id), zap.String("addr", conn.Target()))
+		t.writelogt(ctx, taskruntype, id, "worker host %s is busy,so try run task %s on next host", conn.Target(), taskdata.Name)
+		exclude[conn.Target()] = true
+		ctxcancel()
+		goto Retry
+	}
+	log.Error("recv task stream failed", zap.Error(err))
+	stats.GRPCStreamError("RunTask", status.Code(err).String())
+	err = DealRPCErr(err)
+	if err.Error() == resp.GetMsgErr(resp.ErrRPCUnavailable).Error() &&
+		t.canfailover(ctx, taskdata, taskruntype, id, conn.Target(), failover, len(output) > 0) {
+		// worker host is down, run task on other host and never select the down host in this run
+		log.Error("worker host is down, failover task", zap.String("taskid", id), zap.String("addr", conn.Target()))
+		failover++
+		exclude[conn.Target()] = true
+		span.SetAttributes(attribute.Int("task.failover", failover))
+		ctxcancel()
+		goto Retry
+	}
+	t.writelogt(ctx, taskruntype, id, "Task %s[%s] Run Fail: %v", taskdata.Name, id, err.Error())
+	// Alarm
+	log.Error("recv failed", zap.Error(err))
 Check:
+	if ctxcancel != nil {
+		ctxcancel()
+	}
 
 	// 存储任务结果
 	tmptaskresp := define.TaskResp{
@@ -872,7 +990,7 @@ Check:
 		// if conn worker failed,can not get worker host
 		tmptaskresp.RunHost = conn.Target()
 	}
-	t.setdata(taskruntype, id, tmptaskresp, taskresp)
+	t.setdata(ctx, taskruntype, id, tmptaskresp, taskresp)
 	// 处理错误需要加锁
 	// 如果有一个任务失败就取消其他的任务
 
@@ -882,8 +1000,8 @@ Check:
 		select {
 		case <-ctx.Done():
 			log.Error("task is cancel", zap.String("task", realtask.name))
-			t.writelogt(taskruntype, id, "task %s[%s] is canceled", realtask.name, id)
-			t.setdata(taskruntype, id, define.TsCancel, taskstatus)
+			t.writelogt(ctx, taskruntype, id, "task %s[%s] is canceled", realtask.name, id)
+			t.setdata(ctx, taskruntype, id, define.TsCancel, taskstatus)
 			return nil
 		default:
 		}
@@ -898,15 +1016,11 @@ Check:
 		if err != nil {
 			return err
 		}
-		if taskdata.ExpectCode != taskrespcode {
-			return fmt.Errorf("%s task %s[%s] resp code is %d,want resp code %d", taskruntype.String(), id, taskdata.Name, taskrespcode, taskdata.ExpectCode)
-		}
-		if taskdata.ExpectContent != "" {
-			if !strings.Contains(string(output), taskdata.ExpectContent) {
-				return fmt.Errorf("%s task %s[%s] resp context not contains expect content: %s", taskruntype.String(), id, taskdata.Name, taskdata.ExpectContent)
-			}
+		// 广播任务和分片任务已经在每个worker或分片上检查过返回码和返回内容
+		if taskdata.RoutePolicy == define.Broadcast || taskdata.ShardTotal > 0 {
+			return nil
 		}
-		return nil
+		return judgeresp(taskdata, taskruntype, id, taskrespcode, output)
 	}
 	alarmerr = judgeres()
 
@@ -920,16 +1034,75 @@ Check:
 			t.errCode = taskrespcode
 			t.errMsg = alarmerr.Error()
 			t.errTasktype = taskruntype
-			t.setdata(taskruntype, id, define.TsFail, taskstatus)
+			t.setdata(ctx, taskruntype, id, define.TsFail, taskstatus)
 		}
 	} else {
 		log.Debug("task run success", zap.String("task", realtask.name))
-		t.setdata(taskruntype, id, define.TsFinish, taskstatus)
+		t.setdata(ctx, taskruntype, id, define.TsFinish, taskstatus)
 		// 如有任务失败，那么还未运行的任务可以标记为取消
 	}
 	return alarmerr
 }
 
+// canfailover return whether task can failover to other worker when worker host is down,
+// partial is whether task has partially run on the down worker, it can failover only when task is idempotent
+func (t *task2) canfailover(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	realid, host string, failover int, partial bool) bool {
+	limit := taskdata.FailoverLimit
+	switch {
+	case limit == 0:
+		// 没有设置时默认转移一次
+		limit = define.DefaultFailoverLimit
+	case limit < 0:
+		limit = 0
+	}
+	if failover >= limit {
+		t.writelogt(ctx, taskruntype, realid, "worker host %s is down, task %s failover %d times reach failover limit %d",
+			host, taskdata.Name, failover, limit)
+		return false
+	}
+	if partial && !taskdata.Idempotent {
+		t.writelogt(ctx, taskruntype, realid, "worker host %s is down after task %s partially run, task is not idempotent so do not failover",
+			host, taskdata.Name)
+		return false
+	}
+	t.writelogt(ctx, taskruntype, realid, "worker host %s is down, failover task %s to other host [%d/%d]",
+		host, taskdata.Name, failover+1, limit)
+	return true
+}
+
+// judgeresp check task resp code and resp content
+func judgeresp(taskdata *define.GetTask, taskruntype define.TaskRespType, id string, code int, output []byte) error {
+	if taskdata.ExpectCode != code {
+		return fmt.Errorf("%s task %s[%s] resp code is %d,want resp code %d", taskruntype.String(), id, taskdata.Name, code, taskdata.ExpectCode)
+	}
+	if taskdata.ExpectContent != "" {
+		if !strings.Contains(string(output), taskdata.ExpectContent) {
+			return fmt.Errorf("%s task %s[%s] resp context not contains expect content: %s", taskruntype.String(), id, taskdata.Name, taskdata.ExpectContent)
+		}
+	}
+	return nil
+}
+
+// recvtaskresp write task output recv from worker to real log until worker run finished,
+// the last recv must be return code
+func (t *task2) recvtaskresp(ctx context.Context, taskruntype define.TaskRespType, realid string,
+	stream pb.Task_RunTaskClient) ([]byte, error) {
+	var output []byte
+	for {
+		// Recv return err is nil or io.EOF
+		pbtaskresp, err := stream.Recv()
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			return output, err
+		}
+		t.writelog(ctx, taskruntype, realid, pbtaskresp.GetResp())
+		output = append(output, pbtaskresp.GetResp()...)
+	}
+}
+
 // cacheSchedule2 save task status
 type cacheSchedule2 struct {
 	sync.RWMutex
@@ -937,12 +1110,33 @@ type cacheSchedule2 struct {
 	ts    map[string]*task2
 }
 
+// observeredis observe every redis command and pipeline duration
+func observeredis(client *redis.Client) {
+	client.WrapProcess(func(old func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			start := time.Now()
+			err := old(cmd)
+			stats.ObserveRedis(cmd.Name(), time.Since(start))
+			return err
+		}
+	})
+	client.WrapProcessPipeline(func(old func(cmds []redis.Cmder) error) func(cmds []redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			start := time.Now()
+			err := old(cmds)
+			stats.ObserveRedis("pipeline", time.Since(start))
+			return err
+		}
+	})
+}
+
 // Init2 start run already exists task from db
 func Init2() error {
 	client := redis.NewClient(&redis.Options{
 		Addr:     config.CoreConf.Server.Redis.Addr,
 		Password: config.CoreConf.Server.Redis.PassWord,
 	})
+	observeredis(client)
 
 	err := client.Ping().Err()
 	if err != nil {
@@ -954,6 +1148,10 @@ func Init2() error {
 		ts:    make(map[string]*task2),
 		redis: client,
 	}
+	// 吊销的token保存在redis中
+	jwt.InitRevoke(client)
+	// 登陆频率和失败次数保存在redis中
+	loginguard.Init(client)
 
 	ctx, cancel := context.WithTimeout(context.Background(),
 		config.CoreConf.Server.DB.MaxQueryTime.Duration)
@@ -967,17 +1165,18 @@ func Init2() error {
 		log.Debug("Crocodile is Not Install")
 		return nil
 	}
-	eps, _, err := model.GetTasks(ctx, 0, 0, "", "", "")
+	eps, _, err := model.GetTasks(ctx, 0, 0, "", "", "", "", "")
 	if err != nil {
 		log.Error("GetTasks failed", zap.Error(err))
 		return err
 	}
 	log.Debug("start init task", zap.Int("task", len(eps)))
 	for _, t := range eps {
-		Cron2.addtask(t.ID, t.Name, t.Cronexpr, GetRoutePolicy(t.HostGroupID, t.RoutePolicy), t.Run)
+		Cron2.addtask(t.ID, t.Name, t.Cronexpr, GetRoutePolicy(t.ID, t.HostGroupID, t.LabelSelector, t.RoutePolicy), t.Run)
 	}
 
 	go RecvEvent()
+	go monitorhosts()
 	log.Info("init task success", zap.Int("Total", len(eps)))
 	return nil
 }
@@ -1119,7 +1318,7 @@ func (s *cacheSchedule2) GetRunningTask() ([]*define.RunTask, error) {
 			if strings.HasPrefix(err.Error(), "can not get taskid") {
 				// removerunningtask未执行，调度节点挂掉，所以就一直保留
 				// 如果到这里就直接删掉
-				Cron2.removerunningtask(&define.RunTask{ID: runtask.ID})
+				Cron2.removerunningtask(&runtask)
 
 			}
 			continue
@@ -1167,6 +1366,11 @@ func (s *cacheSchedule2) saverunningtask(runningtask *define.RunTask) error {
 	if err != nil {
 		return fmt.Errorf("pipeline.Set failed: %w", err)
 	}
+	// task:run:runid
+	err = pipeline.Set(runidkey(runningtask.RunID), runningtask.ID, 0).Err()
+	if err != nil {
+		return fmt.Errorf("pipeline.Set failed: %w", err)
+	}
 	_, err = pipeline.Exec()
 	if err != nil {
 		return fmt.Errorf("pipeline.Exec failed: %w", err)
@@ -1191,6 +1395,12 @@ func (s *cacheSchedule2) removerunningtask(runningtask *define.RunTask) error {
 	if err != nil {
 		return fmt.Errorf("pipeline.Del failed: %w", err)
 	}
+	if runningtask.RunID != "" {
+		err = pipeline.Del(runidkey(runningtask.RunID)).Err()
+		if err != nil {
+			return fmt.Errorf("pipeline.Del failed: %w", err)
+		}
+	}
 	_, err = pipeline.Exec()
 	if err != nil {
 		return fmt.Errorf("pipeline.SAdd failed: %w", err)
@@ -1198,6 +1408,63 @@ func (s *cacheSchedule2) removerunningtask(runningtask *define.RunTask) error {
 	return nil
 }
 
+// runidkey return the key save task id of a running run
+func runidkey(runid string) string {
+	return "task:run:" + runid
+}
+
+// GetRunTaskID return the task id of run runid
+func (s *cacheSchedule2) GetRunTaskID(ctx context.Context, runid string) (string, error) {
+	taskid, err := s.redis.Get(runidkey(runid)).Result()
+	if err == nil {
+		return taskid, nil
+	}
+	if err != redis.Nil {
+		return "", fmt.Errorf("s.redis.Get failed: %w", err)
+	}
+	tasklog, err := model.GetLogByRunID(ctx, runid)
+	if err != nil {
+		return "", fmt.Errorf("model.GetLogByRunID failed: %w", err)
+	}
+	return tasklog.RunByTaskID, nil
+}
+
+// GetRunLog return the real log of run runid start from byte offset
+// while the run is running read it from redis, after it finished read it from saved task log
+// it has the same return value as GetTaskRealLog
+func (s *cacheSchedule2) GetRunLog(ctx context.Context, runid string,
+	taskruntype define.TaskRespType, realid string, offset int64) ([]byte, error) {
+	taskid, err := s.redis.Get(runidkey(runid)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("s.redis.Get failed: %w", err)
+	}
+	if err == nil {
+		t, ok := s.gettask(taskid)
+		if ok {
+			output, err := t.GetTaskRealLog(taskruntype, realid, offset)
+			if !errors.Is(err, redis.Nil) {
+				return output, err
+			}
+		}
+		// run is finished,redis data is already cleaned
+	}
+
+	tasklog, err := model.GetLogByRunID(ctx, runid)
+	if err != nil {
+		return nil, fmt.Errorf("model.GetLogByRunID failed: %w", err)
+	}
+	for _, taskresp := range tasklog.TaskResps {
+		if taskresp.TaskID != realid || taskresp.TaskType != taskruntype {
+			continue
+		}
+		if offset >= int64(len(taskresp.LogData)) {
+			return nil, io.EOF
+		}
+		return []byte(taskresp.LogData[offset:]), nil
+	}
+	return nil, define.ErrNotExist{Value: realid}
+}
+
 // GetTask return task2
 func (s *cacheSchedule2) GetTask(taskid string) (*task2, bool) {
 	return s.gettask(taskid)
@@ -1,7 +1,14 @@
 package schedule
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/labulaka521/crocodile/core/utils/define"
 )
@@ -22,3 +29,182 @@ func Test_generatename(t *testing.T) {
 		t.Errorf("want get tasktype %d, but get %d", define.MasterTask, tasktype)
 	}
 }
+
+func Test_cutrune(t *testing.T) {
+	log := []byte("run task 任务")
+	for i := 0; i <= len(log); i++ {
+		n := cutrune(log[:i])
+		if n > i {
+			t.Fatalf("cutrune(%q) return %d, it greater than %d", log[:i], n, i)
+		}
+		if !utf8.Valid(log[:n]) {
+			t.Errorf("cutrune(%q) return %d, %q is not valid utf8", log[:i], n, log[:n])
+		}
+	}
+	if n := cutrune(log); n != len(log) {
+		t.Errorf("want get %d, but get %d", len(log), n)
+	}
+	if n := cutrune(log[:len(log)-1]); n != len(log)-3 {
+		t.Errorf("want get %d, but get %d", len(log)-3, n)
+	}
+}
+
+func Test_leastloadhost(t *testing.T) {
+	res := define.HostResource{
+		CPUNum:       4,
+		Load1:        1,
+		MemTotal:     100,
+		MemAvailable: 80,
+		DiskTotal:    100,
+		DiskFree:     90,
+		TaskSlots:    4,
+	}
+	oldversion := &define.Host{Addr: "oldversion", RunningTasks: []string{}}
+	idle := &define.Host{Addr: "idle", RunningTasks: []string{"1"}, HostResource: res}
+	// 内存使用90%
+	res.MemAvailable = 10
+	lowmem := &define.Host{Addr: "lowmem", RunningTasks: []string{}, HostResource: res}
+	// 任务槽位已满
+	res.MemAvailable = 80
+	busy := &define.Host{Addr: "busy", RunningTasks: []string{"1", "2", "3", "4"}, HostResource: res}
+
+	host := leastloadhost([]*define.Host{oldversion, lowmem, busy, idle})
+	if host != idle {
+		t.Errorf("want get host idle, but get %s", host.Addr)
+	}
+	host = leastloadhost([]*define.Host{oldversion, busy})
+	if host != oldversion {
+		t.Errorf("want get host oldversion, but get %s", host.Addr)
+	}
+	if host := leastloadhost(nil); host != nil {
+		t.Errorf("want get nil, but get %s", host.Addr)
+	}
+}
+
+func Test_runningcache(t *testing.T) {
+	cache := &runningcache{running: make(map[string]context.CancelFunc), max: 1}
+	if !cache.TryAdd("1", func() {}) {
+		t.Fatal("want add task 1 success")
+	}
+	if cache.TryAdd("2", func() {}) {
+		t.Error("want reject task 2 because running tasks reach max concurrent")
+	}
+	if running, max := cache.Slots(); running != 1 || max != 1 {
+		t.Errorf("want get slots 1/1, but get %d/%d", running, max)
+	}
+	cache.Del("1")
+	if !cache.TryAdd("2", func() {}) {
+		t.Error("want add task 2 success after task 1 finished")
+	}
+
+	if hostbusy(&define.Host{HostResource: define.HostResource{RunningSlots: 5}}) {
+		t.Error("want worker not busy when max concurrent is unlimited")
+	}
+	if !hostbusy(&define.Host{HostResource: define.HostResource{RunningSlots: 2, MaxConcurrent: 2}}) {
+		t.Error("want worker busy when running slots reach max concurrent")
+	}
+}
+
+func Test_runningcacheDrain(t *testing.T) {
+	cache := &runningcache{running: make(map[string]context.CancelFunc)}
+	if !cache.TryAdd("1", func() {}) {
+		t.Fatal("want add task 1 success")
+	}
+	cache.SetDrain()
+	if !cache.IsDrain() {
+		t.Error("want worker is draining")
+	}
+	if cache.TryAdd("2", func() {}) {
+		t.Error("want reject task 2 because worker is draining")
+	}
+	if running, _ := cache.Slots(); running != 1 {
+		t.Errorf("want running task 1 continue running, but get running %d", running)
+	}
+}
+
+func Test_splitrealid(t *testing.T) {
+	realid := broadcastrealid("233903600084979712", "233903600084979713")
+	taskid, hostid := splitrealid(realid)
+	if taskid != "233903600084979712" || hostid != "233903600084979713" {
+		t.Errorf("want get task and host id, but get %s %s", taskid, hostid)
+	}
+	taskid, hostid = splitrealid("233903600084979712")
+	if taskid != "233903600084979712" || hostid != "" {
+		t.Errorf("want get task id and empty host id, but get %s %s", taskid, hostid)
+	}
+}
+
+func Test_offlinecache(t *testing.T) {
+	oc := &offlinecache{hosts: make(map[string]int64)}
+	host := &define.Host{ID: "233903600084979713", LastUpdateTimeUnix: 100}
+	if oc.isoffline(host) {
+		t.Error("host should not be offline without offline event")
+	}
+	oc.set(define.HostEvent{HostID: host.ID, Event: define.HostOffline, EventTime: 120})
+	if !oc.isoffline(host) {
+		t.Error("host should be offline after offline event")
+	}
+	host.LastUpdateTimeUnix = 130
+	if oc.isoffline(host) {
+		t.Error("host should not be offline after recv heartbeat")
+	}
+	host.LastUpdateTimeUnix = 100
+	oc.set(define.HostEvent{HostID: host.ID, Event: define.HostOnline, EventTime: 140})
+	if oc.isoffline(host) {
+		t.Error("host should not be offline after online event")
+	}
+}
+
+func Test_credentialstore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crocodile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "crocodile.credential")
+
+	cs := &credentialstore{}
+	err = cs.load(file)
+	if err != nil || cs.get() != "" {
+		t.Fatalf("want load empty credential, but get %s %v", cs.get(), err)
+	}
+	credential, err := newcredential("233903600084979712")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(credential, "233903600084979712.") {
+		t.Errorf("want credential start with host id, but get %s", credential)
+	}
+	err = cs.save(credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs = &credentialstore{}
+	err = cs.load(file)
+	if err != nil || cs.get() != credential {
+		t.Errorf("want load credential %s, but get %s %v", credential, cs.get(), err)
+	}
+}
+
+func Test_credentialcache(t *testing.T) {
+	cc := &credentialcache{byid: make(map[string]*cachedhost), byaddr: make(map[string]*cachedhost)}
+	host := &define.Host{ID: "233903600084979713", Addr: "127.0.0.1:8080", Credential: "hash"}
+	cc.set(host)
+	get, err := cc.getbyid(context.Background(), host.ID)
+	if err != nil || get != host {
+		t.Fatalf("want get cached host by id, but get %v %v", get, err)
+	}
+	get, err = cc.getbyaddr(context.Background(), host.Addr)
+	if err != nil || get != host {
+		t.Fatalf("want get cached host by addr, but get %v %v", get, err)
+	}
+	cc.delete(host.ID)
+	if len(cc.byid) != 0 || len(cc.byaddr) != 0 {
+		t.Errorf("want cache is empty after delete, but get %d %d", len(cc.byid), len(cc.byaddr))
+	}
+	cc.set(host)
+	cc.byid[host.ID].expire = time.Now().Add(-time.Second)
+	if _, ok := cc.cached(cc.byid[host.ID], true); ok {
+		t.Error("expired host should not be cached")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/labulaka521/crocodile/common/log"
@@ -16,58 +17,84 @@ import (
 
 // Select a next run host
 
-// Next will return next run host
+// Next will return next run host, worker in exclude will not be returned
 // if Next is nil,because not find valid host
-type Next func() *define.Host
+type Next func(exclude map[string]bool) *define.Host
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
 // GetRoutePolicy return a type Next, it will return a host
-func GetRoutePolicy(hgid string, routepolicy define.RoutePolicy) Next {
+func GetRoutePolicy(taskid, hgid string, selector define.LabelSelector, routepolicy define.RoutePolicy) Next {
 	switch routepolicy {
 	case define.Random:
-		return random(hgid)
+		return random(hgid, selector)
 	case define.RoundRobin:
-		return roundRobin(hgid)
+		return roundRobin(hgid, selector)
 	case define.Weight:
-		return weight(hgid)
+		return weight(hgid, selector)
 	case define.LeastTask:
-		return leastTask(hgid)
+		return leastTask(hgid, selector)
+	case define.LeastLoad:
+		return leastLoad(hgid, selector)
+	case define.ConsistentHash:
+		return consistentHash(taskid, hgid, selector)
 	default:
-		return defaultRoutePolicy(hgid)
+		return defaultRoutePolicy(hgid, selector)
 	}
 }
 
 // getOnlineHosts return online worker host info
-func getOnlineHosts(hgid string) ([]*define.Host, error) {
+// if hgid is empty select from all hosts, and hosts must match label selector and not in exclude
+func getOnlineHosts(hgid string, selector define.LabelSelector, exclude map[string]bool) ([]*define.Host, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), config.CoreConf.Server.DB.MaxQueryTime.Duration)
 	defer cancel()
 
-	hg, err := model.GetHostGroupByID(ctx, hgid)
-	if err != nil {
-		return nil, err
-	}
-
-	onlinehosts := make([]*define.Host, 0, len(hg.HostsID))
-	gethosts, err := model.GetHostByIDS(ctx, hg.HostsID)
-	if err != nil {
-		log.Error("GetHostByIDS failed", zap.Strings("ids", hg.HostsID), zap.Error(err))
-		return nil, err
+	var (
+		gethosts []*define.Host
+		from     = "all hosts"
+		err      error
+	)
+	if hgid != "" {
+		hg, err := model.GetHostGroupByID(ctx, hgid)
+		if err != nil {
+			return nil, err
+		}
+		gethosts, err = model.GetHostByIDS(ctx, hg.HostsID)
+		if err != nil {
+			log.Error("GetHostByIDS failed", zap.Strings("ids", hg.HostsID), zap.Error(err))
+			return nil, err
+		}
+		from = fmt.Sprintf("hostgrop %s[%s]", hg.Name, hgid)
+	} else {
+		gethosts, _, err = model.GetHosts(ctx, 0, 0)
+		if err != nil {
+			log.Error("GetHosts failed", zap.Error(err))
+			return nil, err
+		}
 	}
 
+	onlinehosts := make([]*define.Host, 0, len(gethosts))
 	for _, host := range gethosts {
-		if !host.Online {
+		if !host.Online || offlinehosts.isoffline(host) {
+			continue
+		}
+		// 排空中的worker不再接收新任务
+		if host.Stop || host.Drain {
 			continue
 		}
-		if host.Stop {
+		if !selector.Match(host.Labels) {
+			continue
+		}
+		// 本次运行中繁忙或者宕机的worker
+		if exclude[host.Addr] {
 			continue
 		}
 		onlinehosts = append(onlinehosts, host)
 	}
 	if len(onlinehosts) == 0 {
-		err := fmt.Errorf("can not get valid host from hostgrop %s[%s]", hg.Name, hgid)
+		err := fmt.Errorf("can not get valid host from %s", from)
 		return nil, err
 	}
 	return onlinehosts, nil
@@ -77,10 +104,10 @@ func getOnlineHosts(hgid string) ([]*define.Host, error) {
 var defaultRoutePolicy = random
 
 // random return a Next func,it will random return host
-func random(hgid string) Next {
+func random(hgid string, selector define.LabelSelector) Next {
 	log.Debug("add Next func Random")
-	return func() *define.Host {
-		hosts, err := getOnlineHosts(hgid)
+	return func(exclude map[string]bool) *define.Host {
+		hosts, err := getOnlineHosts(hgid, selector, exclude)
 		if err != nil {
 			log.Error("get online host failed", zap.Error(err))
 			// log.Error("get host failed", zap.Error(err))
@@ -91,11 +118,11 @@ func random(hgid string) Next {
 }
 
 // roundRobin return a Next func,it will RoundRobin return host
-func roundRobin(hgid string) Next {
+func roundRobin(hgid string, selector define.LabelSelector) Next {
 	log.Debug("add Next func RoundRobin")
 	var i = rand.Int()
-	return func() *define.Host {
-		hosts, err := getOnlineHosts(hgid)
+	return func(exclude map[string]bool) *define.Host {
+		hosts, err := getOnlineHosts(hgid, selector, exclude)
 		if err != nil {
 			log.Error("get online host failed", zap.Error(err))
 			return nil
@@ -107,10 +134,10 @@ func roundRobin(hgid string) Next {
 }
 
 // weight return a Next Func,it will return host by host weight
-func weight(hgid string) Next {
+func weight(hgid string, selector define.LabelSelector) Next {
 	log.Debug("add Next func Weight")
-	return func() *define.Host {
-		hosts, err := getOnlineHosts(hgid)
+	return func(exclude map[string]bool) *define.Host {
+		hosts, err := getOnlineHosts(hgid, selector, exclude)
 		if err != nil {
 			log.Error("get online host failed", zap.Error(err))
 			return nil
@@ -134,10 +161,10 @@ func weight(hgid string) Next {
 }
 
 // leastTask return a Next Func, it will return host by leaset host running task
-func leastTask(hgid string) Next {
+func leastTask(hgid string, selector define.LabelSelector) Next {
 	log.Debug("add Next func LeastTask")
-	return func() *define.Host {
-		hosts, err := getOnlineHosts(hgid)
+	return func(exclude map[string]bool) *define.Host {
+		hosts, err := getOnlineHosts(hgid, selector, exclude)
 		if err != nil {
 			log.Error("get online host failed", zap.Error(err))
 			return nil
@@ -154,3 +181,97 @@ func leastTask(hgid string) Next {
 		return leasetTask
 	}
 }
+
+// leastLoad return a Next Func, it will return host by least resource usage report by heartbeat
+func leastLoad(hgid string, selector define.LabelSelector) Next {
+	log.Debug("add Next func LeastLoad")
+	return func(exclude map[string]bool) *define.Host {
+		hosts, err := getOnlineHosts(hgid, selector, exclude)
+		if err != nil {
+			log.Error("get online host failed", zap.Error(err))
+			return nil
+		}
+		return leastloadhost(hosts)
+	}
+}
+
+// leastloadhost return the host with min load, if load is equal return the host with least running tasks
+func leastloadhost(hosts []*define.Host) *define.Host {
+	var (
+		minload       = math.MaxFloat64
+		leastloadhost *define.Host
+	)
+	for _, host := range hosts {
+		load := hostload(host)
+		if load < minload ||
+			(load == minload && len(host.RunningTasks) < len(leastloadhost.RunningTasks)) {
+			leastloadhost = host
+			minload = load
+		}
+	}
+	return leastloadhost
+}
+
+// hostload return the max usage of cpu load, memory, disk and task slots
+// if host not report resource(old version worker), return 1
+func hostload(host *define.Host) float64 {
+	if host.CPUNum <= 0 {
+		return 1
+	}
+	// 1分钟负载除以CPU核数
+	load := host.Load1 / float64(host.CPUNum)
+	if host.MemTotal > 0 {
+		load = math.Max(load, 1-float64(host.MemAvailable)/float64(host.MemTotal))
+	}
+	if host.DiskTotal > 0 {
+		load = math.Max(load, 1-float64(host.DiskFree)/float64(host.DiskTotal))
+	}
+	if host.TaskSlots > 0 {
+		load = math.Max(load, float64(len(host.RunningTasks))/float64(host.TaskSlots))
+	}
+	return load
+}
+
+// consistentHash return a Next Func, it will return the same host for a task while the host is online,
+// if the host is busy or excluded return the next host on the ring
+func consistentHash(taskid, hgid string, selector define.LabelSelector) Next {
+	log.Debug("add Next func ConsistentHash")
+	var (
+		mu   sync.Mutex
+		ring *hashring
+	)
+	return func(exclude map[string]bool) *define.Host {
+		// 排除的worker不从环上移除，避免重新构建环
+		hosts, err := getOnlineHosts(hgid, selector, nil)
+		if err != nil {
+			log.Error("get online host failed", zap.Error(err))
+			return nil
+		}
+		mu.Lock()
+		// 在线worker未变化时不需要重新构建
+		if ring == nil || ring.key != ringkey(hosts) {
+			ring = newhashring(hosts)
+		}
+		r := ring
+		mu.Unlock()
+
+		onlinehosts := make(map[string]*define.Host, len(hosts))
+		for _, host := range hosts {
+			onlinehosts[host.Addr] = host
+		}
+		addr := r.get(taskid, func(addr string) bool {
+			return exclude[addr] || hostbusy(onlinehosts[addr])
+		})
+		if addr == "" {
+			// 其他worker都繁忙时返回顺时针第一个没有排除的worker，由tryGetRCCConn返回繁忙
+			addr = r.get(taskid, func(addr string) bool {
+				return exclude[addr]
+			})
+		}
+		if addr == "" {
+			log.Error("all online hosts are excluded", zap.String("taskid", taskid))
+			return nil
+		}
+		return onlinehosts[addr]
+	}
+}
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/labulaka521/crocodile/common/errgroup"
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/tasktype"
+	"github.com/labulaka521/crocodile/core/tracing"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 分片任务
+// 任务拆分为ShardTotal个分片，每个分片按路由策略选择worker运行，worker通过SHARD_INDEX SHARD_TOTAL获取分片信息
+// 每个分片使用realid taskid@shard<index>保存日志、状态和结果，在任务树中作为任务的子节点，所有分片成功任务才成功
+
+const shardprefix = "shard"
+
+// shardrealid return the realid of task shard
+func shardrealid(taskid string, index int) string {
+	return taskid + realidsep + shardprefix + strconv.Itoa(index)
+}
+
+// shardname return the name of task shard
+func shardname(name string, index, total int) string {
+	return fmt.Sprintf("%s[%d/%d]", name, index, total)
+}
+
+// runshard run all shards of task in parallel, if a shard fail other shards will be canceled
+func (t *task2) runshard(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	id string, next Next) error {
+	total := taskdata.ShardTotal
+	for i := 0; i < total; i++ {
+		realid := shardrealid(id, i)
+		err := t.addtaskinfo(ctx, taskruntype, realid)
+		if err != nil {
+			log.Error("t.addtaskinfo failed", zap.String("realid", realid), zap.Error(err))
+			return err
+		}
+		t.setdata(ctx, taskruntype, realid, define.TaskResp{
+			TaskID:   realid,
+			Task:     shardname(taskdata.Name, i, total),
+			Code:     tasktype.DefaultExitCode,
+			TaskType: taskruntype,
+		}, taskresp)
+	}
+	t.writelogt(ctx, taskruntype, id, "split task %s[%s] to %d shards", taskdata.Name, id, total)
+
+	g := errgroup.WithCancel(ctx)
+	for i := 0; i < total; i++ {
+		index := i
+		g.Go(func(ctx context.Context) error {
+			return t.runonshard(ctx, taskdata, taskruntype, id, index, next)
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		t.writelogt(ctx, taskruntype, id, "shards of task %s[%s] run fail: %v", taskdata.Name, id, err)
+		return fmt.Errorf("%s task %s[%s] shard run fail: %w", taskruntype.String(), id, taskdata.Name, err)
+	}
+	t.writelogt(ctx, taskruntype, id, "all %d shards of task %s[%s] run success", total, taskdata.Name, id)
+	return nil
+}
+
+// runonshard select a worker by route policy and run the shard of task on it,
+// if worker is busy the shard will run on next worker, if worker is down the shard will failover to next worker
+func (t *task2) runonshard(ctx context.Context, taskdata *define.GetTask, taskruntype define.TaskRespType,
+	id string, index int, next Next) (reterr error) {
+	realid := shardrealid(id, index)
+	name := shardname(taskdata.Name, index, taskdata.ShardTotal)
+	ctx, span := tracing.Start(ctx, "task.runShard",
+		attribute.String("task.id", id),
+		attribute.Int("task.shard_index", index),
+	)
+	defer func() {
+		tracing.End(span, reterr)
+	}()
+
+	t.setdata(ctx, taskruntype, realid, define.TsRun, taskstatus)
+	var (
+		conn   workerconn
+		code   = tasktype.DefaultExitCode
+		output []byte
+		err    error
+		// worker which is busy or down
+		exclude = map[string]bool{}
+		// failover times when worker is down
+		failover int
+	)
+	for {
+		conn, err = tryGetRCCConn(ctx, next, exclude)
+		if err != nil {
+			t.writelogt(ctx, taskruntype, realid, "Get Rpc Conn Failed From Hostgroup %s[%s] Err: %v",
+				taskdata.HostGroup, taskdata.HostGroupID, err)
+			break
+		}
+		span.SetAttributes(attribute.String("worker.host", conn.Target()))
+		code, output, err = t.runtaskonconn(ctx, taskdata, taskruntype, realid, conn, index, taskdata.ShardTotal)
+		if err == nil {
+			break
+		}
+		rpccode := status.Code(err)
+		if rpccode == codes.ResourceExhausted && len(output) == 0 {
+			log.Warn("worker host is busy, run shard on next host",
+				zap.String("realid", realid), zap.String("addr", conn.Target()))
+			t.writelogt(ctx, taskruntype, realid, "worker host %s is busy,so try run %s on next host",
+				conn.Target(), name)
+			exclude[conn.Target()] = true
+			continue
+		}
+		if rpccode == codes.Unavailable &&
+			t.canfailover(ctx, taskdata, taskruntype, realid, conn.Target(), failover, len(output) > 0) {
+			log.Warn("worker host is down, failover shard",
+				zap.String("realid", realid), zap.String("addr", conn.Target()))
+			failover++
+			exclude[conn.Target()] = true
+			continue
+		}
+		err = DealRPCErr(err)
+		break
+	}
+	var runhost string
+	if conn != nil {
+		runhost = conn.Target()
+	}
+	return t.savesubrun(ctx, taskdata, taskruntype, id, realid, name, runhost, code, output, err)
+}
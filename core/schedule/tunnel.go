@@ -0,0 +1,517 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/labulaka521/crocodile/core/config"
+	pb "github.com/labulaka521/crocodile/core/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// worker主动连接调度中心
+// worker在NAT或者防火墙后时调度中心无法连接worker，开启tunnel后worker与每个调度中心建立一个长连接的双向stream，
+// 调度中心通过stream下发任务、取消任务，worker通过stream返回任务输出，只需要调度中心开放端口
+// 调度中心读取任务输出后返回ack，任务未被ack的输出达到tunnelWindow时worker暂停发送，
+// 避免一个任务输出过快影响同一个stream上的其他任务
+
+const (
+	// max unacked outputs of a task run on tunnel
+	tunnelWindow = 64
+	// reconnect tunnel interval after tunnel closed
+	tunnelReconnectInterval = time.Second * 3
+)
+
+var (
+	// tunnels opened by worker, key is worker addr
+	tunnels = &tunnelcache{sessions: make(map[string]*tunnelsession)}
+
+	errTunnelClosed = status.Error(codes.Unavailable, "worker tunnel is closed")
+)
+
+// ctxerr change context err to grpc status err like grpc stream
+func ctxerr(err error) error {
+	if err == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Canceled, err.Error())
+}
+
+type tunnelcache struct {
+	sync.RWMutex
+	sessions map[string]*tunnelsession
+}
+
+// get return tunnel of worker addr or nil
+func (tc *tunnelcache) get(addr string) *tunnelsession {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.sessions[addr]
+}
+
+// add save tunnel, old tunnel of the same worker will be replaced
+func (tc *tunnelcache) add(session *tunnelsession) {
+	tc.Lock()
+	tc.sessions[session.addr] = session
+	tc.Unlock()
+}
+
+// del delete tunnel if it is not replaced by new tunnel
+func (tc *tunnelcache) del(session *tunnelsession) {
+	tc.Lock()
+	if tc.sessions[session.addr] == session {
+		delete(tc.sessions, session.addr)
+	}
+	tc.Unlock()
+}
+
+// tunnelsession is a tunnel opened by worker, scheduler run task on worker by it
+type tunnelsession struct {
+	addr     string
+	stream   pb.Tunnel_ConnectServer
+	sendlock sync.Mutex
+
+	sync.Mutex
+	seq    int64
+	runs   map[int64]*tunnelrun
+	closed bool
+}
+
+// Target return worker addr
+func (ts *tunnelsession) Target() string {
+	return ts.addr
+}
+
+func (ts *tunnelsession) send(down *pb.TunnelDown) error {
+	ts.sendlock.Lock()
+	defer ts.sendlock.Unlock()
+	return ts.stream.Send(down)
+}
+
+// RunTask dispatch task to worker by tunnel, task output can be recv from returned stream
+func (ts *tunnelsession) RunTask(ctx context.Context, in *pb.TaskReq, opts ...grpc.CallOption) (pb.Task_RunTaskClient, error) {
+	ts.Lock()
+	if ts.closed {
+		ts.Unlock()
+		return nil, errTunnelClosed
+	}
+	ts.seq++
+	run := &tunnelrun{
+		ctx:     ctx,
+		session: ts,
+		seq:     ts.seq,
+		// worker最多发送tunnelWindow个未ack的输出和一个结束消息
+		resps: make(chan *pb.TunnelUp, tunnelWindow+1),
+		end:   make(chan struct{}),
+	}
+	ts.runs[run.seq] = run
+	ts.Unlock()
+
+	down := &pb.TunnelDown{Seq: run.seq, Task: in}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			down.Metadata = append(down.Metadata, &pb.Metadata{Key: key, Value: value})
+		}
+	}
+	err := ts.send(down)
+	if err != nil {
+		log.Error("send task by tunnel failed", zap.String("addr", ts.addr), zap.Error(err))
+		run.finish()
+		return nil, errTunnelClosed
+	}
+	go run.watchcancel()
+	return run, nil
+}
+
+func (ts *tunnelsession) delrun(seq int64) {
+	ts.Lock()
+	delete(ts.runs, seq)
+	ts.Unlock()
+}
+
+// dispatch send worker msg to task run
+func (ts *tunnelsession) dispatch(up *pb.TunnelUp) {
+	ts.Lock()
+	run, ok := ts.runs[up.GetSeq()]
+	ts.Unlock()
+	if !ok {
+		// task run is finished or canceled
+		return
+	}
+	select {
+	case run.resps <- up:
+	default:
+		log.Warn("task outputs exceed tunnel window, drop it", zap.String("addr", ts.addr), zap.Int64("seq", up.GetSeq()))
+	}
+}
+
+// close end all task run on tunnel
+func (ts *tunnelsession) close() {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.closed = true
+	for seq, run := range ts.runs {
+		close(run.resps)
+		run.endonce.Do(func() { close(run.end) })
+		delete(ts.runs, seq)
+	}
+}
+
+// tunnelrun is a task run on tunnel, it implement pb.Task_RunTaskClient
+type tunnelrun struct {
+	ctx     context.Context
+	session *tunnelsession
+	seq     int64
+	resps   chan *pb.TunnelUp
+	// outputs recv from resps
+	recved  int64
+	end     chan struct{}
+	endonce sync.Once
+}
+
+func (tr *tunnelrun) finish() {
+	tr.endonce.Do(func() {
+		close(tr.end)
+		tr.session.delrun(tr.seq)
+	})
+}
+
+// watchcancel send cancel to worker when ctx is done
+func (tr *tunnelrun) watchcancel() {
+	select {
+	case <-tr.ctx.Done():
+		err := tr.session.send(&pb.TunnelDown{Seq: tr.seq, Cancel: true})
+		if err != nil {
+			log.Error("send cancel by tunnel failed", zap.String("addr", tr.session.addr), zap.Error(err))
+		}
+	case <-tr.end:
+	}
+}
+
+// Recv return task output, return io.EOF if task run success
+func (tr *tunnelrun) Recv() (*pb.TaskResp, error) {
+	select {
+	case up, ok := <-tr.resps:
+		if !ok {
+			return nil, errTunnelClosed
+		}
+		if up.GetEnd() {
+			tr.finish()
+			if codes.Code(up.GetCode()) == codes.OK {
+				return nil, io.EOF
+			}
+			return nil, status.Error(codes.Code(up.GetCode()), up.GetErrMsg())
+		}
+		tr.recved++
+		if tr.recved%(tunnelWindow/2) == 0 {
+			// ack后worker可以继续发送输出
+			err := tr.session.send(&pb.TunnelDown{Seq: tr.seq, Ack: tr.recved})
+			if err != nil {
+				log.Error("send ack by tunnel failed", zap.String("addr", tr.session.addr), zap.Error(err))
+			}
+		}
+		return &pb.TaskResp{Resp: up.GetResp()}, nil
+	case <-tr.ctx.Done():
+		tr.finish()
+		return nil, ctxerr(tr.ctx.Err())
+	}
+}
+
+// Header implement grpc.ClientStream
+func (tr *tunnelrun) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+// Trailer implement grpc.ClientStream
+func (tr *tunnelrun) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+// CloseSend implement grpc.ClientStream
+func (tr *tunnelrun) CloseSend() error {
+	return nil
+}
+
+// Context implement grpc.ClientStream
+func (tr *tunnelrun) Context() context.Context {
+	return tr.ctx
+}
+
+// SendMsg implement grpc.ClientStream
+func (tr *tunnelrun) SendMsg(m interface{}) error {
+	return errors.New("can not send msg to task run on tunnel")
+}
+
+// RecvMsg implement grpc.ClientStream
+func (tr *tunnelrun) RecvMsg(m interface{}) error {
+	out, ok := m.(*pb.TaskResp)
+	if !ok {
+		return fmt.Errorf("unsupport msg type %T", m)
+	}
+	resp, err := tr.Recv()
+	if err != nil {
+		return err
+	}
+	out.Resp = resp.GetResp()
+	return nil
+}
+
+// TunnelService implementation proto Tunnel interface
+type TunnelService struct {
+	Auth Auth
+}
+
+// Connect recv tunnel from worker, first msg is worker port
+func (ts *TunnelService) Connect(stream pb.Tunnel_ConnectServer) error {
+	p, ok := peer.FromContext(stream.Context())
+	if !ok {
+		return errors.New("get peer failed")
+	}
+	hello, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	ip, _, _ := net.SplitHostPort(p.Addr.String())
+	session := &tunnelsession{
+		addr:   fmt.Sprintf("%s:%d", ip, hello.GetPort()),
+		stream: stream,
+		runs:   make(map[int64]*tunnelrun),
+	}
+	err = checkauthhost(stream.Context(), session.addr)
+	if err != nil {
+		return err
+	}
+	tunnels.add(session)
+	log.Info("worker tunnel connected", zap.String("addr", session.addr))
+	defer func() {
+		tunnels.del(session)
+		session.close()
+		log.Info("worker tunnel closed", zap.String("addr", session.addr))
+	}()
+	for {
+		up, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		session.dispatch(up)
+	}
+}
+
+// RunTunnel worker open tunnel to every scheduler, scheduler will run task by tunnel
+func RunTunnel(port int) {
+	for _, addr := range config.CoreConf.Client.ServerAddrs {
+		go runtunnel(addr, port)
+	}
+}
+
+// runtunnel keep tunnel to scheduler until worker stop
+func runtunnel(addr string, port int) {
+	for {
+		err := connecttunnel(addr, port)
+		log.Error("tunnel to scheduler closed", zap.String("server", addr), zap.Error(err))
+		select {
+		case <-clentstophb:
+			return
+		case <-time.After(tunnelReconnectInterval):
+		}
+	}
+}
+
+// connecttunnel open tunnel and run task recv from tunnel, return when tunnel closed,
+// and all task run by tunnel will be canceled
+func connecttunnel(addr string, port int) error {
+	conn, err := getgRPCConn(context.Background(), addr)
+	if err != nil {
+		return fmt.Errorf("getgRPCConn failed: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := pb.NewTunnelClient(conn).Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("Connect failed: %w", err)
+	}
+	tw := &tunnelworker{
+		ctx:    ctx,
+		stream: stream,
+		tasks:  make(map[int64]*tunneltask),
+	}
+	err = tw.send(&pb.TunnelUp{Port: int32(port)})
+	if err != nil {
+		return fmt.Errorf("stream.Send failed: %w", err)
+	}
+	log.Info("tunnel to scheduler connected", zap.String("server", addr))
+	for {
+		down, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		switch {
+		case down.GetTask() != nil:
+			go tw.runtask(down)
+		case down.GetCancel():
+			tw.cancel(down.GetSeq())
+		case down.GetAck() > 0:
+			tw.ack(down.GetSeq(), down.GetAck())
+		}
+	}
+}
+
+// tunnelworker run task recv from tunnel
+type tunnelworker struct {
+	ctx      context.Context
+	stream   pb.Tunnel_ConnectClient
+	sendlock sync.Mutex
+
+	sync.Mutex
+	tasks map[int64]*tunneltask
+}
+
+func (tw *tunnelworker) send(up *pb.TunnelUp) error {
+	tw.sendlock.Lock()
+	defer tw.sendlock.Unlock()
+	return tw.stream.Send(up)
+}
+
+func (tw *tunnelworker) get(seq int64) *tunneltask {
+	tw.Lock()
+	defer tw.Unlock()
+	return tw.tasks[seq]
+}
+
+func (tw *tunnelworker) cancel(seq int64) {
+	if task := tw.get(seq); task != nil {
+		task.cancel()
+	}
+}
+
+func (tw *tunnelworker) ack(seq, ack int64) {
+	if task := tw.get(seq); task != nil {
+		task.setack(ack)
+	}
+}
+
+// runtask run task like TaskService.RunTask, and send task end to scheduler
+func (tw *tunnelworker) runtask(down *pb.TunnelDown) {
+	md := metadata.MD{}
+	for _, m := range down.GetMetadata() {
+		md.Append(m.GetKey(), m.GetValue())
+	}
+	ctx, cancel := context.WithCancel(metadata.NewIncomingContext(tw.ctx, md))
+	task := &tunneltask{
+		ctx:       ctx,
+		ctxcancel: cancel,
+		seq:       down.GetSeq(),
+		tw:        tw,
+	}
+	task.cond = sync.NewCond(&task.Mutex)
+	tw.Lock()
+	tw.tasks[task.seq] = task
+	tw.Unlock()
+
+	err := (&TaskService{}).RunTask(down.GetTask(), task)
+
+	tw.Lock()
+	delete(tw.tasks, task.seq)
+	tw.Unlock()
+	task.cancel()
+
+	end := &pb.TunnelUp{Seq: task.seq, End: true}
+	if err != nil {
+		st := status.Convert(err)
+		end.Code = int32(st.Code())
+		end.ErrMsg = st.Message()
+	}
+	err = tw.send(end)
+	if err != nil {
+		log.Error("send task end by tunnel failed", zap.Int64("seq", task.seq), zap.Error(err))
+	}
+}
+
+// tunneltask is a task recv from tunnel, it implement pb.Task_RunTaskServer
+type tunneltask struct {
+	sync.Mutex
+	cond      *sync.Cond
+	ctx       context.Context
+	ctxcancel context.CancelFunc
+	seq       int64
+	tw        *tunnelworker
+	// outputs sent and acked by scheduler
+	sent, acked int64
+}
+
+func (tt *tunneltask) cancel() {
+	tt.ctxcancel()
+	tt.Lock()
+	tt.cond.Broadcast()
+	tt.Unlock()
+}
+
+func (tt *tunneltask) setack(ack int64) {
+	tt.Lock()
+	if ack > tt.acked {
+		tt.acked = ack
+	}
+	tt.cond.Broadcast()
+	tt.Unlock()
+}
+
+// Send send task output by tunnel, wait scheduler ack if unacked outputs reach tunnelWindow
+func (tt *tunneltask) Send(resp *pb.TaskResp) error {
+	tt.Lock()
+	for tt.sent-tt.acked >= tunnelWindow && tt.ctx.Err() == nil {
+		tt.cond.Wait()
+	}
+	tt.sent++
+	tt.Unlock()
+	if err := tt.ctx.Err(); err != nil {
+		return ctxerr(err)
+	}
+	return tt.tw.send(&pb.TunnelUp{Seq: tt.seq, Resp: resp.GetResp()})
+}
+
+// SetHeader implement grpc.ServerStream
+func (tt *tunneltask) SetHeader(metadata.MD) error {
+	return nil
+}
+
+// SendHeader implement grpc.ServerStream
+func (tt *tunneltask) SendHeader(metadata.MD) error {
+	return nil
+}
+
+// SetTrailer implement grpc.ServerStream
+func (tt *tunneltask) SetTrailer(metadata.MD) {}
+
+// Context implement grpc.ServerStream
+func (tt *tunneltask) Context() context.Context {
+	return tt.ctx
+}
+
+// SendMsg implement grpc.ServerStream
+func (tt *tunneltask) SendMsg(m interface{}) error {
+	resp, ok := m.(*pb.TaskResp)
+	if !ok {
+		return fmt.Errorf("unsupport msg type %T", m)
+	}
+	return tt.Send(resp)
+}
+
+// RecvMsg implement grpc.ServerStream
+func (tt *tunneltask) RecvMsg(m interface{}) error {
+	return io.EOF
+}
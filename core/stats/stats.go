@@ -1,28 +1,233 @@
 package stats
 
 import (
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	// "github.com/prometheus/client_golang/prometheus"
-
-	"log"
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labulaka521/crocodile/common/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
 )
 
 // 监控数据项
-// goroutinue total
-// 占用内存
-// 任务总数
-//
+// 任务运行 开始、结束、失败次数，运行时间
+// 任务锁竞争
+// redis、db 调用耗时
+// 主机组在线、离线worker数量
+// grpc stream 错误
+// 报警发送失败次数
+
+const namespace = "crocodile"
+
+// DefaultPath default metrics url path
+const DefaultPath = "/metrics"
+
 var (
+	taskRunsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "runs_started_total",
+		Help:      "Total number of task runs started.",
+	}, []string{"task", "trigger"})
+
+	taskRunsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "runs_finished_total",
+		Help:      "Total number of task runs finished, include failed runs.",
+	}, []string{"task", "trigger"})
+
+	taskRunsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "runs_failed_total",
+		Help:      "Total number of task runs failed.",
+	}, []string{"task", "trigger"})
+
+	taskRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of task runs.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 10), // 0.1s ~ 7.3h
+	}, []string{"task", "status"})
+
+	taskLock = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "lock_total",
+		Help:      "Total number of task run lock attempts by result (acquired, contended, error).",
+	}, []string{"result"})
+
+	redisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "command_duration_seconds",
+		Help:      "Duration of redis commands.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms ~ 4s
+	}, []string{"command"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of db exec and query.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"operation"})
+
+	grpcStreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "stream_errors_total",
+		Help:      "Total number of grpc stream errors.",
+	}, []string{"method", "code"})
+
+	alarmSendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "alarm",
+		Name:      "send_failures_total",
+		Help:      "Total number of alarm send failures by sender.",
+	}, []string{"sender"})
+
+	workersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "hostgroup", "workers"),
+		"Number of workers in host group by state (online, offline).",
+		[]string{"hostgroup", "state"}, nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		taskRunsStarted,
+		taskRunsFinished,
+		taskRunsFailed,
+		taskRunDuration,
+		taskLock,
+		redisDuration,
+		dbDuration,
+		grpcStreamErrors,
+		alarmSendFailures,
+		workers,
+	)
+}
+
+// TaskRunStarted count task run start
+func TaskRunStarted(task, trigger string) {
+	taskRunsStarted.WithLabelValues(task, trigger).Inc()
+}
+
+// TaskRunFinished count task run finished and observe run duration
+func TaskRunFinished(task, trigger string, success bool, d time.Duration) {
+	taskRunsFinished.WithLabelValues(task, trigger).Inc()
+	status := "success"
+	if !success {
+		status = "fail"
+		taskRunsFailed.WithLabelValues(task, trigger).Inc()
+	}
+	taskRunDuration.WithLabelValues(task, status).Observe(d.Seconds())
+}
 
-// GuageVecApiDuration = prometheus.NewGaugeFunc(opts prometheus.GaugeOpts, function func() float64)
+// task lock result
+const (
+	LockAcquired  = "acquired"
+	LockContended = "contended"
+	LockError     = "error"
 )
 
-// Stats start listen port 9100,prometheus will pull data from this url
-// http://ip:9100/metrics
-func Stats() {
+// TaskLock count task run lock attempt
+func TaskLock(result string) {
+	taskLock.WithLabelValues(result).Inc()
+}
+
+// ObserveRedis observe redis command duration
+func ObserveRedis(command string, d time.Duration) {
+	redisDuration.WithLabelValues(command).Observe(d.Seconds())
+}
+
+// ObserveDB observe db exec or query duration
+func ObserveDB(operation string, d time.Duration) {
+	dbDuration.WithLabelValues(operation).Observe(d.Seconds())
+}
+
+// GRPCStreamError count grpc stream error
+func GRPCStreamError(method, code string) {
+	grpcStreamErrors.WithLabelValues(method, code).Inc()
+}
+
+// AlarmSendFailed count alarm send failure
+func AlarmSendFailed(sender string) {
+	alarmSendFailures.WithLabelValues(sender).Inc()
+}
+
+// HostGroupWorkers online and offline worker count of a host group
+type HostGroupWorkers struct {
+	HostGroup string
+	Online    int
+	Offline   int
+}
+
+// WorkerCounter return worker count of all host groups
+type WorkerCounter func(ctx context.Context) ([]HostGroupWorkers, error)
+
+// workerCollector collect worker count when prometheus scrape
+type workerCollector struct {
+	sync.RWMutex
+	counter WorkerCounter
+}
+
+var workers = &workerCollector{}
+
+// SetWorkerCounter set the func to count workers, only server need set it
+func SetWorkerCounter(counter WorkerCounter) {
+	workers.Lock()
+	workers.counter = counter
+	workers.Unlock()
+}
+
+// Describe implement prometheus.Collector
+func (w *workerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workersDesc
+}
+
+// Collect implement prometheus.Collector
+func (w *workerCollector) Collect(ch chan<- prometheus.Metric) {
+	w.RLock()
+	counter := w.counter
+	w.RUnlock()
+	if counter == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hgworkers, err := counter(ctx)
+	if err != nil {
+		log.Error("count workers failed", zap.Error(err))
+		return
+	}
+	for _, hg := range hgworkers {
+		ch <- prometheus.MustNewConstMetric(workersDesc, prometheus.GaugeValue, float64(hg.Online), hg.HostGroup, "online")
+		ch <- prometheus.MustNewConstMetric(workersDesc, prometheus.GaugeValue, float64(hg.Offline), hg.HostGroup, "offline")
+	}
+}
+
+// Handler return prometheus metrics http handler
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
 
-	http.Handle("/metrics", promhttp.Handler())
-	log.Fatal(http.ListenAndServe("0.0.0.0:9100", nil))
-	// promhttp.Handler
+// Stats start listen port,prometheus will pull data from this url
+// http://ip:port/metrics
+func Stats(port int, path string) error {
+	if path == "" {
+		path = DefaultPath
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, Handler())
+	addr := fmt.Sprintf(":%d", port)
+	log.Info("start run metrics server", zap.String("addr", addr), zap.String("path", path))
+	return http.ListenAndServe(addr, mux)
 }
@@ -1,7 +1,49 @@
 package stats
 
-import "testing"
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
 
 func TestStats(t *testing.T) {
-	Stats()
+	TaskRunStarted("test", "manual")
+	TaskRunFinished("test", "manual", false, time.Second)
+	TaskLock(LockContended)
+	AlarmSendFailed("email")
+	SetWorkerCounter(func(ctx context.Context) ([]HostGroupWorkers, error) {
+		return []HostGroupWorkers{{HostGroup: "hg", Online: 2, Offline: 1}}, nil
+	})
+	defer SetWorkerCounter(nil)
+
+	if v := testutil.ToFloat64(taskRunsFailed.WithLabelValues("test", "manual")); v != 1 {
+		t.Errorf("want get failed runs 1, but get %v", v)
+	}
+
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+	res, err := srv.Client().Get(srv.URL + DefaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, metric := range []string{
+		`crocodile_task_runs_started_total{task="test",trigger="manual"} 1`,
+		`crocodile_task_lock_total{result="contended"} 1`,
+		`crocodile_alarm_send_failures_total{sender="email"} 1`,
+		`crocodile_hostgroup_workers{hostgroup="hg",state="online"} 2`,
+	} {
+		if !strings.Contains(string(body), metric) {
+			t.Errorf("metrics not contain %s", metric)
+		}
+	}
 }
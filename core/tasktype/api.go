@@ -22,6 +22,9 @@ type DataAPI struct {
 	Method  string            `json:"method" comment:"Method"`
 	PayLoad string            `json:"payload" comment:"PayLoad"`
 	Header  map[string]string `json:"header" comment:"Header"`
+	// 分片任务的分片信息，替换URL PayLoad Header中的{{SHARD_INDEX}} {{SHARD_TOTAL}}
+	ShardIndex int `json:"-"`
+	ShardTotal int `json:"-"`
 }
 
 // Header
@@ -33,6 +36,19 @@ func (da DataAPI)Type() string {
 	return "api"
 }
 
+// shard return a copy of DataAPI which shard template is replaced
+func (da DataAPI) shard() DataAPI {
+	r := shardreplacer(da.ShardIndex, da.ShardTotal)
+	da.URL = r.Replace(da.URL)
+	da.PayLoad = r.Replace(da.PayLoad)
+	header := make(map[string]string, len(da.Header))
+	for k, v := range da.Header {
+		header[k] = r.Replace(v)
+	}
+	da.Header = header
+	return da
+}
+
 // Run implment TaskRun interface
 func (da DataAPI) Run(ctx context.Context) io.ReadCloser {
 	pr, pw := io.Pipe()
@@ -44,6 +60,9 @@ func (da DataAPI) Run(ctx context.Context) io.ReadCloser {
 			pw.Write([]byte(fmt.Sprintf("\n%sRun Finished,Return Code:%5d", now, exitCode))) // write exitCode,total 5 byte
 			// pw.Write([]byte(fmt.Sprintf("%3d", exitCode))) // write exitCode,total 3 byte
 		}()
+		if da.ShardTotal > 0 {
+			da = da.shard()
+		}
 		// go1.13 use NewRequestWithContext
 
 		req, err := http.NewRequestWithContext(ctx, da.Method, da.URL, bytes.NewReader([]byte(da.PayLoad)))
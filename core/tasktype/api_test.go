@@ -72,3 +72,26 @@ func TestDataAPI_Run(t *testing.T) {
 		t.Errorf("status code is %d, not 200", code)
 	}
 }
+
+func TestDataAPI_shard(t *testing.T) {
+	dataapi := DataAPI{
+		URL:        "http://127.0.0.1/job?shard={{SHARD_INDEX}}",
+		PayLoad:    `{"index":{{SHARD_INDEX}},"total":{{SHARD_TOTAL}}}`,
+		Header:     map[string]string{"X-Shard": "{{SHARD_INDEX}}/{{SHARD_TOTAL}}"},
+		ShardIndex: 2,
+		ShardTotal: 4,
+	}
+	shard := dataapi.shard()
+	if shard.URL != "http://127.0.0.1/job?shard=2" {
+		t.Errorf("replace url failed, get %s", shard.URL)
+	}
+	if shard.PayLoad != `{"index":2,"total":4}` {
+		t.Errorf("replace payload failed, get %s", shard.PayLoad)
+	}
+	if shard.Header["X-Shard"] != "2/4" {
+		t.Errorf("replace header failed, get %s", shard.Header["X-Shard"])
+	}
+	if dataapi.Header["X-Shard"] != "{{SHARD_INDEX}}/{{SHARD_TOTAL}}" {
+		t.Errorf("origin header should not be changed, get %s", dataapi.Header["X-Shard"])
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/labulaka521/crocodile/core/tracing"
 	"github.com/labulaka521/crocodile/core/utils/resp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var _ TaskRuner = DataCode{}
@@ -23,6 +25,9 @@ type DataCode struct {
 	Lang     Lang   `json:"lang"`
 	LangDesc string `json:"langdesc" comment:"Lang"`
 	Code     string `json:"code" comment:"Code"`
+	// 分片任务的分片信息，通过环境变量SHARD_INDEX SHARD_TOTAL传给任务
+	ShardIndex int `json:"-"`
+	ShardTotal int `json:"-"`
 }
 
 // Lang task type lang code
@@ -243,6 +248,11 @@ func (ds DataCode) Run(ctx context.Context) io.ReadCloser {
 			codepath string
 			cmd      *exec.Cmd
 		)
+		_, span := tracing.Start(ctx, "worker.exec", attribute.String("task.lang", ds.Lang.String()))
+		defer func() {
+			span.SetAttributes(attribute.Int("task.exit_code", exitCode))
+			tracing.End(span, err)
+		}()
 		defer pw.Close()
 		defer func() {
 			now := time.Now().Local().Format("2006-01-02 15:04:05: ")
@@ -256,6 +266,9 @@ func (ds DataCode) Run(ctx context.Context) io.ReadCloser {
 			pw.Write([]byte(err.Error()))
 			return
 		}
+		if ds.ShardTotal > 0 {
+			cmd.Env = append(os.Environ(), shardenv(ds.ShardIndex, ds.ShardTotal)...)
+		}
 		cmd.Stdout = pw
 		cmd.Stderr = pw
 		err = cmd.Start()
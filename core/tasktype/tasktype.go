@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	pb "github.com/labulaka521/crocodile/core/proto"
 	"github.com/labulaka521/crocodile/core/utils/define"
@@ -15,6 +17,29 @@ const (
 	DefaultExitCode int = -1
 )
 
+const (
+	// ShardIndex env name of code task and template name of api task, value is the shard index start from 0
+	ShardIndex = "SHARD_INDEX"
+	// ShardTotal env name of code task and template name of api task, value is the total of shards
+	ShardTotal = "SHARD_TOTAL"
+)
+
+// shardenv return shard env of code task
+func shardenv(index, total int) []string {
+	return []string{
+		ShardIndex + "=" + strconv.Itoa(index),
+		ShardTotal + "=" + strconv.Itoa(total),
+	}
+}
+
+// shardreplacer replace {{SHARD_INDEX}} {{SHARD_TOTAL}} of api task
+func shardreplacer(index, total int) *strings.Replacer {
+	return strings.NewReplacer(
+		"{{"+ShardIndex+"}}", strconv.Itoa(index),
+		"{{"+ShardTotal+"}}", strconv.Itoa(total),
+	)
+}
+
 // TaskRuner run task interface
 // Please Implment io.ReadCloser
 // reader last 3 byte must be exit code
@@ -34,6 +59,8 @@ func GetDataRun(t *pb.TaskReq) (TaskRuner, error) {
 			return nil, err
 		}
 		code.LangDesc = code.Lang.String()
+		code.ShardIndex = int(t.GetShardIndex())
+		code.ShardTotal = int(t.GetShardTotal())
 		return code, err
 
 	case define.API:
@@ -45,6 +72,8 @@ func GetDataRun(t *pb.TaskReq) (TaskRuner, error) {
 		if api.Header == nil {
 			api.Header = make(map[string]string)
 		}
+		api.ShardIndex = int(t.GetShardIndex())
+		api.ShardTotal = int(t.GetShardTotal())
 		return api, err
 
 	default:
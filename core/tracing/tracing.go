@@ -0,0 +1,231 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis"
+	"github.com/labulaka521/crocodile/core/config"
+	"github.com/labulaka521/crocodile/core/utils/define"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// 链路追踪
+// server: 任务运行 -> 子任务 -> redis、db调用
+// client: 通过grpc metadata传递trace context，记录worker执行
+// span通过otlp grpc导出，测试时可以使用内存exporter
+
+const instrumentation = "github.com/labulaka521/crocodile"
+
+var provider *sdktrace.TracerProvider
+
+func init() {
+	// 未开启trace时也设置propagator,保证trace context可以在server和client间传递
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// Init init tracer provider, span will be exported to otlp collector
+func Init(mode define.RunMode) error {
+	cfg := config.CoreConf.Trace
+	if !cfg.Enable {
+		return nil
+	}
+	opts := []otlptracegrpc.Option{}
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("otlptracegrpc.New failed: %w", err)
+	}
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	servicename := "crocodile-server"
+	if mode == define.Client {
+		servicename = "crocodile-client"
+	}
+	setprovider(servicename, sdktrace.WithBatcher(exporter), sampler)
+	return nil
+}
+
+// InitWithExporter init tracer provider export span sync to exporter,
+// use it in test with tracetest.NewInMemoryExporter
+func InitWithExporter(exporter sdktrace.SpanExporter) {
+	setprovider("crocodile-test", sdktrace.WithSyncer(exporter), sdktrace.AlwaysSample())
+}
+
+func setprovider(servicename string, export sdktrace.TracerProviderOption, sampler sdktrace.Sampler) {
+	provider = sdktrace.NewTracerProvider(
+		export,
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", servicename))),
+	)
+	otel.SetTracerProvider(provider)
+}
+
+// Shutdown flush all span and stop tracer provider
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Tracer return crocodile tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start start a span as child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// SetError record err and mark span failed
+func SetError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End record err and end span
+func End(span trace.Span, err error) {
+	SetError(span, err)
+	span.End()
+}
+
+// Detach return a ctx which keep span in ctx but will not be canceled with ctx
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// TraceID return trace id in ctx, if not exist return ""
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// recording only trace redis and db call when ctx has a recording span
+func recording(ctx context.Context) bool {
+	return trace.SpanFromContext(ctx).IsRecording()
+}
+
+// metadataCarrier adapt grpc metadata to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	values := metadata.MD(mc).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for key := range mc {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// InjectGRPC inject trace context in ctx to grpc outgoing metadata
+func InjectGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// ExtractGRPC extract trace context from grpc incoming metadata
+func ExtractGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// Redis return a client which trace every command as child span of ctx,
+// if ctx has no recording span, return client itself
+func Redis(ctx context.Context, client *redis.Client) *redis.Client {
+	if !recording(ctx) {
+		return client
+	}
+	// WithContext clone client and keep the process wrapped before
+	c := client.WithContext(ctx)
+	c.WrapProcess(func(old func(redis.Cmder) error) func(redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			_, span := Start(ctx, "redis."+cmd.Name(),
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", cmd.Name()),
+			)
+			err := old(cmd)
+			if err == redis.Nil {
+				End(span, nil)
+			} else {
+				End(span, err)
+			}
+			return err
+		}
+	})
+	c.WrapProcessPipeline(func(old func([]redis.Cmder) error) func([]redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			_, span := Start(ctx, "redis.pipeline",
+				attribute.String("db.system", "redis"),
+				attribute.Int("db.redis.num_cmd", len(cmds)),
+			)
+			err := old(cmds)
+			if err == redis.Nil {
+				End(span, nil)
+			} else {
+				End(span, err)
+			}
+			return err
+		}
+	})
+	return c
+}
+
+// DBHook trace db exec and query as child span of ctx, use it by db.Hook
+func DBHook(ctx context.Context, operation, query string) func(err error) {
+	if !recording(ctx) {
+		return nil
+	}
+	_, span := Start(ctx, "db."+operation,
+		attribute.String("db.operation", operation),
+		attribute.String("db.statement", query),
+	)
+	return func(err error) {
+		End(span, err)
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPropagateGRPC(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	InitWithExporter(exporter)
+	defer Shutdown(context.Background())
+
+	ctx, span := Start(context.Background(), "task.run")
+	traceid := TraceID(ctx)
+	if len(traceid) != 32 {
+		t.Fatalf("want get 32 length trace id, but get %s", traceid)
+	}
+
+	// server inject trace context, worker extract it from incoming metadata
+	md, _ := metadata.FromOutgoingContext(InjectGRPC(ctx))
+	workerctx := ExtractGRPC(metadata.NewIncomingContext(context.Background(), md))
+	_, workerspan := Start(workerctx, "worker.RunTask")
+	End(workerspan, errors.New("exit code 1"))
+	End(span, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("want get 2 spans, but get %d", len(spans))
+	}
+	if spans[0].Name != "worker.RunTask" || spans[0].SpanContext.TraceID().String() != traceid {
+		t.Errorf("worker span not in trace %s", traceid)
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Errorf("worker span's parent is not task.run span")
+	}
+	if len(spans[0].Events) != 1 {
+		t.Errorf("want record error on worker span")
+	}
+}
+
+func TestDBHook(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	InitWithExporter(exporter)
+	defer Shutdown(context.Background())
+
+	if end := DBHook(context.Background(), "query", "SELECT 1"); end != nil {
+		t.Errorf("want not trace db call without parent span")
+	}
+	ctx, span := Start(context.Background(), "task.run")
+	DBHook(ctx, "query", "SELECT 1")(nil)
+	span.End()
+	if n := len(exporter.GetSpans()); n != 2 {
+		t.Errorf("want get 2 spans, but get %d", n)
+	}
+}
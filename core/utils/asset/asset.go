@@ -37,14 +37,22 @@
 // web/crocodile/static/js/chunk-elementUI.4de05055.js
 // web/crocodile/static/js/chunk-libs.5cd940d3.js
 // sql/README.md
+// sql/apitoken.sql
+// sql/approval.sql
 // sql/casbin_rule.sql
+// sql/enrolltoken.sql
 // sql/host.sql
+// sql/hostevent.sql
 // sql/hostgroup.sql
 // sql/log.sql
 // sql/notify.sql
 // sql/operate.sql
+// sql/project.sql
+// sql/projectmember.sql
 // sql/task.sql
+// sql/totprecovery.sql
 // sql/user.sql
+// sql/usergroup.sql
 package asset
 
 import (
@@ -841,7 +849,7 @@ func webCrocodileStaticJsChunkLibs5cd940d3Js() (*asset, error) {
 	return a, nil
 }
 
-var _sqlReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x52\x56\x56\xf0\xcb\x2f\x49\xe5\x72\xc9\x57\xc8\xcb\x2f\x51\x08\x4a\xcd\x4b\xcc\x4d\x55\x28\x2e\xcc\x51\x48\xcb\xcc\x49\x55\xf0\x4b\xcc\x4d\x05\x04\x00\x00\xff\xff\x45\x73\xa8\xec\x23\x00\x00\x00")
+var _sqlReadmeMd = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x14\xca\x31\x4e\xc6\x30\x0c\x05\xe0\x3d\xa7\x78\x52\xd7\x8a\x5e\x82\xb9\x03\x0b\xb3\x69\x1e\x69\x24\xc7\x2e\xb1\x23\xc1\xed\xd1\xbf\x7f\xdb\x86\xd3\x93\xe5\xdd\x61\x9e\xf8\xa0\xc9\x20\xe2\x47\xf1\xdd\x95\x38\x65\xb0\x94\xcf\x9b\x06\xa9\xb5\x5b\xc3\xe5\xba\x86\xc5\x8e\x6e\x95\xbf\x0c\xf8\xc4\x25\xf1\xd5\x0d\x73\x29\x63\x87\x68\xf8\x4b\x23\x6f\x0e\xa4\xe3\xf2\xc9\x63\x78\xa5\x1e\xa3\xb7\x29\xc9\xb7\xe6\x08\x47\xb7\x48\x51\x65\x45\xfc\x45\x72\x04\x64\x12\xeb\x69\x53\x2a\x2b\x24\x11\x29\x33\xd7\x53\xfe\x07\x00\x0d\x5b\x69\x35\xa7\x00\x00\x00")
 
 func sqlReadmeMdBytes() ([]byte, error) {
 	return bindataRead(
@@ -856,12 +864,52 @@ func sqlReadmeMd() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/README.md", size: 35, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/README.md", size: 167, mode: os.FileMode(420), modTime: time.Unix(1792367862, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _sqlCasbin_ruleSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x94\xdf\x6a\xdb\x30\x14\x87\xef\xfd\x14\xe7\x4e\xf6\x10\x24\x69\x53\x18\x84\x5e\x38\x89\x9a\x09\x5c\x65\xc4\xca\xd8\x5d\xa3\x66\x4a\x6b\xe6\x7f\x48\xb2\x20\xb0\x87\x1f\xee\x4a\x69\x3c\x53\x5f\x6c\x92\xc1\x60\x6c\xfd\xe0\x3b\xe7\xd3\x91\x96\x64\x43\xd9\x22\x58\xed\x48\xcc\x09\xf0\x78\x99\x10\xa0\x77\xc0\xb6\x1c\xc8\x77\x9a\xf2\x14\x0e\x47\xa1\x1f\xb3\xf2\x41\x35\xb9\x3c\x40\x18\x00\x1c\xea\x07\x73\xae\xe5\x01\xac\x50\xc7\x67\xa1\xc2\xd9\x74\x1a\xc1\x9a\xdc\xc5\xfb\x84\x03\xdb\x27\x09\x6e\x53\x76\x3a\x98\x98\x0d\x26\xae\x06\x13\xd7\x83\x89\xf9\x60\xe2\xe6\x83\x44\x10\x01\x61\x1b\xca\xc8\x2d\x2d\xcb\x6a\xbd\x7c\x5b\x5c\x7d\x89\x77\x29\xe1\xb7\x8d\x39\x7d\x2e\x1e\xe7\x8b\x80\xb2\x94\xec\x38\x50\xc6\xb7\xf0\x4e\x1a\x84\x7f\x7c\x61\x3b\xc5\x76\x86\xed\x15\xb6\xd7\xd8\xce\xb1\xbd\x89\xe0\x5b\x9c\xec\x49\x0a\x21\xaa\x11\x46\xf1\x8f\x22\x2b\x11\x46\x13\x51\x67\x13\x3b\x9b\x3c\x57\xda\x3c\xa9\xaa\xa9\x3f\x21\x8c\xc2\x0d\xe1\xd1\xaf\xf0\xeb\x36\x6d\x5f\x6b\x92\x10\x4e\xda\xef\x3d\x8f\x10\x46\xaf\x4f\xf4\xef\x45\xb0\x4a\x15\x22\x1f\xbb\x8a\x4d\x23\xb5\xf9\xb0\x88\xff\x8a\xeb\x9a\x37\x42\xff\x1c\x55\xba\xef\x02\xba\xbe\x2f\xf8\x4e\x55\xb7\x3b\x3b\xfa\x7c\x8f\x3d\xda\x7e\x54\x37\x5a\xaa\x49\x56\x9e\xaa\x77\xdd\x3a\xd7\xeb\x03\xda\x55\xfa\x17\xd3\xbd\x56\x2d\x73\x79\x34\x4e\x78\xfd\x4a\x1d\x02\x7b\x75\x3a\xe4\xf5\x0a\x55\xf2\x29\xd3\x46\x9d\x5b\xe2\xcb\x99\x74\x8f\x14\x79\xee\xaf\x3f\xf1\xfa\xf3\xe5\x34\xbc\xdd\x37\x3e\x9a\x14\xaa\xd0\x46\x98\x46\x7b\x9c\x56\xd7\xd4\xde\x91\x75\x0d\xed\xf5\x5b\xd5\x52\x09\x23\xbd\x00\xcb\xca\x64\xa7\xb3\xcf\xdb\xdc\x39\xb1\xbb\x91\x97\xc0\x0b\xd4\x6a\x7b\x7f\x4f\xf9\x22\xf8\x1d\x00\x00\xff\xff\x17\xb6\xd1\xde\xc3\x0c\x00\x00")
+var _sqlApitokenSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x92\x5f\x6b\xd3\x50\x18\xc6\xef\xfb\x29\x5e\x7a\x95\x80\x17\x99\xce\x31\x90\x5d\x64\xcd\x99\x1e\x4c\x33\x4d\x4f\xc4\x5d\x2d\x31\x39\x62\xd8\x96\x94\x24\xc5\x5d\x0e\x2a\xd8\xc9\xaa\x01\x85\x31\x57\x15\x41\xf0\xcf\x95\xc2\x98\xa5\xa1\xec\xcb\xe4\x24\xed\xb7\x90\x24\x4d\x5b\x59\x61\xbd\x7d\xf3\x3e\xbf\xe7\xc9\x7b\x9e\x9a\x8a\x44\x82\x80\x88\x9b\x32\x02\xbc\x05\xca\x36\x01\xf4\x14\x37\x48\x03\x74\xd3\x73\x4d\xd7\xb2\xf7\xe9\xae\xd1\xb4\x03\x77\x8f\x3a\x3a\x70\x15\x00\x00\xdd\xb6\x74\xa8\x3d\x10\x55\x6e\x65\x9d\xcf\x35\x8a\x26\xcb\x50\xdb\xae\xd7\x91\x42\xa0\x8a\xa5\xea\xad\x62\xd1\x31\x0e\xa8\x0e\x4f\x44\x35\xdf\xbe\x23\xcc\x6d\x4b\x68\x4b\xd4\x64\x02\xd5\xea\x4c\x18\x47\xdf\xd2\xe3\x13\x16\x76\xd3\xef\xbf\x4b\xc4\xc4\x39\x07\xac\xad\x2e\xb2\x2b\x54\xfe\x0b\xe3\xf6\xdd\xb5\x52\xd5\x5a\x1c\x71\x91\x69\x72\x7c\xc4\xfe\x7c\x4e\x3f\xfc\x48\x3a\x7f\x67\xc9\x7d\xd3\x6d\xce\x45\x5f\xb9\x29\x7a\xf2\xa9\x3d\x3e\x0b\x47\x27\x6d\x76\x7e\x01\x1e\x35\x2c\xf0\x5a\x0e\xbc\xf4\xec\x80\x4e\xff\xc4\xf0\xf7\xb0\xe4\xcf\x43\x85\x9b\xb0\xec\xdd\xaf\x51\x7b\x98\xbc\xef\xc6\xc3\x5e\xfa\xf1\x55\x1c\x45\xec\xcd\x57\x2c\x41\xdc\x1f\xa4\x3f\x07\x71\xbf\x3b\x3e\x0b\x59\xe7\xb2\xb4\xa0\x87\x4d\xdb\xa3\xc4\xce\xae\x8e\x15\x72\x9d\x2d\xcc\xd0\xa3\xab\xd7\x49\xef\x4b\x72\x7a\x39\x3e\xbd\x00\x21\xee\x67\xb4\x62\x56\xd2\xf6\x0d\x3f\xd0\x7c\x6a\x2d\xc7\x4b\x7a\x47\x2c\x7c\x1b\x0f\xaf\xb2\x53\xe6\xd4\x92\x63\x7a\xd4\x08\x96\x4c\xc5\x3a\xe7\x2c\x1a\xfc\xa7\x7f\xa4\xe2\xba\xa8\xee\xc0\x43\xb4\x03\x5c\xd6\x3d\xbe\x98\x6b\x0a\x7e\xac\xa1\x7c\xac\xdb\xd6\xe1\x6e\xd9\xd1\x49\x65\x26\x5b\xd3\xcf\x79\x25\xb8\xbc\x19\x7c\x85\x47\xca\x7d\xac\xa0\x0d\xec\x38\xae\xb4\x39\x0d\x92\x3d\x4b\x03\x91\x8d\x56\xf0\x7c\xfd\xe0\xd9\xea\xbd\xca\xbf\x01\x00\x89\x36\x81\x8e\x21\x03\x00\x00")
+
+func sqlApitokenSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlApitokenSql,
+		"sql/apitoken.sql",
+	)
+}
+
+func sqlApitokenSql() (*asset, error) {
+	bytes, err := sqlApitokenSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/apitoken.sql", size: 801, mode: os.FileMode(420), modTime: time.Unix(1792363871, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlApprovalSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\x93\xdf\x6f\xd2\x50\x14\xc7\xdf\xf9\x2b\x4e\x78\x82\xc4\x07\x7e\xec\x61\xc1\xec\xa1\xd0\x3b\xbd\x0a\xc5\x94\x8b\xd9\x9e\xd6\x0e\xae\x49\xb3\xd1\x92\x52\xa6\xbe\xa1\xd1\xcc\x4c\x89\x64\xa2\x4b\x36\x4d\x24\x21\xd1\x27\x86\x6e\x19\x5a\xa2\xfc\x33\xed\x6d\xf9\x2f\x4c\xa9\xa5\x6c\x71\x91\xbd\x9d\xd3\x9c\xef\xf7\x9c\xf3\xe9\xb9\x39\x11\x71\x04\x01\xe1\xb2\x79\x04\x78\x1d\x84\x22\x01\xb4\x81\x4b\xa4\x04\x52\x45\xd7\x2a\x5a\x55\xd9\xa5\x5b\x72\xbd\xae\x6b\x7b\xf2\xae\x04\xb1\x08\x00\x80\xa4\x54\x25\xc8\xdd\xe5\xc4\x58\x72\x35\x3e\xd3\x08\xe5\x7c\x1e\x72\xc5\x42\x01\x09\x04\xa2\x98\x8f\xde\xf2\x0b\xe5\x8a\xa1\x68\xaa\x04\x58\x20\x61\x1d\x8f\xd6\xb9\x72\x9e\x40\x22\x54\xb8\xa7\x23\xf6\xed\x39\x7b\xd7\xb6\x7e\x7d\x84\x64\xc6\x7e\x75\x62\x8f\x4d\x6b\x3c\xb6\x0f\x7a\x90\xca\x58\x93\x01\xeb\xfe\xfc\x9b\xa6\x33\xee\xa4\xe3\xf6\xde\xf8\x69\xd0\xc8\x90\x1b\x3b\x98\xff\xd7\x54\x41\xb7\x68\x34\x6c\xe7\x6b\x31\xbf\xa8\x16\xe4\x1a\x95\xe0\x21\x27\xce\x2c\xd2\x89\xa5\x2c\xec\x4e\xdb\xf9\x32\x0c\x6c\x2a\x9a\x6a\x50\xd5\x90\xa0\x80\x78\x5c\x2e\x10\xb4\x41\x42\x01\x7b\xdb\xb1\xcc\xbe\x73\xfc\xc2\x57\xb2\xf7\x43\xd6\x1e\xc0\xbd\x52\x51\x98\xc3\x9a\x61\xa6\x3a\xae\x36\xc2\x41\x52\x89\xff\x4d\x62\x0f\x7a\xec\xf3\x0f\xcb\x34\x03\x9f\x86\x21\x1b\xcd\xc6\x35\xd0\x93\xa1\xd0\x39\xb8\x60\xad\x67\x1e\xee\xdf\x2f\x7d\x13\x48\x65\xec\xd1\xf7\x69\xeb\xd8\x9d\xec\x43\xda\x8b\xd9\xeb\x43\x67\xfc\x09\x56\x66\xf1\x61\x7f\xda\x6d\xcd\x97\xd5\xa9\x6c\xd0\xec\xd3\xe5\xa9\x3b\xdd\x33\xf7\x74\x64\x99\x66\x08\x5e\xa7\x7b\x0a\x7d\x7c\x13\x93\xf9\xb6\x57\x4d\x44\x5a\x93\xf5\x9d\x10\x5c\x72\x49\x70\x76\x7f\x9f\x9d\x7d\xbd\xbc\x15\x51\x6a\xf4\x1a\x7e\x0b\x47\xeb\x5f\x29\x3b\xba\x98\x1e\x9d\x07\xfa\x66\xbd\xba\xb4\x9e\x9d\x9c\xb3\x0f\xc3\x4b\xfa\x07\x22\x2e\x70\xe2\x26\xdc\x47\x9b\x10\xf3\x9e\x59\xdc\xff\xee\xe5\x92\x52\x7d\xb2\x55\xd9\x56\x24\x88\x2d\xc2\xbf\x5a\x11\xfc\xfd\x58\x70\x07\xf1\x48\x1c\x09\x77\xb0\x80\xd6\xb0\xaa\x6a\x7c\x76\x3e\x8a\x87\xa9\x84\xc8\x5a\xd3\x78\xb4\x5a\xdb\x5e\xb9\x1d\xf9\x33\x00\x43\x85\xe9\xd8\x0e\x04\x00\x00")
+
+func sqlApprovalSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlApprovalSql,
+		"sql/approval.sql",
+	)
+}
+
+func sqlApprovalSql() (*asset, error) {
+	bytes, err := sqlApprovalSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/approval.sql", size: 1038, mode: os.FileMode(420), modTime: time.Unix(1792366098, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlCasbin_ruleSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xc4\x96\x41\x6b\xdb\x30\x14\xc7\xef\xfe\x14\xba\xd9\x1e\x02\x27\x6d\x0a\x83\xd0\x83\x93\xa8\x99\xc1\x75\x4a\x2c\x8f\xdd\x1a\xd5\x53\x5a\x2f\x8e\x25\x24\x59\x10\xd8\x87\x1f\xde\xba\x2c\xf1\x9c\xf8\xb0\x49\x86\x40\x48\xfc\xe0\xf7\xd7\xef\xc9\x4f\x9a\xa1\x65\x94\x4c\x9d\xf9\x1a\x85\x18\x01\x1c\xce\x62\x04\xa2\x07\x90\xac\x30\x40\x5f\xa2\x14\xa7\x60\x93\x13\xf9\x52\x54\xcf\xa2\x2e\xe9\x06\x78\x0e\x00\x1b\xfe\xac\x0e\x9c\x6e\x80\x26\x22\x7f\x23\xc2\x1b\x8f\x46\x3e\x58\xa0\x87\x30\x8b\x31\x48\xb2\x38\x86\x4d\x95\x1e\xf5\x56\x8c\x7b\x2b\x6e\x7a\x2b\x6e\x7b\x2b\x26\xbd\x15\x77\x57\x2a\x1c\x1f\xa0\x64\x19\x25\xe8\x3e\xaa\x2a\xb6\x98\x1d\x1f\xce\x3f\x85\xeb\x14\xe1\xfb\x5a\x6d\x3f\xee\x5f\x26\x53\x27\x4a\x52\xb4\xc6\x20\x4a\xf0\x0a\x9c\x48\x03\xde\x2f\x5f\x50\x8f\xa0\x1e\x43\x7d\x03\xf5\x2d\xd4\x13\xa8\xef\x7c\xf0\x39\x8c\x33\x94\x02\xcf\xe5\x2e\x74\xc3\xaf\xfb\xa2\x72\xa1\x1b\x10\x5e\x04\x7a\x1c\xbc\x31\xa9\x5e\x05\xab\xf9\x07\x17\xba\xde\x12\x61\xff\xbb\xf7\xb4\x4a\x9b\xaf\x05\x8a\x11\x46\xcd\xef\x0c\xfb\x2e\x74\xdf\x3f\xfe\xbf\x87\x48\x98\xd8\x93\x72\xe8\x14\xcb\x9a\x4a\x75\x35\xc4\x7f\xc5\xb5\xcd\x2b\x22\x77\x83\x4a\xb7\x1d\xa0\xed\xfb\x8c\x6f\x9c\x14\x88\xba\xb1\x6f\x67\x59\xc1\xae\x28\x4b\x7b\x34\xc2\xb9\x60\x9a\x1c\x89\xc7\x2e\x1a\xdd\xc0\x5c\xb0\x6f\x34\x57\x83\xee\xe1\x01\x32\xb4\x3b\xd0\x8e\x60\xd4\x79\x33\xa3\x06\x9f\xd4\x43\x0f\x69\x3b\xaa\x6b\x49\x45\x50\x54\x5b\x76\xb2\x5a\xe3\x7a\x6d\x40\xdb\x4a\xff\x62\x9a\xd7\x2a\x69\x49\x73\x65\x84\xd7\xad\xd4\x20\xb0\x53\xa7\x41\x5e\xa7\x50\x41\x5f\x0b\xa9\xc4\xe1\xe7\x09\xd0\xbc\x93\xe6\x91\xa4\x2c\xed\xad\x8f\xbc\xff\x69\xf1\x78\xfb\xc3\x0d\x24\x95\xb2\x60\x95\x6c\x02\x58\x46\xd7\x55\xc9\xf2\x9d\x91\xab\xc4\x15\xaa\x62\x8a\x5b\x5d\xac\x69\xc3\x97\xa6\x82\x59\xea\x85\xd1\x30\xc4\x66\xe2\x85\x62\x3b\x5a\x5d\x3a\xb9\x2d\xc8\xb6\x1a\xa1\xd3\xbc\xd5\x04\x9d\x6d\xf8\xfd\x5e\x9d\xd1\x9f\x32\xbb\x8d\xb0\x1c\xa2\xb3\x15\x96\x33\x5c\x38\xc0\x88\xd8\x4b\x45\x54\x2d\x2d\xde\x44\x4c\x53\xbb\x77\xbe\x61\x68\xa7\x5f\xc6\xa9\x20\x8a\x5a\x01\x56\x4c\x15\xdb\x83\xcd\x9b\xba\x71\x62\xbb\x91\xe7\xc0\x33\xd4\x7c\xf5\xf8\x18\xe1\xa9\xf3\x63\x00\x5f\x84\x05\xb6\x69\x15\x00\x00")
 
 func sqlCasbin_ruleSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -876,12 +924,32 @@ func sqlCasbin_ruleSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/casbin_rule.sql", size: 3267, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/casbin_rule.sql", size: 5481, mode: os.FileMode(420), modTime: time.Unix(1792366098, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _sqlHostSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\xd2\x3f\xef\xd2\x40\x18\x07\xf0\xbd\xaf\xe2\xd2\xa9\x4d\x1c\x8a\xd1\x84\xc4\x30\x14\x38\xe4\x62\x29\x5a\xae\x06\x26\x5a\x69\x85\x06\x7a\x25\x6d\x51\x46\xff\x25\x88\xc1\xc8\x24\x09\x96\x81\xc4\x44\x07\x07\x12\x89\x1a\x19\x7c\x33\xf4\x80\x77\x61\x0a\x4a\x0b\x3f\xe8\x7a\xf9\xdc\xf7\x79\x7a\xdf\x9c\x02\x45\x0c\x01\x16\xb3\x12\x04\xa8\x00\xe4\x32\x06\xb0\x8a\x2a\xb8\x02\xb4\x86\xeb\x34\x1c\xc3\xea\x98\xf5\x96\xe3\xf9\x1a\xe0\x18\xf0\xef\xd3\x2c\x43\x03\xb9\xa2\xa8\x70\xa9\x34\xbf\x37\xb2\x2a\x49\x20\x57\x2e\x95\xa0\x8c\x01\xbb\xfe\xb5\xa2\xc1\x6f\x94\x67\x6f\xc5\x44\x37\x0c\x57\x03\x8f\x45\x65\xef\x6e\xdf\xbd\xe4\x8a\x8e\xe7\x87\xc1\x22\x9c\xbd\x48\xca\x28\x9d\xe8\xb6\x19\xeb\x94\x20\x5c\x8f\x0d\xc7\xef\x93\xda\xed\x11\x62\x91\x26\xd6\xbd\xb6\xa7\x01\x0c\xab\x38\x06\xdb\x3f\xe3\xed\x7c\xb4\x99\xbe\x59\xaf\x56\xe1\xbb\x79\x92\x3d\x37\xad\x66\xcb\xd7\x00\x92\x71\x9c\x94\x87\x05\x51\x95\x30\x48\x09\x42\x7c\x09\x9d\xbd\xde\x0d\x4e\x22\x3d\xdf\xe9\x9e\xc9\x23\x15\xce\xc7\xa5\xd3\x57\xe1\xcb\x80\x0e\xbf\x6c\xe7\xa3\x9b\x63\x3c\x33\x5d\xcf\x72\x48\x72\xf5\x4b\x9b\x6f\x86\x6f\x69\xf0\x2d\xfc\xf0\x33\x69\x3b\xba\xe7\xab\x5d\x43\xf7\x4d\x6c\xd9\xa6\x4a\xac\xfe\x95\x75\x92\xcb\x7c\x5a\xd2\x8f\x0b\x3a\xf9\xb1\x9b\x2c\x4f\xfe\xa2\x69\xeb\x6e\xfb\xec\x05\xfe\x7b\x96\x8d\x2f\x08\x3f\x0f\xe8\xf7\xaf\x09\xfa\x50\x41\x25\x51\xa9\x81\x07\xb0\xc6\x45\xbd\xe1\xe3\x23\x55\x46\x8f\x54\x18\x9d\x44\x8d\xea\xd7\x0f\x15\xe1\x0e\x55\xe1\x19\x1e\xca\xf7\x91\x0c\x33\x88\x10\x27\x9f\x3d\xa6\x45\xf9\x15\x88\x33\x3d\xff\x69\xda\x7e\x72\xe7\x1e\xc3\x30\x7f\x03\x00\x00\xff\xff\x1f\x45\xf4\x07\xc6\x02\x00\x00")
+var _sqlEnrolltokenSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x92\x4f\x4f\x13\x41\x14\xc0\xef\xfd\x14\x2f\x3d\xb5\x09\x87\xc5\x20\x21\x31\x3d\x6c\xbb\x03\x4e\x6c\x17\xdd\xce\x1a\x39\xb1\x4b\x3b\x86\x4d\xb7\x5d\xb2\x7f\x4c\xb9\x79\xa8\x16\x0d\x1a\x0e\x1a\x22\x36\x11\x93\x1a\x7b\x50\x62\xc4\x60\xb3\x1b\xe0\xcb\xec\xcc\xc2\xb7\x30\x74\x3b\x14\x22\x84\xde\x26\x2f\xef\xf7\x7b\x7f\xe6\x95\x34\x24\x13\x04\x44\x2e\x96\x11\xe0\x45\x50\x97\x09\xa0\x67\xb8\x4a\xaa\x60\xd4\x5c\xa7\xe6\xd4\x2d\x9b\xae\xd2\x96\xeb\xd8\xb6\xef\x34\x68\xcb\x80\x5c\x06\x00\xc0\xb0\xea\x06\x94\x1e\xca\x5a\x6e\x76\x21\x3f\xc2\x54\xbd\x5c\x86\xd2\x72\xa5\x82\x54\x02\x59\xac\x64\x67\xd2\xc4\x31\x36\xca\x9d\x9f\xbb\x29\x37\x8e\xfa\xc9\x9b\x6d\x6f\xdd\xbc\x77\x7f\x5e\x50\xeb\x8e\xe7\x2f\xb9\x4e\xb0\x81\x95\x9b\xea\x28\x68\x51\xd6\xcb\x04\xb2\xd9\x89\x86\x1f\x0e\xd8\xeb\x6d\xb6\xf3\x9e\xbd\xdd\x67\xaf\xbe\x25\x7b\x9d\x78\x18\xf1\x5e\x98\x44\x9d\x49\x37\xb6\xb9\x46\x6d\xcf\x80\xa7\xb2\x96\x5a\x25\x49\x9a\xd6\x7b\x76\x70\x92\x1c\x1f\x24\x7b\x1d\xbe\xdf\x4d\x7e\x9e\x40\x83\x6e\x16\x5e\x98\x76\x40\x67\x2e\x5f\xa2\x4c\xd3\x6c\xeb\x1e\xf5\x0c\xc0\x2a\xf9\xdf\x3e\x7b\x45\xde\x7b\xc9\xfa\xdf\xe3\xe3\xd3\xe4\xc3\x80\xff\xf8\xca\x3f\xfe\x02\x29\x1e\x86\xf1\xf0\xdd\xf9\xa7\x1d\xb6\x75\x24\x84\xc1\xed\x36\x69\x62\x63\x7f\x7f\x5f\x55\x09\x98\xb6\x37\x2c\x97\x12\xab\x49\xef\x56\x9c\x9d\x76\x79\xef\x0b\xdf\x3d\x3a\xdf\xfd\x23\x5a\x49\x63\xc2\xe6\xd2\xa6\xe9\x36\xae\xad\xf0\x8e\x0d\xb2\x7e\x97\x1f\x0e\x04\x5f\x73\xa9\xe9\xd3\xe2\xe6\xf4\x3f\xcb\xb6\x3e\xb3\x28\x8c\xc3\x10\x2b\xd7\x25\xd3\x8d\x94\xe2\xe9\x48\x63\xfe\xb1\x86\x2b\xb2\xb6\x02\x8f\xd0\x0a\xe4\x2e\x6e\x39\x9f\xc6\x75\x15\x3f\xd1\xd1\x28\x6c\x58\xf5\xf6\xaa\xb8\xf9\xf1\x15\xe7\x33\x79\xa4\x2e\x61\x15\x15\x70\xab\xe5\x28\xc5\xcb\x6a\x17\x73\x54\x11\x29\x04\xfe\xf3\x85\xe6\xda\xdc\x83\xcc\xbf\x01\x00\x8c\xe9\xeb\xc9\x59\x03\x00\x00")
+
+func sqlEnrolltokenSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlEnrolltokenSql,
+		"sql/enrolltoken.sql",
+	)
+}
+
+func sqlEnrolltokenSql() (*asset, error) {
+	bytes, err := sqlEnrolltokenSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/enrolltoken.sql", size: 857, mode: os.FileMode(420), modTime: time.Unix(1792363167, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlHostSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x95\x5b\x4f\xdb\x56\x1c\xc0\xdf\xf3\x29\x8e\x78\x22\x52\x1f\x92\x6a\x4c\x95\x26\x1e\x42\x62\xa8\xb5\xe0\x74\x89\x3d\xb5\x4f\xf5\x21\x39\x2d\x56\x7c\x41\xb6\x43\xd9\x1b\x29\x1d\xb7\x11\xa0\x1d\x85\x0d\x82\x28\x2b\x1b\xa9\xb4\x94\x4c\x45\x99\xe7\x28\xcb\x97\xf1\x39\xc7\x7c\x8b\xc9\x84\x25\xb6\x69\x20\x25\x2f\xb1\x74\xf4\xfb\xfd\x6f\xe7\x92\xcc\x32\x09\x9e\x01\x7c\x62\x22\xcd\x00\x76\x12\x70\x19\x1e\x30\x8f\xd9\x1c\x9f\x03\x62\x5e\xd7\xf2\x5a\x41\x92\xd1\xd3\x59\xcd\x30\x45\x30\x1a\x01\x57\x3f\x51\x2a\x88\x20\xf9\x30\x91\x1d\x8d\x3f\x88\x5e\x32\x9c\x90\x4e\x83\x64\x66\x7a\x9a\xe1\x78\x30\xe2\x58\x2d\x52\xb5\xd9\xd4\xc8\xbd\x3e\x02\x0b\x05\x5d\x04\xdf\x27\xb2\x97\xdc\xfd\xb1\xcf\x71\x0f\x35\xc3\xc4\xd5\x06\x3e\x5c\xf4\x93\x5e\x74\x15\x2a\xa8\x4f\xc7\x63\xb1\xc1\x61\xf1\x76\xc5\x4f\xeb\x25\x55\x95\xd4\xe7\x3c\x34\x8a\x86\x08\x78\xe6\x31\xdf\x07\xdc\xce\xb6\x7b\xbc\x41\xf7\x5f\x39\xad\x16\x5e\x3f\xf6\x63\x2f\x90\xf4\x7c\xd6\x14\x01\xcb\xf1\xfd\x48\x29\x66\x32\x21\xa4\x79\x10\x8f\xc5\xfa\x12\x72\xb8\x74\xb1\x12\x08\x69\x98\xda\x5c\x88\xec\xa1\xb1\x70\xba\x64\xff\x25\x2e\x57\xc9\xda\xa9\x7b\xbc\x71\x3d\x8d\x79\xa4\x1b\x92\xa6\xfa\x4b\xff\x5c\xe5\x74\x6d\x95\x54\xff\xc4\x5b\x7f\xfb\x59\x19\x1a\xa6\x30\x57\x80\x26\xe2\x25\x05\x09\xaa\xb4\x30\xa0\x1c\x7f\x31\x07\xe7\x64\xb7\x41\xf6\x9a\x17\x7b\xe7\x81\x2e\x22\x05\xea\xc5\xd0\x04\xfe\xe7\x47\x46\xfa\x02\x7c\xb2\x42\x3e\xd5\xfc\x68\x7e\xae\xc4\x95\x94\xdb\x43\x27\x1f\x09\xe4\x9d\x45\xde\x36\x02\x35\x68\xb0\x10\x17\x41\x2a\x23\x78\x1b\xf4\x26\x3c\x8e\x57\x97\x2f\xde\x1c\xb9\xe7\x47\x6e\xbb\x1d\x56\x8c\x0d\xa5\x18\xbb\x49\x11\x1f\xce\x11\x1f\x28\x51\x90\xc2\x6b\x26\x94\x45\x30\xc1\x4e\x79\xcd\x10\xb8\x1c\x3b\xc5\x31\xa9\x9b\x07\xb2\xd8\xc2\xcb\x3f\xe2\xfa\x2f\x00\xd7\xf7\xdc\xf5\x97\x21\x63\x62\x1e\x4a\x32\x9c\x91\xd1\x97\x59\xf1\xd6\x19\xdd\xa9\x0d\x14\x17\x24\xa3\x78\xb7\x5c\xe9\xfb\x32\x3d\x18\xa4\x9c\xd4\xd1\x9d\xf2\x1c\x28\x35\xa1\x51\xcc\xc9\x9a\x69\xdc\xbe\xbb\xba\x47\x8b\x9c\xb6\x9d\x76\x25\xb4\xc7\xae\x6e\x88\x21\x45\xa4\xfe\x1e\x57\x6b\xa1\x9b\x23\x64\x54\xe0\x42\x52\x53\xf3\x25\x5d\x47\xaa\x39\x84\xb2\xba\x88\x4f\x4e\xf1\x3f\x4d\xbc\xf5\xba\xe7\x03\x31\xc7\xb2\x1d\xab\x72\xf1\xeb\x36\x5e\x6d\x06\xcf\xf5\x0c\x92\x8d\xc0\x59\x8c\x45\xaf\x07\xf0\x9f\x4a\xf2\x6e\x85\xd6\xff\x05\x45\xf4\xc3\xf8\x3c\x94\x4b\xe8\x5e\xef\xcb\x2f\xd6\x54\x59\x52\xbd\x09\x65\x32\xe9\xeb\xbe\x67\x50\x36\x90\x6f\x34\xd5\x1a\xb5\x3b\x74\xbd\x49\x16\xcb\x80\xee\xfc\xe5\x36\x96\xb0\xfd\x87\x63\xd5\x71\x67\xc9\xeb\xcb\xe5\xe5\x46\x0f\x5e\x93\xcd\x53\xf7\x63\x03\xb7\xdf\x06\x76\x83\x0e\x25\x75\xc8\x40\x64\xf3\x0d\xfd\x60\x03\xc7\xaa\xe0\xe5\x0a\xd9\xfc\x9d\xec\x34\xc9\x6e\xa3\xdb\x27\xd0\xfd\xbb\x9a\x46\xeb\x67\x72\x78\x84\xb7\x37\x1d\xeb\x27\x6a\x77\x02\x77\x90\x8e\x0a\x48\x35\x25\x28\x07\xda\x76\x4b\xd7\x5e\x68\x7a\x11\xe9\x4e\xbb\x43\x77\x6a\xe4\x53\x0d\x2f\x6f\x38\xad\x13\xba\xb6\x41\x2a\xbf\xe1\xad\x5d\xba\xff\x0a\xaf\xd4\xdd\xb3\xb2\x31\x0b\xef\x8f\x7d\x0d\x1c\xcb\xa6\x1f\x6c\xb2\xd7\xec\x02\x06\xca\xeb\xc8\x34\xb5\x22\x52\xdd\x8f\x27\xee\x59\xd9\x97\xcf\xa3\x2c\x3b\x9d\xc8\x3e\x01\xdf\x32\x4f\x46\xbd\xb7\x34\xda\x5f\x12\x38\xf6\x3b\x81\xf1\x56\xbc\x57\x76\xe1\x69\xf7\xd9\x1c\xed\x3e\x9f\xd1\x48\x94\xe1\xa6\x58\x8e\x19\x67\x55\x55\x4b\x4d\xf4\xb2\xf6\x0a\xca\x31\xfc\x78\xc9\x7c\xf6\x40\x99\xf9\xea\x9b\x48\x24\xf2\xdf\x00\x55\x40\x5b\xc9\xda\x07\x00\x00")
 
 func sqlHostSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -896,12 +964,32 @@ func sqlHostSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/host.sql", size: 710, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/host.sql", size: 2010, mode: os.FileMode(420), modTime: time.Unix(1792368030, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlHosteventSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x91\xb1\x4e\xc2\x40\x1c\xc6\x77\x9e\xe2\x9f\x4e\x6d\xe2\x00\x44\x13\x82\x61\x38\xca\x21\x17\xcb\x61\x8e\xab\x91\xa9\x87\xb4\xc6\x26\xb6\x4d\xa0\x1a\x47\x67\x89\x89\x1b\x0b\xbe\x83\x83\x21\x4d\x19\x78\x99\x16\xe2\x5b\x98\xab\xad\xc5\x38\xc8\x74\xc9\xdd\xff\xf7\x7d\xf7\x7d\x7f\x9d\x61\xc4\x31\x70\xd4\x36\x30\x90\x2e\xd0\x01\x07\x7c\x45\x86\x7c\x08\x62\x32\x0d\x26\x81\xed\xde\x39\xd6\x6d\x30\x0b\x9d\x07\xc7\x0f\x05\xa8\x15\x00\x00\xe1\xda\x02\x08\xe5\x80\x4c\x3e\xb0\x08\xd5\x19\xee\x63\xca\x41\x1f\xf4\xb3\x53\x21\x1d\xe5\xe8\x7b\x52\xb2\x72\x5a\xef\x21\xa6\xd6\x1a\x5a\x66\x41\x4d\xc3\x80\x0e\xee\x22\xd3\xe0\xa0\x28\x25\x97\x44\xeb\xed\x32\x2e\xe9\xb1\x6d\x4f\x05\x5c\x22\x96\xe1\xf5\x93\x7f\xf0\x5e\x30\x0b\xd3\xe5\x7b\xfa\xf6\xb4\x6f\xef\x8f\x3d\xa7\x14\xa9\x55\xab\x07\x7d\x22\x7d\x7d\x29\x44\xf2\xec\x32\xf0\x1f\xb0\xba\xc7\xc5\xf3\x64\xbd\x82\x5a\x33\x89\x9e\x77\xf1\x06\xea\xcd\x24\x9a\xef\xe2\xcd\x2f\x99\xd0\xf5\x9c\x43\xa5\xb6\x8b\xd5\xe7\xe2\x23\xc7\xe1\x82\x91\x3e\x62\x23\x38\xc7\x23\x50\xe5\x06\xb4\xfc\x41\x5e\x08\xd7\x7e\xb4\x8a\xae\xd5\xa2\x75\xad\xa2\x61\x7a\x46\x28\x6e\x11\xdf\x0f\x3a\xed\x1f\x2b\xd9\xc4\x10\xf3\xd6\x7d\x78\xd3\xf0\xae\x8f\x4f\xbf\x06\x00\x7b\x69\xa1\xca\x08\x02\x00\x00")
+
+func sqlHosteventSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlHosteventSql,
+		"sql/hostevent.sql",
+	)
+}
+
+func sqlHosteventSql() (*asset, error) {
+	bytes, err := sqlHosteventSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/hostevent.sql", size: 520, mode: os.FileMode(420), modTime: time.Unix(1792361758, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _sqlHostgroupSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x48\x2e\xca\x4f\xce\x4f\xc9\xcc\x49\x8d\xcf\xc8\x2f\x2e\x49\x2f\xca\x2f\x2d\x48\x50\xd0\xe0\x52\x50\x50\x50\x48\xc8\x4c\x49\x50\x70\xf6\x70\x0c\xd2\x30\xb4\xd0\x04\x6b\xf2\x0b\xf5\xf1\x51\x70\xf6\xf7\xf5\x75\xf5\x0b\x51\x50\xf2\x74\x51\xd2\x81\x28\xcc\x4b\xcc\x4d\x4d\x50\x08\x73\x0c\x02\xab\x36\x36\x40\x52\xed\xe2\xea\xe6\x18\xea\x13\xa2\xa0\xa4\x84\xd0\xf8\x64\xc7\xee\x67\x73\x76\x3d\xdf\xdd\xf2\x74\x42\xef\xf3\xe5\x1b\x60\xa6\x14\xa5\xe6\x26\x16\x65\x23\xcc\x31\x34\x40\x36\x08\xab\x49\x4f\x97\xb4\x3f\xdb\xbc\x02\x66\x40\x72\x51\x6a\x62\x49\xaa\x53\xa5\xa7\x0b\x36\x77\x63\xd5\xdf\x31\xfb\xe9\xee\x5d\x4f\x76\xed\x42\xf8\x05\x14\x0c\x9e\x2e\xc5\x09\x0a\x21\xae\x11\x21\xe8\x6e\x46\x28\x83\xd8\x15\x92\x09\xf2\xb8\xa7\x5f\x08\xa6\x35\x06\xe8\xb6\x3c\x9b\xbe\xed\xe5\xf4\x2d\x30\xfd\xa5\x05\x29\x44\xeb\x7f\x36\x7b\xcb\xb3\x69\x1b\x50\xf4\x07\x04\x79\xfa\x3a\x06\x45\x2a\x78\xbb\x46\x2a\x68\x80\x22\x4a\x13\x22\x0e\xe2\x27\x64\xa6\x54\xc4\x43\xa2\x44\x03\x12\x35\x9a\x5c\x9a\xae\x7e\xee\x9e\x7e\xae\xb6\x9e\x79\x79\xf9\x2e\x4e\x70\x4b\x40\xa1\x14\xec\x1a\x62\x5b\x5a\x92\x66\x91\x9b\x64\x62\xcd\x05\x08\x00\x00\xff\xff\x65\xd7\x71\xb8\x2b\x02\x00\x00")
+var _sqlHostgroupSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x52\xdf\x6b\xd3\x50\x18\x7d\xef\x5f\xf1\xd1\xa7\x14\x7c\x48\xd5\x87\x81\xec\x21\x6d\xee\xf4\x62\x9b\x49\x7a\x27\xdb\xd3\x52\x9b\xa8\x55\xdb\x84\xdb\x56\xf4\xad\xc2\xc0\x1f\xb0\xb1\x07\x75\xb0\x96\x61\xa1\xe8\x14\x0c\x1b\x0e\x89\x37\x54\xff\x99\x7b\x6f\xd6\xff\x42\xb2\x90\xa5\xab\x05\xfb\x96\xe4\x3b\xe7\x7c\x27\xe7\x3b\x65\x13\x69\x04\x01\xd1\x4a\x15\x04\x78\x0d\x8c\x75\x02\x68\x13\xd7\x48\x0d\xac\x06\x75\x1b\xae\xdd\x7c\xe6\x6c\x3f\x76\x3b\xdd\x47\xd4\xed\x79\x16\x28\x39\x00\x00\xab\x69\x5b\x50\xbe\xa3\x99\x4a\x71\xa5\x70\x41\x32\x36\x2a\x15\x28\xaf\x57\xab\xc8\x20\x90\xc7\x7a\xfe\x5a\x02\x6c\xd7\x5b\x8e\x05\xf7\x35\xf3\x02\x7d\x43\x9d\x41\xeb\x68\x4d\xdb\xa8\x10\xc8\xe7\x33\x22\x0f\x42\x39\x64\x51\xb8\x23\xf6\x77\xa3\x2f\x27\xa9\x0a\x75\x5a\x75\xfa\x34\xd3\x29\xaa\xb3\x42\x0b\x95\xc4\xf8\xb5\xfc\x71\x9c\x0a\x34\xa8\x53\xef\x3a\xa5\x97\x58\x5f\xe4\x7b\x21\xff\xcd\x40\x84\x8c\x33\x96\xfd\x4b\x1c\x03\xd6\x3b\x16\x10\xb4\x49\xe6\x3d\x67\x30\x8f\xba\x4f\x9c\x46\x77\xf9\x55\xf2\x6d\x5f\x9c\x1e\x4d\x47\xbf\xa2\x81\x8f\x75\xe0\x01\x8b\xbe\x32\x1e\xec\x8a\xd3\x23\xce\xf6\x78\x18\xf2\xc9\x87\x64\x9a\xae\xa8\x7b\x1e\x75\x9f\x3b\x14\xdb\x9d\x2c\x94\xeb\xea\xff\xd2\x15\xfe\x48\x7e\x0a\x38\x63\x20\x87\x7d\x31\x3e\x3c\xf7\x7f\x47\x13\xbf\xa8\xf2\xe0\x1b\x24\xcf\x62\x7f\x8f\x4f\xfe\x44\xef\x8f\xe5\xf7\xf1\xe5\x2d\xa2\xc3\x1d\x1e\x86\xe2\xdd\x68\x3a\xec\x9f\x7f\x7e\x95\xa8\x5c\x4d\x96\x34\xe3\x33\x63\x83\xfc\x6b\x40\x9d\xcf\x54\x1e\xfc\x9c\x1e\x9c\xa5\xfc\x9e\x67\x2f\xcd\x97\x83\x33\xf9\xf1\xe4\x0a\xff\x9e\x89\xab\x9a\xb9\x05\x77\xd1\x16\x28\x71\x2d\x0b\xc9\xf7\xf8\xdd\x6a\xda\x2f\xb6\x93\x02\x2a\x49\x11\xe7\x87\x5e\xdc\x63\x65\xe6\x62\x85\x5c\x01\x19\xb7\xb1\x81\x56\x71\xbb\xed\xea\xa5\x4b\x17\x71\xc2\x35\x44\x56\x7b\xdd\x87\x2b\xad\x07\x37\x6f\xe5\xfe\x0e\x00\x2e\xa9\x78\x00\x3a\x03\x00\x00")
 
 func sqlHostgroupSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -916,12 +1004,12 @@ func sqlHostgroupSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/hostgroup.sql", size: 555, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/hostgroup.sql", size: 826, mode: os.FileMode(420), modTime: time.Unix(1792366098, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _sqlLogSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x93\xdf\x6b\xd3\x50\x14\xc7\xdf\xf7\x57\x1c\xf2\xd4\x42\x0b\x2b\xfa\x30\x26\x7b\x48\xdb\xbb\x79\xb1\x4d\x25\xbd\x95\xed\xa9\xa9\x4d\x2c\xc1\x36\x19\xc9\x2d\xe8\xdb\xf6\x20\xea\x36\x59\xd5\x62\x19\xe2\x66\xc1\x99\x07\x1f\x6a\x61\x08\x9a\x41\xff\x99\xdd\x24\xfd\x2f\x24\x4d\x9b\x1f\x5b\xc5\x3e\x05\xee\x39\xdf\x4f\xbe\xe7\x70\xbe\x05\x11\xf1\x04\x01\xe1\xf3\x25\x04\x78\x1b\x84\x0a\x01\xb4\x8b\xab\xa4\x0a\x52\xd3\xd0\x9b\xba\xac\xb6\x95\x7a\x5b\x6f\x49\x90\x5a\x03\x00\x90\x54\x59\x02\x2c\x10\xe0\x6b\xa4\x52\xc7\x42\x41\x44\x65\x24\x10\x28\x54\xca\xb3\x2f\x87\x8b\x5c\x26\xe8\xd4\x1a\x1d\x45\x82\x27\xbc\x58\x78\xc8\x8b\xa9\x7b\xeb\xe9\x19\x5d\xa8\x95\x4a\x50\x44\xdb\x7c\xad\x44\x80\xe3\x22\xe1\x8d\x6d\xb3\xa3\x21\xeb\xbd\x73\xad\x9f\x0b\x04\x6d\x98\xcf\xfd\x1f\xce\x08\xb9\x8d\x95\x08\x91\x01\x93\x36\x0c\x4a\x55\xdf\x45\x1e\xef\xf8\xa6\xef\xc8\xd7\x21\x92\xb3\xeb\x03\x66\x1d\x3b\x83\x5f\xd3\xc1\x95\x33\xfa\xe1\x5a\x1f\x16\x20\x45\x93\xff\x87\x09\x29\xae\xfd\xd1\xf9\x72\x11\x50\x20\x89\xa1\x3a\x6d\xb4\x8d\xae\x16\xb0\x12\xa0\x65\x24\xe7\xc0\x66\xaf\xc6\xde\xa4\xe7\x0d\x4f\x02\x5e\x6c\x30\xda\x35\xff\x8d\x88\x31\xde\x5a\xde\xf0\x24\xf0\x04\xb9\x4d\xe7\x4d\x8f\x1d\x5d\x40\x36\xb7\xc9\xbe\x8d\xbd\xab\xcb\xf8\x9e\x0d\xc5\xdc\x37\x25\x28\xa3\x22\xae\x95\x09\xda\x25\xb7\x17\xeb\x0c\x2e\xd9\x64\x10\x4a\x0c\xb5\xd5\x52\x0c\xfa\x72\x7f\x95\x59\x3c\xeb\x3b\x3b\x7d\xef\x7c\xfa\xcd\xae\x4f\xc3\xad\x1a\x46\x53\x97\x57\x51\x4f\xfb\x67\xde\x68\xe4\x4d\xfa\xec\xf3\xb9\xfb\xf5\x30\x06\xe8\x98\x2d\x09\x92\x66\x83\xe6\x9b\xc9\xd0\x39\x1c\x71\x99\x6c\x16\x38\xf6\xfa\xcf\xb4\x7f\xb6\x78\x09\xb5\xfe\xd0\x2b\xda\x9f\x13\x66\x6b\x70\xc7\x36\x3b\x3f\xbe\xc5\x59\x7e\xa4\xf1\x2b\x5d\x0e\x8b\x8e\x75\x0e\x9a\x63\x12\x69\x59\x7a\xec\x71\x4a\x22\x34\xf0\x58\xc4\x65\x5e\xdc\x83\x47\x68\x0f\x52\x7e\x5e\xd3\xf3\x82\xff\x20\xa9\xf2\x8b\x7a\x90\xcc\x54\x90\xd0\x3b\x55\xb3\x4e\xfd\x62\x94\x9e\xcc\x22\x87\xe9\xb5\x34\x12\x76\xb0\x80\xb6\xb0\xa6\xe9\xc5\x7c\x68\xcc\xf7\x5c\x45\x64\xab\x4b\x9f\x6d\x74\x9e\xde\x7f\xb0\xf6\x37\x00\x00\xff\xff\x8c\x78\x1c\x95\x58\x04\x00\x00")
+var _sqlLogSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x94\x5f\x6f\xd2\x50\x18\xc6\xef\xf9\x14\x6f\x7a\x05\x09\x24\x43\xbd\x58\x66\x76\x51\xe0\x6c\x9e\x08\xc5\x94\x83\xd9\xae\x28\x42\x25\x44\x68\x97\xb6\x24\x7a\xb7\x5d\x18\x75\x9b\x19\x3a\x22\x59\x8c\x9b\x24\x4e\x12\xbd\x40\x92\xc5\x7f\x4c\xf9\x32\x3b\xe5\xf0\x2d\x4c\x39\xd0\x96\x3f\x06\xae\x9a\x9c\xf3\x3e\xbf\x3c\xef\xdb\xf7\x39\x71\x19\x89\x04\x01\x11\x63\x49\x04\x78\x0b\xa4\x34\x01\xb4\x83\x33\x24\x03\x4a\xc1\xd0\x0b\x7a\xb1\x5c\x51\x73\x15\xbd\xa4\x40\x30\x00\x00\xa0\x94\x8b\x0a\x60\x89\x80\x98\x25\xe9\x1c\x96\xe2\x32\x4a\x21\x89\x40\x3c\x9d\x1a\x7d\x05\x9c\x10\xc2\xbc\xd2\xa8\x69\x4e\x71\xfc\x9e\x28\x07\xa3\xeb\xa1\x11\x5b\xca\x26\x93\x90\x40\x5b\x62\x36\x49\x40\x10\x3c\x19\xeb\xd7\x59\xeb\xd8\x13\x5b\x46\xbe\xa0\xba\xf2\xdb\xb7\x96\xc8\x87\xa7\x7f\xd9\x8f\x0e\xeb\xff\x61\x3f\xbf\x78\x10\x2d\x5f\x55\x15\x78\x28\xca\x1c\xb2\xb6\x04\x72\xd3\xeb\xd1\xc3\x16\xad\xbf\x1e\xb4\xbf\xb9\x3e\xf2\xe6\x93\xd5\xbb\xe0\x04\xcf\x80\x69\xe5\x0d\xcb\x2a\x3b\x2e\x62\x78\xdb\x19\xdb\x9c\x7c\x0d\x3c\x39\xbd\xde\xa7\xed\x23\xbb\xf9\x7d\xd8\xbc\xb2\x3b\x5f\x07\xed\xb7\x13\x90\xaa\x15\x97\x61\x5c\xca\xa0\x77\x6a\x7f\xb8\xe0\x14\x98\xc6\x58\xba\x95\xaf\x18\x35\x8d\xb3\xa6\x40\x8b\x48\xf6\x7e\x8f\x3e\xef\xf2\x5f\xc3\x79\xbe\xc6\xac\x9a\xf9\x7f\x84\x8f\xf1\xaa\xcd\x5a\xc7\xdc\x13\x44\x37\xec\x97\x75\x7a\x78\x01\x91\xe8\x06\xfd\xd4\x65\x57\x97\xfe\x39\x1b\xaa\xb9\x67\x2a\x90\x42\x09\x9c\x4d\x11\xb4\x43\x66\x07\x6b\x37\x2f\x69\xbf\xe9\xad\x48\xb9\x54\x52\x0d\xeb\xd9\xde\x2a\xbd\xb0\xf6\x67\x7a\xf2\xc6\x7e\xf7\x8b\x5e\x9f\xb8\x53\x35\x8c\x82\x5e\x5c\x45\x3d\x6c\x9c\xb1\x4e\x87\xf5\x1b\xf4\xfd\xf9\xe0\xe3\x81\x0f\x50\x35\x4b\x0a\x4c\x9b\xe5\xc5\x37\xfd\x96\x7d\xd0\x11\xc2\x91\x08\x08\xf4\xc5\xef\x61\xe3\x6c\x72\xe2\x6a\x9d\xa6\x57\xb4\x3f\x26\x8c\xc6\x30\xe8\xf6\xe8\xf9\xd1\x0c\x67\xf1\x92\xfa\xb7\x74\x31\x0c\x27\x66\x40\x0a\xcc\xa7\x65\xe1\xb2\xfb\x29\x53\xa1\x81\x07\x32\x4e\x89\xf2\x2e\xdc\x47\xbb\x10\x74\x5e\x8c\xd0\xf8\xc2\x39\x50\xca\xc5\xa7\x39\x9e\xcc\x20\x4f\xe8\xdc\xad\x99\xb3\x9c\x4b\x2f\x3d\xe1\x49\x0e\xe7\x4a\xc7\x8f\x4c\x70\xfc\xda\x84\x02\x21\x24\x6d\x63\x09\x6d\x62\x4d\xd3\x13\x31\xd7\xb8\xd3\x53\x06\x91\xcd\x9a\xf5\x78\xbd\xfa\xe8\xce\xdd\xc0\xbf\x01\x00\x4f\xab\x77\xb5\xfa\x04\x00\x00")
 
 func sqlLogSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -936,7 +1024,7 @@ func sqlLogSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/log.sql", size: 1112, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/log.sql", size: 1274, mode: os.FileMode(420), modTime: time.Unix(1792360485, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -961,7 +1049,7 @@ func sqlNotifySql() (*asset, error) {
 	return a, nil
 }
 
-var _sqlOperateSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x93\x5d\x6b\xd3\x50\x1c\xc6\xef\xf7\x29\xfe\xe4\xaa\x81\x0d\x3a\xdf\x18\xc8\x2e\xb2\xf6\x6c\x1e\x6c\x52\x49\x4f\x65\xbb\x6a\x66\x13\x31\xd0\xe4\x8c\x34\x05\x2f\x45\x76\x31\x5c\xbb\x16\x56\x6d\x99\x53\xe8\x85\x3a\x44\x6b\x85\xfa\x32\xab\xf6\xcb\xe4\x9c\xc4\x2b\xbf\x82\x24\x29\x26\xae\x5e\x74\xb9\x48\xe0\xe4\x7f\x7e\xe7\x79\x9e\x3c\xc9\xa9\x48\x22\x08\x88\xb4\x51\x40\x80\x37\x41\x29\x12\x40\xdb\xb8\x44\x4a\xa0\x55\x1d\x5a\xa5\xba\x59\x33\x2a\x74\xcf\x70\x76\x5d\x43\x83\xcc\x12\xcc\x2e\xcd\xd4\x35\xc0\x0a\x01\x90\xca\xa4\x58\xc1\x4a\x4e\x45\x32\x52\x08\xe4\x8a\x72\xf4\x14\x70\x5e\x58\x4e\xc6\x1b\xe1\x7c\xee\x96\xa4\x66\x56\xd7\xc4\xe8\x18\xa5\x5c\x28\x40\x1e\x6d\x4a\xe5\x02\x01\x41\x48\x36\xf2\xe3\x96\xf7\xe3\xd4\xef\x9e\xf1\x83\x2f\x17\x20\x75\xc3\xb1\x77\x2d\x43\x83\xbb\x92\x1a\xc1\xae\x67\x2f\x01\x63\x9d\x56\x9a\xe6\xd0\x9a\x11\x7b\x98\x23\x64\xff\x0f\xf0\x3f\x4e\xd8\xcb\xc3\x34\xc3\x32\xdc\x07\x54\x4f\xf4\xdc\x58\x4c\xce\x3c\x87\xea\x8d\x5a\xca\xd7\xea\x62\xbe\xf8\xd9\x80\xbd\xe8\xcd\x83\xfe\x0d\xe9\xea\x65\x60\xac\xd3\xf2\xdf\x8c\xc0\xfb\x79\xc8\x5e\x3f\xf6\x26\x13\xf6\x64\x10\x2f\xa5\x4f\x99\x15\xc2\x35\xad\x38\x41\xb4\x85\xd4\x05\x52\xe4\xbd\xcf\xbf\x7a\xe3\x34\x48\x37\xea\x55\xc7\xdc\x73\x4d\x6a\x27\x7a\xaf\x64\xb3\xe2\xc5\xad\xc1\x87\x31\xef\x1f\xfd\xfe\xde\xf4\xbe\x3e\x0a\x0e\xde\xcd\xbe\x47\x74\x9f\xb1\x4f\xdf\xfa\xcf\xc7\xfc\xe8\x15\xef\x9e\xb3\x76\x9f\x3f\x1d\xf1\xd6\x90\x7d\x3b\x66\xed\x3e\x6b\x3e\xf3\x4f\xf6\xe3\xb1\x90\x10\x59\x0b\xa6\x9d\x60\xd0\x8c\x0d\x0a\xcb\x2b\x2b\x20\xf0\x76\x3b\x98\x8e\x84\x44\x5c\x95\xd6\x1a\x96\x5d\xd7\x40\x46\x79\x5c\x96\x09\xda\x4e\xd5\xdb\x9b\x0e\x79\xf7\xdc\x3f\xd9\x67\xef\x7b\x7c\xf8\x29\x65\x0a\xee\xa8\x58\x96\xd4\x1d\xb8\x8d\x76\x20\x13\xfe\x28\x62\xea\x65\xb8\xa8\x99\xfa\xc3\x4a\x52\xe6\x4c\x52\x6c\x71\x49\x44\xca\x16\x56\xd0\x3a\xb6\x6d\x9a\xdf\xf8\x9b\x65\x18\x4c\x09\x91\xf5\x86\x7b\x7f\xcd\xba\x77\xed\xe6\x9f\x00\x00\x00\xff\xff\xd7\x1b\x17\xfa\xb9\x03\x00\x00")
+var _sqlOperateSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\x93\x5d\x6f\xd2\x50\x18\xc7\xef\xf7\x29\x9e\xf4\x0a\x92\x2d\x61\xbe\x65\x89\xd9\x45\x07\x67\xf3\x44\xe8\x96\x72\x30\xdb\x15\x45\x5a\x63\x23\xf4\x10\x28\x89\x97\xc6\x70\x31\x1d\x0c\x92\xa1\x10\x9c\x26\x24\xbe\x10\xa3\x88\x09\xbe\x4c\x9c\x7c\x99\x9e\xd3\x7a\xe5\x57\x30\x6d\xc9\x5a\x87\x17\xd0\x8b\x36\x79\xfa\x9c\xdf\xf9\xff\x9f\x97\xb8\x8c\x44\x82\x80\x88\x5b\x49\x04\x78\x1b\xa4\x5d\x02\x68\x1f\xa7\x49\x1a\x94\x7c\x99\xe6\xa9\xaa\x17\xb4\x2c\x2d\x69\xe5\x9c\xa9\x29\x10\x59\x81\xd9\xa3\xe8\xaa\x02\x58\x22\x00\x62\x86\xec\x66\xb1\x14\x97\x51\x0a\x49\x04\xe2\xbb\x29\xef\x2b\xe0\x84\xb0\x1a\xa4\x57\xdd\xfc\xf8\x2d\x51\x8e\xac\x6f\x44\xbd\x6b\xa4\x4c\x32\x09\x09\xb4\x2d\x66\x92\x04\x04\x21\x38\xc8\x4f\x1a\xd6\xf9\xa9\xdd\x1e\xf0\xc3\x6f\x97\x20\x15\xad\x6c\xe4\x8a\x9a\x02\x77\x44\xd9\x83\x5d\x8f\x2d\x01\x63\xad\x46\x98\x56\xa6\x05\xcd\xf7\x30\x47\x88\xfd\x1f\x60\x7f\x9e\xb0\x57\x47\x61\x46\x51\x33\xef\x53\x35\xd0\x73\x63\x31\x39\xf3\x1c\xaa\x56\x0b\x21\x5f\xeb\x8b\xf9\xe2\x83\x3e\x7b\xd9\x99\x07\xfd\x5b\xa4\xab\xcb\xc0\x58\xab\x61\xbf\x1b\x81\xf5\xeb\x88\xbd\x7d\x6c\x4d\x26\xec\x69\xdf\x0f\x85\x6f\x99\x0d\x84\xa9\x17\xfd\x0a\xa2\x1d\x24\x2f\x50\x45\xde\xf9\xfa\xbb\x33\x0e\x83\x54\xad\x92\x2f\xeb\x25\x53\xa7\x46\xa0\xf7\x4a\x2c\x16\xbd\x7c\xd4\xf9\x34\xe6\xdd\xe3\x3f\x3f\xeb\xd6\xf7\x47\xce\xe1\x87\x59\x3f\xbc\xf7\x8c\x7d\xfa\xde\x7e\x31\xe6\xc7\x6f\x78\xfb\x8c\x35\xbb\xfc\xd9\x88\x37\x86\xec\xc7\x09\x6b\x76\x59\xfd\xb9\xdd\xab\xf9\x69\x2e\xc1\xb3\xe6\x4c\x5b\x4e\xbf\xee\x1b\x14\x56\xd7\xd6\x40\xe0\xcd\xa6\x33\x1d\x09\x81\xb8\x3c\x2d\x54\x8b\x46\x45\x81\x14\x4a\xe0\x4c\x8a\xa0\xfd\xd0\x78\x5b\xd3\x21\x6f\x9f\xd9\xbd\x1a\xfb\xd8\xe1\xc3\x2f\x61\x53\xb9\x92\x6e\xd2\x07\x9a\xb1\x44\x07\xac\xf3\xa9\xdd\x1e\x88\x7b\xd8\x9a\xbc\xb6\x9f\xd4\x2f\xca\x65\xf7\x6a\x7e\x64\xae\x07\xb0\x27\xe3\x94\x28\x1f\xc0\x6d\x74\x00\x11\x77\x1d\xa3\xa1\x9f\x6e\x50\xd1\xd5\x87\xd9\x60\x65\x22\xc1\xfa\x44\x57\xa2\x48\xda\xc1\x12\xda\xc4\x86\x41\x13\x5b\x17\x92\x5c\xb1\x69\x44\x36\xab\xe6\xbd\x8d\xe2\xdd\x6b\x37\xff\x0e\x00\x67\x3b\x0b\xfd\x1f\x04\x00\x00")
 
 func sqlOperateSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -976,12 +1064,52 @@ func sqlOperateSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/operate.sql", size: 953, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/operate.sql", size: 1055, mode: os.FileMode(420), modTime: time.Unix(1792363871, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _sqlTaskSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x55\x5f\x4f\x1a\x59\x14\x7f\x96\x4f\x71\xe2\x13\x24\x92\x0c\xd8\x64\x0d\x9b\x3e\x20\x4c\xdb\x49\xf9\x63\x70\xdc\xad\x4f\x9d\x11\x6e\xd7\x89\x30\x43\x86\x99\x44\xdf\x74\xbb\xad\x84\x95\xc8\x76\x75\x69\x2a\x5b\x97\x44\x94\x34\x2b\x6a\x34\x14\xc1\xea\x87\xe9\xdc\x3b\xe3\x53\xbf\xc2\x66\x66\x10\x67\x58\xac\xed\xcb\x05\x2e\xe7\xfc\xce\xef\xfc\xf9\x9d\xeb\xf7\x83\xd6\xeb\xe1\x52\x1d\x57\xca\x30\x49\x79\xfc\x7e\xc0\x87\x15\xfb\x0a\xf4\x62\xbb\xff\x8d\xd4\x56\xf1\xde\xbb\x20\xa5\x75\x3e\x98\x26\xa4\xd4\x30\x0e\xf7\xf5\xad\x26\x29\x7e\x24\xdb\xc7\xd7\xeb\x9b\x5f\x2e\x36\x6c\x1b\xbc\x79\xa4\xf5\x1a\x46\xeb\x52\xff\xd4\x0a\xdc\xd8\xe3\xbd\x75\x72\xda\xec\xa3\x04\x28\x0a\x1f\x56\x8d\xd2\xaf\x9e\x48\x8a\x0e\xb3\x34\xb0\xe1\xe9\x18\x0d\xcc\x23\x48\x24\x59\xa0\x9f\x31\xb3\xec\x2c\x70\x69\x59\x4a\x4b\x19\x21\x8b\x9e\x2b\x7c\x61\x89\x03\xaf\x67\x8c\x13\x32\x1c\x44\x9e\x84\x53\xde\xc0\x94\xcf\x32\x4e\xcc\xc5\x62\x10\x49\xc6\xe3\x74\x82\x85\x71\x26\x3a\x3e\xe1\x19\xe3\x44\x3e\x87\x38\xf8\x29\x9c\x32\x4d\xc1\x3b\x49\x8d\xb2\x1d\x24\xad\x1f\x1c\x5b\x5e\x66\x14\x76\x25\x8f\x38\x60\x12\xec\xad\x43\x94\x7e\x14\x9e\x8b\xb1\x40\x0d\xbb\xea\x27\x3d\xfc\xfe\x77\x08\x84\x22\x52\x06\x41\x30\xf4\x84\x65\x67\xc6\xe1\x06\x29\xca\x2b\x3c\x07\x71\x3a\xca\xcc\xc5\x59\xfa\x19\x3b\xec\x4d\xb6\x8f\x49\xb9\x65\x05\x96\x55\x91\x83\xe9\x64\x32\xf6\xff\xa0\x8a\xac\xa2\x5b\x4f\xf2\xf6\x08\x57\xf6\x8d\xf5\x0f\xb8\xd4\x34\x8e\x5f\xe2\xee\xbe\x71\x55\x31\xea\x1b\x16\x4a\x9e\x97\x91\xa8\xb0\x7c\x61\x89\xc9\x14\x9c\xd9\x4f\x51\xbe\x5b\x88\x41\x43\x99\xe8\xa0\x61\x56\x53\x1d\x18\x29\x55\x9c\xe1\x65\x3e\x9b\x45\xd9\xbb\x78\xbd\xe0\xb3\x05\x34\x02\xd5\x66\x88\xcf\xdb\x46\x7d\xc3\xc1\x2d\xbd\x28\x64\x33\xf7\x51\x1b\x4c\xdd\x48\x6a\x16\xc4\x57\x98\x0d\x51\x1b\x01\x7b\x17\x37\x19\xf1\x0a\x9a\x5e\x61\xa2\xf6\x68\x81\x7b\xb6\x06\xb8\xe3\xe3\x0e\xcc\xe2\x0e\xee\x75\xb5\x6e\xb7\x3f\x70\x8b\x52\x41\x79\x2c\x4b\x6a\xfe\x7b\x40\xb4\x4e\x8f\xd4\xba\x7a\xef\xb7\x3e\x48\x5a\x96\x44\x7a\x39\x2f\x3b\x0a\x14\xa0\x28\xea\x5e\x2e\xad\x77\xa4\xda\x2f\xbf\x51\x6f\x1a\x97\x97\xf8\x62\x73\x02\xbf\x3a\xf9\x41\xfb\x54\x06\xfd\xe0\xcd\xe7\xd5\x35\x5c\x7c\xfd\x79\x75\x8d\x54\xdb\xd6\xd9\x30\xcf\x5a\xd1\xbc\xff\xa3\x69\x9e\xe7\x67\xb6\x00\x84\x1c\x92\x54\xe5\x8e\xf9\xf7\x07\x86\x47\xd8\x68\xbf\x22\xd5\x36\xa9\xb6\xaf\xab\x67\x5f\x2e\x36\xae\x7b\x6f\x8d\xd6\x9e\x3f\x80\xcb\xa7\x5a\xa7\x6c\xeb\xdf\x69\x62\xc5\xe0\xb3\xbc\x9c\x9b\x2b\x20\xd9\x3d\x09\x41\x57\x9e\xa3\xd2\x74\x2e\x9c\xfe\x1a\x71\xac\x18\x5b\x47\x92\xaa\xa0\x19\x29\x2b\xa4\x57\xee\xd7\xb0\xf1\xf1\x48\xdf\x3a\xd1\x0f\xff\xd2\xb7\x1b\x10\x08\xa5\x78\x31\x23\xe5\x20\x18\x4a\x49\xaa\x98\x49\x49\x0b\x82\x08\x93\xa1\x9f\x91\xf0\xcb\xa2\x02\x0f\x42\x31\xc4\x17\x2c\x6d\x59\x81\xd0\x72\x1e\xa5\x15\x53\xf6\x43\x71\x46\x05\x22\xb5\x5d\x52\xdb\x31\xae\xb6\xf0\xce\x7b\xfd\x9f\x35\x88\x24\xa3\xb4\x5d\x2a\xad\xd3\xa5\xc0\xdc\x1a\x83\x9f\x41\x8a\x72\x05\x10\x15\x24\x2a\x1c\xb8\xf7\x87\x13\xf0\xfa\x65\x13\x17\x5f\xdf\x56\x76\x56\xe1\x15\xb5\xf0\x2d\xac\xec\x72\xde\xa4\xdf\x6f\xa8\xa5\x0a\xbd\xf7\x27\xf9\x7b\x17\x82\xae\x4b\xbc\x77\x62\x9c\x35\x60\xd2\x75\x49\x8a\x15\x5c\xda\xb5\x6b\x8f\x72\xbc\xbc\xe4\x1e\xdd\x7b\x3a\x6a\x3f\x09\x0e\x0d\xb2\x42\xee\x9b\x97\xaf\xad\x40\x7b\xb0\xc0\xfe\x20\xc5\x53\xaf\x7e\xf0\xc6\x67\x21\xaa\xf9\xcc\x77\x22\x6a\x9d\x12\xf9\xb7\xae\x5d\xb5\xc8\xd6\xf9\x9d\xb8\x33\x29\x26\x1e\x4e\xcd\xc3\x53\x7a\xde\x6b\x3e\x46\xbe\x09\xcf\xd8\x53\x7a\x1e\x38\x21\xb3\xfc\xdc\x7a\x76\xbc\xf6\xeb\xe3\xfa\x23\xbd\x20\x70\xe0\x75\x6e\x1a\x9f\xc7\x07\x74\xe2\x31\x93\xa0\x1f\x32\xa2\x28\x45\xa7\x07\xbc\xcc\xea\xcd\xd2\xec\x43\x55\x79\x31\x95\x5b\x78\xf0\xe3\x7f\x01\x00\x00\xff\xff\xb6\xf4\xbe\xa6\x9f\x07\x00\x00")
+var _sqlProjectSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\xd0\xb1\x4a\xc3\x40\x1c\x06\xf0\xbd\x4f\xf1\x27\x53\x02\x0e\x2d\x3a\x14\xa4\xc3\xb5\xb9\xea\x61\x7a\xd5\xe4\x22\x76\xea\xc5\xe4\x84\xa8\x49\x4a\x48\x41\x9f\x40\x17\xc1\xc9\x0e\x99\x04\x41\x27\x29\x58\x04\xe3\xeb\x98\x94\xbe\x85\x24\x21\xd6\x6a\x41\xd7\x3f\xdf\xef\x4b\xee\xeb\xe8\x18\x31\x0c\x0c\xb5\x35\x0c\xa4\x0b\xb4\xcf\x00\x1f\x11\x83\x19\xc0\xed\x30\xb0\x03\xc7\x3d\x17\xc3\x51\x18\x9c\x0a\x3b\xe2\x20\xd7\x00\x00\xb8\xeb\x70\xe8\xec\x22\x5d\x6e\x34\x95\x82\x50\x53\xd3\xa0\xd3\xef\xf5\x30\x65\x20\x11\x55\xda\x28\x83\xbe\xe5\x09\x0e\x87\x48\x2f\xd2\x9b\xf5\x6f\x69\x15\x77\x91\xa9\x31\x90\xa4\x25\x5c\xdc\xbf\xcd\xe3\xe7\xf4\xf6\x66\xfe\x38\xad\x2a\x42\xe1\x59\xe1\xd9\xb2\xa4\x51\xff\xab\x25\x7d\xb8\xca\x5e\x9e\x2a\x6f\x87\xc2\x8a\x44\xfb\x92\xa8\xeb\xfe\x79\xad\xbf\x8e\xd3\xf7\xe4\x23\x49\x88\xba\x5a\xc2\xdc\xfc\x35\x84\xb2\xdf\xbe\xfe\x93\x67\x93\xd7\xc5\x64\x56\xf9\xf1\xc8\xf9\xb7\xcf\xe2\x59\x76\x37\x5d\xf1\xfb\x3a\xe9\x21\x7d\x00\x7b\x78\x00\x72\xbe\xbe\x52\xde\x4d\x4a\x0e\x4c\x5c\x9c\xb9\xeb\x5c\x0c\xcb\xb9\xe5\x72\x76\xa5\xa6\x60\xba\x43\x28\x6e\x11\xdf\x0f\xd4\xf6\xd7\xb7\xf2\x15\x0c\xcc\x5a\xe3\xe8\xa4\xe9\x1d\x6f\x6d\xd7\x3e\x07\x00\x6d\x39\x75\x55\x05\x02\x00\x00")
+
+func sqlProjectSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlProjectSql,
+		"sql/project.sql",
+	)
+}
+
+func sqlProjectSql() (*asset, error) {
+	bytes, err := sqlProjectSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/project.sql", size: 517, mode: os.FileMode(420), modTime: time.Unix(1792364982, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlProjectmemberSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\x90\xcd\x4a\xf3\x40\x14\x86\xf7\xb9\x8a\x43\x57\x09\x74\xf1\xe5\xb3\x8b\x12\xe9\x22\x4d\xa6\x3a\x98\x4c\x24\x99\x8a\x5d\x75\xda\x64\x84\x91\x26\x53\x86\x94\x7a\x03\x82\xba\x14\xa1\x0b\x37\xba\x10\x5d\xb9\x10\x51\xbc\xa0\xfe\xdd\x85\xc4\x54\x23\x82\xee\x86\x73\x9e\x77\x1e\xce\xeb\x84\xc8\xa6\x08\xa8\xdd\xf6\x10\xe0\x0e\x90\x80\x02\x3a\xc4\x11\x8d\x80\xc5\x4a\xc6\x32\x11\x23\xde\x1f\x2b\x79\xcc\xe3\x3c\xe5\xe9\x90\x2b\x06\xba\x06\x00\xc0\x36\x53\xec\x32\x70\x76\xed\x50\x37\x9b\xc6\x47\x9e\x74\x3d\x0f\x9c\xc0\xf7\x11\xa1\x50\x5b\xdf\xbe\x2d\xaf\x1f\xb1\x5b\xab\x97\xa9\x89\x48\xfe\xe4\x97\x57\x0f\x8b\xb3\xd7\x8a\x57\x72\xc4\x19\x60\x42\x2b\xd6\x45\x1d\xbb\xeb\x51\x30\x7f\x5a\x56\xf7\x97\xab\xf3\x27\x30\xad\x03\xc1\xa7\x5c\xc1\x7f\x2b\x18\x73\x35\xc8\xa5\x82\x2d\x0b\x25\xa2\x78\x34\xac\x60\x9a\x71\xf5\xf9\x7d\xac\xf8\x20\xe7\x54\xa4\xbf\x49\xfe\x55\x92\xf9\xc5\xcd\xfc\xf4\x6e\x31\x7b\x59\xcf\x9e\x37\xf9\xfd\x10\xfb\x76\xd8\x83\x3d\xd4\x03\xfd\x5b\x23\xf5\xf2\x50\xa3\xa4\x8a\x2d\x13\xc9\x49\xbf\x98\x81\x5e\xae\x34\x03\x91\x1d\x4c\x50\x0b\x67\x99\x74\xdb\x5f\xc2\xa2\x9b\x08\xd1\xd6\x24\x3f\x6a\xa6\xc3\xc6\xb6\xf6\x3e\x00\xa8\xd9\x7a\xab\xa4\x01\x00\x00")
+
+func sqlProjectmemberSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlProjectmemberSql,
+		"sql/projectmember.sql",
+	)
+}
+
+func sqlProjectmemberSql() (*asset, error) {
+	bytes, err := sqlProjectmemberSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/projectmember.sql", size: 420, mode: os.FileMode(420), modTime: time.Unix(1792364982, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlTaskSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\x56\xdf\x53\xd3\x58\x1b\xbe\x96\xbf\xe2\x0c\x57\x65\xc6\xce\xa4\xc5\xef\xd3\xe9\x37\x5e\x14\x1a\xb5\x9f\xa5\xb0\x6d\xd8\xd5\x2b\x1b\x9a\xe3\x92\x25\x4d\x3a\xf9\xb1\x2b\x77\x45\x45\x0a\x52\xed\xb2\xd4\x2a\x74\xd1\xce\xf0\xa3\x3a\xb4\x96\xd1\xa9\xa5\x11\xf8\x63\xcc\x39\x49\xaf\xfc\x17\x76\x92\x53\x4a\x8a\x65\x81\x9b\xfc\x38\x79\xcf\xf3\x3c\x79\xcf\xfb\x3e\xe7\x78\xbd\xc0\xd0\x75\xb4\x54\x46\xf9\x1c\x18\xa6\x06\xbc\x5e\x80\xaa\x79\x32\x04\xcc\x6c\xa3\xf3\x84\x4b\x19\xb4\xb9\xe6\xa7\x8c\xe6\x07\x3b\x04\x2f\x6d\x59\xd5\x6d\x73\xb5\x82\xb3\x5f\x70\xa1\xde\x5e\x78\xf9\xfd\xeb\x32\x89\x41\x2f\x3f\x1a\xfa\x96\x55\x3b\x34\x0f\x6a\xbe\xe3\x78\xb4\xb9\x80\x3f\x55\x3a\x28\x3e\x8a\x42\xd5\xa2\xb5\xf4\x78\x60\x34\x46\x07\x19\x1a\x30\xc1\x91\x08\x0d\xc2\xb7\x40\x74\x9c\x01\xf4\xbd\x70\x9c\x89\x83\x44\x52\x96\x92\x12\xc7\x0b\xf0\x81\xca\x2a\x33\x09\xe0\x19\xb8\x92\xe0\xb9\x04\x18\xbd\x13\x8c\x79\x7c\x37\x86\x9c\xe0\xe8\x64\x24\x02\x46\xc7\xc7\xc6\xe8\x28\x03\x06\xc3\xa1\xc1\xab\x03\x57\x12\x22\x9b\x82\x09\xf0\x73\x30\x66\x87\x02\xcf\x30\xd5\x2f\xb6\xfb\xd3\xe6\x4e\xdd\x99\x65\xb3\x30\xb3\x69\x98\x00\xe1\x28\x73\x32\x21\x44\xdf\x0a\x4e\x46\x18\x40\x9d\x9e\x6a\xee\xe9\x68\xe3\x39\xf0\x05\x46\x25\x0e\x02\x7f\xe0\x0e\xc3\x4c\x0c\x82\x63\xa4\x10\xab\xb2\x09\x30\x46\x87\xc2\x93\x63\x0c\x7d\x8f\x39\x3d\x1b\x17\xea\x38\x57\x73\x88\x65\x4d\x4c\x80\x91\xf1\xf1\xc8\x8f\xa4\xaa\xac\xc1\x93\x99\xf8\xf5\x47\x94\xdf\xb6\x16\x3e\xa0\xa5\x8a\x55\x7f\x82\x5a\xdb\xd6\x51\xde\x2a\x2f\x3b\x28\x69\x56\x86\xa2\xca\xb0\xca\x4c\x98\x53\xdc\x7f\x7f\x83\x1a\x3a\x81\xe8\x2e\x68\x38\xd4\x5d\x30\x67\x51\x5d\x18\x31\x4d\x9c\x60\x65\x56\x10\xa0\x70\x96\xae\x87\xac\xa0\xc0\x3e\xa8\x44\x21\xda\x6f\x58\xe5\x65\x97\xb6\xe4\x34\x2f\x70\xe7\x49\xeb\x56\x5d\x5f\x69\x0e\xc4\xbf\x28\x3b\x25\xad\x0f\xec\x59\xda\x64\xc8\xaa\x70\x64\x36\x1c\x22\xa5\x05\x7a\x6b\xab\x8b\x3b\x38\xe8\xc2\xcc\xae\x23\xbd\x65\xb4\x5a\x9d\x82\x9b\x96\x14\xf5\xb6\x2c\x69\xe9\xcb\x80\x18\x4d\x1d\x97\x5a\xa6\xfe\xb4\x03\x92\x96\xa5\xdf\x60\x52\xbd\x0c\x04\x5e\xcc\xa0\xbd\x8d\x76\x79\xdf\x5c\xaf\x85\x43\xc0\x68\xb6\xcc\xf7\x2d\xa3\x99\x43\x7b\x1b\x46\xeb\x85\xa1\xeb\xc6\x41\x81\x7c\x75\x18\x04\x76\x0a\x0a\x71\x28\xc0\xa4\x2a\xc9\xae\x75\xf0\x53\x94\xbb\x45\xfa\x32\xbd\x5b\x30\xab\x87\xed\xcc\x22\x7e\xfe\x1e\xbd\xa9\x80\xff\xc7\xc7\xa3\x9d\xf4\x49\x22\xfd\x28\xed\x86\xf3\xf5\xc2\xf5\xcf\x60\x6d\x0d\x17\x3b\x45\x63\x95\x2b\xd6\xe1\x21\xfa\xfa\xf2\x2a\x9a\xdf\xbb\x6e\x1c\xe4\x80\xb9\xb3\xf2\x2d\x33\x87\xb2\xcf\xbe\x65\xe6\x70\xb1\xe1\x5c\xb7\xec\x6b\x29\x6b\x8f\xff\x59\xb1\xaf\xfb\x9f\x49\xdb\xf2\x29\x28\x69\xea\x19\x5d\xeb\xf5\x9d\x6e\x3c\xab\x31\x8f\x8b\x0d\x5c\x6c\xb4\x8b\x9f\xbf\x7f\x5d\x6e\xeb\xaf\xad\xda\xa6\xd7\x87\x72\x9f\x8c\x66\x8e\xb8\x96\x3b\xc4\xe1\x60\x05\x56\x4e\x4d\x2a\x50\xee\xad\x5f\xff\xf9\x69\x73\xd9\x64\xc7\xfc\x5c\xc6\x48\xb0\xd3\x69\x59\xfa\xfd\xf2\xd0\xa8\x56\xc6\xef\x9a\x46\xab\xf5\x23\x2e\x20\xcf\x28\xff\xc2\x38\xaa\xe1\xd5\x7d\xb4\xb2\x8c\x17\x9f\xdb\xa6\xe1\x94\x3d\xc9\x43\xbb\x94\xb1\xb6\xe7\x08\x0a\xb1\x21\x49\x53\xe1\x84\x24\xf0\xc9\xd9\xf3\x2d\xd0\xfa\xf2\xd1\x5c\xdd\x33\xab\xaf\xcc\xc2\x16\xf0\x05\x62\xac\xc8\x49\x29\xe0\x0f\xc4\x24\x4d\xe4\x62\xd2\x14\x2f\x82\xe1\xc0\x2f\x90\xff\x75\x5a\x05\xd7\x02\x11\xc8\x2a\x8e\x35\x81\xff\x90\xe7\x88\xc4\x72\xe0\xbf\x81\x51\x49\x54\x78\x45\x85\xa2\x7a\x87\x55\xa6\xc1\xf5\xc0\x88\x2c\xb1\x5c\x92\x55\x54\x47\xd1\xd4\xf1\x5b\x5c\x4b\x26\xa1\xa2\x9c\x2f\x0b\xed\x1f\xe1\x95\x6a\xa7\xdf\xb3\x79\xb4\xf4\xb6\x2b\x31\x28\x08\xc0\x1f\x08\x8a\xb3\x60\x38\xf0\x93\x26\xc9\x5a\xaa\x97\xe3\xc4\x58\x2e\x43\x82\xf2\xcb\xb8\xd8\x20\x79\x35\xd7\x9e\x92\x9e\xc6\x85\x3a\xa0\x8c\xa6\xdd\x89\xed\x37\x79\x94\x6d\x38\x4c\xca\x34\x2b\x73\x8c\xa4\xb2\x17\xa1\xc8\x3e\x33\x17\x17\x5c\x38\x64\xc0\xc1\xe1\x39\x98\x4a\x4b\x76\xd2\x2e\xe8\xce\xbd\xf6\xf7\xd8\xac\x2e\x02\x72\xeb\xfc\x42\xa9\xf2\x87\x24\xcf\x40\xb9\x53\x1d\xcd\x6a\x3b\xb3\x8a\x6a\x05\x5c\x6a\xa1\xfc\x0b\xb2\x9b\xa3\x52\x05\xcd\x37\x0c\xfd\x15\x89\x6c\x2f\xe4\xf0\xab\xba\xcb\x44\x1f\xb2\xbc\x60\x17\x71\x84\x4f\xf1\x67\xb5\xa2\xab\x13\x09\x0a\xe1\xb0\x3b\xad\x30\xdf\x5e\x2b\x59\x07\xbb\xe6\x8e\x8e\xb2\x75\x37\x93\xb9\xf6\xd4\x29\xef\x1d\xbc\x5b\x3e\x4e\x07\x69\x59\x12\xee\xc3\xbb\x65\xe0\xf5\x91\x24\x91\x21\x47\x10\x7c\x94\x86\x49\xd5\xde\x99\x4f\xa9\xe9\x97\x6d\x5c\x7a\x8b\x4b\xeb\xd6\xd1\x2a\x5a\xdf\x30\xdf\xcd\x81\xd1\xf1\x10\x4d\x48\x8c\x66\x8b\x02\xf6\xc6\xde\x7d\xf5\x53\x54\x0f\x81\x48\x16\xa2\x77\x8b\x77\x03\xb6\x9f\x54\x50\xf6\xd9\x89\x8d\xc4\x55\x56\xd5\x94\x8b\xa8\x22\xde\x71\x5c\xbf\x1d\xf7\x22\x95\xa6\xff\x85\xff\x7e\x0b\xfc\x3d\x83\x68\x73\xcf\xfa\xbc\x05\x86\x7b\x06\x49\x13\x90\xfe\x86\x29\x56\x9e\xe9\xf5\xe9\xf3\x3c\xc6\x39\xb5\xb9\xb6\x49\x86\x4f\x5d\xf8\x7c\x44\x36\x49\xe2\xa2\x80\xdc\x70\xf6\x93\xc7\xdc\x59\x19\x72\x10\xb5\x34\x77\x49\x44\xa3\xb9\x84\x77\xcb\xc4\xd3\xce\xc4\x9d\x88\x85\xc7\x82\xb1\xfb\xe0\x2e\x7d\xdf\x63\x9f\x17\x87\xae\x0e\x5c\xb9\x4b\xdf\x07\x09\x9e\x7b\xf4\xc0\x39\x19\x7a\xc8\x01\xb1\xe7\x43\x72\x8a\x4f\x00\x8f\xfb\x30\xd0\xf3\x39\x6d\x1f\x3c\x3d\xae\x2d\x7a\x68\x60\x08\xd0\xd1\xdb\xe1\x28\x7d\x33\x2c\x8a\x52\x68\xa4\xab\xda\xce\x6d\x9c\x66\x6e\x6a\xea\xc3\x1b\xa9\xa9\x6b\xff\xfb\x67\x00\x21\x7f\x5b\xf7\x60\x0b\x00\x00")
 
 func sqlTaskSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -996,12 +1124,32 @@ func sqlTaskSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/task.sql", size: 1951, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/task.sql", size: 2912, mode: os.FileMode(420), modTime: time.Unix(1792371132, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _sqlUserSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x53\x5f\x6b\xd3\x50\x14\x7f\xef\xa7\x38\xf4\xa9\x01\x0b\x4d\xeb\xc3\xa8\xec\x21\x6d\xef\x34\xd8\x66\x92\xde\x89\x7b\x5a\xb2\xe6\x6e\x0d\x36\x49\x49\x53\xe6\xa3\x13\xa9\xab\x63\xac\x1b\xc2\xd6\x2a\xb2\x89\xca\x9e\x52\x86\xe2\x64\x11\xf7\x65\x7a\x13\xf3\x2d\x24\x49\xd3\xc0\x98\xd6\xe1\x43\x20\xf7\x70\x7f\x7f\xee\x39\xbf\x93\xcd\x82\xf7\xe6\xcc\xdd\xb9\xa0\x83\x3d\x28\xe4\x52\xd9\x2c\x10\x4d\x56\x5b\xd3\x7f\x45\xd5\x37\x83\xaf\xdd\x34\x74\x02\x2c\x1b\xd4\x2c\xd2\x22\x9b\xa6\xac\x41\x3e\xbc\xb2\x45\x1a\x4d\xd9\x0a\x0e\x65\x11\x71\x18\x01\xe6\x4a\x55\x04\xfc\x12\x08\xcb\x18\xd0\x13\xbe\x8e\xeb\x20\x35\x4c\xa3\x61\x28\x6a\x8b\xac\x75\x3b\xc4\x94\x20\x93\x02\x00\x90\x54\x45\x82\xf2\x03\x4e\xcc\xb0\x0b\x0c\x84\x00\x61\xa5\x5a\x85\xf2\x72\xad\x86\x04\x0c\xe9\xc8\x1b\x5f\x49\xdf\x89\xee\xeb\xb2\x46\x24\x78\xcc\x89\x21\xa8\x90\x63\x12\x4c\x05\x2d\x71\x2b\x55\x0c\xe9\xf4\x75\x38\x1d\xec\xc5\xf8\xa6\xdc\x69\xb6\xe5\x4e\x67\xcb\x30\x95\x84\x87\xcd\xcd\x23\xa2\xaf\x4f\xe8\xb8\x47\xc7\x3d\xef\x64\x3b\xe6\x32\x8d\x16\x91\x80\x17\x70\x86\xbd\x01\x9d\xbb\xee\xc2\x3b\x77\xe8\xfb\x5d\x60\x8b\xee\xd0\xf6\x9f\x8f\xa2\x22\xe4\x8b\x9e\x7d\xea\x0d\x7a\xf4\xe0\x18\x0a\xc5\x5f\xf6\x15\xb5\x3f\xc4\x02\x1b\x86\xb9\x1e\x34\xe8\x9f\x25\xdc\xe3\x31\x1d\x7c\xa6\xfb\xe3\x89\xf3\xc9\x1b\x3a\xfe\xb0\x07\x33\xb3\x44\x93\xcd\xa7\x7f\x7a\xf2\xcd\x6f\xfe\xf8\xca\xfd\x72\x96\x86\x29\x43\x18\x8b\x5b\xf4\xde\x7f\x61\x7b\xf6\x79\xac\x3f\x0b\x51\x3c\x6f\x76\x9e\xbc\x7f\xd8\xf7\x0f\xfb\x9e\x73\x40\xed\x91\x37\x7a\xe9\xf6\x77\xdd\x77\x97\x74\xff\x22\x66\x8c\x63\x98\x58\xca\xcf\xb3\x84\xa7\x90\x92\x61\x41\x92\xa9\x28\xc0\xb7\xa0\x99\xfc\xd8\x9e\x7c\x1f\xd1\x9f\xf6\xe4\xea\x34\xa1\x69\x98\x44\xb6\x08\x56\xb5\x28\x14\x7f\x1d\x17\xdd\x79\x4b\x9d\x4b\xf7\xe8\x9b\x7f\xf4\x35\xc6\x77\xdb\xca\xff\xe0\x1f\x89\x7c\x8d\x13\x57\xe1\x21\x5a\x85\x4c\xb0\x57\x4c\x54\x0f\xce\x92\xaa\x3c\x5b\x0b\x57\x27\x13\x6d\x10\x93\x62\x90\x70\x9f\x17\xd0\x22\xaf\xeb\x46\xa5\x34\xd3\x08\x1a\x50\x47\x78\xb1\x6b\x6d\x2c\x68\xeb\x77\xef\xfd\x0e\x00\x00\xff\xff\x32\x6b\xf5\x10\x1f\x04\x00\x00")
+var _sqlTotprecoverySql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x48\x2e\xca\x4f\xce\x4f\xc9\xcc\x49\x8d\x2f\xc9\x2f\x29\x28\x4a\x4d\xce\x2f\x4b\x2d\xaa\x4c\x50\xd0\xe0\x52\x50\x50\x50\x48\x28\xcd\x4c\x49\x50\x70\xf6\x70\x0c\xd2\x30\xb4\xd0\x04\x6b\xf4\x0b\xf5\xf1\x51\x70\xf6\xf7\xf5\x75\xf5\x0b\x51\x50\x7a\x3e\x65\xc5\xb3\x8e\xed\x9e\x2e\x4a\x3a\x10\xf5\xc9\xf9\x29\xa9\x50\x0d\x66\x26\xd8\x34\x3c\xd9\xb1\xe4\xd9\xda\xa5\x2f\x57\xf5\xbc\x58\xdf\xf8\xac\x71\xd1\xd3\x25\xbd\xcf\x17\x34\x16\x67\x24\x1a\x99\x9a\xc1\xcd\x28\x4a\x4d\x2c\x49\x0d\xc9\xcc\x4d\x4d\x50\xf0\xf4\x0b\x41\x18\xe2\xe2\xea\xe6\x18\xea\x13\xa2\x60\x80\x30\xee\x69\xc7\xec\xa7\xbb\x77\x3d\x9b\xbe\xed\xe5\xf4\x2d\x50\xfd\x01\x41\x9e\xbe\x8e\x41\x91\x0a\xde\xae\x91\x0a\x1a\x60\x0f\xe8\x40\xdd\xa5\xc9\xa5\xe9\xea\xe7\xee\xe9\xe7\x6a\xeb\x99\x97\x97\xef\xe2\x04\x37\x10\xe4\xde\x60\xd7\x10\xdb\xd2\x92\x34\x8b\xdc\x24\x13\x6b\x2e\xc0\x00\x21\x72\x97\x2a\x32\x01\x00\x00")
+
+func sqlTotprecoverySqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlTotprecoverySql,
+		"sql/totprecovery.sql",
+	)
+}
+
+func sqlTotprecoverySql() (*asset, error) {
+	bytes, err := sqlTotprecoverySqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/totprecovery.sql", size: 306, mode: os.FileMode(420), modTime: time.Unix(1792365642, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlUserSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x94\x5d\x4f\xda\x5e\x1c\xc7\xef\x7d\x15\xbf\x70\x05\xc9\x9f\x84\xfa\xf0\x8f\x71\xf1\xa2\x48\x75\xcd\xb0\x1a\xa8\xcb\xbc\xb2\xa5\x3d\x4a\x37\xa0\x4d\x29\x71\x97\x6a\x0c\xea\x1c\x13\x0d\x8b\xca\xcc\xa2\x46\x16\xb3\xc4\x36\x6e\xcb\x7c\xc0\xc9\x9b\xe1\xb4\xf6\x5d\x2c\xa5\x14\xd0\xb8\x21\xd9\x45\x93\x9e\xd3\xf3\xfd\xfc\x9e\xbe\x3d\xc1\x20\x58\xa5\x53\x73\xfd\x02\x17\x0b\x30\x10\xea\x0b\x06\x01\xa5\x79\x29\xd5\x7c\x17\xa5\xcc\x82\xf3\x28\x49\x39\x83\x80\x20\x9c\x3d\x0d\xa5\xd0\x82\xca\xa7\xa1\xbf\x71\x64\x11\x09\x49\x5e\x73\x16\x63\x31\x8a\x64\x29\x60\xc9\x70\x94\x02\x7a\x1c\x98\x29\x16\xa8\x57\x74\x9c\x8d\x03\x27\xa8\xb2\x20\x8b\x52\x0a\xcd\xe5\xb2\x48\xe5\xc0\xdf\x07\x00\xc0\x49\x22\x07\x63\xcf\xc9\x98\x9f\x18\x0e\x40\x43\xc0\xcc\x44\xa3\x30\x36\x35\x39\x49\x31\x2c\xf8\xdc\xdc\xe8\x88\xef\x3f\xf7\x7c\x86\x4f\x23\x0e\x5e\x92\xb1\x86\x68\x20\x14\x68\x6b\x22\xd4\x38\x39\x13\x65\xc1\xe7\x7b\x28\xc7\xc5\x82\xa7\x4f\xf2\xd9\xa4\xc2\x67\xb3\x8b\xb2\x2a\xb6\x39\x44\xa8\x1b\x08\xbf\x3b\xc4\x46\x1e\x1b\x79\xeb\x70\xd9\x63\xa9\x72\x0a\x71\x40\x33\xac\x9f\x78\x44\x1d\x7a\x98\x85\x75\x5e\xc5\x9f\x37\x81\x18\x31\xf7\x75\x7b\xa9\xec\x6e\x42\xff\x88\xa5\x1f\x59\xc5\x3c\xde\xde\x83\x81\x91\x3b\xbd\x86\xf5\x63\x2f\xc0\xbc\xac\x26\x24\xb1\x87\x10\xe6\x9e\x81\x8b\x5f\xf0\x96\x51\xaf\x56\xac\xfd\xaa\xbd\x9f\x87\x56\xb2\x28\xcd\xab\x6f\xfe\x54\xf2\xe3\x35\x9f\xac\x99\xdf\x4f\x7d\xd0\x24\x34\x6c\xd1\x43\xef\xed\x15\xdd\xd2\xcf\xbd\xf8\x2d\x13\x79\xf3\x26\xba\x85\xb7\x77\x36\xec\x9d\x0d\xab\xba\x8d\xf5\xb2\x55\x5e\x35\x37\x36\xcd\x83\x6b\xbc\x75\xe1\x11\x3d\x1b\xb6\x53\xea\xef\x96\x12\xdb\x94\x84\x65\x0d\xda\x9e\x72\x0d\xdc\x03\xa6\x7e\xb3\x5c\xbf\x2c\xe3\x5b\xbd\x5e\x3b\x6a\x63\x64\x49\x14\xe2\xb9\xc4\x6b\x24\x74\xb2\x86\x86\xba\x39\xab\xf0\xd1\x5a\xb9\x72\xa7\xd5\x9c\xe2\xe1\xda\x9d\x91\xf7\xb0\x29\x91\x57\x22\x4c\x2f\xc4\x68\x84\x9c\x76\x49\x11\xc6\xa3\x78\x9e\x9f\x51\x44\x5e\x43\xac\x94\x76\x9d\xfb\x57\x4f\xb9\x6e\xaf\xd7\x74\xb3\x74\x65\xee\xfe\xb4\x77\x7f\xb4\x5a\x2f\x6b\x4a\x1c\x09\x2a\xea\xa8\xf4\xff\xc1\x6e\x5d\xbb\x3c\x31\xcf\x2a\xf6\xd7\xf7\x77\xc6\x32\x36\xf2\xf6\x4e\xa5\x13\x47\x65\xf8\xc4\x13\x7f\xa7\xa6\xcb\x6f\x96\x70\xd1\xe8\x84\x76\xe2\xa2\x7c\x56\x8b\x6b\x48\xe1\x20\x4c\x4f\x74\x2b\xd4\x3c\x58\xc2\xc5\x0f\xf5\x5f\x35\xab\x74\x6a\x95\x57\x3b\x99\x6e\xdd\xe6\x59\x05\xec\xbd\x6f\xe6\xd9\xb1\xbd\x56\x30\x4b\xb7\x5e\x24\x41\x45\x4f\xef\xe6\xfa\x27\x5c\xbd\xbe\xdf\xc7\x9c\x22\xfe\x8b\x7e\x3a\x46\x4f\x92\xb1\x59\x78\x41\xcd\x82\xdf\xb9\x4a\x03\xee\xbe\xb3\xe6\x24\xf1\xed\x5c\xe3\xb6\xf4\xbb\x97\xe6\xc3\x6f\x8e\x5d\xb3\x4d\xbb\xfa\xef\x99\x37\xd0\x17\xa0\x98\x09\x9a\xa1\x46\xe9\x4c\x46\x8e\x84\x5b\xd9\x38\x73\x8e\x53\xec\x68\x4e\x9b\x1f\x4e\x27\x06\x9f\xfd\x1e\x00\xbf\x10\xf4\x83\x3c\x06\x00\x00")
 
 func sqlUserSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1016,7 +1164,27 @@ func sqlUserSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "sql/user.sql", size: 1055, mode: os.FileMode(420), modTime: time.Unix(1616823976, 0)}
+	info := bindataFileInfo{name: "sql/user.sql", size: 1596, mode: os.FileMode(420), modTime: time.Unix(1792365642, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlUsergroupSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x48\x2e\xca\x4f\xce\x4f\xc9\xcc\x49\x8d\x2f\x2d\x4e\x2d\x4a\x2f\xca\x2f\x2d\x48\x50\xd0\xe0\x52\x50\x50\x50\x48\x28\xcd\x4c\x49\x50\x70\xf6\x70\x0c\xd2\x30\xb4\xd0\x04\xeb\xf2\x0b\xf5\xf1\x51\x70\xf6\xf7\xf5\x75\xf5\x0b\x51\x50\x7a\x3e\x65\xc5\xb3\x8e\xed\x9e\x2e\x4a\x3a\x10\xf5\x60\xdd\x7e\x89\xb9\xa9\x09\x0a\x61\x8e\x41\x10\x8d\x06\x06\x48\x3a\x5d\x5c\xdd\x1c\x43\x7d\x42\x14\x94\x94\x10\x86\xf8\xb8\x38\x06\x3c\xeb\x6c\x78\x36\xa7\xf3\xd9\xdc\xe6\xe7\xbb\x5b\x14\x9e\x4e\xe8\x79\xbe\xbb\x05\x62\xf6\xd3\xd6\x8d\x4f\x76\xad\x7e\xb2\x63\xf7\xb3\x39\xbb\x9e\xef\x6e\x79\x3a\xa9\xe7\xc9\xee\xdd\x4f\xbb\x16\x3e\x9f\xd5\x02\xd7\x03\xb5\x3d\x20\xc8\xd3\xd7\x31\x28\x52\xc1\xdb\x35\x52\x41\x03\xec\x74\x1d\x64\x17\x69\x42\x54\x81\x64\x13\x32\x53\x2a\xe2\xc1\x32\x79\x20\x19\x05\x0d\x64\x65\x5c\x9a\xae\x7e\xee\x9e\x7e\xae\xb6\x9e\x79\x79\xf9\x2e\x4e\x70\x27\x83\x7c\x13\xec\x1a\x62\x5b\x5a\x92\x66\x91\x9b\x64\x62\xcd\x05\x18\x00\xd0\xb4\x1f\xd6\x55\x01\x00\x00")
+
+func sqlUsergroupSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlUsergroupSql,
+		"sql/usergroup.sql",
+	)
+}
+
+func sqlUsergroupSql() (*asset, error) {
+	bytes, err := sqlUsergroupSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sql/usergroup.sql", size: 341, mode: os.FileMode(420), modTime: time.Unix(1792364515, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -1109,15 +1277,23 @@ var _bindata = map[string]func() (*asset, error){
 	"web/crocodile/static/js/chunk-c9c77a48.87569ba7.js":     webCrocodileStaticJsChunkC9c77a4887569ba7Js,
 	"web/crocodile/static/js/chunk-elementUI.4de05055.js":    webCrocodileStaticJsChunkElementui4de05055Js,
 	"web/crocodile/static/js/chunk-libs.5cd940d3.js":         webCrocodileStaticJsChunkLibs5cd940d3Js,
-	"sql/README.md":       sqlReadmeMd,
-	"sql/casbin_rule.sql": sqlCasbin_ruleSql,
-	"sql/host.sql":        sqlHostSql,
-	"sql/hostgroup.sql":   sqlHostgroupSql,
-	"sql/log.sql":         sqlLogSql,
-	"sql/notify.sql":      sqlNotifySql,
-	"sql/operate.sql":     sqlOperateSql,
-	"sql/task.sql":        sqlTaskSql,
-	"sql/user.sql":        sqlUserSql,
+	"sql/README.md":         sqlReadmeMd,
+	"sql/apitoken.sql":      sqlApitokenSql,
+	"sql/approval.sql":      sqlApprovalSql,
+	"sql/casbin_rule.sql":   sqlCasbin_ruleSql,
+	"sql/enrolltoken.sql":   sqlEnrolltokenSql,
+	"sql/host.sql":          sqlHostSql,
+	"sql/hostevent.sql":     sqlHosteventSql,
+	"sql/hostgroup.sql":     sqlHostgroupSql,
+	"sql/log.sql":           sqlLogSql,
+	"sql/notify.sql":        sqlNotifySql,
+	"sql/operate.sql":       sqlOperateSql,
+	"sql/project.sql":       sqlProjectSql,
+	"sql/projectmember.sql": sqlProjectmemberSql,
+	"sql/task.sql":          sqlTaskSql,
+	"sql/totprecovery.sql":  sqlTotprecoverySql,
+	"sql/user.sql":          sqlUserSql,
+	"sql/usergroup.sql":     sqlUsergroupSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -1162,15 +1338,23 @@ type bintree struct {
 
 var _bintree = &bintree{nil, map[string]*bintree{
 	"sql": &bintree{nil, map[string]*bintree{
-		"README.md":       &bintree{sqlReadmeMd, map[string]*bintree{}},
-		"casbin_rule.sql": &bintree{sqlCasbin_ruleSql, map[string]*bintree{}},
-		"host.sql":        &bintree{sqlHostSql, map[string]*bintree{}},
-		"hostgroup.sql":   &bintree{sqlHostgroupSql, map[string]*bintree{}},
-		"log.sql":         &bintree{sqlLogSql, map[string]*bintree{}},
-		"notify.sql":      &bintree{sqlNotifySql, map[string]*bintree{}},
-		"operate.sql":     &bintree{sqlOperateSql, map[string]*bintree{}},
-		"task.sql":        &bintree{sqlTaskSql, map[string]*bintree{}},
-		"user.sql":        &bintree{sqlUserSql, map[string]*bintree{}},
+		"README.md":         &bintree{sqlReadmeMd, map[string]*bintree{}},
+		"apitoken.sql":      &bintree{sqlApitokenSql, map[string]*bintree{}},
+		"approval.sql":      &bintree{sqlApprovalSql, map[string]*bintree{}},
+		"casbin_rule.sql":   &bintree{sqlCasbin_ruleSql, map[string]*bintree{}},
+		"enrolltoken.sql":   &bintree{sqlEnrolltokenSql, map[string]*bintree{}},
+		"host.sql":          &bintree{sqlHostSql, map[string]*bintree{}},
+		"hostevent.sql":     &bintree{sqlHosteventSql, map[string]*bintree{}},
+		"hostgroup.sql":     &bintree{sqlHostgroupSql, map[string]*bintree{}},
+		"log.sql":           &bintree{sqlLogSql, map[string]*bintree{}},
+		"notify.sql":        &bintree{sqlNotifySql, map[string]*bintree{}},
+		"operate.sql":       &bintree{sqlOperateSql, map[string]*bintree{}},
+		"project.sql":       &bintree{sqlProjectSql, map[string]*bintree{}},
+		"projectmember.sql": &bintree{sqlProjectmemberSql, map[string]*bintree{}},
+		"task.sql":          &bintree{sqlTaskSql, map[string]*bintree{}},
+		"totprecovery.sql":  &bintree{sqlTotprecoverySql, map[string]*bintree{}},
+		"user.sql":          &bintree{sqlUserSql, map[string]*bintree{}},
+		"usergroup.sql":     &bintree{sqlUsergroupSql, map[string]*bintree{}},
 	}},
 	"web": &bintree{nil, map[string]*bintree{
 		"crocodile": &bintree{nil, map[string]*bintree{
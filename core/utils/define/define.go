@@ -1,8 +1,15 @@
 package define
 
+import (
+	"sort"
+	"strings"
+)
+
 const (
 	// DefaultLimit set get total page
 	DefaultLimit = 20
+	// DefaultFailoverLimit task failover times when worker is down if not set
+	DefaultFailoverLimit = 1
 )
 
 // Role Admin or Normal User
@@ -30,6 +37,35 @@ func (r Role) String() string {
 	}
 }
 
+// ProjectRole role of project member, higher role has all permissions of lower role
+type ProjectRole uint8
+
+const (
+	// ProjectViewer could view tasks, host groups and logs of project
+	ProjectViewer ProjectRole = iota + 1
+	// ProjectOperator could run and kill tasks of project
+	ProjectOperator
+	// ProjectEditor could create, change and delete tasks and host groups of project
+	ProjectEditor
+	// ProjectOwner could change project and manage members
+	ProjectOwner
+)
+
+func (r ProjectRole) String() string {
+	switch r {
+	case ProjectViewer:
+		return "Viewer"
+	case ProjectOperator:
+		return "Operator"
+	case ProjectEditor:
+		return "Editor"
+	case ProjectOwner:
+		return "Owner"
+	default:
+		return "Unknown"
+	}
+}
+
 // TaskType task type
 // shell
 // api
@@ -122,6 +158,9 @@ type User struct {
 	DingPhone string   `json:"dingphone" comment:"钉钉"`             // dingding phone
 	Slack     string   `json:"slack" comment:"Slack"`              // slack user name
 	Telegram  string   `json:"telegram" comment:"Telegram"`        // telegram bot chat id
+	LDAPDN    string   `json:"ldap_dn,omitempty"`                  // ldap用户的DN 为空是本地用户
+	Locked    bool     `json:"locked"`                             // 登陆失败次数过多被锁定
+	TOTP      bool     `json:"totp" comment:"两步验证"`                // 是否开启两步验证
 	Common
 }
 
@@ -160,64 +199,379 @@ type ChangeUserSelf struct {
 	Remark    string `json:"remark"`
 }
 
+// LoginToken return when login or refresh token
+type LoginToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token expire seconds
+}
+
+// RefreshToken get refresh token in post
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ChangeExpiredPassword new password of user whose password is expired
+type ChangeExpiredPassword struct {
+	Password string `json:"password" binding:"required"`
+	TOTPCode string `json:"totp_code"` // 开启两步验证时需要验证码或者恢复码
+}
+
+// LoginTOTP totp code or recovery code when login
+type LoginTOTP struct {
+	TOTPCode string `json:"totp_code"`
+}
+
+// TOTPCode totp code or recovery code
+type TOTPCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPSecret new totp secret, add it to authenticator app by secret or url
+type TOTPSecret struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
+
+// TOTPStatus totp status of user
+type TOTPStatus struct {
+	Enable        bool `json:"enable"`
+	Require       bool `json:"require"`        // 管理员必须开启
+	RecoveryCodes int  `json:"recovery_codes"` // 未使用的恢复码数量
+}
+
+// TOTPEnabled recovery codes return once when totp is enabled
+type TOTPEnabled struct {
+	RecoveryCodes []string    `json:"recovery_codes"`
+	Token         *LoginToken `json:"token,omitempty"` // 登陆时绑定验证器返回登陆token
+}
+
+// OIDCConfig sso login config for login page
+type OIDCConfig struct {
+	Enable               bool `json:"enable"`
+	DisablePasswordLogin bool `json:"disable_password_login"`
+}
+
 // HostGroup define hostgroup
 type HostGroup struct {
 	HostsID     []string `json:"addrs" comment:"WorkerIDs"` // 主机host
 	CreateByUID string   `json:"create_byuid"`              // 创建人ID
 	CreateBy    string   `json:"create_by"`                 // 创建人ID
+	ProjectID   string   `json:"project_id"`                // 所属项目ID
+	Project     string   `json:"project" comment:"项目"`      // 所属项目
+	ApproverIds []string `json:"approver_ids"`              // 审核人 设置后使用此主机组的任务需要审核
+	Approvers   []string `json:"approvers" comment:"审核人"`
 	Common
 }
 
 // CreateHostGroup new hostgroup
 type CreateHostGroup struct {
-	Name    string   `json:"name" binding:"required,max=30"`
-	HostsID []string `json:"addrs"` // 主机host
-	Remark  string   `json:"remark" binding:"max=100"`
+	Name        string   `json:"name" binding:"required,max=30"`
+	HostsID     []string `json:"addrs"` // 主机host
+	ProjectID   string   `json:"project_id" binding:"omitempty,len=18"`
+	ApproverIds []string `json:"approver_ids" binding:"max=10"`
+	Remark      string   `json:"remark" binding:"max=100"`
 }
 
 // ChangeHostGroup new hostgroup
 type ChangeHostGroup struct {
-	ID      string   `json:"id" binding:"required"`
-	HostsID []string `json:"addrs"` // 主机host
-	Remark  string   `json:"remark" binding:"max=100"`
+	ID          string   `json:"id" binding:"required"`
+	HostsID     []string `json:"addrs"` // 主机host
+	ProjectID   string   `json:"project_id" binding:"omitempty,len=18"`
+	ApproverIds []string `json:"approver_ids" binding:"max=10"`
+	Remark      string   `json:"remark" binding:"max=100"`
+}
+
+// Project own tasks and host groups, members have permissions by project role
+type Project struct {
+	CreateByUID string      `json:"create_byuid"`
+	CreateBy    string      `json:"create_by"`
+	Role        ProjectRole `json:"role"` // 当前用户在项目中的角色 0为不是成员
+	RoleDesc    string      `json:"role_desc"`
+	Common
+}
+
+// CreateProject new project
+type CreateProject struct {
+	Name   string `json:"name" binding:"required,max=30"`
+	Remark string `json:"remark" binding:"max=100"`
+}
+
+// ChangeProject change project
+type ChangeProject struct {
+	ID     string `json:"id" binding:"required,len=18"`
+	Name   string `json:"name" binding:"required,max=30"`
+	Remark string `json:"remark" binding:"max=100"`
+}
+
+// ProjectMember member of project
+type ProjectMember struct {
+	UID        string      `json:"uid"`
+	UserName   string      `json:"user_name"`
+	Role       ProjectRole `json:"role"`
+	RoleDesc   string      `json:"role_desc"`
+	CreateTime string      `json:"create_time"`
+}
+
+// SetProjectMember add member or change member's role
+type SetProjectMember struct {
+	ProjectID string      `json:"project_id" binding:"required,len=18"`
+	UID       string      `json:"uid" binding:"required,len=18"`
+	Role      ProjectRole `json:"role" binding:"required,min=1,max=4"`
+}
+
+// DeleteProjectMember remove member of project
+type DeleteProjectMember struct {
+	ProjectID string `json:"project_id" binding:"required,len=18"`
+	UID       string `json:"uid" binding:"required,len=18"`
 }
 
 // Host worker host
 type Host struct {
-	ID                 string   `json:"id" comment:"ID"`
-	Addr               string   `json:"addr" comment:"Worker地址"`
-	HostName           string   `json:"hostname"`
-	Online             bool     `json:"online"`
-	Weight             int      `json:"weight"`
-	RunningTasks       []string `json:"running_tasks"`
-	Version            string   `json:"version"`
-	Stop               bool     `json:"stop" comment:"暂停"`
-	LastUpdateTimeUnix int64    `json:"last_updatetimeunix"`
-	LastUpdateTime     string   `json:"last_updatetime" comment:"更新时间"`
-	Remark             string   `json:"remark"`
+	ID                 string            `json:"id" comment:"ID"`
+	Addr               string            `json:"addr" comment:"Worker地址"`
+	HostName           string            `json:"hostname"`
+	Online             bool              `json:"online"`
+	Weight             int               `json:"weight"`
+	RunningTasks       []string          `json:"running_tasks"`
+	Version            string            `json:"version"`
+	Stop               bool              `json:"stop" comment:"暂停"`
+	Drain              bool              `json:"drain" comment:"排空"` // stop recv new task, offline after running tasks finished
+	LastUpdateTimeUnix int64             `json:"last_updatetimeunix"`
+	LastUpdateTime     string            `json:"last_updatetime" comment:"更新时间"`
+	Remark             string            `json:"remark"`
+	Labels             map[string]string `json:"labels"`   // worker labels set in client config
+	Enrolled           bool              `json:"enrolled"` // worker registry by enroll token and use its own credential
+	Credential         string            `json:"-"`
+	HostResource
+}
+
+// ParseLabels parse labels like ["os=linux", "gpu"] to map, label without "=" will get empty value
+func ParseLabels(labels []string) map[string]string {
+	res := make(map[string]string, len(labels))
+	for _, label := range labels {
+		kv := strings.SplitN(strings.TrimSpace(label), "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			continue
+		}
+		if len(kv) == 2 {
+			res[key] = strings.TrimSpace(kv[1])
+		} else {
+			res[key] = ""
+		}
+	}
+	return res
+}
+
+// FormatLabels format labels map to sorted ["key=value"]
+func FormatLabels(labels map[string]string) []string {
+	res := make([]string, 0, len(labels))
+	for key, value := range labels {
+		res = append(res, key+"="+value)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// LabelOperator label selector operator
+type LabelOperator string
+
+const (
+	// LabelIn worker has the label and its value is in values
+	LabelIn LabelOperator = "In"
+	// LabelNotIn worker has not the label or its value is not in values
+	LabelNotIn LabelOperator = "NotIn"
+	// LabelExists worker has the label
+	LabelExists LabelOperator = "Exists"
+)
+
+// LabelRequirement select worker by a label
+type LabelRequirement struct {
+	Key      string        `json:"key" binding:"required,max=63"`
+	Operator LabelOperator `json:"operator" binding:"required,oneof=In NotIn Exists"`
+	Values   []string      `json:"values" binding:"max=20"`
+}
+
+// Match return whether labels match requirement
+func (lr LabelRequirement) Match(labels map[string]string) bool {
+	value, exist := labels[lr.Key]
+	switch lr.Operator {
+	case LabelExists:
+		return exist
+	case LabelIn:
+		return exist && contains(lr.Values, value)
+	case LabelNotIn:
+		return !exist || !contains(lr.Values, value)
+	default:
+		return false
+	}
+}
+
+// LabelSelector select worker by labels, worker must match all requirements
+type LabelSelector []LabelRequirement
+
+// Match return whether labels match all requirements
+func (ls LabelSelector) Match(labels map[string]string) bool {
+	for _, lr := range ls {
+		if !lr.Match(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// HostEventType host online status change event
+type HostEventType uint8
+
+const (
+	// HostOnline host recv heartbeat again after offline or first registry
+	HostOnline HostEventType = iota + 1
+	// HostOffline host not recv heartbeat over max worker ttl
+	HostOffline
+)
+
+func (he HostEventType) String() string {
+	switch he {
+	case HostOnline:
+		return "online"
+	case HostOffline:
+		return "offline"
+	default:
+		return "unknown"
+	}
+}
+
+// HostEvent host online status change history
+type HostEvent struct {
+	ID            int           `json:"id"`
+	HostID        string        `json:"host_id"`
+	Addr          string        `json:"addr"`
+	HostName      string        `json:"hostname"`
+	Event         HostEventType `json:"event"`
+	EventDesc     string        `json:"event_desc"`
+	EventTime     int64         `json:"event_time"`
+	EventTimeDesc string        `json:"event_timedesc"`
+}
+
+// EnrollToken worker exchange it for its own credential when registry
+type EnrollToken struct {
+	ID             string   `json:"id"`
+	Token          string   `json:"token,omitempty"` // only return once when created
+	HostGroupID    string   `json:"hostgroup_id"`    // worker will join this hostgroup after registry
+	HostGroup      string   `json:"hostgroup"`
+	Labels         []string `json:"labels"`   // labels set to worker, override the same key in client config
+	MaxUses        int      `json:"max_uses"` // 0 is unlimited
+	Uses           int      `json:"uses"`
+	ExpireTimeUnix int64    `json:"expire_timeunix"` // 0 is never expire
+	ExpireTime     string   `json:"expire_time"`
+	Remark         string   `json:"remark"`
+	CreateByUID    string   `json:"create_byuid"`
+	CreateBy       string   `json:"create_by"`
+	CreateTime     string   `json:"create_time"`
+}
+
+// CreateEnrollToken create enroll token
+type CreateEnrollToken struct {
+	HostGroupID string   `json:"hostgroup_id"`
+	Labels      []string `json:"labels"`
+	MaxUses     int      `json:"max_uses" binding:"min=0"`
+	Expire      int64    `json:"expire" binding:"min=0"` // valid seconds after created, 0 is never expire
+	Remark      string   `json:"remark" binding:"max=100"`
+}
+
+// APITokenScope api token can only request api in scope
+type APITokenScope string
+
+const (
+	// ScopeRead only GET request
+	ScopeRead APITokenScope = "read"
+	// ScopeRun GET request and run or kill task
+	ScopeRun APITokenScope = "run"
+	// ScopeWrite all request allowed by user's role
+	ScopeWrite APITokenScope = "write"
+)
+
+// APIToken user personal token for automation, like trigger task in ci
+type APIToken struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Token          string        `json:"token,omitempty"` // only return once when created
+	UID            string        `json:"uid"`
+	UserName       string        `json:"user_name"`
+	Role           Role          `json:"-"`
+	Forbid         bool          `json:"-"`
+	Scope          APITokenScope `json:"scope"`
+	TaskIDs        []string      `json:"task_ids"`        // only can request these tasks, empty is unlimited
+	ExpireTimeUnix int64         `json:"expire_timeunix"` // 0 is never expire
+	ExpireTime     string        `json:"expire_time"`
+	LastUsedUnix   int64         `json:"-"`
+	LastUsedTime   string        `json:"last_used_time"`
+	CreateTime     string        `json:"create_time"`
+}
+
+// CreateAPIToken create api token
+type CreateAPIToken struct {
+	Name    string        `json:"name" binding:"required,max=30"`
+	Scope   APITokenScope `json:"scope" binding:"required,oneof=read run write"`
+	TaskIDs []string      `json:"task_ids"`
+	Expire  int64         `json:"expire" binding:"min=0"` // valid seconds after created, 0 is never expire
+}
+
+// HostResource worker resource usage report by heartbeat
+type HostResource struct {
+	CPUNum        int     `json:"cpu_num"`
+	Load1         float64 `json:"load1"`
+	Load5         float64 `json:"load5"`
+	Load15        float64 `json:"load15"`
+	MemTotal      uint64  `json:"mem_total"`      // bytes
+	MemAvailable  uint64  `json:"mem_available"`  // bytes
+	DiskTotal     uint64  `json:"disk_total"`     // bytes
+	DiskFree      uint64  `json:"disk_free"`      // bytes
+	TaskSlots     int     `json:"task_slots"`     // max tasks worker want to run at the same time
+	RunningSlots  int     `json:"running_slots"`  // tasks running on worker
+	MaxConcurrent int     `json:"max_concurrent"` // max tasks worker can run at the same time, 0 is unlimited
 }
 
 // Task define Task
 type Task struct {
-	TaskType          TaskType    `json:"task_type" binding:"required"`                 // 任务类型
-	TaskData          interface{} `json:"task_data" binding:"required"`                 // 任务数据
-	Run               bool        `json:"run" `                                         // 是否可以自动调度  如果为false则只能手动或者被其他任务依赖运行
-	ParentTaskIds     []string    `json:"parent_taskids" binding:"max=20"`              // 父任务 运行任务前先运行父任务 以父或子任务运行时 任务不会执行自已的父子任务，防止循环依赖
-	ParentRunParallel bool        `json:"parent_runparallel"`                           // 是否以并行运行父任务 0否 1是
-	ChildTaskIds      []string    `json:"child_taskids" binding:"max=20"`               // 子任务 运行结束后运行子任务
-	ChildRunParallel  bool        `json:"child_runparallel"`                            // 是否以并行运行子任务 否 1是
-	CreateBy          string      `json:"create_by"`                                    // 创建人
-	CreateByUID       string      `json:"create_byuid"`                                 // 创建人ID
-	HostGroup         string      `json:"host_group" `                                  // 主机组
-	HostGroupID       string      `json:"host_groupid" binding:"required,len=18"`       // 主机组ID
-	Cronexpr          string      `json:"cronexpr" binding:"required,max=1000"`         // 执行任务表达式
-	Timeout           int         `json:"timeout" binding:"required,min=-1"`            // 任务超时时间 (s) -1 no limit
-	AlarmUserIds      []string    `json:"alarm_userids" binding:"required,max=10"`      // 报警用户 最多十个多个用户
-	RoutePolicy       RoutePolicy `json:"route_policy" binding:"required,min=1,max=4"`  // how to select a run worker from hostgroup
-	ExpectCode        int         `json:"expect_code"`                                  // expect task return code. if not set 0 or 200
-	ExpectContent     string      `json:"expect_content"`                               // expect task return content. if not set do not check
-	AlarmStatus       AlarmStatus `json:"alarm_status" binding:"required,min=-2,max=1"` // alarm when task run success or fail or all all:-2 failed: -1 success: 1
-	Remark            string      `json:"remark" binding:"max=100"`
+	TaskType          TaskType         `json:"task_type" binding:"required"`                      // 任务类型
+	TaskData          interface{}      `json:"task_data" binding:"required"`                      // 任务数据
+	Run               bool             `json:"run" `                                              // 是否可以自动调度  如果为false则只能手动或者被其他任务依赖运行
+	ParentTaskIds     []string         `json:"parent_taskids" binding:"max=20"`                   // 父任务 运行任务前先运行父任务 以父或子任务运行时 任务不会执行自已的父子任务，防止循环依赖
+	ParentRunParallel bool             `json:"parent_runparallel"`                                // 是否以并行运行父任务 0否 1是
+	ChildTaskIds      []string         `json:"child_taskids" binding:"max=20"`                    // 子任务 运行结束后运行子任务
+	ChildRunParallel  bool             `json:"child_runparallel"`                                 // 是否以并行运行子任务 否 1是
+	CreateBy          string           `json:"create_by"`                                         // 创建人
+	CreateByUID       string           `json:"create_byuid"`                                      // 创建人ID
+	HostGroup         string           `json:"host_group" `                                       // 主机组
+	HostGroupID       string           `json:"host_groupid" binding:"omitempty,len=18"`           // 主机组ID 主机组和标签选择器至少设置一个
+	ProjectID         string           `json:"project_id" binding:"omitempty,len=18"`             // 所属项目ID 为空不属于任何项目
+	Project           string           `json:"project"`                                           // 所属项目
+	Cronexpr          string           `json:"cronexpr" binding:"required,max=1000"`              // 执行任务表达式
+	Timeout           int              `json:"timeout" binding:"required,min=-1"`                 // 任务超时时间 (s) -1 no limit
+	AlarmUserIds      []string         `json:"alarm_userids" binding:"required,max=10"`           // 报警用户 最多十个多个用户
+	ApproverIds       []string         `json:"approver_ids" binding:"max=10"`                     // 审核人 设置后修改和手动运行任务需要审核
+	LabelSelector     LabelSelector    `json:"label_selector" binding:"max=10,dive"`              // select run worker by labels, with host group select from host group's workers
+	RoutePolicy       RoutePolicy      `json:"route_policy" binding:"required,min=1,max=7"`       // how to select a run worker from hostgroup
+	BroadcastSuccess  BroadcastSuccess `json:"broadcast_success" binding:"omitempty,min=1,max=3"` // broadcast task run success when all/any/quorum hosts success, default all
+	BroadcastParallel int              `json:"broadcast_parallel" binding:"min=0"`                // broadcast task run on how many hosts at the same time, 0 is unlimited
+	ShardTotal        int              `json:"shard_total" binding:"min=0,max=100"`               // split task to shards run on host group's workers, 0 is not sharded
+	Idempotent        bool             `json:"idempotent"`                                        // task can run again on other worker after partially run on a down worker
+	FailoverLimit     int              `json:"failover_limit" binding:"min=-1,max=10"`            // max times failover to other worker when worker is down, 0 is default 1 time, -1 is no failover
+	ExpectCode        int              `json:"expect_code"`                                       // expect task return code. if not set 0 or 200
+	ExpectContent     string           `json:"expect_content"`                                    // expect task return content. if not set do not check
+	AlarmStatus       AlarmStatus      `json:"alarm_status" binding:"required,min=-2,max=1"`      // alarm when task run success or fail or all all:-2 failed: -1 success: 1
+	Remark            string           `json:"remark" binding:"max=100"`
 }
 
 // AlarmStatus task is alarm
@@ -266,30 +620,40 @@ type IDName struct {
 // GetTask get task
 type GetTask struct {
 	//
-	TaskType          TaskType    `json:"task_type"`
-	TaskTypeDesc      string      `json:"task_typedesc" comment:"任务类型"`
-	TaskData          interface{} `json:"task_data" comment:"任务数据"`
-	Run               bool        `json:"run" comment:"运行"`
-	ParentTaskIds     []string    `json:"parent_taskids"`
-	ParentTaskIdsDesc []string    `json:"parent_taskidsdesc" comment:"父任务"`
-	ParentRunParallel bool        `json:"parent_runparallel" comment:"父任务运行策略"`
-	ChildTaskIds      []string    `json:"child_taskids"`
-	ChildTaskIdsDesc  []string    `json:"child_taskidsdesc"  comment:"子任务"`
-	ChildRunParallel  bool        `json:"child_runparallel" comment:"子任务运行策略"`
-	CreateBy          string      `json:"create_by"`
-	CreateByUID       string      `json:"create_byuid"`
-	HostGroup         string      `json:"host_group" comment:"主机组"`
-	HostGroupID       string      `json:"host_groupid"`
-	Cronexpr          string      `json:"cronexpr" comment:"CronExpr"`
-	Timeout           int         `json:"timeout" comment:"超时时间"`
-	AlarmUserIds      []string    `json:"alarm_userids"`
-	AlarmUserIdsDesc  []string    `json:"alarm_useridsdesc" comment:"报警用户"`
-	RoutePolicy       RoutePolicy `json:"route_policy"`
-	RoutePolicyDesc   string      `json:"route_policydesc" comment:"路由策略"`
-	ExpectCode        int         `json:"expect_code"  comment:"期望返回码"`
-	ExpectContent     string      `json:"expect_content" comment:"期望返回内容"`
-	AlarmStatus       AlarmStatus `json:"alarm_status"`
-	AlarmStatusDesc   string      `json:"alarm_statusdesc" comment:"报警策略"`
+	TaskType          TaskType         `json:"task_type"`
+	TaskTypeDesc      string           `json:"task_typedesc" comment:"任务类型"`
+	TaskData          interface{}      `json:"task_data" comment:"任务数据"`
+	Run               bool             `json:"run" comment:"运行"`
+	ParentTaskIds     []string         `json:"parent_taskids"`
+	ParentTaskIdsDesc []string         `json:"parent_taskidsdesc" comment:"父任务"`
+	ParentRunParallel bool             `json:"parent_runparallel" comment:"父任务运行策略"`
+	ChildTaskIds      []string         `json:"child_taskids"`
+	ChildTaskIdsDesc  []string         `json:"child_taskidsdesc"  comment:"子任务"`
+	ChildRunParallel  bool             `json:"child_runparallel" comment:"子任务运行策略"`
+	CreateBy          string           `json:"create_by"`
+	CreateByUID       string           `json:"create_byuid"`
+	HostGroup         string           `json:"host_group" comment:"主机组"`
+	HostGroupID       string           `json:"host_groupid"`
+	ProjectID         string           `json:"project_id"`
+	Project           string           `json:"project" comment:"项目"`
+	LabelSelector     LabelSelector    `json:"label_selector" comment:"标签选择器"`
+	Cronexpr          string           `json:"cronexpr" comment:"CronExpr"`
+	Timeout           int              `json:"timeout" comment:"超时时间"`
+	AlarmUserIds      []string         `json:"alarm_userids"`
+	AlarmUserIdsDesc  []string         `json:"alarm_useridsdesc" comment:"报警用户"`
+	ApproverIds       []string         `json:"approver_ids"`
+	ApproverIdsDesc   []string         `json:"approver_idsdesc" comment:"审核人"`
+	RoutePolicy       RoutePolicy      `json:"route_policy"`
+	RoutePolicyDesc   string           `json:"route_policydesc" comment:"路由策略"`
+	BroadcastSuccess  BroadcastSuccess `json:"broadcast_success" comment:"广播成功策略"`
+	BroadcastParallel int              `json:"broadcast_parallel" comment:"广播并发数"`
+	ShardTotal        int              `json:"shard_total" comment:"分片数"`
+	Idempotent        bool             `json:"idempotent" comment:"幂等"`
+	FailoverLimit     int              `json:"failover_limit" comment:"故障转移次数"`
+	ExpectCode        int              `json:"expect_code"  comment:"期望返回码"`
+	ExpectContent     string           `json:"expect_content" comment:"期望返回内容"`
+	AlarmStatus       AlarmStatus      `json:"alarm_status"`
+	AlarmStatusDesc   string           `json:"alarm_statusdesc" comment:"报警策略"`
 	Common
 }
 
@@ -305,6 +669,12 @@ const (
 	Weight
 	// LeastTask get host by host LeastTask
 	LeastTask
+	// LeastLoad get host by host resource usage report by heartbeat
+	LeastLoad
+	// ConsistentHash get host by consistent hash of task id, task will run on the same host
+	ConsistentHash
+	// Broadcast run task on every online host
+	Broadcast
 )
 
 func (r RoutePolicy) String() string {
@@ -317,11 +687,57 @@ func (r RoutePolicy) String() string {
 		return "Weight"
 	case LeastTask:
 		return "LeastTask"
+	case LeastLoad:
+		return "LeastLoad"
+	case ConsistentHash:
+		return "ConsistentHash"
+	case Broadcast:
+		return "Broadcast"
+	default:
+		return "Unknown"
+	}
+}
+
+// BroadcastSuccess how to judge broadcast task run success
+type BroadcastSuccess uint8
+
+const (
+	// BroadcastAll task run success on all hosts
+	BroadcastAll BroadcastSuccess = iota + 1
+	// BroadcastAny task run success on any host
+	BroadcastAny
+	// BroadcastQuorum task run success on more than half hosts
+	BroadcastQuorum
+)
+
+func (bs BroadcastSuccess) String() string {
+	switch bs {
+	case BroadcastAll:
+		return "All"
+	case BroadcastAny:
+		return "Any"
+	case BroadcastQuorum:
+		return "Quorum"
 	default:
 		return "Unknown"
 	}
 }
 
+// Judge return whether broadcast task run success by success hosts and total hosts
+func (bs BroadcastSuccess) Judge(success, total int) bool {
+	if total == 0 {
+		return false
+	}
+	switch bs {
+	case BroadcastAny:
+		return success > 0
+	case BroadcastQuorum:
+		return success > total/2
+	default:
+		return success == total
+	}
+}
+
 // Trigger return how to trigger run task
 type Trigger uint8
 
@@ -346,6 +762,8 @@ func (t Trigger) String() string {
 // RunTask running task message
 type RunTask struct {
 	ID           string  `json:"id"`
+	RunID        string  `json:"run_id"`   // unique id of this run
+	TraceID      string  `json:"trace_id"` // opentelemetry trace id of this run
 	Name         string  `json:"name"`
 	Cronexpr     string  `json:"cronexpr"`
 	StartTimeStr string  `json:"start_timestr"`
@@ -369,6 +787,8 @@ type TaskResp struct {
 
 // Log task log
 type Log struct {
+	RunID          string       `json:"run_id"`               // run id
+	TraceID        string       `json:"trace_id"`             // trace id
 	Name           string       `json:"name"`                 // task log
 	RunByTaskID    string       `json:"runby_taskid"`         // run taskid
 	StartTime      int64        `json:"start_time"`           // ms
@@ -400,6 +820,25 @@ type Query struct {
 	Limit  int `form:"limit"`
 }
 
+// LogFrameType real log frame type
+type LogFrameType string
+
+const (
+	// LogFrameData frame carry log data
+	LogFrameData LogFrameType = "log"
+	// LogFrameEnd task run finished,no more log
+	LogFrameEnd LogFrameType = "end"
+	// LogFrameError read log failed
+	LogFrameError LogFrameType = "error"
+)
+
+// LogFrame real log frame push to websocket or sse client
+type LogFrame struct {
+	Type   LogFrameType `json:"type"`
+	Offset int64        `json:"offset"` // next read byte offset, reconnect with it could resume read log
+	Data   string       `json:"data,omitempty"`
+}
+
 // KlOption vue el-select
 type KlOption struct {
 	Label  string `json:"label"`
@@ -495,6 +934,7 @@ type OperateLog struct {
 	OperateTime string   `json:"operate_time"` // 修改时间
 	Desc        string   `json:"desc"`         // 描述
 	Columns     []Column `json:"columns"`      // 修改的字段及新旧值
+	APIToken    string   `json:"api_token"`    // 使用API令牌操作时的令牌名称
 }
 
 // Column change column old and new value
@@ -514,6 +954,8 @@ const (
 	UpgradeNotify
 	// ReviewReq 审核请求
 	ReviewReq
+	// HostNotify 主机上下线通知
+	HostNotify
 )
 
 func (nt NotifyType) String() string {
@@ -522,8 +964,10 @@ func (nt NotifyType) String() string {
 		return "任务通知"
 	case UpgradeNotify:
 		return "新版本发布"
-	// case ReviewReq:
-	// 	return "审核请求" // zaicontent中点击url到任务列表
+	case HostNotify:
+		return "主机通知"
+	case ReviewReq:
+		return "审核请求"
 	default:
 		return "Unknow"
 	}
@@ -540,3 +984,85 @@ type Notify struct {
 	NotifyTime     int64      `json:"notify_time"`
 	NotifyTimeDesc string     `json:"notify_timedesc"`
 }
+
+// ApprovalAction operate of protected task which need approval
+type ApprovalAction uint8
+
+const (
+	// ApprovalCreateTask create task use protected host group
+	ApprovalCreateTask ApprovalAction = iota + 1
+	// ApprovalChangeTask change protected task
+	ApprovalChangeTask
+	// ApprovalRunTask run protected task manually
+	ApprovalRunTask
+)
+
+func (a ApprovalAction) String() string {
+	switch a {
+	case ApprovalCreateTask:
+		return "创建任务"
+	case ApprovalChangeTask:
+		return "修改任务"
+	case ApprovalRunTask:
+		return "运行任务"
+	default:
+		return "Unknown"
+	}
+}
+
+// ApprovalStatus status of approval request
+type ApprovalStatus uint8
+
+const (
+	// ApprovalPending wait approver review
+	ApprovalPending ApprovalStatus = iota + 1
+	// ApprovalApproved approved and the operate is done
+	ApprovalApproved
+	// ApprovalRejected rejected by approver
+	ApprovalRejected
+	// ApprovalCanceled canceled by requester
+	ApprovalCanceled
+)
+
+func (s ApprovalStatus) String() string {
+	switch s {
+	case ApprovalPending:
+		return "待审核"
+	case ApprovalApproved:
+		return "已通过"
+	case ApprovalRejected:
+		return "已拒绝"
+	case ApprovalCanceled:
+		return "已撤销"
+	default:
+		return "Unknown"
+	}
+}
+
+// Approval request of create, change or run protected task
+type Approval struct {
+	ID           string         `json:"id"`
+	Action       ApprovalAction `json:"action"`
+	ActionDesc   string         `json:"action_desc"`
+	TaskID       string         `json:"task_id"`
+	TaskName     string         `json:"task_name"`
+	Content      string         `json:"content"` // 提交的任务数据 JSON
+	ApproverIds  []string       `json:"approver_ids"`
+	Approvers    []string       `json:"approvers"`
+	Status       ApprovalStatus `json:"status"`
+	StatusDesc   string         `json:"status_desc"`
+	CreateByUID  string         `json:"create_byuid"`
+	CreateBy     string         `json:"create_by"`
+	ReviewByUID  string         `json:"review_byuid"`
+	ReviewBy     string         `json:"review_by"`
+	ReviewRemark string         `json:"review_remark"`
+	CreateTime   string         `json:"create_time"`
+	UpdateTime   string         `json:"update_time"`
+}
+
+// ReviewApproval approve or reject approval request
+type ReviewApproval struct {
+	ID      string `json:"id" binding:"required,len=18"`
+	Approve bool   `json:"approve"`
+	Remark  string `json:"remark" binding:"max=100"`
+}
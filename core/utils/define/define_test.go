@@ -0,0 +1,66 @@
+package define
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels := ParseLabels([]string{"os=linux", " zone = a ", "gpu", "=bad", ""})
+	want := map[string]string{"os": "linux", "zone": "a", "gpu": ""}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("want get %v, but get %v", want, labels)
+	}
+	if got := FormatLabels(labels); !reflect.DeepEqual(got, []string{"gpu=", "os=linux", "zone=a"}) {
+		t.Errorf("FormatLabels get %v", got)
+	}
+}
+
+func TestLabelSelectorMatch(t *testing.T) {
+	labels := map[string]string{"os": "linux", "zone": "a"}
+	tests := []struct {
+		name     string
+		selector LabelSelector
+		want     bool
+	}{
+		{"empty", LabelSelector{}, true},
+		{"in", LabelSelector{{Key: "os", Operator: LabelIn, Values: []string{"linux", "darwin"}}}, true},
+		{"in not match", LabelSelector{{Key: "os", Operator: LabelIn, Values: []string{"windows"}}}, false},
+		{"in not exist", LabelSelector{{Key: "gpu", Operator: LabelIn, Values: []string{""}}}, false},
+		{"notin", LabelSelector{{Key: "zone", Operator: LabelNotIn, Values: []string{"b"}}}, true},
+		{"notin not exist", LabelSelector{{Key: "gpu", Operator: LabelNotIn, Values: []string{"true"}}}, true},
+		{"notin match", LabelSelector{{Key: "zone", Operator: LabelNotIn, Values: []string{"a"}}}, false},
+		{"exists", LabelSelector{{Key: "zone", Operator: LabelExists}}, true},
+		{"exists not exist", LabelSelector{{Key: "gpu", Operator: LabelExists}}, false},
+		{"all", LabelSelector{
+			{Key: "os", Operator: LabelIn, Values: []string{"linux"}},
+			{Key: "gpu", Operator: LabelExists},
+		}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.selector.Match(labels); got != tt.want {
+			t.Errorf("%s: want get %v, but get %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestBroadcastSuccessJudge(t *testing.T) {
+	tests := []struct {
+		bs             BroadcastSuccess
+		success, total int
+		want           bool
+	}{
+		{BroadcastAll, 3, 3, true},
+		{BroadcastAll, 2, 3, false},
+		{BroadcastAny, 1, 3, true},
+		{BroadcastAny, 0, 3, false},
+		{BroadcastQuorum, 2, 3, true},
+		{BroadcastQuorum, 2, 4, false},
+		{BroadcastAll, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := tt.bs.Judge(tt.success, tt.total); got != tt.want {
+			t.Errorf("%s %d/%d: want get %v, but get %v", tt.bs, tt.success, tt.total, tt.want, got)
+		}
+	}
+}
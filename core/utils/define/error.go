@@ -20,6 +20,15 @@ func (u ErrForbid) Error() string {
 	return fmt.Sprintf("user %s forbid login", u.Name)
 }
 
+// ErrPasswordExpired user password is expired err
+type ErrPasswordExpired struct {
+	Name string
+}
+
+func (u ErrPasswordExpired) Error() string {
+	return fmt.Sprintf("user %s password is expired", u.Name)
+}
+
 // ErrDelHostID delete host id err
 type ErrDelHostID struct {
 	ID string
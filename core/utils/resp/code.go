@@ -42,6 +42,58 @@ const (
 	ErrDelHostGroupUseByTask = 10424
 	// ErrDelUserUseByOther // 请先删除此用户创建的主机组或者任务后再删除
 	ErrDelUserUseByOther = 10425
+	// ErrTaskLogNotExist 任务日志不存在
+	ErrTaskLogNotExist = 10426
+	// ErrTaskNoHostTarget 请选择主机组或者设置标签选择器
+	ErrTaskNoHostTarget = 10427
+	// ErrTaskShardBroadcast 分片任务不能使用广播路由策略
+	ErrTaskShardBroadcast = 10428
+	// ErrEnrollTokenNotExist 注册令牌不存在
+	ErrEnrollTokenNotExist = 10429
+	// ErrAPITokenNotExist API令牌不存在
+	ErrAPITokenNotExist = 10430
+	// ErrPasswordLoginDisabled 已禁用密码登陆
+	ErrPasswordLoginDisabled = 10431
+	// ErrProjectNotExist 项目不存在
+	ErrProjectNotExist = 10432
+	// ErrProjectExist 项目已存在
+	ErrProjectExist = 10433
+	// ErrProjectIsUse 项目中还有任务或主机组
+	ErrProjectIsUse = 10434
+	// ErrProjectLastOwner 项目至少需要一个所有者
+	ErrProjectLastOwner = 10435
+	// ErrProjectHostGroup 任务只能使用同一项目或者不属于任何项目的主机组
+	ErrProjectHostGroup = 10436
+	// ErrLoginTooFrequent 登陆请求过于频繁
+	ErrLoginTooFrequent = 10437
+	// ErrUserLocked 登陆失败次数过多，用户已被锁定
+	ErrUserLocked = 10438
+	// ErrPasswordExpired 密码已过期
+	ErrPasswordExpired = 10439
+	// ErrPasswordPolicy 密码不符合复杂度要求
+	ErrPasswordPolicy = 10440
+	// ErrPasswordReuse 新密码不能与旧密码相同
+	ErrPasswordReuse = 10441
+	// ErrTOTPRequired 需要两步验证码
+	ErrTOTPRequired = 10442
+	// ErrTOTPInvalid 两步验证码错误
+	ErrTOTPInvalid = 10443
+	// ErrTOTPNotEnrolled 管理员必须开启两步验证
+	ErrTOTPNotEnrolled = 10444
+	// ErrTOTPEnabled 已经开启两步验证
+	ErrTOTPEnabled = 10445
+	// ErrTOTPNoSecret 请先生成两步验证密钥
+	ErrTOTPNoSecret = 10446
+	// ApprovalPending 受保护的任务已提交审核请求，等待审核人审核
+	ApprovalPending = 10447
+	// ErrApprovalNotExist 审核请求不存在
+	ErrApprovalNotExist = 10448
+	// ErrApprovalDone 审核请求已经处理
+	ErrApprovalDone = 10449
+	// ErrNotApprover 不是此请求的审核人
+	ErrNotApprover = 10450
+	// ErrApprovalTaskChanged 提交审核后任务已经被修改
+	ErrApprovalTaskChanged = 10451
 
 	// ErrInternalServer 服务端错误
 	ErrInternalServer = 10500
@@ -60,6 +112,8 @@ const (
 	ErrRPCNotValidHost = 10605
 	// ErrRPCNotConnHost 未找到存活的worker
 	ErrRPCNotConnHost = 10606
+	// ErrRPCWorkerBusy worker运行任务数已达上限
+	ErrRPCWorkerBusy = 10607
 
 	// NeedInstall 系统还未安装，请等待安装后再进行操作
 	NeedInstall = 10700
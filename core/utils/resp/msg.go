@@ -27,6 +27,32 @@ var msgcode = map[int]string{
 	ErrTaskUseByOtherTask:    "存在任务依赖此任务，请先在其他的任务的父子任务中移除此任务",
 	ErrDelHostGroupUseByTask: "正在被其他的任务使用，不能删除",
 	ErrDelUserUseByOther:     "请先删除此用户创建的主机组或者任务后再删除",
+	ErrTaskLogNotExist:       "任务日志不存在",
+	ErrTaskNoHostTarget:      "请选择主机组或者设置标签选择器",
+	ErrTaskShardBroadcast:    "分片任务不能使用广播路由策略",
+	ErrEnrollTokenNotExist:   "注册令牌不存在",
+	ErrAPITokenNotExist:      "API令牌不存在",
+	ErrPasswordLoginDisabled: "已禁用密码登陆，请使用单点登陆",
+	ErrProjectNotExist:       "项目不存在",
+	ErrProjectExist:          "项目已存在",
+	ErrProjectIsUse:          "项目中还有任务或主机组，不能删除",
+	ErrProjectLastOwner:      "项目至少需要一个所有者",
+	ErrProjectHostGroup:      "任务只能使用同一项目或者不属于任何项目的主机组",
+	ErrLoginTooFrequent:      "登陆请求过于频繁，请稍后再试",
+	ErrUserLocked:            "登陆失败次数过多，用户已被锁定，请稍后再试或联系管理员解锁",
+	ErrPasswordExpired:       "密码已过期，请修改密码后登陆",
+	ErrPasswordPolicy:        "密码不符合复杂度要求",
+	ErrPasswordReuse:         "新密码不能与旧密码相同",
+	ErrTOTPRequired:          "请输入两步验证码",
+	ErrTOTPInvalid:           "两步验证码错误",
+	ErrTOTPNotEnrolled:       "管理员必须开启两步验证，请先绑定验证器",
+	ErrTOTPEnabled:           "已经开启两步验证",
+	ErrTOTPNoSecret:          "请先生成两步验证密钥",
+	ApprovalPending:          "已提交审核请求，等待审核人审核",
+	ErrApprovalNotExist:      "审核请求不存在",
+	ErrApprovalDone:          "审核请求已经处理",
+	ErrNotApprover:           "不是此请求的审核人",
+	ErrApprovalTaskChanged:   "提交审核后任务已经被修改，请重新提交",
 
 	ErrInternalServer: "服务端错误",
 
@@ -37,6 +63,7 @@ var msgcode = map[int]string{
 	ErrRPCUnknow:           "调用未知错误",
 	ErrRPCNotValidHost:     "未发现worker",
 	ErrRPCNotConnHost:      "未找到存活的worker",
+	ErrRPCWorkerBusy:       "worker运行任务数已达上限",
 
 	NeedInstall:   "系统还未安装，请等待安装后再进行操作",
 	IsInstall:     "系统已经安装完成，请勿再次执行安装操作",
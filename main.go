@@ -39,6 +39,7 @@ func main() {
 	rootCmd.AddCommand(cmd.Server())
 	rootCmd.AddCommand(cmd.Version())
 	rootCmd.AddCommand(cmd.GeneratePemKey())
+	rootCmd.AddCommand(cmd.CA())
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println("rootCmd.Execute failed", err.Error())
 	}
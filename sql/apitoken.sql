@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `crocodile_apitoken` (
+    `id` CHAR(18) NOT NULL COMMENT "ID",
+    `name` VARCHAR(30) NOT NULL DEFAULT "" COMMENT "令牌名称",
+    `token` CHAR(64) NOT NULL COMMENT "令牌sha256",
+    `uid` CHAR(18) NOT NULL DEFAULT "" COMMENT "所属用户ID",
+    `scope` VARCHAR(10) NOT NULL DEFAULT "" COMMENT "权限范围 read run write",
+    `taskIDs` VARCHAR(1000) NOT NULL DEFAULT "" COMMENT "只能操作的任务ID 为空不限制",
+    `expireTime` INT NOT NULL DEFAULT 0 COMMENT "过期时间 0为不过期",
+    `lastUsedTime` INT NOT NULL DEFAULT 0 COMMENT "最后使用时间",
+    `createTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_token` (`token`),
+    KEY `idx_uid` (`uid`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
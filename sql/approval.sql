@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `crocodile_approval` (
+    `id` CHAR(18) NOT NULL COMMENT "ID",
+    `action` INT NOT NULL DEFAULT 0 COMMENT "请求操作 1:创建任务 2:修改任务 3:运行任务",
+    `taskID` CHAR(18) NOT NULL DEFAULT "" COMMENT "任务ID",
+    `taskName` VARCHAR(30) NOT NULL DEFAULT "" COMMENT "任务名称",
+    `content` MEDIUMTEXT COMMENT "提交的任务数据 JSON",
+    `approverIds` VARCHAR(200) NOT NULL DEFAULT "" COMMENT "审核人",
+    `status` INT NOT NULL DEFAULT 1 COMMENT "状态 1:待审核 2:已通过 3:已拒绝 4:已撤销",
+    `createByID` CHAR(18) NOT NULL DEFAULT "" COMMENT "申请人ID",
+    `reviewByID` CHAR(18) NOT NULL DEFAULT "" COMMENT "审核人ID",
+    `reviewRemark` VARCHAR(100) NOT NULL DEFAULT "" COMMENT "审核备注",
+    `createTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
+    `updateTime` INT NOT NULL DEFAULT 0 COMMENT "更新时间",
+    PRIMARY KEY (`id`),
+    KEY `idx_cbi` (`createByID`),
+    KEY `idx_status` (`status`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
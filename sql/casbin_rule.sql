@@ -14,6 +14,12 @@ INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/task*','(GET)|(POST)|(DELETE)|(PUT)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/task*','(GET)|(POST)|(DELETE)|(PUT)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/task*','(GET)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/task/run','(PUT)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/task/kill','(PUT)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/task/approval','(PUT)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/project*','(GET)|(POST)|(DELETE)|(PUT)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/project*','(GET)|(POST)|(DELETE)|(PUT)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/project*','(GET)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/host*','(GET)|(POST)|(DELETE)|(PUT)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/host*','(GET)|(POST)|(DELETE)|(PUT)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/host*','(GET)','','','');
@@ -26,6 +32,18 @@ INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/registry','(POST)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/all','(GET)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/admin','(PUT)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/admin/sessions','(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/admin/unlock','(PUT)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/admin/totp','(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/sessions','(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/user/sessions','(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/user/sessions','(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/apitoken','(GET)|(POST)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/user/apitoken','(GET)|(POST)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/user/apitoken','(GET)|(POST)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/totp','(GET)|(POST)|(PUT)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/user/totp','(GET)|(POST)|(PUT)|(DELETE)','','','');
+INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/user/totp','(GET)|(POST)|(PUT)|(DELETE)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Admin','/api/v1/user/alarmstatus','(GET)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Normal','/api/v1/user/alarmstatus','(GET)','','','');
 INSERT INTO casbin_rule (p_type,v0,v1,v2,v3,v4,v5) VALUES ('p','Guest','/api/v1/user/alarmstatus','(GET)','','','');
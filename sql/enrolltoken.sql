@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `crocodile_enrolltoken` (
+    `id` CHAR(18) NOT NULL COMMENT "ID",
+    `token` CHAR(64) NOT NULL COMMENT "令牌sha256",
+    `hostGroupID` CHAR(18) NOT NULL DEFAULT "" COMMENT "注册后加入的主机组ID",
+    `labels` VARCHAR(1000) NOT NULL DEFAULT "" COMMENT "注册后设置的标签 key=value,key=value",
+    `maxUses` INT NOT NULL DEFAULT 1 COMMENT "最大使用次数 0为不限制",
+    `uses` INT NOT NULL DEFAULT 0 COMMENT "已使用次数",
+    `expireTime` INT NOT NULL DEFAULT 0 COMMENT "过期时间 0为不过期",
+    `remark` VARCHAR(100) NOT NULL DEFAULT "" COMMENT "备注",
+    `createByID` CHAR(18) NOT NULL DEFAULT "" COMMENT "创建人ID",
+    `createTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_token` (`token`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -8,6 +8,21 @@ CREATE TABLE IF NOT EXISTS `crocodile_host` (
         `version` VARCHAR(10) NOT NULL COMMENT "版本号",
         `lastUpdateTimeUnix` INT NOT NULL DEFAULT 0 COMMENT "更新时间",
         `remark` VARCHAR(100) DEFAULT "" COMMENT "备注",
+        `cpuNum` INT NOT NULL DEFAULT 0 COMMENT "CPU核数",
+        `load1` DOUBLE NOT NULL DEFAULT 0 COMMENT "1分钟负载",
+        `load5` DOUBLE NOT NULL DEFAULT 0 COMMENT "5分钟负载",
+        `load15` DOUBLE NOT NULL DEFAULT 0 COMMENT "15分钟负载",
+        `memTotal` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "总内存 字节",
+        `memAvailable` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "可用内存 字节",
+        `diskTotal` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "总磁盘 字节",
+        `diskFree` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT "可用磁盘 字节",
+        `taskSlots` INT NOT NULL DEFAULT 0 COMMENT "任务槽位数",
+        `runningSlots` INT NOT NULL DEFAULT 0 COMMENT "正在运行的任务数",
+        `maxConcurrent` INT NOT NULL DEFAULT 0 COMMENT "最大并发任务数 0为不限制",
+        `labels` VARCHAR(1000) NOT NULL DEFAULT "" COMMENT "标签 key=value,key=value",
+        `online` BOOL NOT NULL DEFAULT false COMMENT "在线状态 由调度中心的主机监控记录",
+        `drain` BOOL NOT NULL DEFAULT false COMMENT "排空 不再接收新任务 任务运行结束后下线",
+        `credential` VARCHAR(100) NOT NULL DEFAULT "" COMMENT "worker使用注册令牌换取的凭证sha256 为空时使用secrettoken认证",
         PRIMARY KEY(`id`),
         UNIQUE KEY `idx_addr` (`addr`)
 )ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `crocodile_hostevent` (
+    `id` INT AUTO_INCREMENT COMMENT "ID",
+    `hostid` CHAR(18) NOT NULL DEFAULT "" COMMENT "主机ID",
+    `addr` VARCHAR(25) NOT NULL DEFAULT "" COMMENT "Host地址",
+    `hostname` VARCHAR(100) NOT NULL DEFAULT "" COMMENT "主机名",
+    `event` INT NOT NULL DEFAULT 0 COMMENT "事件 1:上线 2:下线",
+    `eventtime` INT NOT NULL DEFAULT 0 COMMENT "事件时间",
+     PRIMARY KEY (`id`),
+     KEY `idx_hostid` (`hostid`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
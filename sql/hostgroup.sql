@@ -4,8 +4,11 @@ CREATE TABLE IF NOT EXISTS `crocodile_hostgroup` (
     `remark` VARCHAR(100) NOT NULL  DEFAULT "" COMMENT "备注",
     `createByID` CHAR(18) NOT NULL DEFAULT "" COMMENT "创建人ID",
     `hostIDs` TEXT COMMENT "主机ID",
+    `projectID` CHAR(18) NOT NULL DEFAULT "" COMMENT "所属项目ID 为空不属于任何项目",
+    `approverIds` VARCHAR(200) NOT NULL DEFAULT "" COMMENT "审核人 最多设置10个 设置后使用此主机组的任务需要审核",
     `createTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
     `updateTime` INT NOT NULL DEFAULT 0 COMMENT "更新时间",
     PRIMARY KEY (`id`),
-    KEY `idx_name` (`name`)
+    KEY `idx_name` (`name`),
+    KEY `idx_pid` (`projectID`)
 )ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -1,5 +1,7 @@
 CREATE TABLE IF NOT EXISTS `crocodile_log` (
     `id` INT AUTO_INCREMENT COMMENT "ID",
+    `runid` CHAR(18) NOT NULL DEFAULT "" COMMENT "运行ID",
+    `traceid` CHAR(32) NOT NULL DEFAULT "" COMMENT "链路追踪ID",
     `name` VARCHAR(30) NOT NULL DEFAULT "" COMMENT "任务名称",
     `taskid` CHAR(18) NOT NULL DEFAULT "" COMMENT "任务ID",
     `starttime` BIGINT NOT NULL DEFAULT 0  COMMENT "开始时间毫秒",
@@ -15,5 +17,6 @@ CREATE TABLE IF NOT EXISTS `crocodile_log` (
     `errtask` CHAR(30) NOT NULL  DEFAULT "" COMMENT "出错任务名称",
      PRIMARY KEY (`id`),
      KEY `idx_name` (`name`),
-     KEY `idx_s_t` (`starttime`,`taskid`)
+     KEY `idx_s_t` (`starttime`,`taskid`),
+     KEY `idx_runid` (`runid`)
 )ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
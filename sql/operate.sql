@@ -9,6 +9,7 @@ CREATE TABLE IF NOT EXISTS `crocodile_operate` (
         `operatetime` INTEGER NOT NULL DEFAULT 0 COMMENT "操作时间",
         `description` VARCHAR(200) COMMENT "操作说明，一般用户用户操作未直接改变数据库变化的操作，例如运行任务",-- "描述"
         `columns` MEDIUMTEXT COMMENT "修改的字段",
+        `apitoken` VARCHAR(30) NOT NULL DEFAULT "" COMMENT "使用API令牌操作时的令牌名称",
          PRIMARY KEY (`id`),
          KEY `idx_username` (`username`)
 )ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `crocodile_projectmember` (
+    `projectID` CHAR(18) NOT NULL COMMENT "项目ID",
+    `uid` CHAR(18) NOT NULL COMMENT "用户ID",
+    `role` INT NOT NULL DEFAULT 1 COMMENT "项目角色 1:Viewer 2:Operator 3:Editor 4:Owner",
+    `createTime` INT NOT NULL DEFAULT 0 COMMENT "加入时间",
+    PRIMARY KEY (`projectID`, `uid`),
+    KEY `idx_uid` (`uid`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
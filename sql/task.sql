@@ -14,10 +14,18 @@ CREATE TABLE IF NOT EXISTS `crocodile_task` (
 	`childRunParallel` BOOL NOT NULL  DEFAULT false  COMMENT "子任务是否并行运行",
 	`createByID` CHAR (18) NOT NULL  DEFAULT "" COMMENT "创建人ID",
 	`hostGroupID` CHAR (18) NOT NULL  DEFAULT "" COMMENT "主机组ID",
+	`projectID` CHAR (18) NOT NULL  DEFAULT "" COMMENT "所属项目ID 为空不属于任何项目",
+	`labelSelector` VARCHAR (2000) NOT NULL DEFAULT "" COMMENT "标签选择器 JSON",
 	`cronExpr` VARCHAR (1000) NOT NULL  DEFAULT "" COMMENT "定时任务表达式,共7位 秒、分、时、日、月、周、年",
 	`timeout` INT NOT NULL DEFAULT -1 COMMENT "任务超时时间，默认-1即不设置超时时间",
 	`alarmUserIds` VARCHAR (200) NOT NULL DEFAULT "" COMMENT "报警用户 最多设置10个",
-	`routePolicy` INT NOT NULL DEFAULT 0 COMMENT "路由策略 1:Random 2:RoundRobin 3:Weight 4:LeastTask",
+	`approverIds` VARCHAR (200) NOT NULL DEFAULT "" COMMENT "审核人 最多设置10个 设置后修改和手动运行任务需要审核",
+	`routePolicy` INT NOT NULL DEFAULT 0 COMMENT "路由策略 1:Random 2:RoundRobin 3:Weight 4:LeastTask 5:LeastLoad 6:ConsistentHash 7:Broadcast",
+	`broadcastSuccess` INT NOT NULL DEFAULT 0 COMMENT "广播任务成功策略 1:All 2:Any 3:Quorum",
+	`broadcastParallel` INT NOT NULL DEFAULT 0 COMMENT "广播任务同时运行的主机数 0为不限制",
+	`shardTotal` INT NOT NULL DEFAULT 0 COMMENT "分片数 0为不分片",
+	`idempotent` BOOL NOT NULL DEFAULT false COMMENT "任务是否幂等 幂等任务在worker运行中途宕机后可以在其他worker重新运行",
+	`failoverLimit` INT NOT NULL DEFAULT 1 COMMENT "worker宕机时故障转移到其他worker的最大次数 0为默认转移1次 -1为不转移",
 	`expectCode` INT NOT NULL  DEFAULT 0 COMMENT "期望返回码 CODE默认为0 HTTP默认为200",
 	`expectContent` TEXT COMMENT "期望返回部分",
 	`alarmStatus` INT NOT NULL  DEFAULT 0 COMMENT "报警策略 1:任务运行结束 2:任务运行失败 3:任务运行成功",
@@ -26,5 +34,6 @@ CREATE TABLE IF NOT EXISTS `crocodile_task` (
 	`updateTime` INT NOT NULL DEFAULT 0 COMMENT "任务上次修改时间 时间戳(秒)",
 	PRIMARY KEY(`id`),
 	KEY `idx_name`(`name`),
-	KEY `idx_cbi` (`createByID`)
+	KEY `idx_cbi` (`createByID`),
+	KEY `idx_pid` (`projectID`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
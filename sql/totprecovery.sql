@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `crocodile_totprecovery` (
+    `uid` CHAR(18) NOT NULL COMMENT "用户ID",
+    `code` CHAR(64) NOT NULL COMMENT "两步验证恢复码sha256",
+    `createTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
+    PRIMARY KEY (`uid`, `code`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
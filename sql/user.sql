@@ -14,8 +14,15 @@ CREATE TABLE IF NOT EXISTS `crocodile_user` (
     `dingphone` CHAR(11) NOT NULL  DEFAULT "" COMMENT "钉钉绑定的手机号",
     `telegram` VARCHAR(20) NOT NULL DEFAULT "" COMMENT "TelegramBot ID",
     `wechat` VARCHAR(20) NOT NULL DEFAULT "" COMMENT "企业微信ID",
+    `oidcSubject` VARCHAR(255) NOT NULL DEFAULT "" COMMENT "单点登陆用户标识",
+    `ldapDN` VARCHAR(255) NOT NULL DEFAULT "" COMMENT "LDAP用户DN",
+    `passwordUpdateTime` INT NOT NULL DEFAULT 0 COMMENT "密码修改时间",
+    `totpSecret` VARCHAR(64) NOT NULL DEFAULT "" COMMENT "两步验证密钥",
+    `totpEnable` INT(1) NOT NULL DEFAULT 0 COMMENT "是否开启两步验证",
+    `totpLastStep` BIGINT NOT NULL DEFAULT 0 COMMENT "最后使用的两步验证时间步 防止重放",
     `createTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
     `updateTime` INT NOT NULL DEFAULT 0 COMMENT "创建时间",
     PRIMARY KEY (`id`),
-    KEY `idx_name`(`name`)
+    KEY `idx_name`(`name`),
+    KEY `idx_oidcsubject`(`oidcSubject`)
 )ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
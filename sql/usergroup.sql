@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `crocodile_usergroup` (
+    `uid` CHAR(18) NOT NULL COMMENT "用户ID",
+    `groupName` VARCHAR(100) NOT NULL DEFAULT "" COMMENT "LDAP所有权组 同组用户共享主机组和任务的所有权",
+    PRIMARY KEY (`uid`, `groupName`),
+    KEY `idx_groupname` (`groupName`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
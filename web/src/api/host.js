@@ -24,10 +24,53 @@ export function stophost(data) {
     })
 }
 
+export function drainhost(data) {
+    return request({
+        url: '/api/v1/host/drain',
+        method: 'put',
+        data: data
+    })
+}
+
 export function deletehost(data) {
     return request({
         url: '/api/v1/host',
         method: 'delete',
         data: data
     })
-}
\ No newline at end of file
+}
+export function gethostevents(params) {
+    return request({
+        url: '/api/v1/host/events',
+        method: 'get',
+        params: params
+    })
+}
+export function revokehost(data) {
+    return request({
+        url: '/api/v1/host/revoke',
+        method: 'put',
+        data: data
+    })
+}
+export function getenrolltokens(params) {
+    return request({
+        url: '/api/v1/host/enrolltoken',
+        method: 'get',
+        params: params
+    })
+}
+export function createenrolltoken(data) {
+    return request({
+        url: '/api/v1/host/enrolltoken',
+        method: 'post',
+        data: data
+    })
+}
+export function deleteenrolltoken(data) {
+    return request({
+        url: '/api/v1/host/enrolltoken',
+        method: 'delete',
+        data: data
+    })
+}
@@ -0,0 +1,65 @@
+import request from '@/utils/request'
+
+
+export function getproject(params) {
+    return request({
+        url: '/api/v1/project',
+        method: 'get',
+        params: params
+    })
+}
+
+export function getselectproject() {
+    return request({
+        url: '/api/v1/project/select',
+        method: 'get',
+    })
+}
+
+export function createproject(data) {
+    return request({
+        url: '/api/v1/project',
+        method: 'post',
+        data: data
+    })
+}
+
+export function changeproject(data) {
+    return request({
+        url: '/api/v1/project',
+        method: 'put',
+        data: data
+    })
+}
+
+export function deleteproject(data) {
+    return request({
+        url: '/api/v1/project',
+        method: 'delete',
+        data: data
+    })
+}
+
+export function getprojectmember(params) {
+    return request({
+        url: '/api/v1/project/member',
+        method: 'get',
+        params: params
+    })
+}
+
+export function setprojectmember(data) {
+    return request({
+        url: '/api/v1/project/member',
+        method: 'put',
+        data: data
+    })
+}
+
+export function deleteprojectmember(data) {
+    return request({
+        url: '/api/v1/project/member',
+        method: 'delete',
+        data: data
+    })
+}
@@ -101,4 +101,31 @@ export function cleantasklog(data) {
     method: 'delete',
     data: data
   })
-}
\ No newline at end of file
+}
+
+// 获取审核请求
+export function getapprovals(params) {
+  return request({
+    url: '/api/v1/task/approval',
+    method: 'get',
+    params: params
+  })
+}
+
+// 通过或拒绝审核请求
+export function reviewapproval(data) {
+  return request({
+    url: '/api/v1/task/approval',
+    method: 'put',
+    data: data
+  })
+}
+
+// 撤销自已的审核请求
+export function cancelapproval(data) {
+  return request({
+    url: '/api/v1/task/approval',
+    method: 'delete',
+    data: data
+  })
+}
@@ -2,18 +2,92 @@ import request from '@/utils/request'
 
 
 
-export function login(data) {
+// 开启两步验证的用户需要验证码或恢复码
+export function login(data, totpcode) {
   return request({
     url: '/api/v1/user/login',
     method: 'post',
-    auth: data
+    auth: data,
+    data: totpcode ? { totp_code: totpcode } : undefined
   })
 }
 
-export function logout() {
+// 密码过期后使用旧密码修改密码并登陆
+export function changeexpiredpassword(auth, password, totpcode) {
+  return request({
+    url: '/api/v1/user/login/password',
+    method: 'put',
+    auth: auth,
+    data: { password: password, totp_code: totpcode }
+  })
+}
+
+// 必须开启两步验证的用户登陆时生成密钥
+export function logincreatetotp(auth) {
+  return request({
+    url: '/api/v1/user/login/totp',
+    method: 'post',
+    auth: auth
+  })
+}
+
+// 必须开启两步验证的用户登陆时绑定验证器并登陆
+export function loginenabletotp(auth, code) {
+  return request({
+    url: '/api/v1/user/login/totp',
+    method: 'put',
+    auth: auth,
+    data: { code: code }
+  })
+}
+
+export function logout(refreshtoken) {
   return request({
     url: '/api/v1/user/logout',
     method: 'post',
+    data: { refresh_token: refreshtoken }
+  })
+}
+
+// 单点登陆配置
+export function getoidcconfig() {
+  return request({
+    url: '/api/v1/user/oidc/config',
+    method: 'get'
+  })
+}
+
+export function refreshtoken(refreshtoken) {
+  return request({
+    url: '/api/v1/user/refresh',
+    method: 'post',
+    data: { refresh_token: refreshtoken }
+  })
+}
+
+// 注销自己的所有会话
+export function logoutsessions() {
+  return request({
+    url: '/api/v1/user/sessions',
+    method: 'delete'
+  })
+}
+
+// 管理员解锁登陆失败次数过多的用户
+export function adminunlockuser(data) {
+  return request({
+    url: '/api/v1/user/admin/unlock',
+    method: 'put',
+    data: data
+  })
+}
+
+// 管理员注销某用户的所有会话
+export function adminlogoutsessions(data) {
+  return request({
+    url: '/api/v1/user/admin/sessions',
+    method: 'delete',
+    data: data
   })
 }
 
@@ -90,3 +164,69 @@ export function getoperatelog(params) {
   })
 }
 
+export function getapitokens(params) {
+  return request({
+    url: '/api/v1/user/apitoken',
+    method: 'get',
+    params: params
+  })
+}
+
+export function createapitoken(data) {
+  return request({
+    url: '/api/v1/user/apitoken',
+    method: 'post',
+    data: data
+  })
+}
+
+export function deleteapitoken(data) {
+  return request({
+    url: '/api/v1/user/apitoken',
+    method: 'delete',
+    data: data
+  })
+}
+
+// 两步验证状态
+export function gettotp() {
+  return request({
+    url: '/api/v1/user/totp',
+    method: 'get'
+  })
+}
+
+// 生成两步验证密钥
+export function createtotp() {
+  return request({
+    url: '/api/v1/user/totp',
+    method: 'post'
+  })
+}
+
+// 使用验证码确认后开启两步验证
+export function enabletotp(data) {
+  return request({
+    url: '/api/v1/user/totp',
+    method: 'put',
+    data: data
+  })
+}
+
+// 关闭两步验证
+export function disabletotp(data) {
+  return request({
+    url: '/api/v1/user/totp',
+    method: 'delete',
+    data: data
+  })
+}
+
+// 管理员重置用户的两步验证
+export function adminresettotp(data) {
+  return request({
+    url: '/api/v1/user/admin/totp',
+    method: 'delete',
+    data: data
+  })
+}
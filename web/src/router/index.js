@@ -67,6 +67,18 @@ export const constantRoutes = [
       }
     ]
   },
+  {
+    path: '/approval',
+    component: Layout,
+    children: [
+      {
+        path: '',
+        name: 'Approval',
+        component: () => import('@/views/approval/index'),
+        meta: { title: '任务审核', icon: 'form' }
+      }
+    ]
+  },
   // {
   //   path: '/test',
   //   component: Layout,
@@ -79,6 +91,18 @@ export const constantRoutes = [
   //     }
   //   ]
   // },
+  {
+    path: '/project',
+    component: Layout,
+    children: [
+      {
+        path: '',
+        name: 'Project',
+        component: () => import('@/views/project/index'),
+        meta: { title: '项目', icon: 'table' }
+      }
+    ]
+  },
   {
     path: '/hostgroup',
     component: Layout,
@@ -1,6 +1,6 @@
-import { login, logout, getInfo } from '@/api/user'
+import { login, logout, getInfo, refreshtoken, changeexpiredpassword } from '@/api/user'
 import { queryversion } from "@/api/install"
-import { getToken, setToken, removeToken } from '@/utils/auth'
+import { getToken, setToken, removeToken, getRefreshToken, setRefreshToken, removeRefreshToken } from '@/utils/auth'
 import { resetRouter } from '@/router'
 
 const state = {
@@ -35,12 +35,47 @@ const mutations = {
 const actions = {
   // user login
   login({ commit }, userInfo) {
-    const { username, password } = userInfo
+    const { username, password, totpcode } = userInfo
     return new Promise((resolve, reject) => {
-      login({ username: username.trim(), password: password }).then(response => {
+      login({ username: username.trim(), password: password }, totpcode).then(response => {
+        // 10439: 密码已过期，需要修改密码后登陆
+        // 10442: 需要两步验证码 10444: 需要先绑定验证器
+        if (response.code !== 0) {
+          reject(response.code)
+          return
+        }
         const { data } = response
-        commit('SET_TOKEN', data)
-        setToken(data)
+        commit('SET_TOKEN', data.access_token)
+        setToken(data.access_token)
+        setRefreshToken(data.refresh_token)
+        resolve()
+      }).catch(error => {
+        reject(error)
+      })
+    })
+  },
+
+  // set token after login by other way, like enable totp on login page
+  settoken({ commit }, token) {
+    commit('SET_TOKEN', token.access_token)
+    setToken(token.access_token)
+    setRefreshToken(token.refresh_token)
+  },
+
+  // change expired password and login
+  changeexpiredpassword({ commit }, userInfo) {
+    const { username, password, newpassword, totpcode } = userInfo
+    return new Promise((resolve, reject) => {
+      changeexpiredpassword({ username: username.trim(), password: password }, newpassword, totpcode).then(response => {
+        // 10442: 需要两步验证码
+        if (response.code !== 0) {
+          reject(response.code)
+          return
+        }
+        const { data } = response
+        commit('SET_TOKEN', data.access_token)
+        setToken(data.access_token)
+        setRefreshToken(data.refresh_token)
         resolve()
       }).catch(error => {
         reject(error)
@@ -85,11 +120,12 @@ const actions = {
   // user logout
   logout({ commit, state }) {
     return new Promise((resolve, reject) => {
-      logout(state.token).then(() => {
+      logout(getRefreshToken()).then(() => {
         commit('SET_TOKEN', '')
         commit('SET_NAME', '')
         commit('SET_ROLES', [])
         removeToken()
+        removeRefreshToken()
         resetRouter()
         resolve()
       }).catch(error => {
@@ -98,12 +134,34 @@ const actions = {
     })
   },
 
+  // refresh token when access token expired
+  refreshToken({ commit }) {
+    return new Promise((resolve, reject) => {
+      const token = getRefreshToken()
+      if (!token) {
+        reject('refresh token is empty')
+        return
+      }
+      refreshtoken(token).then(response => {
+        const { data } = response
+        commit('SET_TOKEN', data.access_token)
+        setToken(data.access_token)
+        setRefreshToken(data.refresh_token)
+        resolve(data.access_token)
+      }).catch(error => {
+        removeRefreshToken()
+        reject(error)
+      })
+    })
+  },
+
   // remove token
   resetToken({ commit }) {
     return new Promise(resolve => {
       commit('SET_TOKEN', '')
       commit('SET_ROLES', [])
       removeToken()
+      removeRefreshToken()
       resolve()
     })
   }
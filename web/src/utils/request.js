@@ -1,7 +1,7 @@
 import axios from 'axios'
 import { MessageBox, Message } from 'element-ui'
 import store from '@/store'
-import { getToken } from '@/utils/auth'
+import { getToken, getRefreshToken } from '@/utils/auth'
 
 // create an axios instance
 const service = axios.create({
@@ -30,6 +30,31 @@ service.interceptors.request.use(
   }
 )
 
+// 多个请求同时遇到token过期时只刷新一次
+let refreshing = null
+
+function refreshandretry(config) {
+  if (!refreshing) {
+    refreshing = store.dispatch('user/refreshToken').then(token => {
+      refreshing = null
+      return token
+    }, error => {
+      refreshing = null
+      return Promise.reject(error)
+    })
+  }
+  return refreshing.then(() => {
+    config._retry = true
+    return service(config)
+  }, error => {
+    // refresh token 过期或已被注销, 重新登陆
+    store.dispatch('user/resetToken').then(() => {
+      location.reload()
+    })
+    return Promise.reject(error)
+  })
+}
+
 // response interceptor
 service.interceptors.response.use(
   /**
@@ -45,9 +70,17 @@ service.interceptors.response.use(
   response => {
     const res = response.data
 
+    // 10401: access token 过期或已被注销, 使用 refresh token 刷新后重试
+    if (res.code === 10401 && !response.config._retry && getRefreshToken() &&
+      response.config.url.indexOf('/api/v1/user/refresh') === -1) {
+      return refreshandretry(response.config)
+    }
+
     // if the custom code is not 20000, it is judged as an error.
     // 10700 是还没安装系统时的返回码
-    if (res.code !== 0 && res.code != 10700) {
+    // 10439 10442 10444 是登陆时密码已过期、需要两步验证码、需要绑定验证器的返回码，由登陆页面处理
+    // 10447 是受保护的任务已提交审核请求，由任务页面提示
+    if (res.code !== 0 && res.code != 10700 && [10439, 10442, 10444, 10447].indexOf(res.code) === -1) {
       Message({
         message: res.msg || 'error',
         type: 'error',